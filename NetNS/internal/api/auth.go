@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenith/netns-mgr/internal/auth"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+// roleKey is the context key holding the role of the authenticated principal
+const roleKey = "role"
+
+// projectKey is the context key holding the project a token is confined to
+// It is unset for tokens that may act on every project.
+const projectKey = "project"
+
+// authenticate resolves a request to a principal and role
+// A verified client certificate authenticates as the API token named by its
+// common name; otherwise a bearer token is required. Requests that do not
+// authenticate are rejected with 401.
+func (s *Server) authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tokenRecord *db.APIToken
+		var err error
+		var rejection string
+
+		if c.Request.TLS != nil && len(c.Request.TLS.VerifiedChains) > 0 {
+			commonName := c.Request.TLS.VerifiedChains[0][0].Subject.CommonName
+			tokenRecord, err = s.service.AuthenticatePrincipal(commonName)
+			rejection = fmt.Sprintf("client certificate %q does not match an API principal", commonName)
+		} else {
+			scheme, secret, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+			if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(secret) == "" {
+				c.Header("WWW-Authenticate", `Bearer realm="netns-mgr"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+				return
+			}
+			tokenRecord, err = s.service.AuthenticateToken(strings.TrimSpace(secret))
+			rejection = "invalid token"
+		}
+
+		if err != nil {
+			if service.IsNotFound(err) {
+				c.Header("WWW-Authenticate", `Bearer realm="netns-mgr", error="invalid_token"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": rejection})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		project, err := s.service.TokenProject(tokenRecord)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(principalKey, tokenRecord.Name)
+		c.Set(roleKey, auth.Role(tokenRecord.Role))
+		if project != nil {
+			c.Set(projectKey, project)
+		}
+		c.Next()
+	}
+}
+
+// requestProject returns the project the request is confined to (nil = none)
+func requestProject(c *gin.Context) *db.Project {
+	project, _ := c.Get(projectKey)
+	confinedProject, _ := project.(*db.Project)
+	return confinedProject
+}
+
+// serviceFor returns the service acting on behalf of the request's project
+// Tokens without a project get the unrestricted service.
+func (s *Server) serviceFor(c *gin.Context) *service.Service {
+	project := requestProject(c)
+	if project == nil {
+		return s.service
+	}
+	return s.service.ForProject(project)
+}
+
+// requireUnconfined rejects project tokens with 403
+// Used for endpoints that expose data of every project, such as metrics and the audit log.
+func requireUnconfined() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if project := requestProject(c); project != nil {
+			message := fmt.Sprintf("tokens of project %q may not %s %s", project.Name, c.Request.Method, c.FullPath())
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": message})
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorize rejects requests whose role is too low with 403
+// Parameters:
+//   - readRole: role required for GET and HEAD requests
+//   - writeRole: role required for every other method
+func authorize(readRole, writeRole auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requiredRole := writeRole
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			requiredRole = readRole
+		}
+
+		role, _ := c.Get(roleKey)
+		principalRole, _ := role.(auth.Role)
+		if !principalRole.Allows(requiredRole) {
+			message := fmt.Sprintf("role %q may not %s %s (requires %s)", principalRole, c.Request.Method, c.FullPath(), requiredRole)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": message})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+// resourceETag returns the strong ETag of a resource as served by its GET endpoint
+func resourceETag(resource any) string {
+	payload, _ := json.Marshal(resource)
+	digest := sha256.Sum256(payload)
+	return `"` + hex.EncodeToString(digest[:16]) + `"`
+}
+
+// etagListed reports whether an If-Match or If-None-Match header lists an ETag ("*" lists any)
+// Parameters:
+//   - header: comma-separated entity tags
+//   - etag: current ETag of the resource
+//   - weak: compare weakly, ignoring "W/" (If-None-Match) instead of strongly (If-Match)
+func etagListed(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// respondResource responds with a resource and its ETag, or with 304 if If-None-Match lists the ETag
+func respondResource(c *gin.Context, resource any) {
+	etag := resourceETag(resource)
+	c.Header("ETag", etag)
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && etagListed(ifNoneMatch, etag, true) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, resource)
+}
+
+// resourceLoader returns how to load a resource as served by its GET endpoint, or nil if it has none
+// Parameters:
+//   - resourceType: label resource type
+//   - name: resource name, or record ID for addresses and routes
+func (s *Server) resourceLoader(c *gin.Context, resourceType, name string) func() (any, error) {
+	requestService := s.serviceFor(c)
+	switch resourceType {
+	case db.LabelNamespaces:
+		return func() (any, error) { return requestService.GetNamespace(name) }
+	case db.LabelVeths:
+		return func() (any, error) { return requestService.GetVeth(name) }
+	case db.LabelBridges:
+		return func() (any, error) { return requestService.GetBridge(name) }
+	case db.LabelGRETunnels:
+		return func() (any, error) { return requestService.GetGRETunnel(name) }
+	case db.LabelAddresses, db.LabelRoutes:
+		return func() (any, error) {
+			id, err := strconv.ParseInt(name, 10, 64)
+			if err != nil {
+				return nil, &service.ValidationError{Message: "invalid ID"}
+			}
+			if resourceType == db.LabelAddresses {
+				return requestService.GetAddress(id)
+			}
+			return requestService.GetRoute(id)
+		}
+	}
+	return nil
+}
+
+// preconditionHolds evaluates If-Match against the current state of a resource
+// Requests without If-Match always proceed. Otherwise the request proceeds
+// only if the resource's current ETag is listed; a changed or missing
+// resource is rejected with 412. Returns false after responding.
+// Parameters:
+//   - load: loads the resource as served by its GET endpoint
+func preconditionHolds(c *gin.Context, load func() (any, error)) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		return true
+	}
+
+	current, err := load()
+	switch {
+	case service.IsNotFound(err):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error() + "; If-Match cannot match"})
+		return false
+	case err != nil:
+		respondError(c, err)
+		return false
+	case !etagListed(ifMatch, resourceETag(current), false):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "resource has changed: its ETag does not match If-Match"})
+		return false
+	}
+	return true
+}
+
+// serializeConditionalRequests runs changes to resources that support If-Match one at a time
+// Every change on a conditional route holds the lock, with or without
+// If-Match, so no change can modify a resource between another request's
+// ETag check and its change. If-Match on a route that cannot evaluate it
+// is rejected with 400 rather than ignored.
+func (s *Server) serializeConditionalRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		route := c.Request.Method + " " + c.FullPath()
+		if !operationDocs[route].Conditional {
+			if c.GetHeader("If-Match") != "" {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("If-Match is not supported on %s", route)})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		s.conditionalMutex.Lock()
+		defer s.conditionalMutex.Unlock()
+		c.Next()
+	}
+}
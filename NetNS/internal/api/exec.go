@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+// execOutputEvent is the data of the stdout and stderr events of an exec stream
+type execOutputEvent struct {
+	Data string `json:"data"`
+}
+
+// execExitEvent is the data of the final exit event of an exec stream
+type execExitEvent struct {
+	ExitCode int    `json:"exit_code"`       // -1 if a signal ended the command
+	Error    string `json:"error,omitempty"` // Set when the command was stopped, e.g. by its timeout
+}
+
+// execEventStream sends the output of a command as Server-Sent Events
+// Headers are only written with the first event, so a command that fails
+// to start is still answered with a plain JSON error.
+type execEventStream struct {
+	c       *gin.Context
+	mutex   sync.Mutex
+	started bool
+}
+
+// start writes the event stream headers once; the caller holds the mutex
+func (stream *execEventStream) start() {
+	if stream.started {
+		return
+	}
+	stream.started = true
+	stream.c.Header("Content-Type", "text/event-stream")
+	stream.c.Header("Cache-Control", "no-cache")
+	stream.c.Header("Connection", "keep-alive")
+	stream.c.Header("X-Accel-Buffering", "no")
+	stream.c.Status(http.StatusOK)
+}
+
+// send writes one event and flushes it to the client
+func (stream *execEventStream) send(eventType string, data any) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	stream.start()
+	stream.c.SSEvent(eventType, data)
+	stream.c.Writer.Flush()
+}
+
+// execOutputWriter turns the writes of one output of a command into events
+// A multi-byte character split across writes is held back until it is complete.
+type execOutputWriter struct {
+	stream    *execEventStream
+	eventType string
+	pending   []byte
+}
+
+func (writer *execOutputWriter) Write(data []byte) (int, error) {
+	writer.pending = append(writer.pending, data...)
+	completeLength := completeUTF8Length(writer.pending)
+	if completeLength > 0 {
+		writer.stream.send(writer.eventType, execOutputEvent{Data: string(writer.pending[:completeLength])})
+		writer.pending = append(writer.pending[:0], writer.pending[completeLength:]...)
+	}
+	return len(data), nil
+}
+
+// flush sends whatever is still held back
+func (writer *execOutputWriter) flush() {
+	if len(writer.pending) > 0 {
+		writer.stream.send(writer.eventType, execOutputEvent{Data: string(writer.pending)})
+		writer.pending = nil
+	}
+}
+
+// completeUTF8Length returns the length of data without a trailing incomplete UTF-8 character
+func completeUTF8Length(data []byte) int {
+	for index := len(data) - 1; index >= 0 && index >= len(data)-utf8.UTFMax; index-- {
+		if utf8.RuneStart(data[index]) {
+			if !utf8.FullRune(data[index:]) {
+				return index
+			}
+			break
+		}
+	}
+	return len(data)
+}
+
+// execInNamespace runs a command in a namespace and streams its output as Server-Sent Events
+// Events: "stdout" and "stderr" with {"data": ...} as output arrives, then one
+// "exit" with the exit code. Disconnecting kills the command.
+func (s *Server) execInNamespace(c *gin.Context) {
+	var request service.ExecRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	stream := &execEventStream{c: c}
+	stdout := &execOutputWriter{stream: stream, eventType: "stdout"}
+	stderr := &execOutputWriter{stream: stream, eventType: "stderr"}
+
+	exitCode, err := s.serviceFor(c).ExecInNamespace(c.Request.Context(), c.Param("name"), request, nil, stdout, stderr)
+
+	stream.mutex.Lock()
+	started := stream.started
+	stream.mutex.Unlock()
+	// A command stopped by its timeout still reports how it ended
+	if err != nil && !started && !errors.Is(err, context.DeadlineExceeded) {
+		respondError(c, err)
+		return
+	}
+
+	stdout.flush()
+	stderr.flush()
+	exitEvent := execExitEvent{ExitCode: exitCode}
+	if err != nil {
+		exitEvent.Error = err.Error()
+	}
+	stream.send("exit", exitEvent)
+}
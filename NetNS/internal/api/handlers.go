@@ -1,38 +1,128 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/jobs"
+	"github.com/zenith/netns-mgr/internal/metrics"
 	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
+	"github.com/zenith/netns-mgr/internal/txn"
 )
 
-// === Namespace Handlers ===
+// respondError maps service errors to HTTP status codes
+func respondError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case service.IsValidation(err):
+		status = http.StatusBadRequest
+	case service.IsNotFound(err):
+		status = http.StatusNotFound
+	case service.IsForbidden(err):
+		status = http.StatusForbidden
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// nextCursorHeader carries the cursor of the next page of a list response
+const nextCursorHeader = "X-Next-Cursor"
+
+// listOptions reads the list options shared by the list endpoints, responding with 400 on malformed ones
+func listOptions(c *gin.Context) (service.ListOptions, bool) {
+	options := service.ListOptions{
+		Selector:  c.Query("selector"),
+		Interface: c.Query("interface"),
+		Within:    c.Query("within"),
+		Sort:      c.Query("sort"),
+		Cursor:    c.Query("cursor"),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		var err error
+		if options.Limit, err = strconv.Atoi(limit); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit %q", limit)})
+			return options, false
+		}
+	}
+	for parameter, target := range map[string]*time.Time{"created_after": &options.CreatedAfter, "created_before": &options.CreatedBefore} {
+		if value := c.Query(parameter); value != "" {
+			var err error
+			if *target, err = time.Parse(time.RFC3339, value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s %q: expected an RFC 3339 time", parameter, value)})
+				return options, false
+			}
+		}
+	}
+	return options, true
+}
 
-type createNamespaceRequest struct {
-	Name     string `json:"name" binding:"required"`
-	Metadata string `json:"metadata"`
+// respondPage responds with one page of a list, passing the cursor of the next page in a header
+func respondPage(c *gin.Context, records any, nextCursor string) {
+	if nextCursor != "" {
+		c.Header(nextCursorHeader, nextCursor)
+	}
+	c.JSON(http.StatusOK, records)
 }
 
-func (s *Server) createNamespace(c *gin.Context) {
-	var request createNamespaceRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
+// bindJSON decodes the request body, responding with 400 on malformed JSON
+func bindJSON(c *gin.Context, request any) bool {
+	if err := c.ShouldBindJSON(request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// runOperation runs a long operation during the request, or as a background job with ?async=true
+// An asynchronous run responds 202 with the queued job and its location; the
+// job's result is the body a synchronous run responds with.
+// Parameters:
+//   - operation: description of the job, e.g. "delete namespace lab1"
+//   - status: status of a successful synchronous run
+//   - run: the operation; it must report its kernel changes through step
+func (s *Server) runOperation(c *gin.Context, operation string, status int, run jobs.Func) {
+	if c.Query("async") != "true" {
+		result, err := run(nil)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(status, result)
 		return
 	}
 
-	// Create in system
-	if err := s.namespaceManager.Create(request.Name); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var projectID *int64
+	if project := requestProject(c); project != nil {
+		projectID = &project.ID
+	}
+	job, err := s.jobManager.Submit(operation, c.GetString(principalKey), projectID, run)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("%s/jobs/%d", apiPrefix, job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// === Namespace Handlers ===
+
+func (s *Server) createNamespace(c *gin.Context) {
+	var request service.CreateNamespaceRequest
+	if !bindJSON(c, &request) {
 		return
 	}
 
-	// Record in database
-	ns, err := s.repository.CreateNamespace(request.Name, request.Metadata)
+	ns, err := s.serviceFor(c).CreateNamespace(request)
 	if err != nil {
-		s.namespaceManager.Delete(request.Name)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -40,152 +130,159 @@ func (s *Server) createNamespace(c *gin.Context) {
 }
 
 func (s *Server) listNamespaces(c *gin.Context) {
-	namespaces, err := s.repository.ListNamespaces()
+	options, ok := listOptions(c)
+	if !ok {
+		return
+	}
+	namespaces, nextCursor, err := s.serviceFor(c).ListNamespaces(options)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, namespaces)
+	respondPage(c, namespaces, nextCursor)
 }
 
-func (s *Server) getNamespace(c *gin.Context) {
-	name := c.Param("name")
-
-	ns, err := s.repository.GetNamespaceByName(name)
+// namespaceStatus compares the namespaces in the kernel with the recorded ones
+func (s *Server) namespaceStatus(c *gin.Context) {
+	namespaceStatuses, err := s.serviceFor(c).NamespaceStatuses()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	if ns == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "namespace not found"})
+	c.JSON(http.StatusOK, namespaceStatuses)
+}
+
+func (s *Server) getNamespace(c *gin.Context) {
+	ns, err := s.serviceFor(c).GetNamespace(c.Param("name"))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, ns)
+	respondResource(c, ns)
 }
 
-func (s *Server) deleteNamespace(c *gin.Context) {
-	name := c.Param("name")
-
-	// Delete from system
-	if err := s.namespaceManager.Delete(name); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+// updateNamespace replaces the metadata of a namespace
+func (s *Server) updateNamespace(c *gin.Context) {
+	var request service.UpdateNamespaceRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	if !preconditionHolds(c, s.resourceLoader(c, db.LabelNamespaces, c.Param("name"))) {
 		return
 	}
 
-	// Remove from database
-	s.repository.DeleteNamespace(name)
+	namespace, err := s.serviceFor(c).UpdateNamespace(c.Param("name"), request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "namespace deleted"})
+	c.Header("ETag", resourceETag(namespace))
+	c.JSON(http.StatusOK, namespace)
 }
 
-// === Veth Handlers ===
+func (s *Server) deleteNamespace(c *gin.Context) {
+	namespaceName := c.Param("name")
+	// The job would run after the lock taken for the ETag check is released
+	if c.GetHeader("If-Match") != "" && c.Query("async") == "true" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match is not supported with async=true"})
+		return
+	}
+	if !preconditionHolds(c, s.resourceLoader(c, db.LabelNamespaces, namespaceName)) {
+		return
+	}
+	requestService := s.serviceFor(c)
 
-type createVethRequest struct {
-	Name      string `json:"name" binding:"required"`
-	PeerName  string `json:"peer_name" binding:"required"`
-	Namespace string `json:"namespace"`
-	PeerNs    string `json:"peer_namespace"`
+	s.runOperation(c, "delete namespace "+namespaceName, http.StatusOK, func(step txn.StepObserver) (any, error) {
+		if err := requestService.WithStepObserver(step).DeleteNamespace(namespaceName); err != nil {
+			return nil, err
+		}
+		return gin.H{"message": "namespace deleted"}, nil
+	})
 }
 
+// === Veth Handlers ===
+
 func (s *Server) createVeth(c *gin.Context) {
-	var request createVethRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request service.CreateVethRequest
+	if !bindJSON(c, &request) {
 		return
 	}
 
-	// Create in system
-	if err := s.vethManager.Create(request.Name, request.PeerName, request.Namespace, request.PeerNs); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	veth, err := s.serviceFor(c).CreateVeth(request)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Get namespace IDs
-	var nsID, peerNsID *int64
-	if request.Namespace != "" {
-		if ns, _ := s.repository.GetNamespaceByName(request.Namespace); ns != nil {
-			nsID = &ns.ID
-		}
-	}
-	if request.PeerNs != "" {
-		if ns, _ := s.repository.GetNamespaceByName(request.PeerNs); ns != nil {
-			peerNsID = &ns.ID
-		}
-	}
+	c.JSON(http.StatusCreated, veth)
+}
 
-	// Record in database
-	veth, err := s.repository.CreateVethPair(request.Name, request.PeerName, nsID, peerNsID)
+func (s *Server) listVeths(c *gin.Context) {
+	options, ok := listOptions(c)
+	if !ok {
+		return
+	}
+	veths, nextCursor, err := s.serviceFor(c).ListVeths(c.Query("namespace"), options)
 	if err != nil {
-		s.vethManager.Delete(request.Name)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, veth)
+	respondPage(c, veths, nextCursor)
 }
 
-func (s *Server) listVeths(c *gin.Context) {
-	veths, err := s.repository.ListVethPairs()
+func (s *Server) getVeth(c *gin.Context) {
+	veth, err := s.serviceFor(c).GetVeth(c.Param("name"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, veths)
+	respondResource(c, veth)
 }
 
 func (s *Server) deleteVeth(c *gin.Context) {
-	name := c.Param("name")
-
-	// Delete from system
-	if err := s.vethManager.Delete(name); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := s.serviceFor(c).DeleteVeth(c.Param("name")); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Remove from database
-	s.repository.DeleteVethPair(name)
-
 	c.JSON(http.StatusOK, gin.H{"message": "veth pair deleted"})
 }
 
-// === Address Handlers ===
+func (s *Server) vethUp(c *gin.Context) {
+	if err := s.serviceFor(c).SetVethUp(c.Param("name"), c.Query("namespace")); err != nil {
+		respondError(c, err)
+		return
+	}
 
-type addAddressRequest struct {
-	Interface string `json:"interface" binding:"required"`
-	Address   string `json:"address" binding:"required"`
-	Namespace string `json:"namespace"`
+	c.JSON(http.StatusOK, gin.H{"message": "interface is up"})
 }
 
-func (s *Server) addAddress(c *gin.Context) {
-	var request addAddressRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+func (s *Server) vethDown(c *gin.Context) {
+	if err := s.serviceFor(c).SetVethDown(c.Param("name"), c.Query("namespace")); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Add to system
-	if err := s.addressManager.Add(request.Address, request.Interface, request.Namespace); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+	c.JSON(http.StatusOK, gin.H{"message": "interface is down"})
+}
 
-	// Get namespace ID
-	var nsID *int64
-	if request.Namespace != "" {
-		if ns, _ := s.repository.GetNamespaceByName(request.Namespace); ns != nil {
-			nsID = &ns.ID
-		}
+// === Address Handlers ===
+
+func (s *Server) addAddress(c *gin.Context) {
+	var request service.AddressRequest
+	if !bindJSON(c, &request) {
+		return
 	}
 
-	// Record in database
-	addr, err := s.repository.CreateIPAddress(request.Interface, nsID, request.Address)
+	addr, err := s.serviceFor(c).AddAddress(request)
 	if err != nil {
-		s.addressManager.Delete(request.Address, request.Interface, request.Namespace)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -193,99 +290,124 @@ func (s *Server) addAddress(c *gin.Context) {
 }
 
 func (s *Server) listAddresses(c *gin.Context) {
-	nsName := c.Query("namespace")
-
-	var nsID *int64
-	if nsName != "" {
-		if ns, _ := s.repository.GetNamespaceByName(nsName); ns != nil {
-			nsID = &ns.ID
-		}
+	options, ok := listOptions(c)
+	if !ok {
+		return
 	}
-
-	addresses, err := s.repository.ListIPAddresses(nsID)
+	addresses, nextCursor, err := s.serviceFor(c).ListAddresses(c.Query("namespace"), options)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, addresses)
+	respondPage(c, addresses, nextCursor)
 }
 
 func (s *Server) deleteAddress(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
 		return
 	}
 
-	// Get address info for system deletion
-	addr, err := s.repository.GetIPAddress(id)
-	if err != nil || addr == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "address not found"})
+	if !preconditionHolds(c, s.resourceLoader(c, db.LabelAddresses, c.Param("id"))) {
 		return
 	}
 
-	// Get namespace name
-	var nsName string
-	if addr.NsID != nil {
-		if ns, _ := s.repository.GetNamespace(*addr.NsID); ns != nil {
-			nsName = ns.Name
-		}
+	if err := s.serviceFor(c).DeleteAddressByID(id); err != nil {
+		respondError(c, err)
+		return
 	}
 
-	// Delete from system
-	if err := s.addressManager.Delete(addr.Address, addr.InterfaceName, nsName); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, gin.H{"message": "address deleted"})
+}
+
+func (s *Server) getAddress(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
 		return
 	}
 
-	// Remove from database
-	s.repository.DeleteIPAddress(id)
+	address, err := s.serviceFor(c).GetAddress(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "address deleted"})
+	respondResource(c, address)
 }
 
-// === Route Handlers ===
+// updateAddress changes the label and lifetimes of a recorded address
+func (s *Server) updateAddress(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+
+	var request service.UpdateAddressRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	if !preconditionHolds(c, s.resourceLoader(c, db.LabelAddresses, c.Param("id"))) {
+		return
+	}
+
+	address, err := s.serviceFor(c).UpdateAddress(id, request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
-type addRouteRequest struct {
-	Destination string `json:"destination" binding:"required"`
-	Gateway     string `json:"gateway"`
-	Interface   string `json:"interface"`
-	Namespace   string `json:"namespace"`
+	c.Header("ETag", resourceETag(address))
+	c.JSON(http.StatusOK, address)
 }
 
-func (s *Server) addRoute(c *gin.Context) {
-	var request addRouteRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// deleteAddressByValue removes an address given ?interface=, ?address= and ?namespace=
+// Unlike deleteAddress it also removes addresses that were never recorded.
+func (s *Server) deleteAddressByValue(c *gin.Context) {
+	if c.Query("selector") != "" {
+		s.deleteBySelector(c, db.LabelAddresses)
 		return
 	}
 
-	if request.Gateway == "" && request.Interface == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "either gateway or interface is required"})
+	request := service.AddressRequest{
+		Interface: c.Query("interface"),
+		Address:   c.Query("address"),
+		Namespace: c.Query("namespace"),
+	}
+
+	if err := s.serviceFor(c).DeleteAddress(request); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Add to system
-	if err := s.routeManager.Add(request.Destination, request.Gateway, request.Interface, request.Namespace); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, gin.H{"message": "address deleted"})
+}
+
+// addressStatus returns the addresses currently present in a namespace
+func (s *Server) addressStatus(c *gin.Context) {
+	addressInfos, err := s.serviceFor(c).AddressInfos(c.Query("namespace"))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Get namespace ID
-	var nsID *int64
-	if request.Namespace != "" {
-		if ns, _ := s.repository.GetNamespaceByName(request.Namespace); ns != nil {
-			nsID = &ns.ID
-		}
+	c.JSON(http.StatusOK, addressInfos)
+}
+
+// === Route Handlers ===
+
+func (s *Server) addRoute(c *gin.Context) {
+	var request service.AddRouteRequest
+	if !bindJSON(c, &request) {
+		return
 	}
 
-	// Record in database
-	route, err := s.repository.CreateRoute(nsID, request.Destination, request.Gateway, request.Interface)
+	route, err := s.serviceFor(c).AddRoute(request)
 	if err != nil {
-		s.routeManager.Delete(request.Destination, request.Namespace)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -293,92 +415,117 @@ func (s *Server) addRoute(c *gin.Context) {
 }
 
 func (s *Server) listRoutes(c *gin.Context) {
-	nsName := c.Query("namespace")
-
-	var nsID *int64
-	if nsName != "" {
-		if ns, _ := s.repository.GetNamespaceByName(nsName); ns != nil {
-			nsID = &ns.ID
-		}
+	options, ok := listOptions(c)
+	if !ok {
+		return
 	}
-
-	routes, err := s.repository.ListRoutes(nsID)
+	routes, nextCursor, err := s.serviceFor(c).ListRoutes(c.Query("namespace"), options)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, routes)
+	respondPage(c, routes, nextCursor)
 }
 
 func (s *Server) deleteRoute(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
 		return
 	}
 
-	// Get route info for system deletion
-	route, err := s.repository.GetRoute(id)
-	if err != nil || route == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "route not found"})
+	if !preconditionHolds(c, s.resourceLoader(c, db.LabelRoutes, c.Param("id"))) {
 		return
 	}
 
-	// Get namespace name
-	var nsName string
-	if route.NsID != nil {
-		if ns, _ := s.repository.GetNamespace(*route.NsID); ns != nil {
-			nsName = ns.Name
-		}
+	if err := s.serviceFor(c).DeleteRouteByID(id); err != nil {
+		respondError(c, err)
+		return
 	}
 
-	// Delete from system
-	if err := s.routeManager.Delete(route.Destination, nsName); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, gin.H{"message": "route deleted"})
+}
+
+func (s *Server) getRoute(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
 		return
 	}
 
-	// Remove from database
-	s.repository.DeleteRoute(id)
+	route, err := s.serviceFor(c).GetRoute(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "route deleted"})
+	respondResource(c, route)
 }
 
-// === Bridge Handlers ===
+// replaceRoute changes the gateway and interface of a recorded route
+func (s *Server) replaceRoute(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+
+	var request service.ReplaceRouteRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	if !preconditionHolds(c, s.resourceLoader(c, db.LabelRoutes, c.Param("id"))) {
+		return
+	}
+
+	route, err := s.serviceFor(c).ReplaceRoute(id, request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
-type createBridgeRequest struct {
-	Name      string `json:"name" binding:"required"`
-	Namespace string `json:"namespace"`
+	c.Header("ETag", resourceETag(route))
+	c.JSON(http.StatusOK, route)
 }
 
-func (s *Server) createBridge(c *gin.Context) {
-	var request createBridgeRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// deleteRouteByDestination removes a route given ?destination= and ?namespace=
+func (s *Server) deleteRouteByDestination(c *gin.Context) {
+	if c.Query("selector") != "" {
+		s.deleteBySelector(c, db.LabelRoutes)
 		return
 	}
 
-	// Create in system
-	if err := s.bridgeManager.Create(request.Name, request.Namespace); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := s.serviceFor(c).DeleteRoute(c.Query("destination"), c.Query("namespace")); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Get namespace ID
-	var nsID *int64
-	if request.Namespace != "" {
-		if ns, _ := s.repository.GetNamespaceByName(request.Namespace); ns != nil {
-			nsID = &ns.ID
-		}
+	c.JSON(http.StatusOK, gin.H{"message": "route deleted"})
+}
+
+// routeStatus returns the routes currently present in a namespace
+func (s *Server) routeStatus(c *gin.Context) {
+	routeInfos, err := s.serviceFor(c).RouteInfos(c.Query("namespace"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, routeInfos)
+}
+
+// === Bridge Handlers ===
+
+func (s *Server) createBridge(c *gin.Context) {
+	var request service.CreateBridgeRequest
+	if !bindJSON(c, &request) {
+		return
 	}
 
-	// Record in database
-	bridge, err := s.repository.CreateBridge(request.Name, nsID)
+	bridge, err := s.serviceFor(c).CreateBridge(request)
 	if err != nil {
-		s.bridgeManager.Delete(request.Name, request.Namespace)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -386,73 +533,77 @@ func (s *Server) createBridge(c *gin.Context) {
 }
 
 func (s *Server) listBridges(c *gin.Context) {
-	bridges, err := s.repository.ListBridges()
+	options, ok := listOptions(c)
+	if !ok {
+		return
+	}
+	bridges, err := s.serviceFor(c).ListBridges(options)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, bridges)
 }
 
-func (s *Server) deleteBridge(c *gin.Context) {
-	name := c.Param("name")
-	nsName := c.Query("namespace")
-
-	// Delete from system
-	if err := s.bridgeManager.Delete(name, nsName); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+func (s *Server) getBridge(c *gin.Context) {
+	bridge, err := s.serviceFor(c).GetBridge(c.Param("name"))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Remove from database
-	s.repository.DeleteBridge(name)
-
-	c.JSON(http.StatusOK, gin.H{"message": "bridge deleted"})
+	respondResource(c, bridge)
 }
 
-type addPortRequest struct {
-	Interface string `json:"interface" binding:"required"`
-}
+// bridgeStatus returns the bridges currently present in a namespace with their ports
+func (s *Server) bridgeStatus(c *gin.Context) {
+	bridgeInfos, err := s.serviceFor(c).BridgeInfos(c.Query("namespace"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
-func (s *Server) addBridgePort(c *gin.Context) {
-	bridgeName := c.Param("name")
-	nsName := c.Query("namespace")
+	c.JSON(http.StatusOK, bridgeInfos)
+}
 
-	var request addPortRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+func (s *Server) deleteBridge(c *gin.Context) {
+	if err := s.serviceFor(c).DeleteBridge(c.Param("name"), c.Query("namespace")); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Add to system
-	if err := s.bridgeManager.AddPort(bridgeName, request.Interface, nsName); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, gin.H{"message": "bridge deleted"})
+}
+
+func (s *Server) addBridgePort(c *gin.Context) {
+	var request service.BridgePortRequest
+	if !bindJSON(c, &request) {
 		return
 	}
+	request.Bridge = c.Param("name")
+	if request.Namespace == "" {
+		request.Namespace = c.Query("namespace")
+	}
 
-	// Record in database
-	if bridge, _ := s.repository.GetBridgeByName(bridgeName); bridge != nil {
-		s.repository.AddBridgePort(bridge.ID, request.Interface)
+	if err := s.serviceFor(c).AddBridgePort(request); err != nil {
+		respondError(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "port added"})
 }
 
 func (s *Server) removeBridgePort(c *gin.Context) {
-	bridgeName := c.Param("name")
-	ifaceName := c.Param("iface")
-	nsName := c.Query("namespace")
-
-	// Remove from system
-	if err := s.bridgeManager.RemovePort(ifaceName, nsName); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	request := service.BridgePortRequest{
+		Bridge:    c.Param("name"),
+		Interface: c.Param("iface"),
+		Namespace: c.Query("namespace"),
 	}
 
-	// Remove from database
-	if bridge, _ := s.repository.GetBridgeByName(bridgeName); bridge != nil {
-		s.repository.RemoveBridgePort(bridge.ID, ifaceName)
+	if err := s.serviceFor(c).RemoveBridgePort(request); err != nil {
+		respondError(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "port removed"})
@@ -460,49 +611,15 @@ func (s *Server) removeBridgePort(c *gin.Context) {
 
 // === GRE Tunnel Handlers ===
 
-type createGRETunnelRequest struct {
-	Name      string `json:"name" binding:"required"`
-	LocalIP   string `json:"local_ip" binding:"required"`
-	RemoteIP  string `json:"remote_ip" binding:"required"`
-	Key       uint32 `json:"key"`
-	TTL       uint8  `json:"ttl"`
-	Namespace string `json:"namespace"`
-}
-
 func (s *Server) createGRETunnel(c *gin.Context) {
-	var request createGRETunnelRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Create in system
-	tunnel := netns.GRETunnel{
-		Name:      request.Name,
-		LocalIP:   request.LocalIP,
-		RemoteIP:  request.RemoteIP,
-		Key:       request.Key,
-		TTL:       request.TTL,
-		Namespace: request.Namespace,
-	}
-	if err := s.greManager.CreateWithOptions(tunnel); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var request service.CreateGRETunnelRequest
+	if !bindJSON(c, &request) {
 		return
 	}
 
-	// Get namespace ID
-	var nsID *int64
-	if request.Namespace != "" {
-		if ns, _ := s.repository.GetNamespaceByName(request.Namespace); ns != nil {
-			nsID = &ns.ID
-		}
-	}
-
-	// Record in database
-	greTunnel, err := s.repository.CreateGRETunnel(request.Name, request.LocalIP, request.RemoteIP, request.Key, request.TTL, nsID)
+	greTunnel, err := s.serviceFor(c).CreateGRETunnel(request)
 	if err != nil {
-		s.greManager.Delete(request.Name, request.Namespace)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -510,63 +627,76 @@ func (s *Server) createGRETunnel(c *gin.Context) {
 }
 
 func (s *Server) listGRETunnels(c *gin.Context) {
-	nsName := c.Query("namespace")
-
-	var nsID *int64
-	if nsName != "" {
-		if ns, _ := s.repository.GetNamespaceByName(nsName); ns != nil {
-			nsID = &ns.ID
-		}
+	options, ok := listOptions(c)
+	if !ok {
+		return
+	}
+	tunnels, nextCursor, err := s.serviceFor(c).ListGRETunnels(c.Query("namespace"), options)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
-	tunnels, err := s.repository.ListGRETunnels(nsID)
+	respondPage(c, tunnels, nextCursor)
+}
+
+// greStatus returns the GRE tunnels currently present in a namespace
+func (s *Server) greStatus(c *gin.Context) {
+	tunnelInfos, err := s.serviceFor(c).GRETunnelInfos(c.Query("namespace"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, tunnels)
+	c.JSON(http.StatusOK, tunnelInfos)
 }
 
 func (s *Server) getGRETunnel(c *gin.Context) {
-	name := c.Param("name")
-
-	tunnel, err := s.repository.GetGRETunnelByName(name)
+	tunnel, err := s.serviceFor(c).GetGRETunnel(c.Param("name"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
+		return
+	}
+
+	respondResource(c, tunnel)
+}
+
+// updateGRETunnel changes the endpoints, key or TTL of a GRE tunnel
+func (s *Server) updateGRETunnel(c *gin.Context) {
+	var request service.UpdateGRETunnelRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	if !preconditionHolds(c, s.resourceLoader(c, db.LabelGRETunnels, c.Param("name"))) {
 		return
 	}
 
-	if tunnel == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "GRE tunnel not found"})
+	tunnel, err := s.serviceFor(c).UpdateGRETunnel(c.Param("name"), request)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
+	c.Header("ETag", resourceETag(tunnel))
 	c.JSON(http.StatusOK, tunnel)
 }
 
 func (s *Server) deleteGRETunnel(c *gin.Context) {
-	name := c.Param("name")
-	nsName := c.Query("namespace")
-
-	// Delete from system
-	if err := s.greManager.Delete(name, nsName); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !preconditionHolds(c, s.resourceLoader(c, db.LabelGRETunnels, c.Param("name"))) {
 		return
 	}
 
-	// Remove from database
-	s.repository.DeleteGRETunnel(name)
+	if err := s.serviceFor(c).DeleteGRETunnel(c.Param("name"), c.Query("namespace")); err != nil {
+		respondError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "GRE tunnel deleted"})
 }
 
 func (s *Server) greUp(c *gin.Context) {
-	name := c.Param("name")
-	nsName := c.Query("namespace")
-
-	if err := s.greManager.SetUp(name, nsName); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := s.serviceFor(c).SetGRETunnelUp(c.Param("name"), c.Query("namespace")); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -574,62 +704,660 @@ func (s *Server) greUp(c *gin.Context) {
 }
 
 func (s *Server) greDown(c *gin.Context) {
-	name := c.Param("name")
-	nsName := c.Query("namespace")
-
-	if err := s.greManager.SetDown(name, nsName); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := s.serviceFor(c).SetGRETunnelDown(c.Param("name"), c.Query("namespace")); err != nil {
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "GRE tunnel is down"})
 }
 
-type createPeerTunnelsRequest struct {
-	TunnelName  string `json:"tunnel_name" binding:"required"`
-	Ns1         string `json:"ns1" binding:"required"`
-	Ns1IP       string `json:"ns1_ip" binding:"required"`
-	Ns1TunnelIP string `json:"ns1_tunnel_ip" binding:"required"`
-	Ns2         string `json:"ns2" binding:"required"`
-	Ns2IP       string `json:"ns2_ip" binding:"required"`
-	Ns2TunnelIP string `json:"ns2_tunnel_ip" binding:"required"`
-}
-
 func (s *Server) createPeerTunnels(c *gin.Context) {
-	var request createPeerTunnelsRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request service.CreatePeerTunnelsRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Reject bad requests before a job is queued for them
+	if err := request.Validate(); err != nil {
+		respondError(c, err)
+		return
+	}
+	requestService := s.serviceFor(c)
+
+	s.runOperation(c, "create gre peer "+request.TunnelName, http.StatusCreated, func(step txn.StepObserver) (any, error) {
+		tunnelRecords, err := requestService.WithStepObserver(step).CreatePeerTunnels(request)
+		if err != nil {
+			return nil, err
+		}
+
+		tunnelNames := make([]string, 0, len(tunnelRecords))
+		for _, tunnelRecord := range tunnelRecords {
+			tunnelNames = append(tunnelNames, tunnelRecord.Name)
+		}
+		return gin.H{
+			"message": "peer tunnels created",
+			"tunnels": tunnelNames,
+		}, nil
+	})
+}
+
+// === Macvlan/IPVlan Handlers ===
+
+func (s *Server) createMacvlan(c *gin.Context) {
+	var request service.CreateMacvlanRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	link, err := s.serviceFor(c).CreateMacvlan(request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+func (s *Server) listMacvlans(c *gin.Context) {
+	options, ok := listOptions(c)
+	if !ok {
+		return
+	}
+	links, err := s.serviceFor(c).ListMacvlans(c.Query("kind"), options)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+func (s *Server) deleteMacvlan(c *gin.Context) {
+	if err := s.serviceFor(c).DeleteMacvlan(c.Param("name"), c.Query("namespace")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "link deleted"})
+}
+
+// === Bond Handlers ===
+
+func (s *Server) createBond(c *gin.Context) {
+	var request service.CreateBondRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	bond, err := s.serviceFor(c).CreateBond(request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, bond)
+}
+
+func (s *Server) listBonds(c *gin.Context) {
+	options, ok := listOptions(c)
+	if !ok {
+		return
+	}
+	bonds, err := s.serviceFor(c).ListBonds(c.Query("namespace"), options)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bonds)
+}
+
+func (s *Server) bondStatus(c *gin.Context) {
+	bondInfos, err := s.serviceFor(c).BondInfos(c.Query("namespace"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bondInfos)
+}
+
+func (s *Server) deleteBond(c *gin.Context) {
+	if err := s.serviceFor(c).DeleteBond(c.Param("name"), c.Query("namespace")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "bond deleted"})
+}
+
+// === Dummy Interface Handlers ===
+
+func (s *Server) createDummy(c *gin.Context) {
+	var request service.CreateDummyRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	dummy, err := s.serviceFor(c).CreateDummy(request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dummy)
+}
+
+func (s *Server) listDummies(c *gin.Context) {
+	options, ok := listOptions(c)
+	if !ok {
+		return
+	}
+	dummies, err := s.serviceFor(c).ListDummies(c.Query("namespace"), options)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dummies)
+}
+
+func (s *Server) deleteDummy(c *gin.Context) {
+	if err := s.serviceFor(c).DeleteDummy(c.Param("name"), c.Query("namespace")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "dummy interface deleted"})
+}
+
+// === Traffic Control Handlers ===
+
+func (s *Server) setQdisc(c *gin.Context) {
+	var request service.SetImpairmentRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	request.Interface = c.Param("interface")
+	if request.Namespace == "" {
+		request.Namespace = c.Query("namespace")
+	}
+
+	qdisc, err := s.serviceFor(c).SetImpairment(request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, qdisc)
+}
+
+func (s *Server) showQdisc(c *gin.Context) {
+	qdiscInfo, err := s.serviceFor(c).ShowImpairment(c.Param("interface"), c.Query("namespace"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, qdiscInfo)
+}
+
+func (s *Server) clearQdisc(c *gin.Context) {
+	if err := s.serviceFor(c).ClearImpairment(c.Param("interface"), c.Query("namespace")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "impairment cleared"})
+}
+
+func (s *Server) listQdiscs(c *gin.Context) {
+	qdiscs, err := s.serviceFor(c).ListImpairments(c.Query("namespace"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, qdiscs)
+}
+
+// === Interface Handlers ===
+
+// hostNamespaceParam addresses the host namespace in interface paths
+const hostNamespaceParam = "-"
+
+// listInterfaces returns every interface across all namespaces, or one namespace with ?ns=
+func (s *Server) listInterfaces(c *gin.Context) {
+	nsName, filtered := c.GetQuery("ns")
+
+	var interfaces []netns.InterfaceInfo
+	var err error
+	switch {
+	case !filtered || nsName == "":
+		interfaces, err = s.serviceFor(c).ListAllInterfaces()
+	case nsName == hostNamespaceParam:
+		interfaces, err = s.serviceFor(c).ListInterfaces("")
+	default:
+		interfaces, err = s.serviceFor(c).ListInterfaces(nsName)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, interfaces)
+}
+
+func (s *Server) updateInterface(c *gin.Context) {
+	var request service.SetLinkRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	request.Interface = c.Param("name")
+	request.Namespace = c.Param("namespace")
+	if request.Namespace == hostNamespaceParam {
+		request.Namespace = ""
+	}
+
+	linkProperty, err := s.serviceFor(c).SetLinkProperties(request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, linkProperty)
+}
+
+// === Metrics Handler ===
+
+func (s *Server) metrics(c *gin.Context) {
+	c.Header("Content-Type", metrics.ContentType)
+	c.Status(http.StatusOK)
+
+	if err := s.metricsScraper.Write(c.Writer); err != nil {
+		return
+	}
+	s.requestMetrics.Write(c.Writer)
+}
+
+// === Event Handlers ===
+
+// eventKeepaliveInterval is how often a comment is sent to keep idle event streams open
+const eventKeepaliveInterval = 15 * time.Second
+
+// managedNamespaceNames lists the namespaces recorded in the database, for the watcher
+func (s *Server) managedNamespaceNames() ([]string, error) {
+	namespaces, err := s.repository.ListNamespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		names = append(names, ns.Name)
+	}
+	return names, nil
+}
+
+// streamEvents streams netlink events as Server-Sent Events
+// Optional filters: ?namespace=<name> ("-" = host) and ?type=link,addr,route,neigh,namespace
+// Project tokens only receive events of their project's namespaces.
+func (s *Server) streamEvents(c *gin.Context) {
+	nsName, filterNamespace := c.GetQuery("namespace")
+	if nsName == hostNamespaceParam {
+		nsName = ""
+	}
+
+	eventService := s.serviceFor(c)
+	if filterNamespace && eventService.Project() != nil {
+		// Resolves the project prefix and rejects foreign namespaces
+		namespaceDetails, err := eventService.GetNamespace(nsName)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		nsName = namespaceDetails.Name
+	}
+	// Project tokens only see their own namespaces. The set is loaded once and
+	// reloaded when a namespace comes or goes; the watcher only reports
+	// recorded namespaces, so a new one is already in the database.
+	var projectNamespaces map[string]bool
+	loadProjectNamespaces := func() {
+		namespaceRecords, _, err := eventService.ListNamespaces(service.ListOptions{})
+		if err != nil {
+			return
+		}
+		projectNamespaces = make(map[string]bool, len(namespaceRecords))
+		for _, namespaceRecord := range namespaceRecords {
+			projectNamespaces[namespaceRecord.Name] = true
+		}
+	}
+	inProject := func(event netns.Event) bool {
+		if eventService.Project() == nil {
+			return true
+		}
+		// A deleted namespace is no longer recorded, so check before reloading
+		inProjectBefore := projectNamespaces[event.Namespace]
+		if event.Type == netns.EventTypeNamespace {
+			loadProjectNamespaces()
+		}
+		if event.Type == netns.EventTypeNamespace && event.Action == netns.EventActionDelete {
+			return inProjectBefore
+		}
+		return projectNamespaces[event.Namespace]
+	}
+	if eventService.Project() != nil {
+		loadProjectNamespaces()
+	}
+
+	eventTypes := make(map[string]bool)
+	for _, eventType := range strings.Split(c.Query("type"), ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			eventTypes[eventType] = true
+		}
+	}
+
+	events, unsubscribe := s.watcher.Subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepalive := time.NewTicker(eventKeepaliveInterval)
+	defer keepalive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-keepalive.C:
+			io.WriteString(w, ": keepalive\n\n")
+			return true
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			if (filterNamespace && event.Namespace != nsName) || !inProject(event) {
+				return true
+			}
+			if len(eventTypes) > 0 && !eventTypes[event.Type] {
+				return true
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		}
+	})
+}
+
+// === Label Handlers ===
+
+// updateLabels merges the labels of the request body into a resource's labels
+// Parameters:
+//   - resourceType: label resource type
+//   - name: resource name, or record ID for addresses and routes
+func (s *Server) updateLabels(c *gin.Context, resourceType, name string) {
+	request := service.UpdateLabelsRequest{Resource: resourceType, Name: name}
+	if !bindJSON(c, &request) {
+		return
+	}
+	loadResource := s.resourceLoader(c, resourceType, name)
+	if loadResource != nil && !preconditionHolds(c, loadResource) {
+		return
+	}
+
+	labels, err := s.serviceFor(c).UpdateLabels(request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Hand back the new ETag for the next conditional change
+	if loadResource != nil {
+		if resource, err := loadResource(); err == nil {
+			c.Header("ETag", resourceETag(resource))
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"labels": labels})
+}
+
+// deleteBySelector deletes every resource of a type matching ?selector=
+func (s *Server) deleteBySelector(c *gin.Context, resourceType string) {
+	selectorExpression := c.Query("selector")
+	if err := service.ValidateDeleteSelector(selectorExpression); err != nil {
+		respondError(c, err)
+		return
+	}
+	requestService := s.serviceFor(c)
+
+	operation := fmt.Sprintf("delete %s selected by %s", resourceType, selectorExpression)
+	s.runOperation(c, operation, http.StatusOK, func(step txn.StepObserver) (any, error) {
+		deletedNames, err := requestService.WithStepObserver(step).DeleteBySelector(resourceType, selectorExpression)
+		if err != nil {
+			return nil, err
+		}
+		return gin.H{
+			"message": fmt.Sprintf("%d %s deleted", len(deletedNames), resourceType),
+			"deleted": deletedNames,
+		}, nil
+	})
+}
+
+func (s *Server) updateNamespaceLabels(c *gin.Context) {
+	s.updateLabels(c, db.LabelNamespaces, c.Param("name"))
+}
+
+func (s *Server) updateVethLabels(c *gin.Context) {
+	s.updateLabels(c, db.LabelVeths, c.Param("name"))
+}
+
+func (s *Server) updateAddressLabels(c *gin.Context) {
+	s.updateLabels(c, db.LabelAddresses, c.Param("id"))
+}
+
+func (s *Server) updateRouteLabels(c *gin.Context) {
+	s.updateLabels(c, db.LabelRoutes, c.Param("id"))
+}
+
+func (s *Server) updateBridgeLabels(c *gin.Context) {
+	s.updateLabels(c, db.LabelBridges, c.Param("name"))
+}
+
+func (s *Server) updateGRETunnelLabels(c *gin.Context) {
+	s.updateLabels(c, db.LabelGRETunnels, c.Param("name"))
+}
+
+func (s *Server) updateMacvlanLabels(c *gin.Context) {
+	s.updateLabels(c, db.LabelMacvlans, c.Param("name"))
+}
+
+func (s *Server) updateBondLabels(c *gin.Context) {
+	s.updateLabels(c, db.LabelBonds, c.Param("name"))
+}
+
+func (s *Server) updateDummyLabels(c *gin.Context) {
+	s.updateLabels(c, db.LabelDummies, c.Param("name"))
+}
+
+func (s *Server) deleteNamespacesBySelector(c *gin.Context) {
+	s.deleteBySelector(c, db.LabelNamespaces)
+}
+
+func (s *Server) deleteVethsBySelector(c *gin.Context) {
+	s.deleteBySelector(c, db.LabelVeths)
+}
+
+func (s *Server) deleteBridgesBySelector(c *gin.Context) {
+	s.deleteBySelector(c, db.LabelBridges)
+}
+
+func (s *Server) deleteGRETunnelsBySelector(c *gin.Context) {
+	s.deleteBySelector(c, db.LabelGRETunnels)
+}
+
+func (s *Server) deleteMacvlansBySelector(c *gin.Context) {
+	s.deleteBySelector(c, db.LabelMacvlans)
+}
+
+func (s *Server) deleteBondsBySelector(c *gin.Context) {
+	s.deleteBySelector(c, db.LabelBonds)
+}
+
+func (s *Server) deleteDummiesBySelector(c *gin.Context) {
+	s.deleteBySelector(c, db.LabelDummies)
+}
+
+// === Quota Handlers ===
+
+// listQuotas returns the quotas and usage of the caller's project, or of all projects
+func (s *Server) listQuotas(c *gin.Context) {
+	quotaUsages, err := s.serviceFor(c).QuotaUsages(c.Query("project"))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Create peer tunnels in system
-	err := s.greManager.CreatePeerTunnels(
-		request.Ns1, request.Ns1IP, request.Ns1TunnelIP,
-		request.Ns2, request.Ns2IP, request.Ns2TunnelIP,
-		request.TunnelName,
-	)
+	c.JSON(http.StatusOK, quotaUsages)
+}
+
+// === Job Handlers ===
+
+// listJobs returns jobs without their logs, newest first
+// Project tokens only see their project's jobs.
+func (s *Server) listJobs(c *gin.Context) {
+	limit := db.DefaultJobLimit
+	if value := c.Query("limit"); value != "" {
+		parsedLimit, err := strconv.Atoi(value)
+		if err != nil || parsedLimit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = parsedLimit
+	}
+
+	var projectID *int64
+	if project := requestProject(c); project != nil {
+		projectID = &project.ID
+	}
+	jobList, err := s.jobManager.List(projectID, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Record in database
-	tunnel1Name := request.TunnelName + "-1"
-	tunnel2Name := request.TunnelName + "-2"
+	c.JSON(http.StatusOK, jobList)
+}
+
+func (s *Server) getJob(c *gin.Context) {
+	job, ok := s.requestJob(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// cancelJob cancels a queued or running job
+// A running job stops before its next step and reverts its transaction in
+// progress, so it may still be running when the response is sent.
+func (s *Server) cancelJob(c *gin.Context) {
+	job, ok := s.requestJob(c)
+	if !ok {
+		return
+	}
+
+	if err := s.jobManager.Cancel(job.ID); err != nil {
+		if errors.Is(err, jobs.ErrFinished) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("job %d already finished", job.ID)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if job, _ = s.jobManager.Get(job.ID); job == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "job disappeared"})
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// requestJob returns the job named by the :id parameter, responding with an error if there is none
+// Jobs of other projects are not found for project tokens.
+func (s *Server) requestJob(c *gin.Context) (*db.Job, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return nil, false
+	}
+
+	job, err := s.jobManager.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	project := requestProject(c)
+	if job == nil || (project != nil && (job.ProjectID == nil || *job.ProjectID != project.ID)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("job %d not found", id)})
+		return nil, false
+	}
+	return job, true
+}
 
-	var ns1ID, ns2ID *int64
-	if ns1, _ := s.repository.GetNamespaceByName(request.Ns1); ns1 != nil {
-		ns1ID = &ns1.ID
+// === Audit Log Handlers ===
+
+// listAudit returns audit entries, newest first, filtered by query parameters
+func (s *Server) listAudit(c *gin.Context) {
+	filter := db.AuditFilter{
+		Actor:        c.Query("actor"),
+		Source:       c.Query("source"),
+		Operation:    c.Query("operation"),
+		ResourceType: db.AuditResourceType(c.Query("resource_type")),
+		ResourceName: c.Query("resource"),
+		Namespace:    c.Query("namespace"),
+		Result:       c.Query("result"),
 	}
-	if ns2, _ := s.repository.GetNamespaceByName(request.Ns2); ns2 != nil {
-		ns2ID = &ns2.ID
+
+	for parameterName, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := c.Query(parameterName); value != "" {
+			parsedTime, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + parameterName + ": expected RFC 3339 time"})
+				return
+			}
+			*target = parsedTime
+		}
 	}
 
-	s.repository.CreateGRETunnel(tunnel1Name, request.Ns1IP, request.Ns2IP, 0, 0, ns1ID)
-	s.repository.CreateGRETunnel(tunnel2Name, request.Ns2IP, request.Ns1IP, 0, 0, ns2ID)
+	for parameterName, target := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if value := c.Query(parameterName); value != "" {
+			parsedValue, err := strconv.Atoi(value)
+			if err != nil || parsedValue < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + parameterName})
+				return
+			}
+			*target = parsedValue
+		}
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = db.DefaultAuditLimit
+	}
+
+	entries, total, err := s.repository.ListAuditEntries(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "peer tunnels created",
-		"tunnels": []string{tunnel1Name, tunnel2Name},
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
 	})
 }
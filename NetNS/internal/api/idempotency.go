@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyKeyHeader names the header that makes a POST safe to retry
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the length of an Idempotency-Key
+const maxIdempotencyKeyLength = 255
+
+// idempotentResponseLimit is the largest response body stored for replay
+// Requests with larger responses are not stored and may run again.
+const idempotentResponseLimit = 1 << 20
+
+// replayedHeaders are the response headers stored and replayed with the body
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// idempotencyResponseWriter keeps the response body so it can be stored for replay
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (writer *idempotencyResponseWriter) Write(data []byte) (int, error) {
+	if writer.body.Len()+len(data) > idempotentResponseLimit {
+		writer.truncated = true
+	} else {
+		writer.body.Write(data)
+	}
+	return writer.ResponseWriter.Write(data)
+}
+
+// idempotency replays the stored response of a POST repeated with the same Idempotency-Key
+// Keys belong to the authenticated principal and are kept for
+// db.IdempotencyKeyTTL. Reusing a key for a different request is rejected
+// with 422 and repeating a request that is still running with 409. Server
+// errors are not stored, so such requests can be retried with the same key.
+func (s *Server) idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			message := fmt.Sprintf("%s is longer than %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+
+		// A request is identified by its method, URL and body
+		payload, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body: " + err.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(payload))
+		digest := sha256.New()
+		fmt.Fprintf(digest, "%s %s\n", c.Request.Method, c.Request.URL.RequestURI())
+		digest.Write(payload)
+		requestHash := hex.EncodeToString(digest.Sum(nil))
+
+		principal := c.GetString(principalKey)
+		stored, err := s.repository.ReserveIdempotencyKey(principal, key, requestHash)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if stored != nil {
+			switch {
+			case stored.RequestHash != requestHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": idempotencyKeyHeader + " was already used for a different request"})
+			case stored.StatusCode == 0:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this " + idempotencyKeyHeader + " is still in progress"})
+			default:
+				for name, value := range stored.Headers {
+					c.Header(name, value)
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(stored.StatusCode, stored.Headers["Content-Type"], stored.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		responseWriter := &idempotencyResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = responseWriter
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError || responseWriter.truncated {
+			err = s.repository.ReleaseIdempotencyKey(principal, key)
+		} else {
+			headers := make(map[string]string)
+			for _, name := range replayedHeaders {
+				if value := c.Writer.Header().Get(name); value != "" {
+					headers[name] = value
+				}
+			}
+			err = s.repository.StoreIdempotentResponse(principal, key, c.Writer.Status(), headers, responseWriter.body.Bytes())
+		}
+		if err != nil {
+			log.Printf("idempotency: failed to update key %q of %s: %v", key, principal, err)
+		}
+	}
+}
@@ -0,0 +1,647 @@
+package api
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+// apiVersion is reported in the OpenAPI document
+const apiVersion = "1.0.0"
+
+// apiPrefix is the path prefix of the documented routes
+const apiPrefix = "/api/v1"
+
+// operationDoc describes one API operation for the OpenAPI document
+// Paths, path parameters and operation IDs come from the gin route table;
+// request schemas come from the service request types and their
+// `validate` tags, so the document follows what the server enforces.
+type operationDoc struct {
+	Summary     string
+	Query       []string // Query parameter names
+	Request     any      // Zero value of the JSON body type (nil = no body)
+	PathFields  []string // Body fields that the handler takes from the path instead
+	Response    any      // Zero value of the success response type (nil = message)
+	Status      int      // Success status (0 = 200)
+	Public      bool     // Served without a token
+	Async       bool     // Runs as a background job with ?async=true
+	Conditional bool     // Honors If-Match with the ETag of the resource's GET
+	Paged       bool     // Sorts and pages with ?sort=, ?limit= and ?cursor=
+}
+
+// messageResponse is the body of operations that only report success
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+// errorResponse is the body of every failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// peerTunnelsResponse is the body returned after creating a GRE tunnel pair
+type peerTunnelsResponse struct {
+	Message string   `json:"message"`
+	Tunnels []string `json:"tunnels"`
+}
+
+// labelsResponse is the body returned after changing the labels of a resource
+type labelsResponse struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// bulkDeleteResponse is the body returned after deleting resources by label selector
+type bulkDeleteResponse struct {
+	Message string   `json:"message"`
+	Deleted []string `json:"deleted"`
+}
+
+// auditListResponse is the body returned by the audit log listing
+type auditListResponse struct {
+	Entries []db.AuditEntry `json:"entries"`
+	Total   int             `json:"total"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
+}
+
+// operationDocs documents every /api/v1 route, keyed by "METHOD path"
+var operationDocs = map[string]operationDoc{
+	"GET /api/v1/openapi.json": {Summary: "OpenAPI document for this API", Response: map[string]any{}, Public: true},
+	"GET /api/v1/docs":         {Summary: "Swagger UI for this API", Response: "", Public: true},
+	"GET /api/v1/docs/:asset":  {Summary: "Stylesheet or script of the Swagger UI", Response: "", Public: true},
+
+	"POST /api/v1/namespaces":         {Summary: "Create a namespace", Request: service.CreateNamespaceRequest{}, Response: db.Namespace{}, Status: http.StatusCreated},
+	"GET /api/v1/namespaces":          {Summary: "List recorded namespaces", Query: []string{"selector", "created_after", "created_before"}, Response: []db.Namespace{}, Paged: true},
+	"GET /api/v1/namespaces/status":   {Summary: "List namespaces present on the host", Response: []service.NamespaceStatus{}},
+	"GET /api/v1/namespaces/:name":    {Summary: "Get a namespace with its resources (with ETag)", Response: db.NamespaceWithDetails{}},
+	"PUT /api/v1/namespaces/:name":    {Summary: "Replace the labels of a namespace", Request: service.UpdateNamespaceRequest{}, Response: db.NamespaceWithDetails{}, Conditional: true},
+	"DELETE /api/v1/namespaces/:name": {Summary: "Delete a namespace", Async: true, Conditional: true},
+	"POST /api/v1/namespaces/:name/exec": {
+		Summary: "Run a command in a namespace, streaming stdout, stderr and exit events as Server-Sent Events (admin only)",
+		Request: service.ExecRequest{}, Response: "",
+	},
+
+	"POST /api/v1/veths":            {Summary: "Create a veth pair", Request: service.CreateVethRequest{}, Response: db.VethPair{}, Status: http.StatusCreated},
+	"GET /api/v1/veths":             {Summary: "List recorded veth pairs", Query: []string{"namespace", "interface", "selector", "created_after", "created_before"}, Response: []db.VethPair{}, Paged: true},
+	"GET /api/v1/veths/:name":       {Summary: "Get a recorded veth pair (with ETag)", Response: db.VethPair{}},
+	"DELETE /api/v1/veths/:name":    {Summary: "Delete a veth pair by either end"},
+	"POST /api/v1/veths/:name/up":   {Summary: "Bring a veth end up", Query: []string{"namespace"}},
+	"POST /api/v1/veths/:name/down": {Summary: "Bring a veth end down", Query: []string{"namespace"}},
+
+	"POST /api/v1/addresses":       {Summary: "Add an address to an interface", Request: service.AddressRequest{}, Response: db.IPAddress{}, Status: http.StatusCreated},
+	"GET /api/v1/addresses":        {Summary: "List recorded addresses", Query: []string{"namespace", "interface", "within", "selector", "created_after", "created_before"}, Response: []db.IPAddress{}, Paged: true},
+	"GET /api/v1/addresses/status": {Summary: "List addresses present in a namespace", Query: []string{"namespace"}, Response: []netns.AddressInfo{}},
+	"DELETE /api/v1/addresses":     {Summary: "Remove an address by value, or every address matching ?selector=", Query: []string{"interface", "address", "namespace", "selector"}, Async: true},
+	"GET /api/v1/addresses/:id":    {Summary: "Get a recorded address (with ETag)", Response: db.IPAddress{}},
+	"PATCH /api/v1/addresses/:id":  {Summary: "Change the label and lifetimes of a recorded address", Request: service.UpdateAddressRequest{}, Response: db.IPAddress{}, Conditional: true},
+	"DELETE /api/v1/addresses/:id": {Summary: "Remove a recorded address", Conditional: true},
+	"POST /api/v1/routes":          {Summary: "Add a route", Request: service.AddRouteRequest{}, Response: db.Route{}, Status: http.StatusCreated},
+	"GET /api/v1/routes":           {Summary: "List recorded routes", Query: []string{"namespace", "interface", "within", "selector", "created_after", "created_before"}, Response: []db.Route{}, Paged: true},
+	"GET /api/v1/routes/status":    {Summary: "List routes present in a namespace", Query: []string{"namespace"}, Response: []netns.RouteInfo{}},
+	"DELETE /api/v1/routes":        {Summary: "Delete a route by destination, or every route matching ?selector=", Query: []string{"destination", "namespace", "selector"}, Async: true},
+	"GET /api/v1/routes/:id":       {Summary: "Get a recorded route (with ETag)", Response: db.Route{}},
+	"PUT /api/v1/routes/:id":       {Summary: "Replace the gateway and interface of a recorded route", Request: service.ReplaceRouteRequest{}, Response: db.Route{}, Conditional: true},
+	"DELETE /api/v1/routes/:id":    {Summary: "Delete a recorded route", Conditional: true},
+	"POST /api/v1/bridges":         {Summary: "Create a bridge", Request: service.CreateBridgeRequest{}, Response: db.Bridge{}, Status: http.StatusCreated},
+	"GET /api/v1/bridges":          {Summary: "List recorded bridges", Query: []string{"selector"}, Response: []db.Bridge{}},
+	"GET /api/v1/bridges/status":   {Summary: "List bridges present in a namespace", Query: []string{"namespace"}, Response: []netns.BridgeInfo{}},
+	"GET /api/v1/bridges/:name":    {Summary: "Get a recorded bridge (with ETag)", Response: db.Bridge{}},
+	"DELETE /api/v1/bridges/:name": {Summary: "Delete a bridge", Query: []string{"namespace"}},
+	"POST /api/v1/bridges/:name/ports": {
+		Summary: "Add an interface to a bridge", Query: []string{"namespace"},
+		Request: service.BridgePortRequest{}, PathFields: []string{"bridge"},
+	},
+	"DELETE /api/v1/bridges/:name/ports/:iface": {Summary: "Remove an interface from a bridge", Query: []string{"namespace"}},
+
+	"POST /api/v1/gre":            {Summary: "Create a GRE tunnel", Request: service.CreateGRETunnelRequest{}, Response: db.GRETunnel{}, Status: http.StatusCreated},
+	"GET /api/v1/gre":             {Summary: "List recorded GRE tunnels", Query: []string{"namespace", "interface", "within", "selector", "created_after", "created_before"}, Response: []db.GRETunnel{}, Paged: true},
+	"GET /api/v1/gre/status":      {Summary: "List GRE tunnels present in a namespace", Query: []string{"namespace"}, Response: []netns.GRETunnelInfo{}},
+	"GET /api/v1/gre/:name":       {Summary: "Get a recorded GRE tunnel (with ETag)", Response: db.GRETunnel{}},
+	"PATCH /api/v1/gre/:name":     {Summary: "Change the endpoints, key or TTL of a GRE tunnel", Request: service.UpdateGRETunnelRequest{}, Response: db.GRETunnel{}, Conditional: true},
+	"DELETE /api/v1/gre/:name":    {Summary: "Delete a GRE tunnel", Query: []string{"namespace"}, Conditional: true},
+	"POST /api/v1/gre/:name/up":   {Summary: "Bring a GRE tunnel up", Query: []string{"namespace"}},
+	"POST /api/v1/gre/:name/down": {Summary: "Bring a GRE tunnel down", Query: []string{"namespace"}},
+	"POST /api/v1/gre/peer": {
+		Summary: "Create a GRE tunnel pair between two namespaces",
+		Request: service.CreatePeerTunnelsRequest{}, Response: peerTunnelsResponse{}, Status: http.StatusCreated, Async: true,
+	},
+
+	"POST /api/v1/macvlans":         {Summary: "Create a macvlan or ipvlan link", Request: service.CreateMacvlanRequest{}, Response: db.MacvlanLink{}, Status: http.StatusCreated},
+	"GET /api/v1/macvlans":          {Summary: "List recorded macvlan and ipvlan links", Query: []string{"kind", "selector"}, Response: []db.MacvlanLink{}},
+	"DELETE /api/v1/macvlans/:name": {Summary: "Delete a macvlan or ipvlan link", Query: []string{"namespace"}},
+	"POST /api/v1/bonds":            {Summary: "Create a bond", Request: service.CreateBondRequest{}, Response: db.Bond{}, Status: http.StatusCreated},
+	"GET /api/v1/bonds":             {Summary: "List recorded bonds", Query: []string{"namespace", "selector"}, Response: []db.Bond{}},
+	"GET /api/v1/bonds/status":      {Summary: "List bonds present in a namespace", Query: []string{"namespace"}, Response: []netns.BondInfo{}},
+	"DELETE /api/v1/bonds/:name":    {Summary: "Delete a bond", Query: []string{"namespace"}},
+	"POST /api/v1/dummies":          {Summary: "Create a dummy interface", Request: service.CreateDummyRequest{}, Response: db.DummyInterface{}, Status: http.StatusCreated},
+	"GET /api/v1/dummies":           {Summary: "List recorded dummy interfaces", Query: []string{"namespace", "selector"}, Response: []db.DummyInterface{}},
+	"DELETE /api/v1/dummies/:name":  {Summary: "Delete a dummy interface", Query: []string{"namespace"}},
+
+	"PATCH /api/v1/namespaces/:name/labels": {Summary: "Set (or with null remove) namespace labels", Request: service.UpdateLabelsRequest{}, Response: labelsResponse{}, Conditional: true},
+	"PATCH /api/v1/veths/:name/labels":      {Summary: "Set (or with null remove) veth pair labels", Request: service.UpdateLabelsRequest{}, Response: labelsResponse{}, Conditional: true},
+	"PATCH /api/v1/addresses/:id/labels":    {Summary: "Set (or with null remove) address labels", Request: service.UpdateLabelsRequest{}, Response: labelsResponse{}, Conditional: true},
+	"PATCH /api/v1/routes/:id/labels":       {Summary: "Set (or with null remove) route labels", Request: service.UpdateLabelsRequest{}, Response: labelsResponse{}, Conditional: true},
+	"PATCH /api/v1/bridges/:name/labels":    {Summary: "Set (or with null remove) bridge labels", Request: service.UpdateLabelsRequest{}, Response: labelsResponse{}, Conditional: true},
+	"PATCH /api/v1/gre/:name/labels":        {Summary: "Set (or with null remove) GRE tunnel labels", Request: service.UpdateLabelsRequest{}, Response: labelsResponse{}, Conditional: true},
+	"PATCH /api/v1/macvlans/:name/labels":   {Summary: "Set (or with null remove) macvlan/ipvlan labels", Request: service.UpdateLabelsRequest{}, Response: labelsResponse{}, Conditional: true},
+	"PATCH /api/v1/bonds/:name/labels":      {Summary: "Set (or with null remove) bond labels", Request: service.UpdateLabelsRequest{}, Response: labelsResponse{}, Conditional: true},
+	"PATCH /api/v1/dummies/:name/labels":    {Summary: "Set (or with null remove) dummy interface labels", Request: service.UpdateLabelsRequest{}, Response: labelsResponse{}, Conditional: true},
+
+	"DELETE /api/v1/namespaces": {Summary: "Delete every namespace matching the label selector", Query: []string{"selector"}, Response: bulkDeleteResponse{}, Async: true},
+	"DELETE /api/v1/veths":      {Summary: "Delete every veth pair matching the label selector", Query: []string{"selector"}, Response: bulkDeleteResponse{}, Async: true},
+	"DELETE /api/v1/bridges":    {Summary: "Delete every bridge matching the label selector", Query: []string{"selector"}, Response: bulkDeleteResponse{}, Async: true},
+	"DELETE /api/v1/gre":        {Summary: "Delete every GRE tunnel matching the label selector", Query: []string{"selector"}, Response: bulkDeleteResponse{}, Async: true},
+	"DELETE /api/v1/macvlans":   {Summary: "Delete every macvlan/ipvlan link matching the label selector", Query: []string{"selector"}, Response: bulkDeleteResponse{}, Async: true},
+	"DELETE /api/v1/bonds":      {Summary: "Delete every bond matching the label selector", Query: []string{"selector"}, Response: bulkDeleteResponse{}, Async: true},
+	"DELETE /api/v1/dummies":    {Summary: "Delete every dummy interface matching the label selector", Query: []string{"selector"}, Response: bulkDeleteResponse{}, Async: true},
+
+	"GET /api/v1/tc": {Summary: "List recorded traffic impairments", Query: []string{"namespace"}, Response: []db.Qdisc{}},
+	"PUT /api/v1/tc/:interface": {
+		Summary: "Set the traffic impairment of an interface", Query: []string{"namespace"},
+		Request: service.SetImpairmentRequest{}, PathFields: []string{"interface"}, Response: db.Qdisc{},
+	},
+	"GET /api/v1/tc/:interface":    {Summary: "Show the qdiscs of an interface", Query: []string{"namespace"}, Response: netns.QdiscInfo{}},
+	"DELETE /api/v1/tc/:interface": {Summary: "Clear the traffic impairment of an interface", Query: []string{"namespace"}},
+
+	"GET /api/v1/interfaces": {Summary: "List interfaces in all namespaces, or one with ?ns= (\"-\" = host)", Query: []string{"ns"}, Response: []netns.InterfaceInfo{}},
+	"PATCH /api/v1/interfaces/:namespace/:name": {
+		Summary: "Set interface properties (\"-\" namespace = host)",
+		Request: service.SetLinkRequest{}, PathFields: []string{"interface", "namespace"}, Response: db.LinkProperty{},
+	},
+
+	"POST /api/v1/workloads": {
+		Summary: "Run a supervised command in a namespace (admin only)",
+		Request: service.RunWorkloadRequest{}, Response: db.Workload{}, Status: http.StatusCreated,
+	},
+	"GET /api/v1/workloads":              {Summary: "List workloads with their status", Query: []string{"namespace"}, Response: []db.Workload{}},
+	"GET /api/v1/workloads/:name":        {Summary: "Get a workload with its status", Response: db.Workload{}},
+	"DELETE /api/v1/workloads/:name":     {Summary: "Stop a workload and remove it with its logs"},
+	"POST /api/v1/workloads/:name/start": {Summary: "Start a stopped or ended workload", Response: db.Workload{}},
+	"POST /api/v1/workloads/:name/stop":  {Summary: "Stop a workload (SIGTERM, then SIGKILL after 10s)", Response: db.Workload{}},
+	"GET /api/v1/workloads/:name/logs":   {Summary: "Get the end of a workload's stdout or stderr log as text", Query: []string{"stream", "lines"}, Response: ""},
+
+	"GET /api/v1/events": {Summary: "Stream netlink events as Server-Sent Events", Query: []string{"namespace", "type"}, Response: ""},
+	"GET /api/v1/quotas": {
+		Summary:  "List project quotas and usage (project tokens see their own project)",
+		Query:    []string{"project"},
+		Response: []service.ProjectQuotaUsage{},
+	},
+	"GET /api/v1/jobs":        {Summary: "List background jobs, newest first (project tokens see their own project's)", Query: []string{"limit"}, Response: []db.Job{}},
+	"GET /api/v1/jobs/:id":    {Summary: "Get a background job with its progress and step logs", Response: db.Job{}},
+	"DELETE /api/v1/jobs/:id": {Summary: "Cancel a queued or running job (409 if it has finished)", Response: db.Job{}, Status: http.StatusAccepted},
+	"GET /api/v1/audit": {
+		Summary:  "List audit entries, newest first",
+		Query:    []string{"actor", "source", "operation", "resource_type", "resource", "namespace", "result", "since", "until", "limit", "offset"},
+		Response: auditListResponse{},
+	},
+}
+
+// openAPIDocument builds the OpenAPI document once from the registered routes
+func (s *Server) openAPIDocument() map[string]any {
+	s.openAPIOnce.Do(func() {
+		s.openAPISpec = buildOpenAPIDocument(s.router.Routes())
+	})
+	return s.openAPISpec
+}
+
+// openAPI serves the OpenAPI document
+func (s *Server) openAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, s.openAPIDocument())
+}
+
+// swaggerUI serves a Swagger UI page for the OpenAPI document
+func (s *Server) swaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// swaggerUIAssets holds the Swagger UI stylesheet and script (swagger-ui-dist 5.18.2)
+// They are served by the API itself so the docs work on hosts without internet access.
+//
+//go:embed swaggerui/swagger-ui.css swaggerui/swagger-ui-bundle.js
+var swaggerUIAssets embed.FS
+
+// swaggerUIAsset serves one of the embedded Swagger UI files
+func (s *Server) swaggerUIAsset(c *gin.Context) {
+	c.FileFromFS("swaggerui/"+c.Param("asset"), http.FS(swaggerUIAssets))
+}
+
+// swaggerUIPage loads the embedded Swagger UI and points it at openapi.json
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>netns-mgr API</title>
+  <link rel="stylesheet" href="` + apiPrefix + `/docs/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="` + apiPrefix + `/docs/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "` + apiPrefix + `/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// pathParamPattern matches gin path parameters such as ":name"
+var pathParamPattern = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+// buildOpenAPIDocument describes every /api/v1 route in OpenAPI 3 form
+// Parameters:
+//   - routes: the gin route table
+func buildOpenAPIDocument(routes gin.RoutesInfo) map[string]any {
+	builder := &schemaBuilder{components: map[string]any{}}
+	builder.components["Error"] = builder.schemaFor(reflect.TypeOf(errorResponse{}))
+	errorReference := map[string]any{"$ref": "#/components/schemas/Error"}
+
+	paths := map[string]any{}
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, apiPrefix+"/") {
+			continue
+		}
+		doc := operationDocs[route.Method+" "+route.Path]
+
+		operation := map[string]any{
+			"operationId": handlerOperationID(route.Handler),
+			"tags":        []string{auditResourceType(route.Path)},
+		}
+		if doc.Summary != "" {
+			operation["summary"] = doc.Summary
+		}
+		if !doc.Public {
+			operation["security"] = []any{map[string]any{"bearerAuth": []string{}}}
+		}
+
+		var parameters []any
+		for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+			parameters = append(parameters, map[string]any{
+				"name": match[1], "in": "path", "required": true,
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+		queryNames := doc.Query
+		if doc.Async {
+			queryNames = append(queryNames[:len(queryNames):len(queryNames)], "async")
+		}
+		for _, queryName := range queryNames {
+			parameters = append(parameters, map[string]any{
+				"name": queryName, "in": "query",
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+		if doc.Paged {
+			parameters = append(parameters,
+				map[string]any{
+					"name": "sort", "in": "query",
+					"description": "Sort field, e.g. created_at; prefix with - for descending order",
+					"schema":      map[string]any{"type": "string"},
+				},
+				map[string]any{
+					"name": "limit", "in": "query",
+					"description": "Page size; the " + nextCursorHeader + " response header holds the cursor of the next page",
+					"schema":      map[string]any{"type": "integer", "minimum": 1, "maximum": db.MaxListLimit},
+				},
+				map[string]any{
+					"name": "cursor", "in": "query",
+					"description": "Return the page after the one whose response carried this " + nextCursorHeader,
+					"schema":      map[string]any{"type": "string"},
+				},
+			)
+		}
+		if route.Method == http.MethodPost && !doc.Public {
+			parameters = append(parameters, map[string]any{
+				"name": idempotencyKeyHeader, "in": "header",
+				"description": "Replay the stored response when the same request is repeated with this key",
+				"schema":      map[string]any{"type": "string", "maxLength": maxIdempotencyKeyLength},
+			})
+		}
+		if doc.Conditional {
+			parameters = append(parameters, map[string]any{
+				"name": "If-Match", "in": "header",
+				"description": "Only apply the change if the resource still has one of these ETags",
+				"schema":      map[string]any{"type": "string"},
+			})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if doc.Request != nil {
+			requestType := reflect.TypeOf(doc.Request)
+			var requestSchema map[string]any
+			if len(doc.PathFields) > 0 {
+				requestSchema = builder.objectSchema(requestType, doc.PathFields)
+			} else {
+				requestSchema = builder.schemaFor(requestType)
+			}
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": requestSchema}},
+			}
+		}
+
+		successStatus := doc.Status
+		if successStatus == 0 {
+			successStatus = http.StatusOK
+		}
+		responses := map[string]any{
+			strconv.Itoa(successStatus): builder.successResponse(doc.Response),
+			"default": map[string]any{
+				"description": "Error (400 invalid request, 401 missing or invalid token, 403 role too low, 404 not found, 409 conflict, 412 ETag mismatch, 422 Idempotency-Key reused, 500 server error)",
+				"content":     map[string]any{"application/json": map[string]any{"schema": errorReference}},
+			},
+		}
+		if doc.Paged {
+			successResponse := responses[strconv.Itoa(successStatus)].(map[string]any)
+			successResponse["headers"] = map[string]any{
+				nextCursorHeader: map[string]any{
+					"description": "Cursor of the next page; absent on the last page",
+					"schema":      map[string]any{"type": "string"},
+				},
+			}
+		}
+		if doc.Async {
+			jobResponse := builder.successResponse(db.Job{})
+			jobResponse["description"] = "Job queued with ?async=true; poll the Location header for its result"
+			responses[strconv.Itoa(http.StatusAccepted)] = jobResponse
+		}
+		operation["responses"] = responses
+
+		openAPIPath := pathParamPattern.ReplaceAllString(route.Path, "{$1}")
+		pathItem, _ := paths[openAPIPath].(map[string]any)
+		if pathItem == nil {
+			pathItem = map[string]any{}
+			paths[openAPIPath] = pathItem
+		}
+		pathItem[strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "netns-mgr API",
+			"description": "Manage Linux network namespaces and their interfaces",
+			"version":     apiVersion,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": builder.components,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "Token from `netns-mgr token create`; viewer reads, operator changes, admin reads the audit log",
+				},
+			},
+		},
+	}
+}
+
+// handlerOperationID derives an operation ID from a handler function name
+// gin reports method values as "pkg.(*Server).createVeth-fm".
+func handlerOperationID(handlerName string) string {
+	if dotIndex := strings.LastIndex(handlerName, "."); dotIndex >= 0 {
+		handlerName = handlerName[dotIndex+1:]
+	}
+	return strings.TrimSuffix(handlerName, "-fm")
+}
+
+// schemaBuilder converts Go types to OpenAPI schemas
+// Named struct types are emitted once as components and referenced.
+type schemaBuilder struct {
+	components map[string]any
+}
+
+// successResponse describes the success response of an operation
+// Parameters:
+//   - response: zero value of the response type; nil means a message body and "" a non-JSON body
+func (builder *schemaBuilder) successResponse(response any) map[string]any {
+	switch response.(type) {
+	case nil:
+		response = messageResponse{}
+	case string:
+		return map[string]any{"description": "Success"}
+	}
+	return map[string]any{
+		"description": "Success",
+		"content": map[string]any{"application/json": map[string]any{
+			"schema": builder.schemaFor(reflect.TypeOf(response)),
+		}},
+	}
+}
+
+// timeType is rendered as an RFC 3339 string
+var timeType = reflect.TypeOf(time.Time{})
+
+// rawJSONType holds any JSON value
+var rawJSONType = reflect.TypeOf(json.RawMessage(nil))
+
+// schemaFor returns the schema of a Go type, registering struct components
+func (builder *schemaBuilder) schemaFor(valueType reflect.Type) map[string]any {
+	if valueType.Kind() == reflect.Pointer {
+		schema := builder.schemaFor(valueType.Elem())
+		if _, isReference := schema["$ref"]; isReference {
+			return map[string]any{"allOf": []any{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	}
+
+	switch {
+	case valueType == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case valueType == rawJSONType:
+		return map[string]any{}
+	case valueType.Kind() == reflect.Struct && valueType.Name() != "":
+		componentName := valueType.Name()
+		if _, exists := builder.components[componentName]; !exists {
+			builder.components[componentName] = map[string]any{} // Placeholder for recursive types
+			builder.components[componentName] = builder.objectSchema(valueType, nil)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + componentName}
+	case valueType.Kind() == reflect.Struct:
+		return builder.objectSchema(valueType, nil)
+	case valueType.Kind() == reflect.Slice && valueType.Elem().Kind() == reflect.Uint8:
+		return map[string]any{"type": "string", "format": "byte"}
+	case valueType.Kind() == reflect.Slice || valueType.Kind() == reflect.Array:
+		return map[string]any{"type": "array", "items": builder.schemaFor(valueType.Elem())}
+	case valueType.Kind() == reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": builder.schemaFor(valueType.Elem())}
+	case valueType.Kind() == reflect.String:
+		return map[string]any{"type": "string"}
+	case valueType.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case valueType.Kind() >= reflect.Int && valueType.Kind() <= reflect.Uint64:
+		schema := map[string]any{"type": "integer"}
+		if valueType.Kind() >= reflect.Uint {
+			schema["minimum"] = 0
+		}
+		return schema
+	case valueType.Kind() == reflect.Float32 || valueType.Kind() == reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// objectSchema describes a struct's JSON fields, flattening embedded structs
+// Parameters:
+//   - structType: struct type to describe
+//   - omitFields: JSON names of fields to leave out
+func (builder *schemaBuilder) objectSchema(structType reflect.Type, omitFields []string) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	builder.addFields(structType, omitFields, properties, &required)
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds the JSON fields of a struct to an object schema
+func (builder *schemaBuilder) addFields(structType reflect.Type, omitFields []string, properties map[string]any, required *[]string) {
+	for fieldIndex := 0; fieldIndex < structType.NumField(); fieldIndex++ {
+		field := structType.Field(fieldIndex)
+		jsonTag := field.Tag.Get("json")
+		jsonName, _, _ := strings.Cut(jsonTag, ",")
+
+		if field.Anonymous && jsonName == "" {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Pointer {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				builder.addFields(embeddedType, omitFields, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() || jsonName == "-" {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		if containsName(omitFields, jsonName) {
+			continue
+		}
+
+		fieldSchema := builder.schemaFor(field.Type)
+		if applyValidateTag(fieldSchema, field.Tag.Get("validate")) {
+			*required = append(*required, jsonName)
+		}
+		properties[jsonName] = fieldSchema
+	}
+}
+
+// applyValidateTag adds the schema keywords matching a `validate` tag
+// Returns true if the tag makes the field required.
+// Parameters:
+//   - schema: field schema to extend
+//   - validateTag: go-playground/validator tag, e.g. "required,cidr"
+func applyValidateTag(schema map[string]any, validateTag string) bool {
+	if validateTag == "" {
+		return false
+	}
+
+	required := false
+	targetSchema := schema
+	insideDive := false
+	insideKeys := false
+	for _, rule := range strings.Split(validateTag, ",") {
+		switch {
+		case rule == "dive":
+			// Remaining rules apply to the slice elements or map values
+			items, _ := targetSchema["items"].(map[string]any)
+			if items == nil {
+				items, _ = targetSchema["additionalProperties"].(map[string]any)
+			}
+			if items == nil {
+				return required
+			}
+			targetSchema = items
+			insideDive = true
+		case rule == "keys" || rule == "endkeys":
+			// OpenAPI 3.0 cannot constrain map keys
+			insideKeys = rule == "keys"
+		case insideKeys:
+		case rule == "required":
+			if !insideDive {
+				required = true
+			} else if targetSchema["type"] == "string" {
+				targetSchema["minLength"] = 1
+			}
+		case strings.Contains(rule, "|"):
+			var alternatives []any
+			for _, alternative := range strings.Split(rule, "|") {
+				alternativeSchema := map[string]any{}
+				applyValidationRule(alternativeSchema, alternative)
+				alternatives = append(alternatives, alternativeSchema)
+			}
+			targetSchema["anyOf"] = alternatives
+		default:
+			applyValidationRule(targetSchema, rule)
+		}
+	}
+	return required
+}
+
+// applyValidationRule adds the schema keywords for a single validator rule
+func applyValidationRule(schema map[string]any, rule string) {
+	ruleName, ruleParam, _ := strings.Cut(rule, "=")
+	switch ruleName {
+	case "ifname":
+		schema["maxLength"] = 15
+		schema["pattern"] = `^[^/\s]*$`
+	case "nsname":
+		schema["pattern"] = `^[^/\s]*$`
+		schema["not"] = map[string]any{"enum": []string{".", ".."}}
+	case "labelvalue":
+		schema["pattern"] = `^[A-Za-z0-9._-]{0,63}$`
+	case "cidr", "ip", "mac":
+		schema["format"] = ruleName
+	case "eq":
+		schema["enum"] = []string{ruleParam}
+	case "oneof":
+		schema["enum"] = strings.Fields(ruleParam)
+	case "min", "max", "gte", "lte":
+		limit, err := strconv.ParseFloat(ruleParam, 64)
+		if err != nil {
+			return
+		}
+		keywords := map[string]string{"array": "Items", "string": "Length"}
+		keywordSuffix, isCount := keywords[fmt.Sprint(schema["type"])]
+		switch {
+		case isCount && (ruleName == "min" || ruleName == "gte"):
+			schema["min"+keywordSuffix] = limit
+		case isCount:
+			schema["max"+keywordSuffix] = limit
+		case ruleName == "min" || ruleName == "gte":
+			schema["minimum"] = limit
+		default:
+			schema["maximum"] = limit
+		}
+	}
+}
+
+// containsName reports whether names contains name
+func containsName(names []string, name string) bool {
+	for _, candidate := range names {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
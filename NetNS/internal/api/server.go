@@ -1,9 +1,23 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/zenith/netns-mgr/internal/auth"
 	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/jobs"
+	"github.com/zenith/netns-mgr/internal/metrics"
 	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
 )
 
 // Server represents the API server
@@ -11,15 +25,30 @@ type Server struct {
 	router           *gin.Engine
 	repository       *db.Repository
 	namespaceManager *netns.Manager
-	vethManager      *netns.VethManager
-	addressManager   *netns.AddressManager
-	routeManager     *netns.RouteManager
-	bridgeManager    *netns.BridgeManager
-	greManager       *netns.GREManager
+	service          *service.Service
+	jobManager       *jobs.Manager
+	metricsScraper   *metrics.Scraper
+	requestMetrics   *metrics.RequestMetrics
+	watcher          *netns.Watcher
+	conditionalMutex sync.Mutex // Held by changes on routes that support If-Match
+	openAPIOnce      sync.Once
+	openAPISpec      map[string]any
+	config           Config
+}
+
+// Config holds the options of an API server
+type Config struct {
+	AllowedOrigins []string // Origins allowed to make cross-origin requests ("*" = any)
+	TLSCertFile    string   // PEM server certificate (empty = plain HTTP)
+	TLSKeyFile     string   // PEM private key of the server certificate
+	ClientCAFile   string   // PEM CA bundle for client certificates (empty = no mTLS)
 }
 
 // NewServer creates a new API server
-func NewServer(repository *db.Repository) *Server {
+// Parameters:
+//   - repository: repository the API reads and records resources in
+//   - config: server options
+func NewServer(repository *db.Repository, config Config) *Server {
 	gin.SetMode(gin.ReleaseMode)
 	ginRouter := gin.Default()
 
@@ -29,12 +58,13 @@ func NewServer(repository *db.Repository) *Server {
 		router:           ginRouter,
 		repository:       repository,
 		namespaceManager: namespaceManager,
-		vethManager:      netns.NewVethManager(namespaceManager),
-		addressManager:   netns.NewAddressManager(namespaceManager),
-		routeManager:     netns.NewRouteManager(namespaceManager),
-		bridgeManager:    netns.NewBridgeManager(namespaceManager),
-		greManager:       netns.NewGREManager(namespaceManager),
+		service:          service.New(repository),
+		jobManager:       jobs.NewManager(repository),
+		metricsScraper:   metrics.NewScraper(namespaceManager, repository),
+		requestMetrics:   metrics.NewRequestMetrics(),
+		config:           config,
 	}
+	server.watcher = netns.NewWatcher(namespaceManager, server.managedNamespaceNames)
 
 	server.setupRoutes()
 	return server
@@ -44,84 +74,450 @@ func NewServer(repository *db.Repository) *Server {
 func (s *Server) setupRoutes() {
 	// Middleware
 	s.router.Use(gin.Recovery())
-	s.router.Use(corsMiddleware())
+	s.router.Use(corsMiddleware(s.config.AllowedOrigins))
+	s.router.Use(requestMetricsMiddleware(s.requestMetrics))
+	s.router.Use(auditMiddleware(s.repository))
 
 	// Health check
 	s.router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Prometheus metrics
+	s.router.GET("/metrics", s.authenticate(), authorize(auth.RoleViewer, auth.RoleViewer), requireUnconfined(), s.metrics)
+
 	// API v1
-	v1 := s.router.Group("/api/v1")
+	v1 := s.router.Group(apiPrefix)
+	{
+		// OpenAPI document and Swagger UI (no token needed)
+		v1.GET("/openapi.json", s.openAPI)
+		v1.GET("/docs", s.swaggerUI)
+		v1.GET("/docs/:asset", s.swaggerUIAsset)
+	}
+
+	// Everything else needs a token: viewers read, operators change, admins audit.
+	// Project tokens only see and change their own project's resources.
+	// POSTs may be retried with an Idempotency-Key, and changes made
+	// conditional with If-Match on the ETag of a resource's GET.
+	authenticated := v1.Group("", s.authenticate(), s.idempotency(), s.serializeConditionalRequests())
+	resourceAccess := authorize(auth.RoleViewer, auth.RoleOperator)
 	{
 		// Namespaces
-		ns := v1.Group("/namespaces")
+		ns := authenticated.Group("/namespaces", resourceAccess)
 		{
 			ns.POST("", s.createNamespace)
 			ns.GET("", s.listNamespaces)
+			ns.GET("/status", s.namespaceStatus)
 			ns.GET("/:name", s.getNamespace)
+			ns.PUT("/:name", s.updateNamespace)
 			ns.DELETE("/:name", s.deleteNamespace)
+			ns.DELETE("", s.deleteNamespacesBySelector)
+			ns.PATCH("/:name/labels", s.updateNamespaceLabels)
+		}
+
+		// Commands run as root with the host's filesystem, so exec is
+		// reserved for unconfined admins (output as Server-Sent Events)
+		authenticated.POST("/namespaces/:name/exec", authorize(auth.RoleAdmin, auth.RoleAdmin), requireUnconfined(), s.execInNamespace)
+
+		// Supervised workloads run as root too, so only unconfined admins change them
+		workloads := authenticated.Group("/workloads", authorize(auth.RoleViewer, auth.RoleAdmin), requireUnconfined())
+		{
+			workloads.POST("", s.runWorkload)
+			workloads.GET("", s.listWorkloads)
+			workloads.GET("/:name", s.getWorkload)
+			workloads.DELETE("/:name", s.deleteWorkload)
+			workloads.POST("/:name/start", s.startWorkload)
+			workloads.POST("/:name/stop", s.stopWorkload)
+			workloads.GET("/:name/logs", s.workloadLogs)
 		}
 
 		// Veth pairs
-		veths := v1.Group("/veths")
+		veths := authenticated.Group("/veths", resourceAccess)
 		{
 			veths.POST("", s.createVeth)
 			veths.GET("", s.listVeths)
+			veths.GET("/:name", s.getVeth)
 			veths.DELETE("/:name", s.deleteVeth)
+			veths.DELETE("", s.deleteVethsBySelector)
+			veths.PATCH("/:name/labels", s.updateVethLabels)
+			veths.POST("/:name/up", s.vethUp)
+			veths.POST("/:name/down", s.vethDown)
 		}
 
 		// IP addresses
-		addrs := v1.Group("/addresses")
+		addrs := authenticated.Group("/addresses", resourceAccess)
 		{
 			addrs.POST("", s.addAddress)
 			addrs.GET("", s.listAddresses)
+			addrs.GET("/status", s.addressStatus)
+			addrs.GET("/:id", s.getAddress)
+			addrs.DELETE("", s.deleteAddressByValue)
+			addrs.PATCH("/:id", s.updateAddress)
 			addrs.DELETE("/:id", s.deleteAddress)
+			addrs.PATCH("/:id/labels", s.updateAddressLabels)
 		}
 
 		// Routes
-		routes := v1.Group("/routes")
+		routes := authenticated.Group("/routes", resourceAccess)
 		{
 			routes.POST("", s.addRoute)
 			routes.GET("", s.listRoutes)
+			routes.GET("/status", s.routeStatus)
+			routes.GET("/:id", s.getRoute)
+			routes.DELETE("", s.deleteRouteByDestination)
+			routes.PUT("/:id", s.replaceRoute)
 			routes.DELETE("/:id", s.deleteRoute)
+			routes.PATCH("/:id/labels", s.updateRouteLabels)
 		}
 
 		// Bridges
-		bridges := v1.Group("/bridges")
+		bridges := authenticated.Group("/bridges", resourceAccess)
 		{
 			bridges.POST("", s.createBridge)
 			bridges.GET("", s.listBridges)
+			bridges.GET("/status", s.bridgeStatus)
+			bridges.GET("/:name", s.getBridge)
 			bridges.DELETE("/:name", s.deleteBridge)
+			bridges.DELETE("", s.deleteBridgesBySelector)
+			bridges.PATCH("/:name/labels", s.updateBridgeLabels)
 			bridges.POST("/:name/ports", s.addBridgePort)
 			bridges.DELETE("/:name/ports/:iface", s.removeBridgePort)
 		}
 
 		// GRE Tunnels
-		gre := v1.Group("/gre")
+		gre := authenticated.Group("/gre", resourceAccess)
 		{
 			gre.POST("", s.createGRETunnel)
 			gre.GET("", s.listGRETunnels)
+			gre.GET("/status", s.greStatus)
 			gre.GET("/:name", s.getGRETunnel)
+			gre.PATCH("/:name", s.updateGRETunnel)
 			gre.DELETE("/:name", s.deleteGRETunnel)
+			gre.DELETE("", s.deleteGRETunnelsBySelector)
+			gre.PATCH("/:name/labels", s.updateGRETunnelLabels)
 			gre.POST("/:name/up", s.greUp)
 			gre.POST("/:name/down", s.greDown)
 			gre.POST("/peer", s.createPeerTunnels)
 		}
+
+		// Macvlan/IPVlan links
+		macvlans := authenticated.Group("/macvlans", resourceAccess)
+		{
+			macvlans.POST("", s.createMacvlan)
+			macvlans.GET("", s.listMacvlans)
+			macvlans.DELETE("/:name", s.deleteMacvlan)
+			macvlans.DELETE("", s.deleteMacvlansBySelector)
+			macvlans.PATCH("/:name/labels", s.updateMacvlanLabels)
+		}
+
+		// Bonds
+		bonds := authenticated.Group("/bonds", resourceAccess)
+		{
+			bonds.POST("", s.createBond)
+			bonds.GET("", s.listBonds)
+			bonds.GET("/status", s.bondStatus)
+			bonds.DELETE("/:name", s.deleteBond)
+			bonds.DELETE("", s.deleteBondsBySelector)
+			bonds.PATCH("/:name/labels", s.updateBondLabels)
+		}
+
+		// Dummy interfaces
+		dummies := authenticated.Group("/dummies", resourceAccess)
+		{
+			dummies.POST("", s.createDummy)
+			dummies.GET("", s.listDummies)
+			dummies.DELETE("/:name", s.deleteDummy)
+			dummies.DELETE("", s.deleteDummiesBySelector)
+			dummies.PATCH("/:name/labels", s.updateDummyLabels)
+		}
+
+		// Traffic impairment (netem/tbf/htb)
+		tc := authenticated.Group("/tc", resourceAccess)
+		{
+			tc.GET("", s.listQdiscs)
+			tc.PUT("/:interface", s.setQdisc)
+			tc.GET("/:interface", s.showQdisc)
+			tc.DELETE("/:interface", s.clearQdisc)
+		}
+
+		// Generic interface properties ("-" addresses the host namespace)
+		interfaces := authenticated.Group("/interfaces", resourceAccess)
+		{
+			interfaces.GET("", s.listInterfaces)
+			interfaces.PATCH("/:namespace/:name", s.updateInterface)
+		}
+
+		// Live netlink events (Server-Sent Events)
+		authenticated.GET("/events", resourceAccess, s.streamEvents)
+
+		// Project quotas and their usage
+		authenticated.GET("/quotas", resourceAccess, s.listQuotas)
+
+		// Background jobs started with ?async=true
+		jobList := authenticated.Group("/jobs", resourceAccess)
+		{
+			jobList.GET("", s.listJobs)
+			jobList.GET("/:id", s.getJob)
+			jobList.DELETE("/:id", s.cancelJob)
+		}
+
+		// Audit log of mutating operations
+		authenticated.GET("/audit", authorize(auth.RoleAdmin, auth.RoleAdmin), requireUnconfined(), s.listAudit)
 	}
 }
 
-// Run starts the server
+// Run starts the server, over TLS if a certificate is configured
 func (s *Server) Run(addr string) error {
-	return s.router.Run(addr)
+	if s.config.TLSCertFile == "" {
+		return s.router.Run(addr)
+	}
+
+	reloader, err := newCertificateReloader(s.config.TLSCertFile, s.config.TLSKeyFile, s.config.ClientCAFile)
+	if err != nil {
+		return err
+	}
+	go reloader.watch(certificateReloadInterval)
+
+	httpServer := &http.Server{
+		Addr:      addr,
+		Handler:   s.router,
+		TLSConfig: reloader.tlsConfig(),
+	}
+	return httpServer.ListenAndServeTLS("", "")
+}
+
+// StartMetrics starts the background metrics scraper
+// Parameters:
+//   - interval: time between scrapes of interface counters and resource counts
+func (s *Server) StartMetrics(interval time.Duration) {
+	s.metricsScraper.Start(interval)
+}
+
+// StartJobs marks jobs left unfinished by a previous run as interrupted and starts the job workers
+// Parameters:
+//   - workerCount: number of jobs run at the same time
+func (s *Server) StartJobs(workerCount int) error {
+	return s.jobManager.Start(workerCount)
+}
+
+// requestMetricsMiddleware records request counts and durations by route
+func requestMetricsMiddleware(requestMetrics *metrics.RequestMetrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startTime := time.Now()
+		c.Next()
+
+		// Use the route pattern to keep label cardinality bounded
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		requestMetrics.Observe(c.Request.Method, route, c.Writer.Status(), time.Since(startTime))
+	}
+}
+
+// principalKey is the context key holding the authenticated principal of a request
+const principalKey = "principal"
+
+// auditBodyLimit caps how much of a request or error response is kept in the audit log
+const auditBodyLimit = 64 * 1024
+
+// auditResponseWriter keeps the start of the response body so failures can be audited
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (writer *auditResponseWriter) Write(data []byte) (int, error) {
+	if remaining := auditBodyLimit - writer.body.Len(); remaining > 0 {
+		if len(data) < remaining {
+			remaining = len(data)
+		}
+		writer.body.Write(data[:remaining])
+	}
+	return writer.ResponseWriter.Write(data)
+}
+
+// auditMiddleware records every mutating request and its result in the audit log
+// Requests that never authenticated are not recorded, so anonymous clients
+// cannot grow the log. Secrets in request bodies are redacted first.
+// Parameters:
+//   - repository: repository the audit entries are written to
+func auditMiddleware(repository *db.Repository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method != http.MethodPost && method != http.MethodPut && method != http.MethodPatch && method != http.MethodDelete {
+			c.Next()
+			return
+		}
+
+		// Keep a copy of the payload and hand the handler an identical body
+		var payload []byte
+		if c.Request.Body != nil {
+			payload, _ = io.ReadAll(io.LimitReader(c.Request.Body, auditBodyLimit))
+			c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(payload), c.Request.Body))
+		}
+
+		responseWriter := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = responseWriter
+
+		c.Next()
+
+		// Unmatched routes never reached a handler
+		route := c.FullPath()
+		if route == "" || c.GetString(principalKey) == "" {
+			return
+		}
+
+		var payloadFields map[string]interface{}
+		json.Unmarshal(payload, &payloadFields)
+
+		entry := &db.AuditEntry{
+			Actor:        c.GetString(principalKey),
+			Source:       "api",
+			Operation:    auditOperation(method, route),
+			ResourceType: auditResourceType(route),
+			ResourceName: firstNonEmpty(c.Param("name"), c.Param("interface"), c.Param("id"), c.Query("address"), c.Query("destination"), c.Query("selector"), stringField(payloadFields, "name")),
+			Namespace:    firstNonEmpty(c.Query("namespace"), c.Param("namespace"), stringField(payloadFields, "namespace")),
+			Payload:      redactAuditPayload(payload),
+			Result:       db.AuditResultSuccess,
+		}
+		if entry.Namespace == hostNamespaceParam {
+			entry.Namespace = ""
+		}
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			entry.Result = db.AuditResultError
+			var errorResponse struct {
+				Error string `json:"error"`
+			}
+			if json.Unmarshal(responseWriter.body.Bytes(), &errorResponse) == nil && errorResponse.Error != "" {
+				entry.Error = errorResponse.Error
+			} else {
+				entry.Error = http.StatusText(c.Writer.Status())
+			}
+		}
+
+		if err := repository.CreateAuditEntry(entry); err != nil {
+			log.Printf("audit: failed to record %s %s: %v", method, route, err)
+		}
+	}
+}
+
+// redactAuditPayload returns a request body as kept in the audit log
+// The values of db.AuditRedactedFields are replaced; environment entries
+// keep their names. Bodies that are not a JSON object (including truncated ones)
+// cannot be redacted and are replaced by a note with their size.
+func redactAuditPayload(payload []byte) string {
+	if len(bytes.TrimSpace(payload)) == 0 {
+		return string(payload)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return fmt.Sprintf("[%d byte body that is not a JSON object omitted]", len(payload))
+	}
+
+	redacted := false
+	for _, field := range db.AuditRedactedFields {
+		value, ok := fields[field]
+		if !ok {
+			continue
+		}
+		redacted = true
+
+		var environment []string
+		if field == "env" && json.Unmarshal(value, &environment) == nil {
+			fields[field], _ = json.Marshal(db.RedactEnvironment(environment))
+			continue
+		}
+		fields[field], _ = json.Marshal(db.AuditRedactedValue)
+	}
+	if !redacted {
+		return string(payload)
+	}
+
+	redactedPayload, err := json.Marshal(fields)
+	if err != nil {
+		return db.AuditRedactedValue
+	}
+	return string(redactedPayload)
+}
+
+// auditOperation maps a method and route pattern to an audit operation
+func auditOperation(method, route string) string {
+	switch {
+	case strings.HasSuffix(route, "/up"):
+		return "up"
+	case strings.HasSuffix(route, "/down"):
+		return "down"
+	case strings.HasSuffix(route, "/exec"):
+		return "exec"
+	case strings.HasSuffix(route, "/start"):
+		return "start"
+	case strings.HasSuffix(route, "/stop"):
+		return "stop"
+	}
+
+	switch method {
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "set"
+	case http.MethodPatch:
+		return "update"
+	default:
+		return "delete"
+	}
 }
 
-// corsMiddleware adds CORS headers
-func corsMiddleware() gin.HandlerFunc {
+// auditResourceType returns the resource collection of a route ("/api/v1/gre/:name/up" -> "gre")
+func auditResourceType(route string) string {
+	resourcePath := strings.TrimPrefix(route, "/api/v1/")
+	if slashIndex := strings.Index(resourcePath, "/"); slashIndex >= 0 {
+		resourcePath = resourcePath[:slashIndex]
+	}
+	return resourcePath
+}
+
+// stringField returns a string field of a decoded JSON object, or "" if missing
+func stringField(fields map[string]interface{}, key string) string {
+	value, _ := fields[key].(string)
+	return value
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// corsMiddleware adds CORS headers for allowed origins
+// Requests from other origins get no CORS headers, so browsers block them.
+// Allowed origins may send the idempotency and conditional request headers and
+// read the ETag, Location, replay and paging headers of responses.
+// Parameters:
+//   - allowedOrigins: origins allowed to make cross-origin requests ("*" = any)
+func corsMiddleware(allowedOrigins []string) gin.HandlerFunc {
+	allowAnyOrigin := false
+	allowedOriginSet := make(map[string]bool)
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			allowAnyOrigin = true
+		}
+		allowedOriginSet[strings.TrimRight(origin, "/")] = true
+	}
+
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		origin := c.GetHeader("Origin")
+		if origin != "" && (allowAnyOrigin || allowedOriginSet[origin]) {
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, "+idempotencyKeyHeader+", If-Match, If-None-Match")
+			c.Header("Access-Control-Expose-Headers", "ETag, Location, Idempotent-Replayed, "+nextCursorHeader)
+			c.Header("Vary", "Origin")
+		}
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// certificateReloadInterval is how often the TLS files are checked for changes
+const certificateReloadInterval = 10 * time.Second
+
+// certificateReloader serves the current server certificate and client CA pool
+// The files are re-read when their modification time changes, so renewed
+// certificates take effect without restarting the server.
+type certificateReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string // Empty = no client certificates
+
+	mutex       sync.RWMutex
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+	modTimes    map[string]time.Time
+}
+
+// newCertificateReloader loads the TLS files once
+// Parameters:
+//   - certFile: PEM server certificate (chain)
+//   - keyFile: PEM private key of the server certificate
+//   - clientCAFile: PEM CA bundle that client certificates must chain to (empty = none)
+func newCertificateReloader(certFile, keyFile, clientCAFile string) (*certificateReloader, error) {
+	reloader := &certificateReloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+	}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// watchedFiles lists the files whose changes trigger a reload
+func (reloader *certificateReloader) watchedFiles() []string {
+	files := []string{reloader.certFile, reloader.keyFile}
+	if reloader.clientCAFile != "" {
+		files = append(files, reloader.clientCAFile)
+	}
+	return files
+}
+
+// load reads the certificate, key and client CA bundle
+func (reloader *certificateReloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, fileName := range reloader.watchedFiles() {
+		fileInfo, err := os.Stat(fileName)
+		if err != nil {
+			return err
+		}
+		modTimes[fileName] = fileInfo.ModTime()
+	}
+
+	certificate, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if reloader.clientCAFile != "" {
+		caPEM, err := os.ReadFile(reloader.clientCAFile)
+		if err != nil {
+			return err
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("no certificates found in client CA file %s", reloader.clientCAFile)
+		}
+	}
+
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+	reloader.certificate = &certificate
+	reloader.clientCAs = clientCAs
+	reloader.modTimes = modTimes
+	return nil
+}
+
+// changed reports whether any watched file was modified since the last load
+func (reloader *certificateReloader) changed() bool {
+	reloader.mutex.RLock()
+	defer reloader.mutex.RUnlock()
+
+	for _, fileName := range reloader.watchedFiles() {
+		fileInfo, err := os.Stat(fileName)
+		if err != nil || !fileInfo.ModTime().Equal(reloader.modTimes[fileName]) {
+			return true
+		}
+	}
+	return false
+}
+
+// watch reloads the files whenever they change
+// A failed reload is logged and the previous certificate stays in use, so a
+// half-written renewal does not take the server down.
+// Parameters:
+//   - interval: time between checks
+func (reloader *certificateReloader) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !reloader.changed() {
+			continue
+		}
+		if err := reloader.load(); err != nil {
+			log.Printf("tls: keeping previous certificate: %v", err)
+			continue
+		}
+		log.Printf("tls: reloaded certificate from %s", reloader.certFile)
+	}
+}
+
+// tlsConfig returns a server TLS configuration backed by the reloader
+// Client certificates are verified if presented; requests without one can
+// still authenticate with a bearer token.
+func (reloader *certificateReloader) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			reloader.mutex.RLock()
+			defer reloader.mutex.RUnlock()
+
+			connectionConfig := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*reloader.certificate},
+			}
+			if reloader.clientCAs != nil {
+				connectionConfig.ClientCAs = reloader.clientCAs
+				connectionConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			}
+			return connectionConfig, nil
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+func (s *Server) runWorkload(c *gin.Context) {
+	var request service.RunWorkloadRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	workload, err := s.serviceFor(c).RunWorkload(request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/v1/workloads/"+workload.Name)
+	c.JSON(http.StatusCreated, workload)
+}
+
+func (s *Server) listWorkloads(c *gin.Context) {
+	workloads, err := s.serviceFor(c).ListWorkloads(c.Query("namespace"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workloads)
+}
+
+func (s *Server) getWorkload(c *gin.Context) {
+	workload, err := s.serviceFor(c).GetWorkload(c.Param("name"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workload)
+}
+
+func (s *Server) startWorkload(c *gin.Context) {
+	workload, err := s.serviceFor(c).StartWorkload(c.Param("name"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workload)
+}
+
+func (s *Server) stopWorkload(c *gin.Context) {
+	workload, err := s.serviceFor(c).StopWorkload(c.Param("name"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workload)
+}
+
+func (s *Server) deleteWorkload(c *gin.Context) {
+	if err := s.serviceFor(c).DeleteWorkload(c.Param("name")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "workload deleted"})
+}
+
+// workloadLogs returns the end of a workload's log as plain text
+// Query: ?stream=stdout|stderr and ?lines=<n> (default 100, -1 = all)
+func (s *Server) workloadLogs(c *gin.Context) {
+	lines := 0
+	if linesParam := c.Query("lines"); linesParam != "" {
+		var err error
+		if lines, err = strconv.Atoi(linesParam); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lines must be a number"})
+			return
+		}
+	}
+
+	logs, err := s.serviceFor(c).WorkloadLogs(c.Param("name"), c.Query("stream"), lines)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(logs))
+}
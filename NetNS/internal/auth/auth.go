@@ -0,0 +1,67 @@
+// Package auth defines the roles and bearer tokens of the REST API.
+//
+// Token secrets are random and shown once when created; only their SHA-256
+// hash is stored. Roles are ordered, each granting everything the previous
+// one does: viewer (read), operator (create/change/delete), admin (audit log).
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Role is the access level granted to an API principal
+type Role string
+
+// Roles, from least to most privileged
+const (
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+// roleRanks orders the roles
+var roleRanks = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ParseRole converts a role name to a Role
+func ParseRole(roleName string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(roleName)))
+	if _, known := roleRanks[role]; !known {
+		return "", fmt.Errorf("unknown role %q (expected viewer, operator or admin)", roleName)
+	}
+	return role, nil
+}
+
+// Allows reports whether the role grants at least the required role
+func (role Role) Allows(requiredRole Role) bool {
+	return roleRanks[role] >= roleRanks[requiredRole] && roleRanks[role] > 0
+}
+
+// tokenPrefix marks netns-mgr secrets so they are easy to spot in configs and logs
+const tokenPrefix = "nsm_"
+
+// tokenBytes is the amount of randomness in a token secret
+const tokenBytes = 32
+
+// GenerateToken returns a new random token secret
+func GenerateToken() (string, error) {
+	secret := make([]byte, tokenBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// HashToken returns the hex SHA-256 hash under which a token secret is stored
+func HashToken(token string) string {
+	tokenHash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(tokenHash[:])
+}
@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/zenith/netns-mgr/internal/db"
+)
+
+// readOnlyCommands are not recorded in the audit log
+var readOnlyCommands = map[string]bool{
+	"list":       true,
+	"show":       true,
+	"status":     true,
+	"logs":       true,
+	"watch":      true,
+	"serve":      true,
+	"audit":      true,
+	"help":       true,
+	"completion": true,
+	"version":    true,
+	"supervise":  true, // Internal: runs as long as its workload and logs nothing the run/start did not
+}
+
+var (
+	auditActor     string
+	auditSource    string
+	auditOperation string
+	auditResource  string
+	auditNs        string
+	auditResult    string
+	auditSince     string
+	auditUntil     string
+	auditLimit     int
+	auditOffset    int
+	auditJSON      bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the audit log of mutating operations",
+	Long: `Show who changed what, newest first. Every create/delete/up/down/set
+through the CLI or REST API is recorded with its actor, payload and result.
+
+Examples:
+  # Who deleted tunnels this week?
+  netns-mgr audit --operation delete --resource gre --since 168h
+
+  # Failed API calls
+  netns-mgr audit --source api --result error`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, err := parseAuditTime(auditSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		until, err := parseAuditTime(auditUntil)
+		if err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+
+		entries, total, err := Repo.ListAuditEntries(db.AuditFilter{
+			Actor:        auditActor,
+			Source:       auditSource,
+			Operation:    auditOperation,
+			ResourceType: db.AuditResourceType(auditResource),
+			Namespace:    auditNs,
+			Result:       auditResult,
+			Since:        since,
+			Until:        until,
+			Limit:        auditLimit,
+			Offset:       auditOffset,
+		})
+		if err != nil {
+			return err
+		}
+
+		if auditJSON {
+			jsonEncoder := json.NewEncoder(os.Stdout)
+			jsonEncoder.SetIndent("", "  ")
+			return jsonEncoder.Encode(entries)
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No audit entries found")
+			return nil
+		}
+
+		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tableWriter, "TIME\tACTOR\tSOURCE\tOPERATION\tRESOURCE\tNAME\tNAMESPACE\tRESULT")
+
+		for _, entry := range entries {
+			resultDisplay := entry.Result
+			if entry.Error != "" {
+				resultDisplay += ": " + entry.Error
+			}
+
+			fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				entry.Timestamp.Local().Format("2006-01-02 15:04:05"),
+				entry.Actor,
+				entry.Source,
+				entry.Operation,
+				displayOrDash(entry.ResourceType),
+				displayOrDash(entry.ResourceName),
+				displayOrDash(entry.Namespace),
+				resultDisplay,
+			)
+		}
+
+		tableWriter.Flush()
+		fmt.Printf("\nShowing %d-%d of %d\n", auditOffset+1, auditOffset+len(entries), total)
+		return nil
+	},
+}
+
+// parseAuditTime accepts RFC 3339 timestamps or durations relative to now (e.g. "24h")
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// recordAudit records a mutating CLI command and its result
+// Parameters:
+//   - executedCmd: command that ran (nil if cobra could not resolve one)
+//   - runErr: error returned by the command, if any
+func recordAudit(executedCmd *cobra.Command, runErr error) {
+	if executedCmd == nil || executedCmd == rootCmd || !executedCmd.Runnable() || readOnlyCommands[executedCmd.Name()] {
+		return
+	}
+
+	entry := &db.AuditEntry{
+		Actor:     cliActor(),
+		Source:    "cli",
+		Operation: executedCmd.Name(),
+		Result:    db.AuditResultSuccess,
+	}
+
+	// "netns-mgr ns delete x" -> resource type "namespaces", as in the API's entries
+	if parentCmd := executedCmd.Parent(); parentCmd != nil && parentCmd != rootCmd {
+		entry.ResourceType = db.AuditResourceType(parentCmd.Name())
+	} else {
+		entry.ResourceType = db.AuditResourceType(executedCmd.Name())
+	}
+
+	positionalArgs := executedCmd.Flags().Args()
+	if len(positionalArgs) > 0 {
+		entry.ResourceName = positionalArgs[0]
+	}
+	// "netns-mgr label veth x env=lab" -> resource type "veths", name "x"
+	if executedCmd == labelCmd && len(positionalArgs) > 1 {
+		entry.ResourceType = db.AuditResourceType(positionalArgs[0])
+		entry.ResourceName = positionalArgs[1]
+	}
+	// "netns-mgr workload run --ns red -- sleep 60" -> name "red-sleep", not the command
+	if executedCmd == workloadRunCmd && len(positionalArgs) > 0 {
+		entry.ResourceName = workloadRunName(positionalArgs)
+	}
+	if nsFlag := executedCmd.Flags().Lookup("ns"); nsFlag != nil {
+		entry.Namespace = nsFlag.Value.String()
+	}
+
+	// Payload: positional args and explicitly set flags, without secrets
+	changedFlags := make(map[string]string)
+	executedCmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		if !flag.Changed {
+			return
+		}
+		changedFlags[flag.Name] = flag.Value.String()
+		if !slices.Contains(db.AuditRedactedFields, flag.Name) {
+			return
+		}
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok && flag.Name == "env" {
+			changedFlags[flag.Name] = "[" + strings.Join(db.RedactEnvironment(sliceValue.GetSlice()), ",") + "]"
+		} else {
+			changedFlags[flag.Name] = db.AuditRedactedValue
+		}
+	})
+	payload, _ := json.Marshal(map[string]interface{}{
+		"args":  positionalArgs,
+		"flags": changedFlags,
+	})
+	entry.Payload = string(payload)
+
+	if runErr != nil {
+		entry.Result = db.AuditResultError
+		entry.Error = runErr.Error()
+	}
+
+	if err := Repo.CreateAuditEntry(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record audit entry: %v\n", err)
+	}
+}
+
+// cliActor returns the invoking user, preferring the sudo caller over root
+func cliActor() string {
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		return sudoUser
+	}
+	if currentUser, err := user.Current(); err == nil {
+		return currentUser.Username
+	}
+	return strings.TrimSpace(os.Getenv("USER"))
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().StringVar(&auditActor, "actor", "", "only entries by this actor")
+	auditCmd.Flags().StringVar(&auditSource, "source", "", "only entries from this source (cli or api)")
+	auditCmd.Flags().StringVar(&auditOperation, "operation", "", "only this operation (create, delete, up, down, set, ...)")
+	auditCmd.Flags().StringVar(&auditResource, "resource", "", "only this resource type (namespaces, veths, gre, ...; command names like ns work too)")
+	auditCmd.Flags().StringVar(&auditNs, "ns", "", "only entries for this namespace")
+	auditCmd.Flags().StringVar(&auditResult, "result", "", "only this result (success or error)")
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "only entries after this time (RFC 3339 or duration ago, e.g. 24h)")
+	auditCmd.Flags().StringVar(&auditUntil, "until", "", "only entries before this time (RFC 3339 or duration ago)")
+	auditCmd.Flags().IntVar(&auditLimit, "limit", db.DefaultAuditLimit, "maximum entries to show")
+	auditCmd.Flags().IntVar(&auditOffset, "offset", 0, "entries to skip")
+	auditCmd.Flags().BoolVar(&auditJSON, "json", false, "print entries as JSON")
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+var (
+	bondNs      string
+	bondMode    string
+	bondMiimon  int
+	bondPrimary string
+	bondSlaves  []string
+)
+
+var bondCmd = &cobra.Command{
+	Use:   "bond",
+	Short: "Manage bonded interfaces",
+	Long: `Manage bonded interfaces for redundant uplinks.
+
+A bond aggregates several managed veth ends inside a namespace into one
+logical interface (active-backup, balance-rr, 802.3ad, ...).`,
+}
+
+var bondCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a bond from managed veth ends",
+	Long: `Create a bond inside a namespace from managed veth ends.
+
+Examples:
+  # Active-backup bond with two uplinks, preferring veth-a
+  netns-mgr bond create bond0 --ns host1 --slaves veth-a,veth-b \
+    --mode active-backup --miimon 100 --primary veth-a
+
+  # LACP bond
+  netns-mgr bond create bond0 --ns host1 --slaves veth-a,veth-b --mode 802.3ad`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bondName := args[0]
+
+		bondRecord, err := Svc.CreateBond(service.CreateBondRequest{
+			Name:      bondName,
+			Mode:      bondMode,
+			Miimon:    &bondMiimon,
+			Primary:   bondPrimary,
+			Slaves:    bondSlaves,
+			Namespace: bondNs,
+			Labels:    createLabels,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created bond: %s (mode=%s, slaves=%s)\n", bondName, bondRecord.Mode, strings.Join(bondSlaves, ", "))
+		return nil
+	},
+}
+
+var bondDeleteCmd = &cobra.Command{
+	Use:   "delete <name> | --selector <selector>",
+	Short: "Delete a bond, or every bond matching a label selector",
+	Args:  nameOrSelector,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if labelSelector != "" {
+			return deleteBySelector(db.LabelBonds, "bonds")
+		}
+		bondName := args[0]
+
+		if err := Svc.DeleteBond(bondName, bondNs); err != nil {
+			return err
+		}
+
+		fmt.Printf("Deleted bond: %s\n", bondName)
+		return nil
+	},
+}
+
+var bondListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List bonds with active slave and per-slave state",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bondInfos, err := Svc.BondInfos(bondNs)
+		if err != nil {
+			return err
+		}
+		bondInfos, err = filterBySelector(bondInfos, db.LabelBonds, bondNs, func(bondInfo netns.BondInfo) string { return bondInfo.Name })
+		if err != nil {
+			return err
+		}
+
+		if len(bondInfos) == 0 {
+			fmt.Println("No bonds found")
+			return nil
+		}
+
+		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tableWriter, "NAME\tMODE\tSTATE\tACTIVE SLAVE\tSLAVES")
+
+		for _, bondInfo := range bondInfos {
+			activeDisplay := bondInfo.ActiveSlave
+			if activeDisplay == "" {
+				activeDisplay = "-"
+			}
+
+			slavesDisplay := "-"
+			if len(bondInfo.Slaves) > 0 {
+				var slaveStates []string
+				for _, slaveInfo := range bondInfo.Slaves {
+					slaveStates = append(slaveStates, fmt.Sprintf("%s(%s,mii=%s)", slaveInfo.Name, slaveInfo.State, slaveInfo.MiiStatus))
+				}
+				slavesDisplay = strings.Join(slaveStates, ", ")
+			}
+
+			fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\t%s\n",
+				bondInfo.Name,
+				bondInfo.Mode,
+				bondInfo.State,
+				activeDisplay,
+				slavesDisplay,
+			)
+		}
+
+		tableWriter.Flush()
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bondCmd)
+
+	bondCreateCmd.Flags().StringVar(&bondNs, "ns", "", "namespace to create the bond in")
+	bondCreateCmd.Flags().StringSliceVar(&bondSlaves, "slaves", nil, "comma-separated managed veth ends to enslave (required)")
+	bondCreateCmd.Flags().StringVar(&bondMode, "mode", service.DefaultBondMode, "bond mode (active-backup, balance-rr, 802.3ad, ...)")
+	bondCreateCmd.Flags().IntVar(&bondMiimon, "miimon", service.DefaultBondMiimon, "MII link monitoring interval in ms (0 = disabled)")
+	bondCreateCmd.Flags().StringVar(&bondPrimary, "primary", "", "preferred slave (active-backup)")
+
+	bondDeleteCmd.Flags().StringVar(&bondNs, "ns", "", "namespace")
+	bondListCmd.Flags().StringVar(&bondNs, "ns", "", "namespace")
+	addLabelFlag(bondCreateCmd)
+	addSelectorFlag(bondDeleteCmd, "delete every bond matching this label selector")
+	addSelectorFlag(bondListCmd, "only list recorded bonds matching this label selector")
+
+	bondCmd.AddCommand(bondCreateCmd)
+	bondCmd.AddCommand(bondDeleteCmd)
+	bondCmd.AddCommand(bondListCmd)
+}
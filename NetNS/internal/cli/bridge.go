@@ -7,7 +7,9 @@ import (
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/db"
 	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
 )
 
 var bridgeNs string
@@ -27,61 +29,38 @@ Examples:
   netns-mgr bridge create br0
 
   # Create bridge in a namespace
-  netns-mgr bridge create br0 --ns myns`,
+  netns-mgr bridge create br0 --ns myns
+
+  # Create a labelled bridge
+  netns-mgr bridge create br0 --label env=lab`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		bridgeName := args[0]
 
-		namespaceManager := netns.NewManager()
-		bridgeManager := netns.NewBridgeManager(namespaceManager)
-
-		// Create in system
-		if err := bridgeManager.Create(bridgeName, bridgeNs); err != nil {
-			return err
-		}
-
-		// Get namespace ID for DB
-		var namespaceID *int64
-		if bridgeNs != "" {
-			namespaceRecord, err := Repo.GetNamespaceByName(bridgeNs)
-			if err == nil && namespaceRecord != nil {
-				namespaceID = &namespaceRecord.ID
-			}
-		}
-
-		// Record in database
-		_, err := Repo.CreateBridge(bridgeName, namespaceID)
+		bridgeRecord, err := Backend.CreateBridge(service.CreateBridgeRequest{Name: bridgeName, Namespace: bridgeNs, Labels: createLabels})
 		if err != nil {
-			// Rollback system change
-			bridgeManager.Delete(bridgeName, bridgeNs)
-			return fmt.Errorf("failed to record bridge: %w", err)
+			return err
 		}
 
-		fmt.Printf("Created bridge: %s\n", bridgeName)
+		fmt.Printf("Created bridge: %s\n", bridgeRecord.Name)
 		return nil
 	},
 }
 
 var bridgeDeleteCmd = &cobra.Command{
-	Use:   "delete <name>",
-	Short: "Delete a bridge",
-	Args:  cobra.ExactArgs(1),
+	Use:   "delete <name> | --selector <selector>",
+	Short: "Delete a bridge, or every bridge matching a label selector",
+	Args:  nameOrSelector,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if labelSelector != "" {
+			return deleteBySelector(db.LabelBridges, "bridges")
+		}
 		bridgeName := args[0]
 
-		namespaceManager := netns.NewManager()
-		bridgeManager := netns.NewBridgeManager(namespaceManager)
-
-		// Delete from system
-		if err := bridgeManager.Delete(bridgeName, bridgeNs); err != nil {
+		if err := Backend.DeleteBridge(bridgeName, bridgeNs); err != nil {
 			return err
 		}
 
-		// Remove from database
-		if err := Repo.DeleteBridge(bridgeName); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to remove from database: %v\n", err)
-		}
-
 		fmt.Printf("Deleted bridge: %s\n", bridgeName)
 		return nil
 	},
@@ -91,10 +70,11 @@ var bridgeListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List bridges",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		namespaceManager := netns.NewManager()
-		bridgeManager := netns.NewBridgeManager(namespaceManager)
-
-		bridgeInfos, err := bridgeManager.GetBridgeInfos(bridgeNs)
+		bridgeInfos, err := Backend.BridgeInfos(bridgeNs)
+		if err != nil {
+			return err
+		}
+		bridgeInfos, err = filterBySelector(bridgeInfos, db.LabelBridges, bridgeNs, func(bridgeInfo netns.BridgeInfo) string { return bridgeInfo.Name })
 		if err != nil {
 			return err
 		}
@@ -133,19 +113,11 @@ var bridgeAddPortCmd = &cobra.Command{
 		bridgeName := args[0]
 		interfaceName := args[1]
 
-		namespaceManager := netns.NewManager()
-		bridgeManager := netns.NewBridgeManager(namespaceManager)
-
-		if err := bridgeManager.AddPort(bridgeName, interfaceName, bridgeNs); err != nil {
+		err := Backend.AddBridgePort(service.BridgePortRequest{Bridge: bridgeName, Interface: interfaceName, Namespace: bridgeNs})
+		if err != nil {
 			return err
 		}
 
-		// Record in database
-		bridgeRecord, err := Repo.GetBridgeByName(bridgeName)
-		if err == nil && bridgeRecord != nil {
-			Repo.AddBridgePort(bridgeRecord.ID, interfaceName)
-		}
-
 		fmt.Printf("Added %s to bridge %s\n", interfaceName, bridgeName)
 		return nil
 	},
@@ -159,19 +131,11 @@ var bridgeRemovePortCmd = &cobra.Command{
 		bridgeName := args[0]
 		interfaceName := args[1]
 
-		namespaceManager := netns.NewManager()
-		bridgeManager := netns.NewBridgeManager(namespaceManager)
-
-		if err := bridgeManager.RemovePort(interfaceName, bridgeNs); err != nil {
+		err := Backend.RemoveBridgePort(service.BridgePortRequest{Bridge: bridgeName, Interface: interfaceName, Namespace: bridgeNs})
+		if err != nil {
 			return err
 		}
 
-		// Remove from database
-		bridgeRecord, err := Repo.GetBridgeByName(bridgeName)
-		if err == nil && bridgeRecord != nil {
-			Repo.RemoveBridgePort(bridgeRecord.ID, interfaceName)
-		}
-
 		fmt.Printf("Removed %s from bridge %s\n", interfaceName, bridgeName)
 		return nil
 	},
@@ -183,6 +147,9 @@ func init() {
 	bridgeCreateCmd.Flags().StringVar(&bridgeNs, "ns", "", "namespace")
 	bridgeDeleteCmd.Flags().StringVar(&bridgeNs, "ns", "", "namespace")
 	bridgeListCmd.Flags().StringVar(&bridgeNs, "ns", "", "namespace")
+	addLabelFlag(bridgeCreateCmd)
+	addSelectorFlag(bridgeDeleteCmd, "delete every bridge matching this label selector")
+	addSelectorFlag(bridgeListCmd, "only list recorded bridges matching this label selector")
 	bridgeAddPortCmd.Flags().StringVar(&bridgeNs, "ns", "", "namespace")
 	bridgeRemovePortCmd.Flags().StringVar(&bridgeNs, "ns", "", "namespace")
 
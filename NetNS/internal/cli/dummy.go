@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+var (
+	dummyNs        string
+	dummyAddresses []string
+)
+
+var dummyCmd = &cobra.Command{
+	Use:   "dummy",
+	Short: "Manage dummy (loopback-style) interfaces",
+	Long: `Manage dummy interfaces.
+
+Dummy interfaces are always up and never lose carrier, which makes them
+the usual place for stable addresses such as router IDs, BGP
+update-source addresses and anycast VIPs.`,
+}
+
+var dummyCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a dummy interface",
+	Long: `Create a dummy interface, optionally with addresses.
+
+Examples:
+  # Router ID loopback in namespace r1
+  netns-mgr dummy create lo1 --ns r1 --address 10.255.0.1/32
+
+  # Anycast VIP with IPv4 and IPv6 addresses
+  netns-mgr dummy create vip0 --ns edge1 --address 192.0.2.10/32 --address 2001:db8::10/128`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interfaceName := args[0]
+
+		_, err := Svc.CreateDummy(service.CreateDummyRequest{
+			Name:      interfaceName,
+			Addresses: dummyAddresses,
+			Namespace: dummyNs,
+			Labels:    createLabels,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created dummy interface: %s\n", interfaceName)
+		return nil
+	},
+}
+
+var dummyDeleteCmd = &cobra.Command{
+	Use:   "delete <name> | --selector <selector>",
+	Short: "Delete a dummy interface, or every dummy interface matching a label selector",
+	Args:  nameOrSelector,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if labelSelector != "" {
+			return deleteBySelector(db.LabelDummies, "dummy interfaces")
+		}
+		interfaceName := args[0]
+
+		if err := Svc.DeleteDummy(interfaceName, dummyNs); err != nil {
+			return err
+		}
+
+		fmt.Printf("Deleted dummy interface: %s\n", interfaceName)
+		return nil
+	},
+}
+
+var dummyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List dummy interfaces",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dummyInfos, err := Svc.DummyInfos(dummyNs)
+		if err != nil {
+			return err
+		}
+		dummyInfos, err = filterBySelector(dummyInfos, db.LabelDummies, dummyNs, func(dummyInfo netns.DummyInfo) string { return dummyInfo.Name })
+		if err != nil {
+			return err
+		}
+
+		if len(dummyInfos) == 0 {
+			fmt.Println("No dummy interfaces found")
+			return nil
+		}
+
+		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tableWriter, "NAME\tSTATE\tADDRESSES")
+
+		for _, dummyInfo := range dummyInfos {
+			addressesDisplay := "-"
+			if len(dummyInfo.Addresses) > 0 {
+				addressesDisplay = strings.Join(dummyInfo.Addresses, ", ")
+			}
+
+			fmt.Fprintf(tableWriter, "%s\t%s\t%s\n",
+				dummyInfo.Name,
+				dummyInfo.State,
+				addressesDisplay,
+			)
+		}
+
+		tableWriter.Flush()
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dummyCmd)
+
+	dummyCreateCmd.Flags().StringVar(&dummyNs, "ns", "", "namespace")
+	dummyCreateCmd.Flags().StringArrayVar(&dummyAddresses, "address", nil, "address in CIDR notation (repeatable)")
+
+	dummyDeleteCmd.Flags().StringVar(&dummyNs, "ns", "", "namespace")
+	dummyListCmd.Flags().StringVar(&dummyNs, "ns", "", "namespace")
+	addLabelFlag(dummyCreateCmd)
+	addSelectorFlag(dummyDeleteCmd, "delete every dummy interface matching this label selector")
+	addSelectorFlag(dummyListCmd, "only list recorded dummy interfaces matching this label selector")
+
+	dummyCmd.AddCommand(dummyCreateCmd)
+	dummyCmd.AddCommand(dummyDeleteCmd)
+	dummyCmd.AddCommand(dummyListCmd)
+}
@@ -6,7 +6,9 @@ import (
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/db"
 	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
 )
 
 var (
@@ -39,7 +41,10 @@ Examples:
   netns-mgr gre create gre1 --local 10.0.0.1 --remote 10.0.0.2 --ns myns --key 100
 
   # Create a GRE tunnel with custom TTL
-  netns-mgr gre create gre1 --local 10.0.0.1 --remote 10.0.0.2 --ttl 64`,
+  netns-mgr gre create gre1 --local 10.0.0.1 --remote 10.0.0.2 --ttl 64
+
+  # Create a labelled GRE tunnel
+  netns-mgr gre create gre1 --local 10.0.0.1 --remote 10.0.0.2 --label env=lab`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tunnelName := args[0]
@@ -48,66 +53,84 @@ Examples:
 			return fmt.Errorf("--local and --remote flags are required")
 		}
 
-		namespaceManager := netns.NewManager()
-		greManager := netns.NewGREManager(namespaceManager)
-
-		// Create GRE tunnel with options
-		tunnelConfig := netns.GRETunnel{
+		tunnelRecord, err := Backend.CreateGRETunnel(service.CreateGRETunnelRequest{
 			Name:      tunnelName,
 			LocalIP:   greLocalIP,
 			RemoteIP:  greRemoteIP,
 			Key:       greKey,
 			TTL:       greTTL,
 			Namespace: greNs,
-		}
-
-		if err := greManager.CreateWithOptions(tunnelConfig); err != nil {
+			Labels:    createLabels,
+		})
+		if err != nil {
 			return err
 		}
 
-		// Get namespace ID for DB
-		var namespaceID *int64
-		if greNs != "" {
-			namespaceRecord, err := Repo.GetNamespaceByName(greNs)
-			if err == nil && namespaceRecord != nil {
-				namespaceID = &namespaceRecord.ID
-			}
+		fmt.Printf("Created GRE tunnel: %s (local=%s, remote=%s)\n", tunnelRecord.Name, greLocalIP, greRemoteIP)
+		return nil
+	},
+}
+
+var greDeleteCmd = &cobra.Command{
+	Use:   "delete <name> | --selector <selector>",
+	Short: "Delete a GRE tunnel, or every GRE tunnel matching a label selector",
+	Args:  nameOrSelector,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if labelSelector != "" {
+			return deleteBySelector(db.LabelGRETunnels, "GRE tunnels")
 		}
+		tunnelName := args[0]
 
-		// Record in database
-		_, err := Repo.CreateGRETunnel(tunnelName, greLocalIP, greRemoteIP, greKey, greTTL, namespaceID)
-		if err != nil {
-			// Rollback system change
-			greManager.Delete(tunnelName, greNs)
-			return fmt.Errorf("failed to record GRE tunnel: %w", err)
+		if err := Backend.DeleteGRETunnel(tunnelName, greNs); err != nil {
+			return err
 		}
 
-		fmt.Printf("Created GRE tunnel: %s (local=%s, remote=%s)\n", tunnelName, greLocalIP, greRemoteIP)
+		fmt.Printf("Deleted GRE tunnel: %s\n", tunnelName)
 		return nil
 	},
 }
 
-var greDeleteCmd = &cobra.Command{
-	Use:   "delete <name>",
-	Short: "Delete a GRE tunnel",
-	Args:  cobra.ExactArgs(1),
+var greSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Change the endpoints, key or TTL of a GRE tunnel",
+	Long: `Change the endpoints, key or TTL of a recorded GRE tunnel.
+
+The tunnel is modified in place, keeping its addresses and routes; only the
+given flags change. A key of 0 removes the key.
+
+Examples:
+  # Point a tunnel at a new remote endpoint
+  netns-mgr gre set gre1 --remote 10.0.0.3
+
+  # Change the key and TTL
+  netns-mgr gre set gre1 --key 200 --ttl 64`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tunnelName := args[0]
 
-		namespaceManager := netns.NewManager()
-		greManager := netns.NewGREManager(namespaceManager)
-
-		// Delete from system
-		if err := greManager.Delete(tunnelName, greNs); err != nil {
-			return err
+		var request service.UpdateGRETunnelRequest
+		if cmd.Flags().Changed("local") {
+			request.LocalIP = &greLocalIP
+		}
+		if cmd.Flags().Changed("remote") {
+			request.RemoteIP = &greRemoteIP
+		}
+		if cmd.Flags().Changed("key") {
+			request.Key = &greKey
+		}
+		if cmd.Flags().Changed("ttl") {
+			request.TTL = &greTTL
+		}
+		if request.LocalIP == nil && request.RemoteIP == nil && request.Key == nil && request.TTL == nil {
+			return fmt.Errorf("at least one of --local, --remote, --key or --ttl is required")
 		}
 
-		// Remove from database
-		if err := Repo.DeleteGRETunnel(tunnelName); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to remove from database: %v\n", err)
+		tunnelRecord, err := Backend.UpdateGRETunnel(tunnelName, request)
+		if err != nil {
+			return err
 		}
 
-		fmt.Printf("Deleted GRE tunnel: %s\n", tunnelName)
+		fmt.Printf("Updated GRE tunnel: %s (local=%s, remote=%s)\n", tunnelRecord.Name, tunnelRecord.LocalIP, tunnelRecord.RemoteIP)
 		return nil
 	},
 }
@@ -115,11 +138,25 @@ var greDeleteCmd = &cobra.Command{
 var greListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List GRE tunnels",
+	Long: `List the GRE tunnels present in a namespace.
+
+With --limit, --filter, --sort or --cursor the recorded tunnels of every
+namespace (or of --ns) are listed instead, a page at a time. The within
+filter keeps tunnels with an endpoint inside the network.
+
+Examples:
+  # Recorded tunnels towards 192.0.2.0/24, by name
+  netns-mgr gre list --filter within=192.0.2.0/24 --sort name`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		namespaceManager := netns.NewManager()
-		greManager := netns.NewGREManager(namespaceManager)
+		if recordListRequested(cmd) {
+			return listGRETunnelRecords()
+		}
 
-		greTunnels, err := greManager.List(greNs)
+		greTunnels, err := Backend.GRETunnelInfos(greNs)
+		if err != nil {
+			return err
+		}
+		greTunnels, err = filterBySelector(greTunnels, db.LabelGRETunnels, greNs, func(tunnelInfo netns.GRETunnelInfo) string { return tunnelInfo.Name })
 		if err != nil {
 			return err
 		}
@@ -158,6 +195,53 @@ var greListCmd = &cobra.Command{
 	},
 }
 
+// listGRETunnelRecords prints one page of the recorded GRE tunnels matching the list flags
+func listGRETunnelRecords() error {
+	options, namespaceName, err := recordListOptions(greNs)
+	if err != nil {
+		return err
+	}
+	tunnelRecords, nextCursor, err := Backend.ListGRETunnels(namespaceName, options)
+	if err != nil {
+		return err
+	}
+	if len(tunnelRecords) == 0 {
+		fmt.Println("No GRE tunnels found")
+		return nil
+	}
+
+	tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, "NAME\tLOCAL\tREMOTE\tKEY\tTTL\tNAMESPACE\tCREATED\tLABELS")
+	namespaceNames := namespaceNamesByID()
+	for _, tunnelRecord := range tunnelRecords {
+		namespaceDisplay := "-"
+		if tunnelRecord.NsID != nil {
+			namespaceDisplay = namespaceNames[*tunnelRecord.NsID]
+		}
+		keyDisplay := "-"
+		if tunnelRecord.Key > 0 {
+			keyDisplay = fmt.Sprintf("%d", tunnelRecord.Key)
+		}
+		ttlDisplay := "inherit"
+		if tunnelRecord.TTL > 0 {
+			ttlDisplay = fmt.Sprintf("%d", tunnelRecord.TTL)
+		}
+		fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			tunnelRecord.Name,
+			tunnelRecord.LocalIP,
+			tunnelRecord.RemoteIP,
+			keyDisplay,
+			ttlDisplay,
+			namespaceDisplay,
+			tunnelRecord.CreatedAt.Format("2006-01-02 15:04:05"),
+			formatLabels(tunnelRecord.Labels),
+		)
+	}
+	tableWriter.Flush()
+	printNextPage(nextCursor)
+	return nil
+}
+
 var greUpCmd = &cobra.Command{
 	Use:   "up <name>",
 	Short: "Bring a GRE tunnel interface up",
@@ -165,10 +249,7 @@ var greUpCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tunnelName := args[0]
 
-		namespaceManager := netns.NewManager()
-		greManager := netns.NewGREManager(namespaceManager)
-
-		if err := greManager.SetUp(tunnelName, greNs); err != nil {
+		if err := Backend.SetGRETunnelUp(tunnelName, greNs); err != nil {
 			return err
 		}
 
@@ -184,10 +265,7 @@ var greDownCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tunnelName := args[0]
 
-		namespaceManager := netns.NewManager()
-		greManager := netns.NewGREManager(namespaceManager)
-
-		if err := greManager.SetDown(tunnelName, greNs); err != nil {
+		if err := Backend.SetGRETunnelDown(tunnelName, greNs); err != nil {
 			return err
 		}
 
@@ -231,38 +309,22 @@ Examples:
 			return fmt.Errorf("--ns1-tunnel-ip and --ns2-tunnel-ip flags are required")
 		}
 
-		namespaceManager := netns.NewManager()
-		greManager := netns.NewGREManager(namespaceManager)
-
-		// Create peer tunnels
-		err := greManager.CreatePeerTunnels(
-			grePeerNs1, grePeerNs1IP, grePeerNs1TIP,
-			grePeerNs2, grePeerNs2IP, grePeerNs2TIP,
-			tunnelName,
-		)
+		peerRequest := service.CreatePeerTunnelsRequest{
+			TunnelName:  tunnelName,
+			Ns1:         grePeerNs1,
+			Ns1IP:       grePeerNs1IP,
+			Ns1TunnelIP: grePeerNs1TIP,
+			Ns2:         grePeerNs2,
+			Ns2IP:       grePeerNs2IP,
+			Ns2TunnelIP: grePeerNs2TIP,
+			Labels:      createLabels,
+		}
+		tunnelRecords, err := Backend.CreatePeerTunnels(peerRequest)
 		if err != nil {
 			return err
 		}
-
-		// Record in database
-		tunnel1Name := tunnelName + "-1"
-		tunnel2Name := tunnelName + "-2"
-
-		// Get namespace IDs
-		namespace1Record, _ := Repo.GetNamespaceByName(grePeerNs1)
-		namespace2Record, _ := Repo.GetNamespaceByName(grePeerNs2)
-
-		var namespace1ID, namespace2ID *int64
-		if namespace1Record != nil {
-			namespace1ID = &namespace1Record.ID
-		}
-		if namespace2Record != nil {
-			namespace2ID = &namespace2Record.ID
-		}
-
-		// Record tunnels
-		Repo.CreateGRETunnel(tunnel1Name, grePeerNs1IP, grePeerNs2IP, 0, 0, namespace1ID)
-		Repo.CreateGRETunnel(tunnel2Name, grePeerNs2IP, grePeerNs1IP, 0, 0, namespace2ID)
+		tunnel1Name := tunnelRecords[0].Name
+		tunnel2Name := tunnelRecords[1].Name
 
 		fmt.Printf("Created GRE tunnel pair:\n")
 		fmt.Printf("  %s in %s (local=%s, remote=%s, tunnel IP=%s)\n", tunnel1Name, grePeerNs1, grePeerNs1IP, grePeerNs2IP, grePeerNs1TIP)
@@ -280,12 +342,22 @@ func init() {
 	greCreateCmd.Flags().StringVar(&greRemoteIP, "remote", "", "remote endpoint IP address (required)")
 	greCreateCmd.Flags().Uint32Var(&greKey, "key", 0, "GRE key for multiplexing (0 = no key)")
 	greCreateCmd.Flags().Uint8Var(&greTTL, "ttl", 0, "time to live (0 = inherit)")
+	addLabelFlag(greCreateCmd)
 
 	// Delete command flags
 	greDeleteCmd.Flags().StringVar(&greNs, "ns", "", "namespace")
+	addSelectorFlag(greDeleteCmd, "delete every GRE tunnel matching this label selector")
+
+	// Set command flags
+	greSetCmd.Flags().StringVar(&greLocalIP, "local", "", "new local endpoint IP address")
+	greSetCmd.Flags().StringVar(&greRemoteIP, "remote", "", "new remote endpoint IP address")
+	greSetCmd.Flags().Uint32Var(&greKey, "key", 0, "new GRE key (0 = no key)")
+	greSetCmd.Flags().Uint8Var(&greTTL, "ttl", 0, "new time to live (0 = inherit)")
 
 	// List command flags
 	greListCmd.Flags().StringVar(&greNs, "ns", "", "namespace")
+	addSelectorFlag(greListCmd, "only list recorded GRE tunnels matching this label selector")
+	addListFlags(greListCmd, "name, created_at or id")
 
 	// Up/down command flags
 	greUpCmd.Flags().StringVar(&greNs, "ns", "", "namespace")
@@ -298,10 +370,12 @@ func init() {
 	grePeerCmd.Flags().StringVar(&grePeerNs2, "ns2", "", "second namespace name (required)")
 	grePeerCmd.Flags().StringVar(&grePeerNs2IP, "ns2-ip", "", "IP address in ns2 for tunnel endpoint (required)")
 	grePeerCmd.Flags().StringVar(&grePeerNs2TIP, "ns2-tunnel-ip", "", "IP address to assign to tunnel interface in ns2 (required)")
+	addLabelFlag(grePeerCmd)
 
 	// Add subcommands
 	greCmd.AddCommand(greCreateCmd)
 	greCmd.AddCommand(greDeleteCmd)
+	greCmd.AddCommand(greSetCmd)
 	greCmd.AddCommand(greListCmd)
 	greCmd.AddCommand(greUpCmd)
 	greCmd.AddCommand(greDownCmd)
@@ -6,12 +6,17 @@ import (
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/db"
 	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
 )
 
 var (
-	ipInterface string
-	ipNs        string
+	ipInterface         string
+	ipNs                string
+	ipAddressLabel      string
+	ipValidLifetime     int
+	ipPreferredLifetime int
 )
 
 var ipCmd = &cobra.Command{
@@ -31,7 +36,10 @@ Examples:
   netns-mgr ip add 10.0.0.1/24 --interface eth0
 
   # Add IP to interface in a namespace
-  netns-mgr ip add 10.0.0.1/24 --interface veth0 --ns myns`,
+  netns-mgr ip add 10.0.0.1/24 --interface veth0 --ns myns
+
+  # Add a labelled IP
+  netns-mgr ip add 10.0.0.1/24 --interface veth0 --label env=lab`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ipAddress := args[0]
@@ -40,40 +48,55 @@ Examples:
 			return fmt.Errorf("--interface is required")
 		}
 
-		namespaceManager := netns.NewManager()
-		addressManager := netns.NewAddressManager(namespaceManager)
-
-		// Add to system
-		if err := addressManager.Add(ipAddress, ipInterface, ipNs); err != nil {
+		_, err := Backend.AddAddress(service.AddressRequest{Interface: ipInterface, Address: ipAddress, Namespace: ipNs, Labels: createLabels})
+		if err != nil {
 			return err
 		}
 
-		// Get namespace ID for DB
-		var namespaceID *int64
-		if ipNs != "" {
-			namespaceRecord, err := Repo.GetNamespaceByName(ipNs)
-			if err == nil && namespaceRecord != nil {
-				namespaceID = &namespaceRecord.ID
-			}
+		fmt.Printf("Added %s to %s\n", ipAddress, ipInterface)
+		return nil
+	},
+}
+
+var ipDeleteCmd = &cobra.Command{
+	Use:   "delete <address> | --selector <selector>",
+	Short: "Remove an IP address from an interface, or every address matching a label selector",
+	Args:  nameOrSelector,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if labelSelector != "" {
+			return deleteBySelector(db.LabelAddresses, "addresses")
 		}
+		ipAddress := args[0]
 
-		// Record in database
-		_, err := Repo.CreateIPAddress(ipInterface, namespaceID, ipAddress)
+		if ipInterface == "" {
+			return fmt.Errorf("--interface is required")
+		}
+
+		err := Backend.DeleteAddress(service.AddressRequest{Interface: ipInterface, Address: ipAddress, Namespace: ipNs})
 		if err != nil {
-			// Rollback system change
-			addressManager.Delete(ipAddress, ipInterface, ipNs)
-			return fmt.Errorf("failed to record IP address: %w", err)
+			return err
 		}
 
-		fmt.Printf("Added %s to %s\n", ipAddress, ipInterface)
+		fmt.Printf("Removed %s from %s\n", ipAddress, ipInterface)
 		return nil
 	},
 }
 
-var ipDeleteCmd = &cobra.Command{
-	Use:   "delete <address>",
-	Short: "Remove an IP address from an interface",
-	Args:  cobra.ExactArgs(1),
+var ipSetCmd = &cobra.Command{
+	Use:   "set <address>",
+	Short: "Change the label and lifetimes of a recorded IP address",
+	Long: `Change the label and lifetimes of a recorded IP address.
+
+Lifetimes change in place and count from now; 0 (the default) means forever.
+A new label (IPv4 only, starting with the interface name) re-adds the address.
+
+Examples:
+  # Let an address expire in an hour, deprecated after 30 minutes
+  netns-mgr ip set 10.0.0.1/24 --interface veth0 --ns myns --valid-lft 3600 --preferred-lft 1800
+
+  # Label an address
+  netns-mgr ip set 10.0.0.1/24 --interface veth0 --ns myns --address-label veth0:web`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ipAddress := args[0]
 
@@ -81,27 +104,67 @@ var ipDeleteCmd = &cobra.Command{
 			return fmt.Errorf("--interface is required")
 		}
 
-		namespaceManager := netns.NewManager()
-		addressManager := netns.NewAddressManager(namespaceManager)
+		addressRecord, err := findAddressRecord(ipAddress, ipInterface, ipNs)
+		if err != nil {
+			return err
+		}
 
-		// Delete from system
-		if err := addressManager.Delete(ipAddress, ipInterface, ipNs); err != nil {
+		request := service.UpdateAddressRequest{
+			ValidLifetime:     ipValidLifetime,
+			PreferredLifetime: ipPreferredLifetime,
+		}
+		if cmd.Flags().Changed("address-label") {
+			request.AddressLabel = &ipAddressLabel
+		}
+		if _, err := Backend.UpdateAddress(addressRecord.ID, request); err != nil {
 			return err
 		}
 
-		fmt.Printf("Removed %s from %s\n", ipAddress, ipInterface)
+		fmt.Printf("Updated %s on %s\n", ipAddress, ipInterface)
 		return nil
 	},
 }
 
+// findAddressRecord looks up the record of an address on an interface
+// Parameters:
+//   - address: address in CIDR notation
+//   - interfaceName: interface holding the address
+//   - namespaceName: namespace of the interface (empty = host)
+func findAddressRecord(address, interfaceName, namespaceName string) (*db.IPAddress, error) {
+	addressRecords, _, err := Backend.ListAddresses(namespaceName, service.ListOptions{Interface: interfaceName})
+	if err != nil {
+		return nil, err
+	}
+	for _, addressRecord := range addressRecords {
+		if addressRecord.Address == address && (namespaceName != "" || addressRecord.NsID == nil) {
+			return &addressRecord, nil
+		}
+	}
+	return nil, fmt.Errorf("no recorded address %s on %s", address, interfaceName)
+}
+
 var ipListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List IP addresses",
+	Long: `List the IP addresses configured in a namespace.
+
+With --limit, --filter, --sort or --cursor the recorded addresses of every
+namespace (or of --ns) are listed instead, a page at a time.
+
+Examples:
+  # Recorded addresses inside 10.0.0.0/16 on veth0
+  netns-mgr ip list --filter within=10.0.0.0/16 --filter interface=veth0`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		namespaceManager := netns.NewManager()
-		addressManager := netns.NewAddressManager(namespaceManager)
+		if recordListRequested(cmd) {
+			return listAddressRecords()
+		}
 
-		addressInfos, err := addressManager.GetAddressInfos(ipNs)
+		addressInfos, err := Backend.AddressInfos(ipNs)
+		if err != nil {
+			return err
+		}
+
+		addressInfos, err = filterBySelector(addressInfos, db.LabelAddresses, ipNs, func(addressInfo netns.AddressInfo) string { return addressInfo.Address })
 		if err != nil {
 			return err
 		}
@@ -112,14 +175,24 @@ var ipListCmd = &cobra.Command{
 		}
 
 		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(tableWriter, "INTERFACE\tADDRESS\tFAMILY\tSCOPE")
+		fmt.Fprintln(tableWriter, "INTERFACE\tADDRESS\tFAMILY\tSCOPE\tLABEL\tVALID")
 
 		for _, addressInfo := range addressInfos {
-			fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\n",
+			labelDisplay := addressInfo.Label
+			if labelDisplay == "" {
+				labelDisplay = "-"
+			}
+			validDisplay := "forever"
+			if addressInfo.ValidLifetime > 0 {
+				validDisplay = fmt.Sprintf("%ds", addressInfo.ValidLifetime)
+			}
+			fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\t%s\t%s\n",
 				addressInfo.Interface,
 				addressInfo.Address,
 				addressInfo.Family,
 				addressInfo.Scope,
+				labelDisplay,
+				validDisplay,
 			)
 		}
 
@@ -128,18 +201,66 @@ var ipListCmd = &cobra.Command{
 	},
 }
 
+// listAddressRecords prints one page of the recorded addresses matching the list flags
+func listAddressRecords() error {
+	options, namespaceName, err := recordListOptions(ipNs)
+	if err != nil {
+		return err
+	}
+	addressRecords, nextCursor, err := Backend.ListAddresses(namespaceName, options)
+	if err != nil {
+		return err
+	}
+	if len(addressRecords) == 0 {
+		fmt.Println("No IP addresses found")
+		return nil
+	}
+
+	tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, "ID\tINTERFACE\tADDRESS\tNAMESPACE\tCREATED\tLABELS")
+	namespaceNames := namespaceNamesByID()
+	for _, addressRecord := range addressRecords {
+		namespaceDisplay := "-"
+		if addressRecord.NsID != nil {
+			namespaceDisplay = namespaceNames[*addressRecord.NsID]
+		}
+		fmt.Fprintf(tableWriter, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			addressRecord.ID,
+			addressRecord.InterfaceName,
+			addressRecord.Address,
+			namespaceDisplay,
+			addressRecord.CreatedAt.Format("2006-01-02 15:04:05"),
+			formatLabels(addressRecord.Labels),
+		)
+	}
+	tableWriter.Flush()
+	printNextPage(nextCursor)
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(ipCmd)
 
 	ipAddCmd.Flags().StringVar(&ipInterface, "interface", "", "interface name (required)")
 	ipAddCmd.Flags().StringVar(&ipNs, "ns", "", "namespace")
+	addLabelFlag(ipAddCmd)
 
 	ipDeleteCmd.Flags().StringVar(&ipInterface, "interface", "", "interface name (required)")
 	ipDeleteCmd.Flags().StringVar(&ipNs, "ns", "", "namespace")
+	addSelectorFlag(ipDeleteCmd, "delete every recorded address matching this label selector")
+
+	ipSetCmd.Flags().StringVar(&ipInterface, "interface", "", "interface name (required)")
+	ipSetCmd.Flags().StringVar(&ipNs, "ns", "", "namespace")
+	ipSetCmd.Flags().StringVar(&ipAddressLabel, "address-label", "", "IPv4 address label, starting with the interface name (empty = interface name)")
+	ipSetCmd.Flags().IntVar(&ipValidLifetime, "valid-lft", 0, "seconds until the address is removed (0 = forever)")
+	ipSetCmd.Flags().IntVar(&ipPreferredLifetime, "preferred-lft", 0, "seconds until the address is deprecated (0 = when it is removed)")
 
 	ipListCmd.Flags().StringVar(&ipNs, "ns", "", "namespace (list all if not specified)")
+	addSelectorFlag(ipListCmd, "only list recorded addresses matching this label selector")
+	addListFlags(ipListCmd, "interface, address, created_at or id")
 
 	ipCmd.AddCommand(ipAddCmd)
 	ipCmd.AddCommand(ipDeleteCmd)
+	ipCmd.AddCommand(ipSetCmd)
 	ipCmd.AddCommand(ipListCmd)
 }
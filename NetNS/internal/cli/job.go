@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/pkg/client"
+)
+
+var (
+	jobLimit int
+	jobWait  bool
+	jobJSON  bool
+)
+
+var jobCmd = &cobra.Command{
+	Use:   "job",
+	Short: "Follow and cancel background jobs of an API server",
+	Long: `Follow and cancel background jobs of an API server.
+
+Long operations started with ?async=true (peer tunnels, namespace and bulk
+deletes) run as jobs in "netns-mgr serve", so this command needs --server.
+
+Examples:
+  netns-mgr --server http://lab1:8080 job list
+  netns-mgr --server http://lab1:8080 job show 12 --wait
+  netns-mgr --server http://lab1:8080 job cancel 12`,
+}
+
+var jobListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List jobs, newest first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiClient, err := jobClient()
+		if err != nil {
+			return err
+		}
+
+		jobs, err := apiClient.ListJobs(context.Background(), jobLimit)
+		if err != nil {
+			return err
+		}
+		if jobJSON {
+			return printJSON(jobs)
+		}
+		if len(jobs) == 0 {
+			fmt.Println("No jobs found")
+			return nil
+		}
+
+		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tableWriter, "ID\tOPERATION\tSTATUS\tSTEPS\tACTOR\tCREATED")
+		for _, job := range jobs {
+			fmt.Fprintf(tableWriter, "%d\t%s\t%s\t%d\t%s\t%s\n",
+				job.ID,
+				job.Operation,
+				job.Status,
+				job.StepsDone,
+				displayOrDash(job.Actor),
+				job.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			)
+		}
+		return tableWriter.Flush()
+	},
+}
+
+var jobShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a job with its step logs",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiClient, jobID, err := jobClientAndID(args[0])
+		if err != nil {
+			return err
+		}
+
+		var job *client.Job
+		if jobWait {
+			job, err = apiClient.WaitJob(context.Background(), jobID, client.DefaultJobPollInterval)
+		} else {
+			job, err = apiClient.GetJob(context.Background(), jobID)
+		}
+		if err != nil {
+			return err
+		}
+		if jobJSON {
+			return printJSON(job)
+		}
+
+		printJob(job)
+		if jobWait && job.Status != client.JobSucceeded {
+			return fmt.Errorf("job %d %s", job.ID, job.Status)
+		}
+		return nil
+	},
+}
+
+var jobCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Cancel a queued or running job",
+	Long: `Cancel a queued or running job.
+
+A queued job never starts. A running job stops before its next step and
+reverts the changes of the operation in progress; use "job show --wait" to
+wait for it to stop.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiClient, jobID, err := jobClientAndID(args[0])
+		if err != nil {
+			return err
+		}
+
+		job, err := apiClient.CancelJob(context.Background(), jobID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Cancelled job %d (%s)\n", job.ID, job.Status)
+		return nil
+	},
+}
+
+// jobClient returns the API client of remote mode; jobs only exist in the server
+func jobClient() (*client.Client, error) {
+	remote, isRemote := Backend.(remoteOperations)
+	if !isRemote {
+		return nil, fmt.Errorf("jobs run in the API server: use --server (or $%s)", serverEnvVar)
+	}
+	return remote.apiClient, nil
+}
+
+// jobClientAndID returns the API client of remote mode and the parsed job ID
+func jobClientAndID(idArg string) (*client.Client, int64, error) {
+	jobID, err := strconv.ParseInt(idArg, 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid job ID %q", idArg)
+	}
+	apiClient, err := jobClient()
+	return apiClient, jobID, err
+}
+
+// printJob prints a job's state, result and step logs
+func printJob(job *client.Job) {
+	fmt.Printf("Job:       %d\n", job.ID)
+	fmt.Printf("Operation: %s\n", job.Operation)
+	fmt.Printf("Status:    %s\n", job.Status)
+	fmt.Printf("Actor:     %s\n", displayOrDash(job.Actor))
+	fmt.Printf("Created:   %s\n", job.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	if job.StartedAt != nil {
+		fmt.Printf("Started:   %s\n", job.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if job.FinishedAt != nil {
+		fmt.Printf("Finished:  %s\n", job.FinishedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if job.Error != "" {
+		fmt.Printf("Error:     %s\n", job.Error)
+	}
+	if len(job.Result) > 0 {
+		fmt.Printf("Result:    %s\n", job.Result)
+	}
+
+	fmt.Printf("\nSteps (%d):\n", job.StepsDone)
+	for _, jobLog := range job.Logs {
+		fmt.Printf("  %s  %s\n", jobLog.Timestamp.Local().Format("15:04:05.000"), jobLog.Message)
+	}
+}
+
+// printJSON prints a value as indented JSON
+func printJSON(value any) error {
+	jsonEncoder := json.NewEncoder(os.Stdout)
+	jsonEncoder.SetIndent("", "  ")
+	return jsonEncoder.Encode(value)
+}
+
+func init() {
+	rootCmd.AddCommand(jobCmd)
+	jobCmd.AddCommand(jobListCmd)
+	jobCmd.AddCommand(jobShowCmd)
+	jobCmd.AddCommand(jobCancelCmd)
+
+	jobListCmd.Flags().IntVar(&jobLimit, "limit", db.DefaultJobLimit, "maximum jobs to show")
+	jobListCmd.Flags().BoolVar(&jobJSON, "json", false, "print jobs as JSON")
+	jobShowCmd.Flags().BoolVar(&jobWait, "wait", false, "wait for the job to finish; fails unless it succeeds")
+	jobShowCmd.Flags().BoolVar(&jobJSON, "json", false, "print the job as JSON")
+}
@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+var (
+	labelSelector string            // --selector of list and delete commands
+	createLabels  map[string]string // --label of create commands
+)
+
+// labelResourceTypes maps the command names accepted by "label" to label resource types
+var labelResourceTypes = map[string]string{
+	"ns":        db.LabelNamespaces,
+	"namespace": db.LabelNamespaces,
+	"veth":      db.LabelVeths,
+	"ip":        db.LabelAddresses,
+	"address":   db.LabelAddresses,
+	"route":     db.LabelRoutes,
+	"bridge":    db.LabelBridges,
+	"gre":       db.LabelGRETunnels,
+	"macvlan":   db.LabelMacvlans,
+	"ipvlan":    db.LabelMacvlans,
+	"bond":      db.LabelBonds,
+	"dummy":     db.LabelDummies,
+}
+
+var labelCmd = &cobra.Command{
+	Use:   "label <kind> <name> <key=value|key->...",
+	Short: "Set or remove labels of a recorded resource",
+	Long: `Set or remove labels of a recorded resource.
+
+Kinds: ns, veth, ip, route, bridge, gre, macvlan, ipvlan, bond, dummy.
+Addresses and routes are identified by their record ID, as listed by
+GET /api/v1/addresses and /api/v1/routes. "key=value" sets a label, "key-"
+removes it.
+
+Label keys are up to 63 letters, digits, '.', '_', '-' or '/'; values are up
+to 63 letters, digits, '.', '_' or '-'. Select labelled resources with
+--selector on list and delete commands, e.g. --selector env=lab,team!=net.
+
+Examples:
+  netns-mgr label ns lab1 env=lab team=net
+  netns-mgr label veth veth0 env-`,
+	Args: cobra.MinimumNArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resourceType, known := labelResourceTypes[args[0]]
+		if !known {
+			return fmt.Errorf("unknown kind %q: expected ns, veth, ip, route, bridge, gre, macvlan, ipvlan, bond or dummy", args[0])
+		}
+
+		request := service.UpdateLabelsRequest{Resource: resourceType, Name: args[1], Labels: make(map[string]*string)}
+		for _, change := range args[2:] {
+			if key, value, isSet := strings.Cut(change, "="); isSet {
+				request.Labels[key] = &value
+			} else if key, isRemove := strings.CutSuffix(change, "-"); isRemove {
+				request.Labels[key] = nil
+			} else {
+				return fmt.Errorf("invalid label change %q: expected key=value or key-", change)
+			}
+		}
+
+		labels, err := Backend.UpdateLabels(request)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Labels of %s %s: %s\n", args[0], args[1], formatLabels(labels))
+		return nil
+	},
+}
+
+// addLabelFlag adds --label to a create command
+func addLabelFlag(cmd *cobra.Command) {
+	cmd.Flags().StringToStringVar(&createLabels, "label", nil, "label to set, key=value (repeatable or comma-separated)")
+}
+
+// addSelectorFlag adds --selector to a list or delete command
+func addSelectorFlag(cmd *cobra.Command, usage string) {
+	cmd.Flags().StringVar(&labelSelector, "selector", "", usage)
+}
+
+// nameOrSelector accepts exactly one positional name, or none with --selector
+func nameOrSelector(cmd *cobra.Command, args []string) error {
+	switch {
+	case labelSelector != "" && len(args) > 0:
+		return fmt.Errorf("give either a name or --selector, not both")
+	case labelSelector == "" && len(args) != 1:
+		return fmt.Errorf("accepts 1 arg or --selector, received %d args", len(args))
+	}
+	return nil
+}
+
+// deleteBySelector deletes every resource of a type matching --selector and reports them
+// Parameters:
+//   - resourceType: label resource type
+//   - kindName: resource kind used in the output, e.g. "veth pairs"
+func deleteBySelector(resourceType, kindName string) error {
+	deletedNames, err := Backend.DeleteBySelector(resourceType, labelSelector)
+	if len(deletedNames) > 0 {
+		fmt.Printf("Deleted %s: %s\n", kindName, strings.Join(deletedNames, ", "))
+	}
+	if err != nil {
+		return err
+	}
+	if len(deletedNames) == 0 {
+		fmt.Printf("No %s match %q\n", kindName, labelSelector)
+	}
+	return nil
+}
+
+// selectedRecordNames returns the names of the recorded resources matching --selector
+// Labels only exist on records, so kernel listings are filtered with it.
+// Addresses and routes are identified by address and destination, and only
+// those in namespaceName are returned (empty = host).
+// Parameters:
+//   - resourceType: label resource type
+//   - namespaceName: namespace of the listing (addresses and routes only)
+func selectedRecordNames(resourceType, namespaceName string) (map[string]bool, error) {
+	options := service.ListOptions{Selector: labelSelector}
+	selectedNames := make(map[string]bool)
+
+	switch resourceType {
+	case db.LabelNamespaces:
+		namespaceRecords, _, err := Backend.ListNamespaces(options)
+		if err != nil {
+			return nil, err
+		}
+		for _, namespaceRecord := range namespaceRecords {
+			selectedNames[namespaceRecord.Name] = true
+		}
+	case db.LabelAddresses:
+		addressRecords, _, err := Backend.ListAddresses(namespaceName, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, addressRecord := range addressRecords {
+			if namespaceName != "" || addressRecord.NsID == nil {
+				selectedNames[addressRecord.Address] = true
+			}
+		}
+	case db.LabelRoutes:
+		routeRecords, _, err := Backend.ListRoutes(namespaceName, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, routeRecord := range routeRecords {
+			if namespaceName != "" || routeRecord.NsID == nil {
+				selectedNames[routeRecord.Destination] = true
+			}
+		}
+	case db.LabelBridges:
+		bridgeRecords, err := Backend.ListBridges(options)
+		if err != nil {
+			return nil, err
+		}
+		for _, bridgeRecord := range bridgeRecords {
+			selectedNames[bridgeRecord.Name] = true
+		}
+	case db.LabelGRETunnels:
+		tunnelRecords, _, err := Backend.ListGRETunnels("", options)
+		if err != nil {
+			return nil, err
+		}
+		for _, tunnelRecord := range tunnelRecords {
+			selectedNames[tunnelRecord.Name] = true
+		}
+	case db.LabelBonds:
+		bondRecords, err := Svc.ListBonds("", options)
+		if err != nil {
+			return nil, err
+		}
+		for _, bondRecord := range bondRecords {
+			selectedNames[bondRecord.Name] = true
+		}
+	case db.LabelDummies:
+		dummyRecords, err := Svc.ListDummies("", options)
+		if err != nil {
+			return nil, err
+		}
+		for _, dummyRecord := range dummyRecords {
+			selectedNames[dummyRecord.Name] = true
+		}
+	default:
+		return nil, fmt.Errorf("resource type %q has no labels", resourceType)
+	}
+	return selectedNames, nil
+}
+
+// filterBySelector keeps the kernel entries whose record matches --selector
+// Entries are returned unchanged without --selector.
+// Parameters:
+//   - entries: kernel entries of the listing
+//   - resourceType: label resource type
+//   - namespaceName: namespace of the listing
+//   - recordName: name, address or destination identifying an entry's record
+func filterBySelector[Entry any](entries []Entry, resourceType, namespaceName string, recordName func(Entry) string) ([]Entry, error) {
+	if labelSelector == "" {
+		return entries, nil
+	}
+	selectedNames, err := selectedRecordNames(resourceType, namespaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var selectedEntries []Entry
+	for _, entry := range entries {
+		if selectedNames[recordName(entry)] {
+			selectedEntries = append(selectedEntries, entry)
+		}
+	}
+	return selectedEntries, nil
+}
+
+// formatLabels renders labels as sorted "key=value" pairs, or "-" if there are none
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "-"
+	}
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func init() {
+	rootCmd.AddCommand(labelCmd)
+}
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+var (
+	linkNs         string
+	linkMTU        int
+	linkMAC        string
+	linkTxQueueLen int
+	linkAlias      string
+	linkUp         bool
+	linkDown       bool
+)
+
+var linkCmd = &cobra.Command{
+	Use:   "link",
+	Short: "Manage generic link properties of any interface",
+}
+
+var linkSetCmd = &cobra.Command{
+	Use:   "set <interface>",
+	Short: "Set MTU, MAC address, txqueuelen, alias or state of an interface",
+	Long: `Set link properties of any interface type. Properties are recorded
+so restore re-applies them.
+
+Examples:
+  # Lower the MTU of a GRE tunnel below the underlay
+  netns-mgr link set gre1 --ns site1 --mtu 1400
+
+  # Pin a MAC address for a reproducible lab
+  netns-mgr link set veth-a --ns host1 --mac 02:00:00:00:01:01
+
+  # Bring an interface down
+  netns-mgr link set veth-a --ns host1 --down
+
+  # Clear the alias
+  netns-mgr link set veth-a --ns host1 --alias ""`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interfaceName := args[0]
+
+		if linkUp && linkDown {
+			return fmt.Errorf("--up and --down are mutually exclusive")
+		}
+
+		request := service.SetLinkRequest{Interface: interfaceName, Namespace: linkNs}
+		if cmd.Flags().Changed("mtu") {
+			request.MTU = &linkMTU
+		}
+		if cmd.Flags().Changed("mac") {
+			request.HardwareAddr = &linkMAC
+		}
+		if cmd.Flags().Changed("txqueuelen") {
+			request.TxQueueLen = &linkTxQueueLen
+		}
+		if cmd.Flags().Changed("alias") {
+			request.Alias = &linkAlias
+		}
+		linkState := netns.LinkStateUp
+		if linkDown {
+			linkState = netns.LinkStateDown
+		}
+		if linkUp || linkDown {
+			request.State = &linkState
+		}
+
+		_, err := Svc.SetLinkProperties(request)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Updated link: %s\n", interfaceName)
+		return nil
+	},
+}
+
+var linkListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every interface across the host and all namespaces",
+	Long: `List every interface on the host and in every namespace, marking
+which ones are managed (recorded in the database) and which are foreign.
+
+Use --ns to restrict the listing to one namespace ("-" = host).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var interfaceInfoList []netns.InterfaceInfo
+		var err error
+		switch linkNs {
+		case "":
+			interfaceInfoList, err = Svc.ListAllInterfaces()
+		case "-":
+			interfaceInfoList, err = Svc.ListInterfaces("")
+		default:
+			interfaceInfoList, err = Svc.ListInterfaces(linkNs)
+		}
+		if err != nil {
+			return err
+		}
+
+		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tableWriter, "NAMESPACE\tNAME\tTYPE\tSTATE\tMTU\tMAC\tMASTER\tADDRESSES\tDETAILS\tMANAGED")
+
+		for _, interfaceInfo := range interfaceInfoList {
+			namespaceDisplay := interfaceInfo.Namespace
+			if namespaceDisplay == "" {
+				namespaceDisplay = "host"
+			}
+
+			details := "-"
+			if interfaceInfo.PeerNamespace != nil {
+				details = "peer-ns=" + *interfaceInfo.PeerNamespace
+				if *interfaceInfo.PeerNamespace == "" {
+					details = "peer-ns=host"
+				}
+			} else if interfaceInfo.RemoteIP != "" {
+				details = fmt.Sprintf("local=%s remote=%s", interfaceInfo.LocalIP, interfaceInfo.RemoteIP)
+			}
+
+			managedDisplay := "foreign"
+			if interfaceInfo.Managed {
+				managedDisplay = "managed"
+			}
+
+			fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+				namespaceDisplay,
+				interfaceInfo.Name,
+				interfaceInfo.Type,
+				interfaceInfo.State,
+				interfaceInfo.MTU,
+				displayOrDash(interfaceInfo.MACAddress),
+				displayOrDash(interfaceInfo.Master),
+				displayOrDash(strings.Join(interfaceInfo.Addresses, ",")),
+				details,
+				managedDisplay,
+			)
+		}
+
+		tableWriter.Flush()
+		return nil
+	},
+}
+
+// displayOrDash returns "-" for empty table cells
+func displayOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func init() {
+	rootCmd.AddCommand(linkCmd)
+
+	linkSetCmd.Flags().StringVar(&linkNs, "ns", "", "namespace")
+	linkSetCmd.Flags().IntVar(&linkMTU, "mtu", 0, "MTU in bytes")
+	linkSetCmd.Flags().StringVar(&linkMAC, "mac", "", "MAC address (e.g. 02:00:00:00:00:01)")
+	linkSetCmd.Flags().IntVar(&linkTxQueueLen, "txqueuelen", 0, "transmit queue length in packets")
+	linkSetCmd.Flags().StringVar(&linkAlias, "alias", "", "interface alias (\"\" clears it)")
+	linkSetCmd.Flags().BoolVar(&linkUp, "up", false, "set the interface administratively up")
+	linkSetCmd.Flags().BoolVar(&linkDown, "down", false, "set the interface administratively down")
+
+	linkListCmd.Flags().StringVar(&linkNs, "ns", "", "only list interfaces in this namespace (\"-\" = host)")
+
+	linkCmd.AddCommand(linkSetCmd)
+	linkCmd.AddCommand(linkListCmd)
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+var (
+	listLimit   int      // --limit of list commands
+	listFilters []string // --filter of list commands
+	listSort    string   // --sort of list commands
+	listCursor  string   // --cursor of list commands
+)
+
+// listFilterKeys are the keys accepted by --filter
+var listFilterKeys = []string{"namespace", "interface", "within", "created-after", "created-before"}
+
+// addListFlags adds --limit, --filter, --sort and --cursor to a list command
+// Parameters:
+//   - cmd: list command
+//   - sortFields: fields accepted by --sort, for the usage text
+func addListFlags(cmd *cobra.Command, sortFields string) {
+	cmd.Flags().IntVar(&listLimit, "limit", 0, "list at most this many records, then print the cursor of the next page")
+	cmd.Flags().StringArrayVar(&listFilters, "filter", nil, "only list records matching key=value (repeatable); keys: "+strings.Join(listFilterKeys, ", "))
+	cmd.Flags().StringVar(&listSort, "sort", "", "sort records by "+sortFields+"; prefix with - for descending order")
+	cmd.Flags().StringVar(&listCursor, "cursor", "", "continue a listing at the cursor printed with the previous page")
+}
+
+// recordListRequested reports whether a list command was given any of the flags of addListFlags
+// Commands that list the kernel state then list the recorded resources instead.
+func recordListRequested(cmd *cobra.Command) bool {
+	for _, flagName := range []string{"limit", "filter", "sort", "cursor"} {
+		if cmd.Flags().Changed(flagName) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordListOptions builds the list options of --selector and the flags of addListFlags
+// Returns the options and the namespace to list (a namespace filter overrides namespaceName).
+// Parameters:
+//   - namespaceName: namespace given with --ns (empty = all)
+func recordListOptions(namespaceName string) (service.ListOptions, string, error) {
+	options := service.ListOptions{
+		Selector: labelSelector,
+		Sort:     listSort,
+		Limit:    listLimit,
+		Cursor:   listCursor,
+	}
+	for _, filter := range listFilters {
+		key, value, found := strings.Cut(filter, "=")
+		if !found {
+			return options, "", fmt.Errorf("invalid filter %q: expected key=value", filter)
+		}
+		switch key {
+		case "namespace", "ns":
+			namespaceName = value
+		case "interface":
+			options.Interface = value
+		case "within":
+			options.Within = value
+		case "created-after", "created_after":
+			createdAfter, err := parseFilterTime(key, value)
+			if err != nil {
+				return options, "", err
+			}
+			options.CreatedAfter = createdAfter
+		case "created-before", "created_before":
+			createdBefore, err := parseFilterTime(key, value)
+			if err != nil {
+				return options, "", err
+			}
+			options.CreatedBefore = createdBefore
+		default:
+			return options, "", fmt.Errorf("unknown filter %q: expected one of %s", key, strings.Join(listFilterKeys, ", "))
+		}
+	}
+	return options, namespaceName, nil
+}
+
+// parseFilterTime parses the time of a creation time filter: RFC 3339, or a date in UTC
+func parseFilterTime(key, value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: expected an RFC 3339 time or a YYYY-MM-DD date", key, value)
+	}
+	return parsed, nil
+}
+
+// printNextPage tells how to list the next page, if there is one
+func printNextPage(nextCursor string) {
+	if nextCursor != "" {
+		fmt.Printf("\nMore records: repeat with --cursor %s\n", nextCursor)
+	}
+}
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+var (
+	macvlanParent   string
+	macvlanParentNs string
+	macvlanNs       string
+	macvlanMode     string
+)
+
+var macvlanCmd = &cobra.Command{
+	Use:   "macvlan",
+	Short: "Manage macvlan interfaces",
+	Long: `Manage macvlan interfaces.
+
+A macvlan gives a namespace its own MAC address directly on a host NIC
+(or any other parent interface) without going through a bridge.`,
+}
+
+var ipvlanCmd = &cobra.Command{
+	Use:   "ipvlan",
+	Short: "Manage ipvlan interfaces",
+	Long: `Manage ipvlan interfaces.
+
+An ipvlan shares the parent's MAC address and attaches a namespace to the
+parent's network at layer 2 (l2) or layer 3 (l3, l3s).`,
+}
+
+// newMacvlanCreateCmd builds the create command for a sub-interface kind
+// Parameters:
+//   - kind: "macvlan" or "ipvlan"
+//   - example: example usage shown in help
+func newMacvlanCreateCmd(kind, example string) *cobra.Command {
+	createCmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: fmt.Sprintf("Create a %s interface", kind),
+		Long: fmt.Sprintf(`Create a %s interface on a parent and move it into a namespace.
+
+Examples:
+%s`, kind, example),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			linkName := args[0]
+
+			if macvlanParent == "" {
+				return fmt.Errorf("--parent is required")
+			}
+
+			linkRecord, err := Svc.CreateMacvlan(service.CreateMacvlanRequest{
+				Name:            linkName,
+				Kind:            kind,
+				Mode:            macvlanMode,
+				Parent:          macvlanParent,
+				ParentNamespace: macvlanParentNs,
+				Namespace:       macvlanNs,
+				Labels:          createLabels,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Created %s: %s (parent=%s, mode=%s)\n", kind, linkName, macvlanParent, linkRecord.Mode)
+			return nil
+		},
+	}
+
+	createCmd.Flags().StringVar(&macvlanParent, "parent", "", "parent interface (required)")
+	createCmd.Flags().StringVar(&macvlanParentNs, "parent-ns", "", "namespace where the parent exists")
+	createCmd.Flags().StringVar(&macvlanNs, "ns", "", "namespace to move the interface into")
+	if kind == netns.KindIPVlan {
+		createCmd.Flags().StringVar(&macvlanMode, "mode", "", "ipvlan mode: l2, l3, l3s (default l2)")
+	} else {
+		createCmd.Flags().StringVar(&macvlanMode, "mode", "", "macvlan mode: bridge, vepa, private, passthru (default bridge)")
+	}
+	addLabelFlag(createCmd)
+
+	return createCmd
+}
+
+// newMacvlanDeleteCmd builds the delete command for a sub-interface kind
+func newMacvlanDeleteCmd(kind string) *cobra.Command {
+	deleteCmd := &cobra.Command{
+		Use:   "delete <name> | --selector <selector>",
+		Short: fmt.Sprintf("Delete a %s interface, or every macvlan and ipvlan matching a label selector", kind),
+		Args:  nameOrSelector,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if labelSelector != "" {
+				return deleteBySelector(db.LabelMacvlans, "macvlan/ipvlan interfaces")
+			}
+			linkName := args[0]
+
+			if err := Svc.DeleteMacvlan(linkName, macvlanNs); err != nil {
+				return err
+			}
+
+			fmt.Printf("Deleted %s: %s\n", kind, linkName)
+			return nil
+		},
+	}
+
+	deleteCmd.Flags().StringVar(&macvlanNs, "ns", "", "namespace")
+	addSelectorFlag(deleteCmd, "delete every macvlan and ipvlan interface matching this label selector")
+
+	return deleteCmd
+}
+
+// newMacvlanListCmd builds the list command for a sub-interface kind
+func newMacvlanListCmd(kind string) *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: fmt.Sprintf("List %s interfaces", kind),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			links, err := Svc.ListMacvlans(kind, service.ListOptions{Selector: labelSelector})
+			if err != nil {
+				return err
+			}
+
+			if len(links) == 0 {
+				fmt.Printf("No %s interfaces found\n", kind)
+				return nil
+			}
+
+			tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tableWriter, "NAME\tMODE\tPARENT\tPARENT NAMESPACE\tNAMESPACE\tCREATED\tLABELS")
+
+			namespaceNames := namespaceNamesByID()
+			for _, link := range links {
+				namespaceName := "-"
+				parentNamespaceName := "-"
+
+				if link.NsID != nil {
+					namespaceName = namespaceNames[*link.NsID]
+				}
+				if link.ParentNsID != nil {
+					parentNamespaceName = namespaceNames[*link.ParentNsID]
+				}
+
+				fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					link.Name,
+					link.Mode,
+					link.Parent,
+					parentNamespaceName,
+					namespaceName,
+					link.CreatedAt.Format("2006-01-02 15:04:05"),
+					formatLabels(link.Labels),
+				)
+			}
+
+			tableWriter.Flush()
+			return nil
+		},
+	}
+
+	addSelectorFlag(listCmd, fmt.Sprintf("only list %s interfaces matching this label selector", kind))
+
+	return listCmd
+}
+
+func init() {
+	rootCmd.AddCommand(macvlanCmd)
+	rootCmd.AddCommand(ipvlanCmd)
+
+	macvlanCmd.AddCommand(newMacvlanCreateCmd(netns.KindMacvlan, `  # Attach namespace ns1 to the host NIC eth0
+  netns-mgr macvlan create mv0 --parent eth0 --ns ns1
+
+  # Use a veth end inside a namespace as parent, in vepa mode
+  netns-mgr macvlan create mv1 --parent veth1 --parent-ns router --ns ns2 --mode vepa`))
+	macvlanCmd.AddCommand(newMacvlanDeleteCmd(netns.KindMacvlan))
+	macvlanCmd.AddCommand(newMacvlanListCmd(netns.KindMacvlan))
+
+	ipvlanCmd.AddCommand(newMacvlanCreateCmd(netns.KindIPVlan, `  # Attach namespace ns1 to eth0 in l2 mode
+  netns-mgr ipvlan create ipv0 --parent eth0 --ns ns1
+
+  # Layer 3 mode
+  netns-mgr ipvlan create ipv1 --parent eth0 --ns ns2 --mode l3`))
+	ipvlanCmd.AddCommand(newMacvlanDeleteCmd(netns.KindIPVlan))
+	ipvlanCmd.AddCommand(newMacvlanListCmd(netns.KindIPVlan))
+}
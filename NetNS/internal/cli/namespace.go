@@ -1,13 +1,27 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
-	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+var nsClearLabels bool // --clear-labels of ns set
+
+// Flags of ns exec
+var (
+	nsExecMountNamespace bool
+	nsExecEnv            []string
+	nsExecTimeout        int
 )
 
 var nsCmd = &cobra.Command{
@@ -22,46 +36,68 @@ var nsCreateCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		namespaceName := args[0]
-		namespaceManager := netns.NewManager()
-
-		// Create in system
-		if err := namespaceManager.Create(namespaceName); err != nil {
-			return err
-		}
 
-		// Record in database
-		_, err := Repo.CreateNamespace(namespaceName, "")
+		// Project tokens get the project prefix added to the name
+		namespaceRecord, err := Backend.CreateNamespace(service.CreateNamespaceRequest{Name: namespaceName, Labels: createLabels})
 		if err != nil {
-			// Rollback system change
-			namespaceManager.Delete(namespaceName)
-			return fmt.Errorf("failed to record namespace: %w", err)
+			return err
 		}
 
-		fmt.Printf("Created namespace: %s\n", namespaceName)
+		fmt.Printf("Created namespace: %s\n", namespaceRecord.Name)
 		return nil
 	},
 }
 
 var nsDeleteCmd = &cobra.Command{
-	Use:   "delete <name>",
-	Short: "Delete a network namespace",
-	Args:  cobra.ExactArgs(1),
+	Use:   "delete <name> | --selector <selector>",
+	Short: "Delete a network namespace, or every namespace matching a label selector",
+	Args:  nameOrSelector,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if labelSelector != "" {
+			return deleteBySelector(db.LabelNamespaces, "namespaces")
+		}
 		namespaceName := args[0]
-		namespaceManager := netns.NewManager()
 
-		// Delete from system
-		if err := namespaceManager.Delete(namespaceName); err != nil {
+		if err := Backend.DeleteNamespace(namespaceName); err != nil {
 			return err
 		}
 
-		// Remove from database
-		if err := Repo.DeleteNamespace(namespaceName); err != nil {
-			// Log but don't fail - system change already made
-			fmt.Fprintf(os.Stderr, "Warning: failed to remove from database: %v\n", err)
+		fmt.Printf("Deleted namespace: %s\n", namespaceName)
+		return nil
+	},
+}
+
+var nsSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Replace the labels of a network namespace",
+	Long: `Replace the labels of a recorded network namespace.
+
+Unlike "netns-mgr label", which only changes the given keys, labels that
+are not given are removed.
+
+Examples:
+  # Replace all labels
+  netns-mgr ns set myns --label env=prod --label team=net
+
+  # Remove all labels
+  netns-mgr ns set myns --clear-labels`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		namespaceName := args[0]
+
+		if len(createLabels) == 0 && !nsClearLabels {
+			return fmt.Errorf("either --label or --clear-labels is required")
+		}
+		if len(createLabels) > 0 && nsClearLabels {
+			return fmt.Errorf("give either --label or --clear-labels, not both")
 		}
 
-		fmt.Printf("Deleted namespace: %s\n", namespaceName)
+		namespaceRecord, err := Backend.UpdateNamespace(namespaceName, service.UpdateNamespaceRequest{Labels: createLabels})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Updated namespace: %s (labels: %s)\n", namespaceRecord.Name, formatLabels(namespaceRecord.Labels))
 		return nil
 	},
 }
@@ -69,61 +105,119 @@ var nsDeleteCmd = &cobra.Command{
 var nsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all network namespaces",
+	Long: `List the network namespaces in the kernel and the recorded ones.
+
+With --limit, --filter, --sort or --cursor only the recorded namespaces are
+listed, a page at a time.
+
+Examples:
+  # Namespaces created since October, 50 at a time
+  netns-mgr ns list --filter created-after=2026-10-01 --limit 50`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		namespaceManager := netns.NewManager()
+		if recordListRequested(cmd) {
+			return listNamespaceRecords()
+		}
 
-		// Get from system
-		systemNamespaces, err := namespaceManager.List()
+		namespaceStatuses, err := Backend.NamespaceStatuses()
 		if err != nil {
 			return err
 		}
 
-		// Get from database
-		databaseNamespaces, err := Repo.ListNamespaces()
+		namespaceStatuses, err = filterBySelector(namespaceStatuses, db.LabelNamespaces, "", func(namespaceStatus service.NamespaceStatus) string { return namespaceStatus.Name })
 		if err != nil {
 			return err
 		}
 
-		// Create a map for quick lookup
-		databaseNamespaceMap := make(map[string]bool)
-		for _, namespaceRecord := range databaseNamespaces {
-			databaseNamespaceMap[namespaceRecord.Name] = true
-		}
-
 		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(tableWriter, "NAME\tSTATUS\tCREATED")
+		fmt.Fprintln(tableWriter, "NAME\tSTATUS\tCREATED\tLABELS")
 
-		for _, namespaceName := range systemNamespaces {
-			status := "active"
+		for _, namespaceStatus := range namespaceStatuses {
 			createdAt := "-"
-
-			// Find in DB for creation time
-			for _, namespaceRecord := range databaseNamespaces {
-				if namespaceRecord.Name == namespaceName {
-					createdAt = namespaceRecord.CreatedAt.Format("2006-01-02 15:04:05")
-					break
-				}
+			if namespaceStatus.CreatedAt != nil {
+				createdAt = namespaceStatus.CreatedAt.Format("2006-01-02 15:04:05")
 			}
+			fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\n", namespaceStatus.Name, namespaceStatus.Status, createdAt, formatLabels(namespaceStatus.Labels))
+		}
 
-			if !databaseNamespaceMap[namespaceName] {
-				status = "untracked"
-			}
+		tableWriter.Flush()
+		return nil
+	},
+}
 
-			fmt.Fprintf(tableWriter, "%s\t%s\t%s\n", namespaceName, status, createdAt)
+// listNamespaceRecords prints one page of the recorded namespaces matching the list flags
+func listNamespaceRecords() error {
+	options, _, err := recordListOptions("")
+	if err != nil {
+		return err
+	}
+	namespaceRecords, nextCursor, err := Backend.ListNamespaces(options)
+	if err != nil {
+		return err
+	}
+	if len(namespaceRecords) == 0 {
+		fmt.Println("No namespaces found")
+		return nil
+	}
+
+	tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, "NAME\tCREATED\tLABELS")
+	for _, namespaceRecord := range namespaceRecords {
+		fmt.Fprintf(tableWriter, "%s\t%s\t%s\n", namespaceRecord.Name, namespaceRecord.CreatedAt.Format("2006-01-02 15:04:05"), formatLabels(namespaceRecord.Labels))
+	}
+	tableWriter.Flush()
+	printNextPage(nextCursor)
+	return nil
+}
+
+var nsShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a namespace and the resources recorded in it",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		namespaceName := args[0]
+
+		details, err := Backend.GetNamespace(namespaceName)
+		if err != nil {
+			return err
 		}
 
-		// Show DB entries that don't exist in system
-		for _, namespaceRecord := range databaseNamespaces {
-			foundInSystem := false
-			for _, namespaceName := range systemNamespaces {
-				if namespaceRecord.Name == namespaceName {
-					foundInSystem = true
-					break
-				}
+		fmt.Printf("Namespace: %s\n", details.Name)
+		fmt.Printf("Created:   %s\n", details.CreatedAt.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Labels:    %s\n\n", formatLabels(details.Labels))
+
+		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tableWriter, "KIND\tNAME\tDETAILS")
+
+		for _, vethPair := range details.VethPairs {
+			fmt.Fprintf(tableWriter, "veth\t%s\tpeer=%s\n", vethPair.Name, vethPair.PeerName)
+		}
+		for _, dummy := range details.Dummies {
+			fmt.Fprintf(tableWriter, "dummy\t%s\t-\n", dummy.Name)
+		}
+		for _, bond := range details.Bonds {
+			fmt.Fprintf(tableWriter, "bond\t%s\tmode=%s slaves=%s\n", bond.Name, bond.Mode, strings.Join(bond.Slaves, ","))
+		}
+		for _, link := range details.Macvlans {
+			fmt.Fprintf(tableWriter, "%s\t%s\tparent=%s mode=%s\n", link.Kind, link.Name, link.Parent, link.Mode)
+		}
+		for _, bridge := range details.Bridges {
+			fmt.Fprintf(tableWriter, "bridge\t%s\t-\n", bridge.Name)
+		}
+		for _, tunnel := range details.GRETunnels {
+			fmt.Fprintf(tableWriter, "gre\t%s\tlocal=%s remote=%s\n", tunnel.Name, tunnel.LocalIP, tunnel.RemoteIP)
+		}
+		for _, address := range details.IPAddresses {
+			fmt.Fprintf(tableWriter, "address\t%s\t%s\n", address.InterfaceName, address.Address)
+		}
+		for _, route := range details.Routes {
+			routeDetails := route.Destination
+			if route.Gateway != "" {
+				routeDetails += " via " + route.Gateway
 			}
-			if !foundInSystem {
-				fmt.Fprintf(tableWriter, "%s\t%s\t%s\n", namespaceRecord.Name, "orphaned", namespaceRecord.CreatedAt.Format("2006-01-02 15:04:05"))
+			if route.InterfaceName != "" {
+				routeDetails += " dev " + route.InterfaceName
 			}
+			fmt.Fprintf(tableWriter, "route\t-\t%s\n", routeDetails)
 		}
 
 		tableWriter.Flush()
@@ -134,7 +228,17 @@ var nsListCmd = &cobra.Command{
 var nsExecCmd = &cobra.Command{
 	Use:   "exec <namespace> -- <command> [args...]",
 	Short: "Execute a command in a namespace",
-	Args:  cobra.MinimumNArgs(2),
+	Long: `Execute a command in a namespace and exit with its exit code.
+
+The command enters the network namespace directly, without "ip netns exec".
+Unless --mount-ns=false is given it also gets a private mount namespace in which
+/sys shows the namespace's interfaces and every file in /etc/netns/<namespace>
+replaces its counterpart in /etc (e.g. /etc/netns/<namespace>/resolv.conf).
+
+Locally the command inherits the terminal, working directory and environment.
+With --server it runs on the server with a minimal environment plus --env; piped
+stdin is sent along and output streams back as it is produced.`,
+	Args: cobra.MinimumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		namespaceName := args[0]
 
@@ -151,23 +255,80 @@ var nsExecCmd = &cobra.Command{
 			return fmt.Errorf("no command specified")
 		}
 
-		commandArgs := args[commandStartIndex:]
+		request := service.ExecRequest{
+			Command:        args[commandStartIndex:],
+			Env:            nsExecEnv,
+			Timeout:        nsExecTimeout,
+			MountNamespace: &nsExecMountNamespace,
+		}
+
+		ctx := context.Background()
+		var stdin io.Reader = os.Stdin
+		if serverURL == "" {
+			request.Env = append(os.Environ(), nsExecEnv...)
+
+			// Ctrl-C reaches the command through the terminal; outliving it
+			// lets the exit code and the audit entry be recorded
+			interrupts := make(chan os.Signal, 1)
+			signal.Notify(interrupts, os.Interrupt, syscall.SIGQUIT)
+			defer signal.Stop(interrupts)
+		} else {
+			// Disconnecting kills the command on the server
+			var stop context.CancelFunc
+			ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
 
-		// Use ip netns exec for simplicity
-		execArgs := append([]string{"netns", "exec", namespaceName}, commandArgs...)
-		execCommand := exec.Command("ip", execArgs...)
-		execCommand.Stdin = os.Stdin
-		execCommand.Stdout = os.Stdout
-		execCommand.Stderr = os.Stderr
+			// An interactive terminal cannot be sent ahead of the command
+			if stdinInfo, err := os.Stdin.Stat(); err == nil && stdinInfo.Mode()&os.ModeCharDevice != 0 {
+				stdin = nil
+			}
+		}
+
+		// Failures from here on are the command's, not its usage; Execute
+		// prints the error (or exits with the command's exit code)
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
 
-		return execCommand.Run()
+		exitCode, err := Backend.ExecInNamespace(ctx, namespaceName, request, stdin, os.Stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+		if exitCode < 0 {
+			return fmt.Errorf("%s was terminated by a signal", request.Command[0])
+		}
+		if exitCode > 0 {
+			return &commandExitError{exitCode: exitCode}
+		}
+		return nil
 	},
 }
 
+// commandExitError carries the non-zero exit code of a command run by ns exec
+// Execute exits with it instead of printing an error.
+type commandExitError struct {
+	exitCode int
+}
+
+func (exitError *commandExitError) Error() string {
+	return fmt.Sprintf("exit status %d", exitError.exitCode)
+}
+
 func init() {
 	rootCmd.AddCommand(nsCmd)
 	nsCmd.AddCommand(nsCreateCmd)
 	nsCmd.AddCommand(nsDeleteCmd)
+	nsCmd.AddCommand(nsSetCmd)
 	nsCmd.AddCommand(nsListCmd)
+	nsCmd.AddCommand(nsShowCmd)
 	nsCmd.AddCommand(nsExecCmd)
+
+	addLabelFlag(nsCreateCmd)
+	addLabelFlag(nsSetCmd)
+	nsSetCmd.Flags().BoolVar(&nsClearLabels, "clear-labels", false, "remove all labels")
+	nsExecCmd.Flags().BoolVar(&nsExecMountNamespace, "mount-ns", true, "run in a private mount namespace with /etc/netns/<namespace> overlays and the namespace's /sys")
+	nsExecCmd.Flags().StringArrayVar(&nsExecEnv, "env", nil, "set an environment variable KEY=VALUE (repeatable)")
+	nsExecCmd.Flags().IntVar(&nsExecTimeout, "timeout", 0, "kill the command after this many seconds (0 = no limit)")
+	addSelectorFlag(nsDeleteCmd, "delete every namespace matching this label selector")
+	addSelectorFlag(nsListCmd, "only list namespaces matching this label selector")
+	addListFlags(nsListCmd, "name, created_at or id")
 }
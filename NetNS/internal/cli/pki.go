@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/pki"
+)
+
+var (
+	pkiDir      string
+	pkiHosts    []string
+	pkiClients  []string
+	pkiValidity time.Duration
+	pkiForce    bool
+)
+
+var pkiCmd = &cobra.Command{
+	Use:   "pki",
+	Short: "Manage certificates for the API server",
+}
+
+var pkiInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generate a lab CA with server and client certificates",
+	Long: `Generate a local certificate authority, a server certificate for
+"netns-mgr serve --tls-cert" and client certificates for mutual TLS.
+
+A client certificate authenticates as the API token whose name matches its
+common name, with that token's role; create the token first with
+"netns-mgr token create <name> --role <role>".
+
+Intended for labs; use your own CA in production.
+
+Examples:
+  # CA, server certificate for localhost and an "admin" client certificate
+  netns-mgr pki init
+
+  # Server reachable as lab1 and 10.0.0.5, clients for two principals
+  netns-mgr pki init --host lab1 --host 10.0.0.5 --client admin --client ci`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		writtenFiles, err := pki.Init(pki.InitOptions{
+			Dir:         pkiDir,
+			ServerHosts: pkiHosts,
+			ClientNames: pkiClients,
+			Validity:    pkiValidity,
+			Force:       pkiForce,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, fileName := range writtenFiles {
+			fmt.Printf("Wrote %s\n", fileName)
+		}
+
+		fmt.Printf("\nServe with:\n  netns-mgr serve --tls-cert %s --tls-key %s --client-ca %s\n",
+			filepath.Join(pkiDir, pki.ServerFile), filepath.Join(pkiDir, pki.ServerKeyFile), filepath.Join(pkiDir, pki.CAFile))
+		if len(pkiClients) > 0 {
+			clientName := pkiClients[0]
+			fmt.Printf("\nConnect as %s with:\n  netns-mgr --server https://%s:8080 --certificate-authority %s --client-certificate %s --client-key %s ns list\n",
+				clientName, pkiHosts[0], filepath.Join(pkiDir, pki.CAFile),
+				filepath.Join(pkiDir, pki.ClientFile(clientName)), filepath.Join(pkiDir, pki.ClientKeyFile(clientName)))
+		}
+		return nil
+	},
+}
+
+// defaultPKIDir returns ~/.netns-mgr/pki, next to the default database
+func defaultPKIDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "pki"
+	}
+	return filepath.Join(home, ".netns-mgr", "pki")
+}
+
+func init() {
+	rootCmd.AddCommand(pkiCmd)
+
+	pkiInitCmd.Flags().StringVar(&pkiDir, "dir", defaultPKIDir(), "output directory")
+	pkiInitCmd.Flags().StringSliceVar(&pkiHosts, "host", []string{"localhost", "127.0.0.1"}, "DNS name or IP of the server (repeatable)")
+	pkiInitCmd.Flags().StringSliceVar(&pkiClients, "client", []string{"admin"}, "client certificate common name, i.e. API principal (repeatable)")
+	pkiInitCmd.Flags().DurationVar(&pkiValidity, "validity", 365*24*time.Hour, "lifetime of the server and client certificates")
+	pkiInitCmd.Flags().BoolVar(&pkiForce, "force", false, "overwrite existing files")
+
+	pkiCmd.AddCommand(pkiInitCmd)
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+var projectPrefix string
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Manage projects (tenants)",
+	Long: `Manage projects, the tenants of a shared "netns-mgr serve".
+
+A project owns the namespaces created with one of its API tokens (see
+"netns-mgr token create --project") and every resource inside them. Project
+tokens only see and change their own project's resources, cannot use the host
+namespace, and the names of the kernel objects they create (namespaces,
+veths, bridges, tunnels, ...) get the project prefix, e.g. "teama-lab1".
+Names passed without the prefix get it added.`,
+}
+
+var projectCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a project",
+	Long: `Create a project.
+
+The prefix (at most 6 lowercase letters and digits) defaults to the first
+4 letters and digits of the name. Kernel interface names are limited to 15
+characters, so keep it short.
+
+Examples:
+  # Project whose objects are named "team-..."
+  netns-mgr project create team-a
+
+  # Explicit prefix
+  netns-mgr project create "Network Team" --prefix net`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := Svc.CreateProject(service.CreateProjectRequest{Name: args[0], Prefix: projectPrefix})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created project: %s (prefix %q)\n", project.Name, project.Prefix)
+		return nil
+	},
+}
+
+var projectListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List projects",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projects, err := Svc.ListProjects()
+		if err != nil {
+			return err
+		}
+
+		if len(projects) == 0 {
+			fmt.Println("No projects found")
+			return nil
+		}
+
+		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tableWriter, "NAME\tPREFIX\tCREATED")
+
+		for _, project := range projects {
+			fmt.Fprintf(tableWriter, "%s\t%s\t%s\n",
+				project.Name,
+				project.Prefix,
+				project.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			)
+		}
+
+		tableWriter.Flush()
+		return nil
+	},
+}
+
+var projectDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a project and its API tokens",
+	Long: `Delete a project together with the API tokens confined to it.
+
+The project must not own namespaces any more; delete them first.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectName := args[0]
+
+		if err := Svc.DeleteProject(projectName); err != nil {
+			return err
+		}
+
+		fmt.Printf("Deleted project: %s\n", projectName)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(projectCmd)
+
+	projectCreateCmd.Flags().StringVar(&projectPrefix, "prefix", "", "prefix of the project's kernel object names (default: derived from the name)")
+
+	projectCmd.AddCommand(projectCreateCmd)
+	projectCmd.AddCommand(projectListCmd)
+	projectCmd.AddCommand(projectDeleteCmd)
+}
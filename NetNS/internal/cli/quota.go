@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+var quotaProject string
+
+var quotaCmd = &cobra.Command{
+	Use:   "quota",
+	Short: "Manage per-project quotas",
+	Long: `Manage per-project quotas.
+
+A quota caps how many namespaces, veths, bridges, gre_tunnels or addresses a
+project may own. Creating more through a project token is refused with
+"quota exceeded" before anything is changed in the kernel. Resources without a
+quota are unlimited.`,
+}
+
+var quotaShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show quotas and current usage",
+	Long: `Show the quotas and current usage of every project, or of one with --project.
+
+With a project token in remote mode only that project is shown.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		quotaUsages, err := Backend.QuotaUsages(quotaProject)
+		if err != nil {
+			return err
+		}
+
+		if len(quotaUsages) == 0 {
+			fmt.Println("No projects found")
+			return nil
+		}
+
+		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tableWriter, "PROJECT\tRESOURCE\tUSED\tLIMIT")
+
+		for _, projectUsage := range quotaUsages {
+			for _, usage := range projectUsage.Quotas {
+				limit := "-"
+				if usage.Limit != nil {
+					limit = strconv.Itoa(*usage.Limit)
+				}
+				fmt.Fprintf(tableWriter, "%s\t%s\t%d\t%s\n", projectUsage.Project, usage.Resource, usage.Used, limit)
+			}
+		}
+
+		tableWriter.Flush()
+		return nil
+	},
+}
+
+var quotaSetCmd = &cobra.Command{
+	Use:   "set <project> <resource> <limit>",
+	Short: "Set or remove a project quota",
+	Long: `Set the maximum number of resources of one kind a project may own.
+
+Resources: ` + strings.Join(db.QuotaResources, ", ") + `.
+A limit of "none" removes the quota. Lowering a quota below the current usage
+keeps existing resources but blocks new ones.
+
+Examples:
+  # At most 5 namespaces for team-a
+  netns-mgr quota set team-a namespaces 5
+
+  # Remove the limit again
+  netns-mgr quota set team-a namespaces none`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if Svc == nil {
+			return fmt.Errorf("%q is not supported with --server", cmd.CommandPath())
+		}
+
+		request := service.SetQuotaRequest{Project: args[0], Resource: args[1]}
+		if args[2] != "none" {
+			limit, err := strconv.Atoi(args[2])
+			if err != nil {
+				return fmt.Errorf("invalid limit %q: expected a number or \"none\"", args[2])
+			}
+			request.Limit = &limit
+		}
+
+		if err := Svc.SetQuota(request); err != nil {
+			return err
+		}
+
+		if request.Limit == nil {
+			fmt.Printf("Removed %s quota of project %s\n", request.Resource, request.Project)
+		} else {
+			fmt.Printf("Set %s quota of project %s to %d\n", request.Resource, request.Project, *request.Limit)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(quotaCmd)
+
+	quotaShowCmd.Flags().StringVar(&quotaProject, "project", "", "only show this project")
+
+	quotaCmd.AddCommand(quotaShowCmd)
+	quotaCmd.AddCommand(quotaSetCmd)
+}
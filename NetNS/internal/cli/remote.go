@@ -0,0 +1,355 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
+	"github.com/zenith/netns-mgr/pkg/client"
+)
+
+// serverEnvVar selects remote mode when --server is not given
+const serverEnvVar = "NETNS_MGR_SERVER"
+
+// tokenEnvVar holds the API token used in remote mode when --token is not given
+const tokenEnvVar = "NETNS_MGR_TOKEN"
+
+// Operations are the operations behind the commands that also work in remote mode
+// It is implemented by *service.Service (local) and remoteOperations (remote).
+type Operations interface {
+	CreateNamespace(request service.CreateNamespaceRequest) (*db.Namespace, error)
+	DeleteNamespace(namespaceName string) error
+	ListNamespaces(options service.ListOptions) ([]db.Namespace, string, error)
+	GetNamespace(namespaceName string) (*db.NamespaceWithDetails, error)
+	UpdateNamespace(namespaceName string, request service.UpdateNamespaceRequest) (*db.NamespaceWithDetails, error)
+	NamespaceStatuses() ([]service.NamespaceStatus, error)
+	ExecInNamespace(ctx context.Context, namespaceName string, request service.ExecRequest, stdin io.Reader, stdout, stderr io.Writer) (int, error)
+
+	CreateVeth(request service.CreateVethRequest) (*db.VethPair, error)
+	DeleteVeth(interfaceName string) error
+	ListVeths(namespaceName string, options service.ListOptions) ([]db.VethPair, string, error)
+	SetVethUp(interfaceName, namespaceName string) error
+	SetVethDown(interfaceName, namespaceName string) error
+
+	AddAddress(request service.AddressRequest) (*db.IPAddress, error)
+	DeleteAddress(request service.AddressRequest) error
+	UpdateAddress(id int64, request service.UpdateAddressRequest) (*db.IPAddress, error)
+	ListAddresses(namespaceName string, options service.ListOptions) ([]db.IPAddress, string, error)
+	AddressInfos(namespaceName string) ([]netns.AddressInfo, error)
+
+	AddRoute(request service.AddRouteRequest) (*db.Route, error)
+	DeleteRoute(destination, namespaceName string) error
+	ReplaceRoute(id int64, request service.ReplaceRouteRequest) (*db.Route, error)
+	ListRoutes(namespaceName string, options service.ListOptions) ([]db.Route, string, error)
+	RouteInfos(namespaceName string) ([]netns.RouteInfo, error)
+
+	CreateBridge(request service.CreateBridgeRequest) (*db.Bridge, error)
+	DeleteBridge(bridgeName, namespaceName string) error
+	ListBridges(options service.ListOptions) ([]db.Bridge, error)
+	AddBridgePort(request service.BridgePortRequest) error
+	RemoveBridgePort(request service.BridgePortRequest) error
+	BridgeInfos(namespaceName string) ([]netns.BridgeInfo, error)
+
+	CreateGRETunnel(request service.CreateGRETunnelRequest) (*db.GRETunnel, error)
+	DeleteGRETunnel(tunnelName, namespaceName string) error
+	UpdateGRETunnel(tunnelName string, request service.UpdateGRETunnelRequest) (*db.GRETunnel, error)
+	CreatePeerTunnels(request service.CreatePeerTunnelsRequest) ([]*db.GRETunnel, error)
+	ListGRETunnels(namespaceName string, options service.ListOptions) ([]db.GRETunnel, string, error)
+	GRETunnelInfos(namespaceName string) ([]netns.GRETunnelInfo, error)
+	SetGRETunnelUp(tunnelName, namespaceName string) error
+	SetGRETunnelDown(tunnelName, namespaceName string) error
+
+	UpdateLabels(request service.UpdateLabelsRequest) (map[string]string, error)
+	DeleteBySelector(resourceType, selector string) ([]string, error)
+
+	QuotaUsages(projectName string) ([]service.ProjectQuotaUsage, error)
+
+	RunWorkload(request service.RunWorkloadRequest) (*db.Workload, error)
+	ListWorkloads(namespaceName string) ([]db.Workload, error)
+	GetWorkload(workloadName string) (*db.Workload, error)
+	StartWorkload(workloadName string) (*db.Workload, error)
+	StopWorkload(workloadName string) (*db.Workload, error)
+	DeleteWorkload(workloadName string) error
+	WorkloadLogs(workloadName, stream string, lines int) (string, error)
+}
+
+// remoteCommands are the top-level commands that can drive a remote server
+var remoteCommands = map[string]bool{
+	"ns":       true,
+	"veth":     true,
+	"ip":       true,
+	"route":    true,
+	"bridge":   true,
+	"gre":      true,
+	"label":    true,
+	"quota":    true,
+	"job":      true,
+	"workload": true,
+	"help":     true,
+}
+
+// checkRemoteSupported rejects commands that only work against the local host
+func checkRemoteSupported(cmd *cobra.Command) error {
+	topLevelCmd := cmd
+	for topLevelCmd.HasParent() && topLevelCmd.Parent().HasParent() {
+		topLevelCmd = topLevelCmd.Parent()
+	}
+	if !topLevelCmd.HasParent() || remoteCommands[topLevelCmd.Name()] {
+		return nil
+	}
+	return fmt.Errorf("%q is not supported with --server", cmd.CommandPath())
+}
+
+// namespaceNamesByID maps namespace IDs to names for display
+func namespaceNamesByID() map[int64]string {
+	namespaceNames := make(map[int64]string)
+	if namespaceRecords, _, err := Backend.ListNamespaces(service.ListOptions{}); err == nil {
+		for _, namespaceRecord := range namespaceRecords {
+			namespaceNames[namespaceRecord.ID] = namespaceRecord.Name
+		}
+	}
+	return namespaceNames
+}
+
+// remoteOperations adapts the API client to Operations for remote mode
+type remoteOperations struct {
+	apiClient *client.Client
+}
+
+func (remote remoteOperations) CreateNamespace(request service.CreateNamespaceRequest) (*db.Namespace, error) {
+	record, err := remote.apiClient.CreateNamespace(context.Background(), client.CreateNamespaceRequest(request))
+	return convertRecord(record, err, namespaceFromWire)
+}
+
+func (remote remoteOperations) DeleteNamespace(namespaceName string) error {
+	return remote.apiClient.DeleteNamespace(context.Background(), namespaceName)
+}
+
+func (remote remoteOperations) ListNamespaces(options service.ListOptions) ([]db.Namespace, string, error) {
+	var nextCursor string
+	namespaceRecords, err := remote.apiClient.ListNamespaces(client.WithNextCursor(context.Background(), &nextCursor), client.ListOptions(options))
+	converted, err := convertRecords(namespaceRecords, err, namespaceFromWire)
+	return converted, nextCursor, err
+}
+
+func (remote remoteOperations) GetNamespace(namespaceName string) (*db.NamespaceWithDetails, error) {
+	record, err := remote.apiClient.GetNamespace(context.Background(), namespaceName)
+	return convertRecord(record, err, namespaceDetailsFromWire)
+}
+
+func (remote remoteOperations) UpdateNamespace(namespaceName string, request service.UpdateNamespaceRequest) (*db.NamespaceWithDetails, error) {
+	record, err := remote.apiClient.UpdateNamespace(context.Background(), namespaceName, client.UpdateNamespaceRequest(request))
+	return convertRecord(record, err, namespaceDetailsFromWire)
+}
+
+// ExecInNamespace sends stdin with the request, as the stream only carries output
+func (remote remoteOperations) ExecInNamespace(ctx context.Context, namespaceName string, request service.ExecRequest, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	if stdin != nil {
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return -1, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		request.Stdin = string(input)
+	}
+	return remote.apiClient.ExecInNamespace(ctx, namespaceName, client.ExecRequest(request), stdout, stderr)
+}
+
+func (remote remoteOperations) NamespaceStatuses() ([]service.NamespaceStatus, error) {
+	statuses, err := remote.apiClient.NamespaceStatuses(context.Background())
+	return convertRecords(statuses, err, namespaceStatusFromWire)
+}
+
+func (remote remoteOperations) CreateVeth(request service.CreateVethRequest) (*db.VethPair, error) {
+	record, err := remote.apiClient.CreateVeth(context.Background(), client.CreateVethRequest(request))
+	return convertRecord(record, err, vethPairFromWire)
+}
+
+func (remote remoteOperations) DeleteVeth(interfaceName string) error {
+	return remote.apiClient.DeleteVeth(context.Background(), interfaceName)
+}
+
+func (remote remoteOperations) ListVeths(namespaceName string, options service.ListOptions) ([]db.VethPair, string, error) {
+	var nextCursor string
+	vethPairs, err := remote.apiClient.ListVeths(client.WithNextCursor(context.Background(), &nextCursor), namespaceName, client.ListOptions(options))
+	converted, err := convertRecords(vethPairs, err, vethPairFromWire)
+	return converted, nextCursor, err
+}
+
+func (remote remoteOperations) SetVethUp(interfaceName, namespaceName string) error {
+	return remote.apiClient.SetVethUp(context.Background(), interfaceName, namespaceName)
+}
+
+func (remote remoteOperations) SetVethDown(interfaceName, namespaceName string) error {
+	return remote.apiClient.SetVethDown(context.Background(), interfaceName, namespaceName)
+}
+
+func (remote remoteOperations) AddAddress(request service.AddressRequest) (*db.IPAddress, error) {
+	record, err := remote.apiClient.AddAddress(context.Background(), client.AddressRequest(request))
+	return convertRecord(record, err, addressFromWire)
+}
+
+func (remote remoteOperations) DeleteAddress(request service.AddressRequest) error {
+	return remote.apiClient.DeleteAddress(context.Background(), client.AddressRequest(request))
+}
+
+func (remote remoteOperations) UpdateAddress(id int64, request service.UpdateAddressRequest) (*db.IPAddress, error) {
+	record, err := remote.apiClient.UpdateAddress(context.Background(), id, client.UpdateAddressRequest(request))
+	return convertRecord(record, err, addressFromWire)
+}
+
+func (remote remoteOperations) ListAddresses(namespaceName string, options service.ListOptions) ([]db.IPAddress, string, error) {
+	var nextCursor string
+	addressRecords, err := remote.apiClient.ListAddresses(client.WithNextCursor(context.Background(), &nextCursor), namespaceName, client.ListOptions(options))
+	converted, err := convertRecords(addressRecords, err, addressFromWire)
+	return converted, nextCursor, err
+}
+
+func (remote remoteOperations) AddressInfos(namespaceName string) ([]netns.AddressInfo, error) {
+	addressInfos, err := remote.apiClient.AddressInfos(context.Background(), namespaceName)
+	return convertRecords(addressInfos, err, addressInfoFromWire)
+}
+
+func (remote remoteOperations) AddRoute(request service.AddRouteRequest) (*db.Route, error) {
+	record, err := remote.apiClient.AddRoute(context.Background(), client.AddRouteRequest(request))
+	return convertRecord(record, err, routeFromWire)
+}
+
+func (remote remoteOperations) DeleteRoute(destination, namespaceName string) error {
+	return remote.apiClient.DeleteRoute(context.Background(), destination, namespaceName)
+}
+
+func (remote remoteOperations) ReplaceRoute(id int64, request service.ReplaceRouteRequest) (*db.Route, error) {
+	record, err := remote.apiClient.ReplaceRoute(context.Background(), id, client.ReplaceRouteRequest(request))
+	return convertRecord(record, err, routeFromWire)
+}
+
+func (remote remoteOperations) ListRoutes(namespaceName string, options service.ListOptions) ([]db.Route, string, error) {
+	var nextCursor string
+	routeRecords, err := remote.apiClient.ListRoutes(client.WithNextCursor(context.Background(), &nextCursor), namespaceName, client.ListOptions(options))
+	converted, err := convertRecords(routeRecords, err, routeFromWire)
+	return converted, nextCursor, err
+}
+
+func (remote remoteOperations) RouteInfos(namespaceName string) ([]netns.RouteInfo, error) {
+	routeInfos, err := remote.apiClient.RouteInfos(context.Background(), namespaceName)
+	return convertRecords(routeInfos, err, routeInfoFromWire)
+}
+
+func (remote remoteOperations) CreateBridge(request service.CreateBridgeRequest) (*db.Bridge, error) {
+	record, err := remote.apiClient.CreateBridge(context.Background(), client.CreateBridgeRequest(request))
+	return convertRecord(record, err, bridgeFromWire)
+}
+
+func (remote remoteOperations) DeleteBridge(bridgeName, namespaceName string) error {
+	return remote.apiClient.DeleteBridge(context.Background(), bridgeName, namespaceName)
+}
+
+func (remote remoteOperations) ListBridges(options service.ListOptions) ([]db.Bridge, error) {
+	bridgeRecords, err := remote.apiClient.ListBridges(context.Background(), client.ListOptions(options))
+	return convertRecords(bridgeRecords, err, bridgeFromWire)
+}
+
+func (remote remoteOperations) AddBridgePort(request service.BridgePortRequest) error {
+	return remote.apiClient.AddBridgePort(context.Background(), client.BridgePortRequest(request))
+}
+
+func (remote remoteOperations) RemoveBridgePort(request service.BridgePortRequest) error {
+	return remote.apiClient.RemoveBridgePort(context.Background(), client.BridgePortRequest(request))
+}
+
+func (remote remoteOperations) BridgeInfos(namespaceName string) ([]netns.BridgeInfo, error) {
+	bridgeInfos, err := remote.apiClient.BridgeInfos(context.Background(), namespaceName)
+	return convertRecords(bridgeInfos, err, bridgeInfoFromWire)
+}
+
+func (remote remoteOperations) CreateGRETunnel(request service.CreateGRETunnelRequest) (*db.GRETunnel, error) {
+	record, err := remote.apiClient.CreateGRETunnel(context.Background(), client.CreateGRETunnelRequest(request))
+	return convertRecord(record, err, tunnelFromWire)
+}
+
+func (remote remoteOperations) DeleteGRETunnel(tunnelName, namespaceName string) error {
+	return remote.apiClient.DeleteGRETunnel(context.Background(), tunnelName, namespaceName)
+}
+
+func (remote remoteOperations) UpdateGRETunnel(tunnelName string, request service.UpdateGRETunnelRequest) (*db.GRETunnel, error) {
+	record, err := remote.apiClient.UpdateGRETunnel(context.Background(), tunnelName, client.UpdateGRETunnelRequest(request))
+	return convertRecord(record, err, tunnelFromWire)
+}
+
+func (remote remoteOperations) CreatePeerTunnels(request service.CreatePeerTunnelsRequest) ([]*db.GRETunnel, error) {
+	tunnelRecords, err := remote.apiClient.CreatePeerTunnels(context.Background(), client.CreatePeerTunnelsRequest(request))
+	return convertRecords(tunnelRecords, err, func(record *client.GRETunnel) *db.GRETunnel {
+		converted := tunnelFromWire(*record)
+		return &converted
+	})
+}
+
+func (remote remoteOperations) ListGRETunnels(namespaceName string, options service.ListOptions) ([]db.GRETunnel, string, error) {
+	var nextCursor string
+	tunnelRecords, err := remote.apiClient.ListGRETunnels(client.WithNextCursor(context.Background(), &nextCursor), namespaceName, client.ListOptions(options))
+	converted, err := convertRecords(tunnelRecords, err, tunnelFromWire)
+	return converted, nextCursor, err
+}
+
+func (remote remoteOperations) GRETunnelInfos(namespaceName string) ([]netns.GRETunnelInfo, error) {
+	tunnelInfos, err := remote.apiClient.GRETunnelInfos(context.Background(), namespaceName)
+	return convertRecords(tunnelInfos, err, tunnelInfoFromWire)
+}
+
+func (remote remoteOperations) SetGRETunnelUp(tunnelName, namespaceName string) error {
+	return remote.apiClient.SetGRETunnelUp(context.Background(), tunnelName, namespaceName)
+}
+
+func (remote remoteOperations) SetGRETunnelDown(tunnelName, namespaceName string) error {
+	return remote.apiClient.SetGRETunnelDown(context.Background(), tunnelName, namespaceName)
+}
+
+func (remote remoteOperations) UpdateLabels(request service.UpdateLabelsRequest) (map[string]string, error) {
+	return remote.apiClient.UpdateLabels(context.Background(), request.Resource, request.Name, request.Labels)
+}
+
+func (remote remoteOperations) DeleteBySelector(resourceType, selector string) ([]string, error) {
+	return remote.apiClient.DeleteBySelector(context.Background(), resourceType, selector)
+}
+
+func (remote remoteOperations) QuotaUsages(projectName string) ([]service.ProjectQuotaUsage, error) {
+	usages, err := remote.apiClient.QuotaUsages(context.Background(), projectName)
+	return convertRecords(usages, err, projectQuotaUsageFromWire)
+}
+
+func (remote remoteOperations) RunWorkload(request service.RunWorkloadRequest) (*db.Workload, error) {
+	record, err := remote.apiClient.RunWorkload(context.Background(), client.RunWorkloadRequest(request))
+	return convertRecord(record, err, workloadFromWire)
+}
+
+func (remote remoteOperations) ListWorkloads(namespaceName string) ([]db.Workload, error) {
+	workloadRecords, err := remote.apiClient.ListWorkloads(context.Background(), namespaceName)
+	return convertRecords(workloadRecords, err, workloadFromWire)
+}
+
+func (remote remoteOperations) GetWorkload(workloadName string) (*db.Workload, error) {
+	record, err := remote.apiClient.GetWorkload(context.Background(), workloadName)
+	return convertRecord(record, err, workloadFromWire)
+}
+
+func (remote remoteOperations) StartWorkload(workloadName string) (*db.Workload, error) {
+	record, err := remote.apiClient.StartWorkload(context.Background(), workloadName)
+	return convertRecord(record, err, workloadFromWire)
+}
+
+func (remote remoteOperations) StopWorkload(workloadName string) (*db.Workload, error) {
+	record, err := remote.apiClient.StopWorkload(context.Background(), workloadName)
+	return convertRecord(record, err, workloadFromWire)
+}
+
+func (remote remoteOperations) DeleteWorkload(workloadName string) error {
+	return remote.apiClient.DeleteWorkload(context.Background(), workloadName)
+}
+
+func (remote remoteOperations) WorkloadLogs(workloadName, stream string, lines int) (string, error) {
+	return remote.apiClient.WorkloadLogs(context.Background(), workloadName, stream, lines)
+}
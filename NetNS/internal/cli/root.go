@@ -1,17 +1,28 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/service"
+	"github.com/zenith/netns-mgr/pkg/client"
 )
 
 var (
-	dbPath string
-	DB     *db.DB
-	Repo   *db.Repository
+	dbPath    string
+	serverURL string
+	apiToken  string
+	caFile    string
+	certFile  string
+	keyFile   string
+	DB        *db.DB
+	Repo      *db.Repository
+	Svc       *service.Service
+	Backend   Operations
 )
 
 var rootCmd = &cobra.Command{
@@ -27,35 +38,77 @@ Supports creating and managing:
   - Bridges
   - GRE tunnels (for peering namespaces)
 
-All operations are persisted to a SQLite database.`,
+All operations are persisted to a SQLite database.
+
+With --server (or $NETNS_MGR_SERVER) the ns, veth, ip, route, bridge, gre,
+label, job and "quota show" commands drive a running "netns-mgr serve" over its REST API
+instead, authenticating with --token (or $NETNS_MGR_TOKEN) or a client certificate.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Skip DB initialization for help commands
 		if cmd.Name() == "help" || cmd.Name() == "version" {
 			return nil
 		}
 
+		// Remote mode: no local database or netlink access
+		if serverURL != "" {
+			if err := checkRemoteSupported(cmd); err != nil {
+				return err
+			}
+			var httpClient *http.Client
+			if caFile != "" || certFile != "" || keyFile != "" {
+				var err error
+				if httpClient, err = client.NewTLSHTTPClient(caFile, certFile, keyFile); err != nil {
+					return err
+				}
+			}
+			apiClient := client.New(serverURL, httpClient)
+			if apiToken == "" {
+				apiToken = os.Getenv(tokenEnvVar)
+			}
+			apiClient.SetToken(apiToken)
+			Backend = remoteOperations{apiClient: apiClient}
+			return nil
+		}
+
 		var err error
 		DB, err = db.Open(dbPath)
 		if err != nil {
 			return fmt.Errorf("failed to open database: %w", err)
 		}
 		Repo = db.NewRepository(DB)
+		Svc = service.New(Repo)
+		Backend = Svc
 		return nil
 	},
-	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if DB != nil {
-			DB.Close()
-		}
-	},
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&dbPath, "db", "", "database path (default: ~/.netns-mgr/netns.db)")
+	rootCmd.PersistentFlags().StringVar(&serverURL, "server", os.Getenv(serverEnvVar), "API server URL for remote mode (e.g. http://lab1:8080)")
+	rootCmd.PersistentFlags().StringVar(&apiToken, "token", "", "API token for remote mode (default: $NETNS_MGR_TOKEN)")
+	rootCmd.PersistentFlags().StringVar(&caFile, "certificate-authority", "", "PEM CA bundle to verify an HTTPS server with in remote mode")
+	rootCmd.PersistentFlags().StringVar(&certFile, "client-certificate", "", "PEM client certificate for mutual TLS in remote mode")
+	rootCmd.PersistentFlags().StringVar(&keyFile, "client-key", "", "PEM private key of the client certificate")
 }
 
 // Execute runs the root command
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	executedCmd, err := rootCmd.ExecuteC()
+
+	// Audit after the command so failures are recorded too
+	if Repo != nil {
+		recordAudit(executedCmd, err)
+	}
+	if DB != nil {
+		DB.Close()
+	}
+
+	// A command run by ns exec has already reported its own failure
+	var exitError *commandExitError
+	if errors.As(err, &exitError) {
+		os.Exit(exitError.exitCode)
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
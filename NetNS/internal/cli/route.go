@@ -6,7 +6,9 @@ import (
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/db"
 	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
 )
 
 var (
@@ -38,7 +40,10 @@ Examples:
   netns-mgr route add 192.168.0.0/24 --interface eth0
 
   # Add route in namespace
-  netns-mgr route add default --gateway 10.0.0.1 --ns myns`,
+  netns-mgr route add default --gateway 10.0.0.1 --ns myns
+
+  # Add a labelled route
+  netns-mgr route add 192.168.0.0/24 --gateway 10.0.0.1 --label env=lab`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		destinationNetwork := args[0]
@@ -47,64 +52,118 @@ Examples:
 			return fmt.Errorf("either --gateway or --interface is required")
 		}
 
-		namespaceManager := netns.NewManager()
-		routeManager := netns.NewRouteManager(namespaceManager)
-
-		// Add to system
-		if err := routeManager.Add(destinationNetwork, routeGateway, routeInterface, routeNs); err != nil {
+		_, err := Backend.AddRoute(service.AddRouteRequest{
+			Destination: destinationNetwork,
+			Gateway:     routeGateway,
+			Interface:   routeInterface,
+			Namespace:   routeNs,
+			Labels:      createLabels,
+		})
+		if err != nil {
 			return err
 		}
 
-		// Get namespace ID for DB
-		var namespaceID *int64
-		if routeNs != "" {
-			namespaceRecord, err := Repo.GetNamespaceByName(routeNs)
-			if err == nil && namespaceRecord != nil {
-				namespaceID = &namespaceRecord.ID
-			}
+		fmt.Printf("Added route: %s\n", destinationNetwork)
+		return nil
+	},
+}
+
+var routeDeleteCmd = &cobra.Command{
+	Use:   "delete <destination> | --selector <selector>",
+	Short: "Delete a route, or every route matching a label selector",
+	Args:  nameOrSelector,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if labelSelector != "" {
+			return deleteBySelector(db.LabelRoutes, "routes")
 		}
+		destinationNetwork := args[0]
 
-		// Record in database
-		_, err := Repo.CreateRoute(namespaceID, destinationNetwork, routeGateway, routeInterface)
-		if err != nil {
-			// Rollback system change
-			routeManager.Delete(destinationNetwork, routeNs)
-			return fmt.Errorf("failed to record route: %w", err)
+		if err := Backend.DeleteRoute(destinationNetwork, routeNs); err != nil {
+			return err
 		}
 
-		fmt.Printf("Added route: %s\n", destinationNetwork)
+		fmt.Printf("Deleted route: %s\n", destinationNetwork)
 		return nil
 	},
 }
 
-var routeDeleteCmd = &cobra.Command{
-	Use:   "delete <destination>",
-	Short: "Delete a route",
-	Args:  cobra.ExactArgs(1),
+var routeSetCmd = &cobra.Command{
+	Use:   "set <destination>",
+	Short: "Replace the gateway and interface of a recorded route",
+	Long: `Replace the gateway and interface of a recorded route.
+
+The kernel swaps the route in place, so traffic to the destination keeps
+flowing. Both --gateway and --interface replace the recorded values.
+
+Examples:
+  # Move the default route of a namespace to another gateway
+  netns-mgr route set default --gateway 10.0.0.254 --ns myns`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		destinationNetwork := args[0]
 
-		namespaceManager := netns.NewManager()
-		routeManager := netns.NewRouteManager(namespaceManager)
+		if routeGateway == "" && routeInterface == "" {
+			return fmt.Errorf("either --gateway or --interface is required")
+		}
+
+		routeRecord, err := findRouteRecord(destinationNetwork, routeNs)
+		if err != nil {
+			return err
+		}
 
-		// Delete from system
-		if err := routeManager.Delete(destinationNetwork, routeNs); err != nil {
+		_, err = Backend.ReplaceRoute(routeRecord.ID, service.ReplaceRouteRequest{
+			Gateway:   routeGateway,
+			Interface: routeInterface,
+		})
+		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Deleted route: %s\n", destinationNetwork)
+		fmt.Printf("Replaced route: %s\n", destinationNetwork)
 		return nil
 	},
 }
 
+// findRouteRecord looks up the record of the route to a destination
+// Parameters:
+//   - destination: route destination (CIDR or "default")
+//   - namespaceName: namespace of the route (empty = host)
+func findRouteRecord(destination, namespaceName string) (*db.Route, error) {
+	routeRecords, _, err := Backend.ListRoutes(namespaceName, service.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, routeRecord := range routeRecords {
+		if routeRecord.Destination == destination && (namespaceName != "" || routeRecord.NsID == nil) {
+			return &routeRecord, nil
+		}
+	}
+	return nil, fmt.Errorf("no recorded route to %s", destination)
+}
+
 var routeListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List routes",
+	Long: `List the routes configured in a namespace.
+
+With --limit, --filter, --sort or --cursor the recorded routes of every
+namespace (or of --ns) are listed instead, a page at a time. The within
+filter keeps routes whose destination lies entirely inside the network.
+
+Examples:
+  # Recorded routes to networks inside 10.0.0.0/8
+  netns-mgr route list --filter within=10.0.0.0/8 --sort destination`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		namespaceManager := netns.NewManager()
-		routeManager := netns.NewRouteManager(namespaceManager)
+		if recordListRequested(cmd) {
+			return listRouteRecords()
+		}
+
+		routeInfos, err := Backend.RouteInfos(routeNs)
+		if err != nil {
+			return err
+		}
 
-		routeInfos, err := routeManager.GetRouteInfos(routeNs)
+		routeInfos, err = filterBySelector(routeInfos, db.LabelRoutes, routeNs, func(routeInfo netns.RouteInfo) string { return routeInfo.Destination })
 		if err != nil {
 			return err
 		}
@@ -141,18 +200,73 @@ var routeListCmd = &cobra.Command{
 	},
 }
 
+// listRouteRecords prints one page of the recorded routes matching the list flags
+func listRouteRecords() error {
+	options, namespaceName, err := recordListOptions(routeNs)
+	if err != nil {
+		return err
+	}
+	routeRecords, nextCursor, err := Backend.ListRoutes(namespaceName, options)
+	if err != nil {
+		return err
+	}
+	if len(routeRecords) == 0 {
+		fmt.Println("No routes found")
+		return nil
+	}
+
+	tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, "ID\tDESTINATION\tGATEWAY\tINTERFACE\tNAMESPACE\tCREATED\tLABELS")
+	namespaceNames := namespaceNamesByID()
+	for _, routeRecord := range routeRecords {
+		namespaceDisplay := "-"
+		if routeRecord.NsID != nil {
+			namespaceDisplay = namespaceNames[*routeRecord.NsID]
+		}
+		gatewayDisplay := routeRecord.Gateway
+		if gatewayDisplay == "" {
+			gatewayDisplay = "-"
+		}
+		interfaceDisplay := routeRecord.InterfaceName
+		if interfaceDisplay == "" {
+			interfaceDisplay = "-"
+		}
+		fmt.Fprintf(tableWriter, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			routeRecord.ID,
+			routeRecord.Destination,
+			gatewayDisplay,
+			interfaceDisplay,
+			namespaceDisplay,
+			routeRecord.CreatedAt.Format("2006-01-02 15:04:05"),
+			formatLabels(routeRecord.Labels),
+		)
+	}
+	tableWriter.Flush()
+	printNextPage(nextCursor)
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(routeCmd)
 
 	routeAddCmd.Flags().StringVar(&routeGateway, "gateway", "", "gateway address")
 	routeAddCmd.Flags().StringVar(&routeInterface, "interface", "", "interface name")
 	routeAddCmd.Flags().StringVar(&routeNs, "ns", "", "namespace")
+	addLabelFlag(routeAddCmd)
 
 	routeDeleteCmd.Flags().StringVar(&routeNs, "ns", "", "namespace")
+	addSelectorFlag(routeDeleteCmd, "delete every recorded route matching this label selector")
+
+	routeSetCmd.Flags().StringVar(&routeGateway, "gateway", "", "new gateway address")
+	routeSetCmd.Flags().StringVar(&routeInterface, "interface", "", "new interface name")
+	routeSetCmd.Flags().StringVar(&routeNs, "ns", "", "namespace")
 
 	routeListCmd.Flags().StringVar(&routeNs, "ns", "", "namespace")
+	addSelectorFlag(routeListCmd, "only list recorded routes matching this label selector")
+	addListFlags(routeListCmd, "destination, created_at or id")
 
 	routeCmd.AddCommand(routeAddCmd)
 	routeCmd.AddCommand(routeDeleteCmd)
+	routeCmd.AddCommand(routeSetCmd)
 	routeCmd.AddCommand(routeListCmd)
 }
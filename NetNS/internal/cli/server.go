@@ -2,14 +2,22 @@ package cli
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/zenith/netns-mgr/internal/api"
+	"github.com/zenith/netns-mgr/internal/jobs"
 )
 
 var (
-	serverPort int
-	serverHost string
+	serverPort            int
+	serverHost            string
+	serverMetricsInterval time.Duration
+	serverCORSOrigins     []string
+	serverTLSCert         string
+	serverTLSKey          string
+	serverClientCA        string
+	serverJobWorkers      int
 )
 
 var serveCmd = &cobra.Command{
@@ -25,12 +33,61 @@ Examples:
   netns-mgr serve --port 9000
 
   # Bind to specific interface
-  netns-mgr serve --host 0.0.0.0 --port 8080`,
+  netns-mgr serve --host 0.0.0.0 --port 8080
+
+  # HTTPS with client certificates (see "netns-mgr pki init")
+  netns-mgr serve --tls-cert server.pem --tls-key server-key.pem --client-ca ca.pem
+
+Every endpoint except /health and the API docs requires a bearer token
+created with "netns-mgr token create". Viewers may read, operators may also
+create, change and delete, and admins may also read the audit log.
+
+With --client-ca, a client certificate signed by that CA may be used instead
+of a token: its common name must match an API token name, whose role it gets.
+Certificate files are reloaded when they change, without a restart.
+
+Prometheus metrics are served on /metrics. Interface counters and
+resource counts are scraped in the background every --metrics-interval.
+
+Long operations (peer tunnels, namespace and bulk deletes) accept ?async=true
+and then run as background jobs on --job-workers workers; follow them with
+"netns-mgr job". Jobs still running when the server stops are marked
+interrupted on the next start.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		addr := fmt.Sprintf("%s:%d", serverHost, serverPort)
 
-		server := api.NewServer(Repo)
-		fmt.Printf("Starting API server on %s\n", addr)
+		if (serverTLSCert == "") != (serverTLSKey == "") {
+			return fmt.Errorf("--tls-cert and --tls-key must be given together")
+		}
+		if serverClientCA != "" && serverTLSCert == "" {
+			return fmt.Errorf("--client-ca requires --tls-cert and --tls-key")
+		}
+
+		tokens, err := Svc.ListAPITokens()
+		if err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			fmt.Println("Warning: no API tokens exist; create one with: netns-mgr token create <name> --role admin")
+		}
+
+		server := api.NewServer(Repo, api.Config{
+			AllowedOrigins: serverCORSOrigins,
+			TLSCertFile:    serverTLSCert,
+			TLSKeyFile:     serverTLSKey,
+			ClientCAFile:   serverClientCA,
+		})
+		if err := server.StartJobs(serverJobWorkers); err != nil {
+			return err
+		}
+		if serverMetricsInterval > 0 {
+			server.StartMetrics(serverMetricsInterval)
+		}
+		scheme := "http"
+		if serverTLSCert != "" {
+			scheme = "https"
+		}
+		fmt.Printf("Starting API server on %s://%s\n", scheme, addr)
 		return server.Run(addr)
 	},
 }
@@ -40,4 +97,10 @@ func init() {
 
 	serveCmd.Flags().IntVar(&serverPort, "port", 8080, "port to listen on")
 	serveCmd.Flags().StringVar(&serverHost, "host", "127.0.0.1", "host to bind to")
+	serveCmd.Flags().StringSliceVar(&serverCORSOrigins, "cors-origin", nil, "origin allowed to make browser requests (repeatable, \"*\" = any; default none)")
+	serveCmd.Flags().StringVar(&serverTLSCert, "tls-cert", "", "PEM server certificate; enables HTTPS")
+	serveCmd.Flags().StringVar(&serverTLSKey, "tls-key", "", "PEM private key of the server certificate")
+	serveCmd.Flags().StringVar(&serverClientCA, "client-ca", "", "PEM CA bundle; accept client certificates it signed (mTLS)")
+	serveCmd.Flags().IntVar(&serverJobWorkers, "job-workers", jobs.DefaultWorkers, "number of background jobs run at the same time")
+	serveCmd.Flags().DurationVar(&serverMetricsInterval, "metrics-interval", 15*time.Second, "interval between metrics scrapes (0 = disabled)")
 }
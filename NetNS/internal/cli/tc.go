@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+var (
+	tcNs        string
+	tcDelay     time.Duration
+	tcJitter    time.Duration
+	tcLoss      float64
+	tcReorder   float64
+	tcDuplicate float64
+	tcCorrupt   float64
+	tcRate      string
+	tcCeil      string
+	tcBurst     uint32
+	tcShaper    string
+)
+
+var tcCmd = &cobra.Command{
+	Use:   "tc",
+	Short: "Manage traffic impairment (latency, loss, rate limits)",
+	Long: `Emulate WAN links on veth ends, GRE tunnels or any other interface.
+
+Impairment is applied with netem; rate limits use a tbf qdisc (or an htb
+class) with netem attached beneath it. Settings are recorded so restore
+can re-apply them.`,
+}
+
+var tcSetCmd = &cobra.Command{
+	Use:   "set <interface>",
+	Short: "Set impairment on an interface, replacing any existing one",
+	Long: `Set impairment on an interface, replacing any existing one.
+
+Examples:
+  # 40ms +/- 5ms latency with 0.5% loss on a veth end
+  netns-mgr tc set veth-a --ns site1 --delay 40ms --jitter 5ms --loss 0.5
+
+  # 10 Mbit/s WAN link with 20ms latency on a GRE tunnel
+  netns-mgr tc set gre1 --ns site1 --rate 10mbit --delay 20ms
+
+  # HTB shaping with burst up to 20 Mbit/s
+  netns-mgr tc set veth-b --ns site2 --rate 10mbit --ceil 20mbit --shaper htb`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interfaceName := args[0]
+
+		_, err := Svc.SetImpairment(service.SetImpairmentRequest{
+			Interface:        interfaceName,
+			Namespace:        tcNs,
+			DelayMs:          durationToMs(tcDelay),
+			JitterMs:         durationToMs(tcJitter),
+			LossPercent:      tcLoss,
+			ReorderPercent:   tcReorder,
+			DuplicatePercent: tcDuplicate,
+			CorruptPercent:   tcCorrupt,
+			Rate:             tcRate,
+			Ceil:             tcCeil,
+			BurstBytes:       tcBurst,
+			Shaper:           tcShaper,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Set impairment on %s\n", interfaceName)
+		return nil
+	},
+}
+
+var tcShowCmd = &cobra.Command{
+	Use:   "show <interface>",
+	Short: "Show the impairment currently applied to an interface",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interfaceName := args[0]
+
+		qdiscInfo, err := Svc.ShowImpairment(interfaceName, tcNs)
+		if err != nil {
+			return err
+		}
+
+		if qdiscInfo.Root == "" {
+			fmt.Printf("%s: no impairment (default qdisc)\n", interfaceName)
+			return nil
+		}
+
+		fmt.Printf("Interface: %s\n", qdiscInfo.Interface)
+		fmt.Printf("Root:      %s\n", qdiscInfo.Root)
+		fmt.Printf("Delay:     %gms (jitter %gms)\n", qdiscInfo.DelayMs, qdiscInfo.JitterMs)
+		fmt.Printf("Loss:      %g%%\n", qdiscInfo.LossPercent)
+		fmt.Printf("Reorder:   %g%%\n", qdiscInfo.ReorderPercent)
+		fmt.Printf("Duplicate: %g%%\n", qdiscInfo.DuplicatePercent)
+		fmt.Printf("Corrupt:   %g%%\n", qdiscInfo.CorruptPercent)
+		if qdiscInfo.RateKbit > 0 {
+			fmt.Printf("Rate:      %dkbit\n", qdiscInfo.RateKbit)
+		}
+		if qdiscInfo.CeilKbit > 0 {
+			fmt.Printf("Ceil:      %dkbit\n", qdiscInfo.CeilKbit)
+		}
+		return nil
+	},
+}
+
+var tcClearCmd = &cobra.Command{
+	Use:   "clear <interface>",
+	Short: "Remove impairment from an interface",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interfaceName := args[0]
+
+		if err := Svc.ClearImpairment(interfaceName, tcNs); err != nil {
+			return err
+		}
+
+		fmt.Printf("Cleared impairment on %s\n", interfaceName)
+		return nil
+	},
+}
+
+var tcListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recorded impairments",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		qdiscs, err := Svc.ListImpairments(tcNs)
+		if err != nil {
+			return err
+		}
+
+		if len(qdiscs) == 0 {
+			fmt.Println("No impairments recorded")
+			return nil
+		}
+
+		namespaceNames := namespaceNamesByID()
+		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tableWriter, "INTERFACE\tNAMESPACE\tDELAY\tJITTER\tLOSS\tRATE\tSHAPER")
+
+		for _, qdisc := range qdiscs {
+			namespaceDisplay := "host"
+			if qdisc.NsID != nil {
+				namespaceDisplay = namespaceNames[*qdisc.NsID]
+			}
+
+			rateDisplay := "-"
+			shaperDisplay := "-"
+			if qdisc.RateKbit > 0 {
+				rateDisplay = fmt.Sprintf("%dkbit", qdisc.RateKbit)
+				shaperDisplay = qdisc.Shaper
+			}
+
+			fmt.Fprintf(tableWriter, "%s\t%s\t%gms\t%gms\t%g%%\t%s\t%s\n",
+				qdisc.InterfaceName,
+				namespaceDisplay,
+				qdisc.DelayMs,
+				qdisc.JitterMs,
+				qdisc.LossPercent,
+				rateDisplay,
+				shaperDisplay,
+			)
+		}
+
+		tableWriter.Flush()
+		return nil
+	},
+}
+
+// durationToMs converts a duration to fractional milliseconds
+func durationToMs(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
+
+func init() {
+	rootCmd.AddCommand(tcCmd)
+
+	tcSetCmd.Flags().StringVar(&tcNs, "ns", "", "namespace")
+	tcSetCmd.Flags().DurationVar(&tcDelay, "delay", 0, "one-way latency (e.g. 40ms)")
+	tcSetCmd.Flags().DurationVar(&tcJitter, "jitter", 0, "latency variation (requires --delay)")
+	tcSetCmd.Flags().Float64Var(&tcLoss, "loss", 0, "random packet loss in percent")
+	tcSetCmd.Flags().Float64Var(&tcReorder, "reorder", 0, "percentage of packets reordered (requires --delay)")
+	tcSetCmd.Flags().Float64Var(&tcDuplicate, "duplicate", 0, "random packet duplication in percent")
+	tcSetCmd.Flags().Float64Var(&tcCorrupt, "corrupt", 0, "random packet corruption in percent")
+	tcSetCmd.Flags().StringVar(&tcRate, "rate", "", "rate limit (e.g. 10mbit, 512kbit)")
+	tcSetCmd.Flags().StringVar(&tcCeil, "ceil", "", "htb ceiling rate (requires --shaper htb)")
+	tcSetCmd.Flags().Uint32Var(&tcBurst, "burst", 0, "tbf bucket size in bytes (0 = automatic)")
+	tcSetCmd.Flags().StringVar(&tcShaper, "shaper", netns.ShaperTBF, "rate limiter (tbf or htb)")
+
+	tcShowCmd.Flags().StringVar(&tcNs, "ns", "", "namespace")
+	tcClearCmd.Flags().StringVar(&tcNs, "ns", "", "namespace")
+	tcListCmd.Flags().StringVar(&tcNs, "ns", "", "namespace")
+
+	tcCmd.AddCommand(tcSetCmd)
+	tcCmd.AddCommand(tcShowCmd)
+	tcCmd.AddCommand(tcClearCmd)
+	tcCmd.AddCommand(tcListCmd)
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/auth"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+var (
+	tokenRole    string
+	tokenProject string
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage API tokens",
+	Long: `Manage the bearer tokens accepted by "netns-mgr serve".
+
+Roles:
+  viewer    read resources, status, events and metrics
+  operator  viewer, plus create, change and delete resources
+  admin     operator, plus read the audit log
+
+The token name is the principal recorded in the audit log. A token created
+with --project only sees and changes the resources of that project.`,
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an API token",
+	Long: `Create an API token. The secret is printed once and cannot be shown again.
+
+Examples:
+  # Read-only token for dashboards
+  netns-mgr token create grafana --role viewer
+
+  # Token for automation that changes resources
+  netns-mgr token create ci --role operator
+
+  # Token confined to the namespaces of project "team-a"
+  netns-mgr token create team-a-ci --role operator --project team-a`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokenName := args[0]
+
+		role, err := auth.ParseRole(tokenRole)
+		if err != nil {
+			return err
+		}
+
+		tokenRecord, secret, err := Svc.CreateAPIToken(service.CreateTokenRequest{
+			Name:    tokenName,
+			Role:    string(role),
+			Project: tokenProject,
+		})
+		if err != nil {
+			return err
+		}
+
+		if tokenRecord.Project != "" {
+			fmt.Printf("Created %s token for project %s: %s\n", tokenRecord.Role, tokenRecord.Project, tokenRecord.Name)
+		} else {
+			fmt.Printf("Created %s token: %s\n", tokenRecord.Role, tokenRecord.Name)
+		}
+		fmt.Printf("Token: %s\n", secret)
+		fmt.Println("Store it now; it cannot be shown again.")
+		return nil
+	},
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API tokens",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokens, err := Svc.ListAPITokens()
+		if err != nil {
+			return err
+		}
+
+		if len(tokens) == 0 {
+			fmt.Println("No API tokens found")
+			return nil
+		}
+
+		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tableWriter, "NAME\tROLE\tPROJECT\tCREATED\tLAST USED")
+
+		for _, token := range tokens {
+			lastUsedDisplay := "never"
+			if token.LastUsedAt != nil {
+				lastUsedDisplay = token.LastUsedAt.Local().Format("2006-01-02 15:04:05")
+			}
+
+			projectDisplay := token.Project
+			if projectDisplay == "" {
+				projectDisplay = "(all)"
+			}
+
+			fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\t%s\n",
+				token.Name,
+				token.Role,
+				projectDisplay,
+				token.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+				lastUsedDisplay,
+			)
+		}
+
+		tableWriter.Flush()
+		return nil
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <name>",
+	Short: "Revoke an API token",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokenName := args[0]
+
+		if err := Svc.RevokeAPIToken(tokenName); err != nil {
+			return err
+		}
+
+		fmt.Printf("Revoked API token: %s\n", tokenName)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+
+	tokenCreateCmd.Flags().StringVar(&tokenRole, "role", string(auth.RoleViewer), "role granted to the token (viewer, operator or admin)")
+	tokenCreateCmd.Flags().StringVar(&tokenProject, "project", "", "confine the token to this project (default: all projects)")
+
+	tokenCmd.AddCommand(tokenCreateCmd)
+	tokenCmd.AddCommand(tokenListCmd)
+	tokenCmd.AddCommand(tokenRevokeCmd)
+}
@@ -6,7 +6,8 @@ import (
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
-	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/service"
 )
 
 var (
@@ -33,7 +34,10 @@ Examples:
   netns-mgr veth create veth0 --peer veth1 --ns myns
 
   # Create veth pair connecting two namespaces
-  netns-mgr veth create veth0 --peer veth1 --ns ns1 --peer-ns ns2`,
+  netns-mgr veth create veth0 --peer veth1 --ns ns1 --peer-ns ns2
+
+  # Create a labelled veth pair
+  netns-mgr veth create veth0 --peer veth1 --label env=lab`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		interfaceName := args[0]
@@ -42,64 +46,36 @@ Examples:
 			return fmt.Errorf("--peer is required")
 		}
 
-		namespaceManager := netns.NewManager()
-		vethManager := netns.NewVethManager(namespaceManager)
-
-		// Create veth pair
-		if err := vethManager.Create(interfaceName, vethPeer, vethNs, vethPeerNs); err != nil {
-			return err
-		}
-
-		// Get namespace IDs for DB
-		var namespaceID, peerNamespaceID *int64
-
-		if vethNs != "" {
-			namespaceRecord, err := Repo.GetNamespaceByName(vethNs)
-			if err == nil && namespaceRecord != nil {
-				namespaceID = &namespaceRecord.ID
-			}
-		}
-
-		if vethPeerNs != "" {
-			namespaceRecord, err := Repo.GetNamespaceByName(vethPeerNs)
-			if err == nil && namespaceRecord != nil {
-				peerNamespaceID = &namespaceRecord.ID
-			}
-		}
-
-		// Record in database
-		_, err := Repo.CreateVethPair(interfaceName, vethPeer, namespaceID, peerNamespaceID)
+		vethPair, err := Backend.CreateVeth(service.CreateVethRequest{
+			Name:          interfaceName,
+			PeerName:      vethPeer,
+			Namespace:     vethNs,
+			PeerNamespace: vethPeerNs,
+			Labels:        createLabels,
+		})
 		if err != nil {
-			// Rollback system change
-			vethManager.Delete(interfaceName)
-			return fmt.Errorf("failed to record veth pair: %w", err)
+			return err
 		}
 
-		fmt.Printf("Created veth pair: %s <-> %s\n", interfaceName, vethPeer)
+		fmt.Printf("Created veth pair: %s <-> %s\n", vethPair.Name, vethPair.PeerName)
 		return nil
 	},
 }
 
 var vethDeleteCmd = &cobra.Command{
-	Use:   "delete <name>",
-	Short: "Delete a veth pair",
-	Args:  cobra.ExactArgs(1),
+	Use:   "delete <name> | --selector <selector>",
+	Short: "Delete a veth pair, or every veth pair matching a label selector",
+	Args:  nameOrSelector,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if labelSelector != "" {
+			return deleteBySelector(db.LabelVeths, "veth pairs")
+		}
 		interfaceName := args[0]
 
-		namespaceManager := netns.NewManager()
-		vethManager := netns.NewVethManager(namespaceManager)
-
-		// Delete from system
-		if err := vethManager.Delete(interfaceName); err != nil {
+		if err := Backend.DeleteVeth(interfaceName); err != nil {
 			return err
 		}
 
-		// Remove from database
-		if err := Repo.DeleteVethPair(interfaceName); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to remove from database: %v\n", err)
-		}
-
 		fmt.Printf("Deleted veth pair: %s\n", interfaceName)
 		return nil
 	},
@@ -108,8 +84,17 @@ var vethDeleteCmd = &cobra.Command{
 var vethListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all veth pairs",
+	Long: `List the recorded veth pairs.
+
+Examples:
+  # Pairs with an end in ns1, newest first, 20 at a time
+  netns-mgr veth list --filter namespace=ns1 --sort -created_at --limit 20`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		vethPairs, err := Repo.ListVethPairs()
+		options, namespaceName, err := recordListOptions("")
+		if err != nil {
+			return err
+		}
+		vethPairs, nextCursor, err := Backend.ListVeths(namespaceName, options)
 		if err != nil {
 			return err
 		}
@@ -120,36 +105,32 @@ var vethListCmd = &cobra.Command{
 		}
 
 		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(tableWriter, "NAME\tPEER\tNAMESPACE\tPEER NAMESPACE\tCREATED")
+		fmt.Fprintln(tableWriter, "NAME\tPEER\tNAMESPACE\tPEER NAMESPACE\tCREATED\tLABELS")
 
+		namespaceNames := namespaceNamesByID()
 		for _, vethPair := range vethPairs {
 			namespaceName := "-"
 			peerNamespaceName := "-"
 
 			if vethPair.NsID != nil {
-				namespaceRecord, _ := Repo.GetNamespace(*vethPair.NsID)
-				if namespaceRecord != nil {
-					namespaceName = namespaceRecord.Name
-				}
+				namespaceName = namespaceNames[*vethPair.NsID]
 			}
-
 			if vethPair.PeerNsID != nil {
-				namespaceRecord, _ := Repo.GetNamespace(*vethPair.PeerNsID)
-				if namespaceRecord != nil {
-					peerNamespaceName = namespaceRecord.Name
-				}
+				peerNamespaceName = namespaceNames[*vethPair.PeerNsID]
 			}
 
-			fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\t%s\n",
+			fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\t%s\t%s\n",
 				vethPair.Name,
 				vethPair.PeerName,
 				namespaceName,
 				peerNamespaceName,
 				vethPair.CreatedAt.Format("2006-01-02 15:04:05"),
+				formatLabels(vethPair.Labels),
 			)
 		}
 
 		tableWriter.Flush()
+		printNextPage(nextCursor)
 		return nil
 	},
 }
@@ -161,10 +142,7 @@ var vethUpCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		interfaceName := args[0]
 
-		namespaceManager := netns.NewManager()
-		vethManager := netns.NewVethManager(namespaceManager)
-
-		if err := vethManager.SetUp(interfaceName, vethNs); err != nil {
+		if err := Backend.SetVethUp(interfaceName, vethNs); err != nil {
 			return err
 		}
 
@@ -180,10 +158,7 @@ var vethDownCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		interfaceName := args[0]
 
-		namespaceManager := netns.NewManager()
-		vethManager := netns.NewVethManager(namespaceManager)
-
-		if err := vethManager.SetDown(interfaceName, vethNs); err != nil {
+		if err := Backend.SetVethDown(interfaceName, vethNs); err != nil {
 			return err
 		}
 
@@ -198,6 +173,11 @@ func init() {
 	vethCreateCmd.Flags().StringVar(&vethPeer, "peer", "", "peer interface name (required)")
 	vethCreateCmd.Flags().StringVar(&vethNs, "ns", "", "namespace for the interface")
 	vethCreateCmd.Flags().StringVar(&vethPeerNs, "peer-ns", "", "namespace for the peer interface")
+	addLabelFlag(vethCreateCmd)
+
+	addSelectorFlag(vethDeleteCmd, "delete every veth pair matching this label selector")
+	addSelectorFlag(vethListCmd, "only list veth pairs matching this label selector")
+	addListFlags(vethListCmd, "name, created_at or id")
 
 	vethUpCmd.Flags().StringVar(&vethNs, "ns", "", "namespace of the interface")
 	vethDownCmd.Flags().StringVar(&vethNs, "ns", "", "namespace of the interface")
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/netns"
+)
+
+var (
+	watchNs    string
+	watchTypes []string
+	watchJSON  bool
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Print link, address, route and neighbor changes as they happen",
+	Long: `Watch netlink events on the host and in every managed namespace.
+
+Namespaces created or deleted while watching are picked up automatically.
+
+Examples:
+  # Everything
+  netns-mgr watch
+
+  # Link and address changes in one namespace, as JSON lines
+  netns-mgr watch --ns r1 --type link,addr --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		eventTypes := make(map[string]bool)
+		for _, eventType := range watchTypes {
+			eventTypes[eventType] = true
+		}
+		filterNamespace := cmd.Flags().Changed("ns")
+		if watchNs == "-" {
+			watchNs = ""
+		}
+
+		namespaceManager := netns.NewManager()
+		watcher := netns.NewWatcher(namespaceManager, func() ([]string, error) {
+			namespaceRecords, err := Repo.ListNamespaces()
+			if err != nil {
+				return nil, err
+			}
+			namespaceNames := make([]string, 0, len(namespaceRecords))
+			for _, namespaceRecord := range namespaceRecords {
+				namespaceNames = append(namespaceNames, namespaceRecord.Name)
+			}
+			return namespaceNames, nil
+		})
+		defer watcher.Stop()
+
+		events, unsubscribe := watcher.Subscribe()
+		defer unsubscribe()
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		jsonEncoder := json.NewEncoder(os.Stdout)
+
+		for {
+			select {
+			case <-signals:
+				return nil
+			case event, ok := <-events:
+				if !ok {
+					return nil
+				}
+				if filterNamespace && event.Namespace != watchNs {
+					continue
+				}
+				if len(eventTypes) > 0 && !eventTypes[event.Type] {
+					continue
+				}
+
+				if watchJSON {
+					jsonEncoder.Encode(event)
+				} else {
+					fmt.Println(formatEvent(event))
+				}
+			}
+		}
+	},
+}
+
+// formatEvent renders an event as a single human-readable line
+func formatEvent(event netns.Event) string {
+	namespaceDisplay := event.Namespace
+	if namespaceDisplay == "" {
+		namespaceDisplay = "host"
+	}
+
+	fields := []string{
+		event.Time.Format("15:04:05.000"),
+		namespaceDisplay,
+		event.Type,
+		event.Action,
+	}
+	if event.Interface != "" {
+		fields = append(fields, "dev="+event.Interface)
+	}
+	if event.State != "" {
+		fields = append(fields, "state="+event.State)
+	}
+	if event.Address != "" {
+		fields = append(fields, "addr="+event.Address)
+	}
+	if event.MAC != "" && event.Type == netns.EventTypeNeighbor {
+		fields = append(fields, "lladdr="+event.MAC)
+	}
+	if event.Destination != "" {
+		fields = append(fields, "dst="+event.Destination)
+	}
+	if event.Gateway != "" {
+		fields = append(fields, "via="+event.Gateway)
+	}
+	return strings.Join(fields, " ")
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringVar(&watchNs, "ns", "", "only show events from this namespace (\"-\" = host)")
+	watchCmd.Flags().StringSliceVar(&watchTypes, "type", nil, "only show these event types (link, addr, route, neigh, namespace)")
+	watchCmd.Flags().BoolVar(&watchJSON, "json", false, "print events as JSON lines")
+}
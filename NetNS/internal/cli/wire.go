@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/service"
+	"github.com/zenith/netns-mgr/pkg/client"
+)
+
+// The API client declares its own copies of the server's records and
+// requests. remoteOperations converts between them with plain struct
+// conversions, which only compile while both sides have the same fields.
+
+// convertRecord converts a record returned by the API client (nil stays nil)
+// Parameters:
+//   - record: record returned by the client
+//   - err: error returned with it, passed through
+//   - convert: converts one record
+func convertRecord[From, To any](record *From, err error, convert func(From) To) (*To, error) {
+	if err != nil || record == nil {
+		return nil, err
+	}
+	converted := convert(*record)
+	return &converted, nil
+}
+
+// convertRecords converts the records returned by the API client (nil stays nil)
+// Parameters:
+//   - records: records returned by the client
+//   - err: error returned with them, passed through
+//   - convert: converts one record
+func convertRecords[From, To any](records []From, err error, convert func(From) To) ([]To, error) {
+	if err != nil || records == nil {
+		return nil, err
+	}
+	converted := make([]To, len(records))
+	for index, record := range records {
+		converted[index] = convert(record)
+	}
+	return converted, nil
+}
+
+func namespaceFromWire(record client.Namespace) db.Namespace { return db.Namespace(record) }
+func vethPairFromWire(record client.VethPair) db.VethPair    { return db.VethPair(record) }
+func addressFromWire(record client.IPAddress) db.IPAddress   { return db.IPAddress(record) }
+func routeFromWire(record client.Route) db.Route             { return db.Route(record) }
+func bridgeFromWire(record client.Bridge) db.Bridge          { return db.Bridge(record) }
+func tunnelFromWire(record client.GRETunnel) db.GRETunnel    { return db.GRETunnel(record) }
+func workloadFromWire(record client.Workload) db.Workload    { return db.Workload(record) }
+
+func namespaceStatusFromWire(status client.NamespaceStatus) service.NamespaceStatus {
+	return service.NamespaceStatus(status)
+}
+
+func addressInfoFromWire(info client.AddressInfo) netns.AddressInfo {
+	return netns.AddressInfo(info)
+}
+
+func routeInfoFromWire(info client.RouteInfo) netns.RouteInfo {
+	return netns.RouteInfo(info)
+}
+
+func bridgeInfoFromWire(info client.BridgeInfo) netns.BridgeInfo {
+	return netns.BridgeInfo(info)
+}
+
+func tunnelInfoFromWire(info client.GRETunnelInfo) netns.GRETunnelInfo {
+	return netns.GRETunnelInfo(info)
+}
+
+// namespaceDetailsFromWire converts a namespace with the records in it
+func namespaceDetailsFromWire(details client.NamespaceWithDetails) db.NamespaceWithDetails {
+	converted := db.NamespaceWithDetails{Namespace: db.Namespace(details.Namespace)}
+	converted.VethPairs, _ = convertRecords(details.VethPairs, nil, vethPairFromWire)
+	converted.IPAddresses, _ = convertRecords(details.IPAddresses, nil, addressFromWire)
+	converted.Routes, _ = convertRecords(details.Routes, nil, routeFromWire)
+	converted.Bridges, _ = convertRecords(details.Bridges, nil, bridgeFromWire)
+	converted.GRETunnels, _ = convertRecords(details.GRETunnels, nil, tunnelFromWire)
+	converted.Bonds, _ = convertRecords(details.Bonds, nil, func(record client.Bond) db.Bond { return db.Bond(record) })
+	converted.Macvlans, _ = convertRecords(details.Macvlans, nil, func(record client.MacvlanLink) db.MacvlanLink { return db.MacvlanLink(record) })
+	converted.Dummies, _ = convertRecords(details.Dummies, nil, func(record client.DummyInterface) db.DummyInterface { return db.DummyInterface(record) })
+	converted.Qdiscs, _ = convertRecords(details.Qdiscs, nil, func(record client.Qdisc) db.Qdisc { return db.Qdisc(record) })
+	return converted
+}
+
+// projectQuotaUsageFromWire converts the quotas of a project
+func projectQuotaUsageFromWire(usage client.ProjectQuotaUsage) service.ProjectQuotaUsage {
+	converted := service.ProjectQuotaUsage{Project: usage.Project}
+	converted.Quotas, _ = convertRecords(usage.Quotas, nil, func(quota client.QuotaUsage) service.QuotaUsage { return service.QuotaUsage(quota) })
+	return converted
+}
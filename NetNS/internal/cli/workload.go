@@ -0,0 +1,393 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/service"
+)
+
+// workloadLogPollInterval is how often "workload logs --follow" checks for new output
+const workloadLogPollInterval = 500 * time.Millisecond
+
+var (
+	workloadNamespace      string
+	workloadName           string
+	workloadRestartPolicy  string
+	workloadMaxRestarts    int
+	workloadRestartDelay   int
+	workloadEnv            []string
+	workloadDir            string
+	workloadMountNamespace bool
+	workloadJSON           bool
+	workloadLogStderr      bool
+	workloadLogLines       int
+	workloadLogFollow      bool
+)
+
+var workloadCmd = &cobra.Command{
+	Use:   "workload",
+	Short: "Run and supervise long-running commands inside namespaces",
+	Long: `Run and supervise long-running commands inside namespaces.
+
+A workload is a command recorded in the database and kept running in its
+namespace by a detached supervisor process, which restarts it according to its
+restart policy. Output goes to stdout.log and stderr.log in
+workloads/<name>/ next to the database. Deleting a namespace stops its
+workloads.
+
+Examples:
+  netns-mgr workload run --ns red --name red-web -- python3 -m http.server 8000
+  netns-mgr workload run --ns red --restart always -- dnsmasq --keep-in-foreground
+  netns-mgr workload list --ns red
+  netns-mgr workload logs red-web -n 50 --follow
+  netns-mgr workload stop red-web`,
+}
+
+var workloadRunCmd = &cobra.Command{
+	Use:   "run --ns <namespace> [--name <name>] -- <command> [args...]",
+	Short: "Record a workload and start it",
+	Long: `Record a workload and start it.
+
+Restart policies: "never", "on-failure" (default: restart after a non-zero
+exit) and "always". The delay between restarts starts at --restart-delay and
+doubles while the command keeps failing quickly, up to a minute.
+
+The command runs with a minimal environment plus --env, in / unless --dir is
+given. The name defaults to <namespace>-<command>.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workloadRecord, err := Backend.RunWorkload(service.RunWorkloadRequest{
+			Name:           workloadRunName(args),
+			Namespace:      workloadNamespace,
+			Command:        args,
+			Env:            workloadEnv,
+			Dir:            workloadDir,
+			RestartPolicy:  workloadRestartPolicy,
+			MaxRestarts:    workloadMaxRestarts,
+			RestartDelay:   workloadRestartDelay,
+			MountNamespace: &workloadMountNamespace,
+		})
+		if err != nil {
+			return err
+		}
+		if workloadJSON {
+			return printJSON(workloadRecord)
+		}
+
+		return reportWorkloadStarted(workloadRecord)
+	},
+}
+
+var workloadListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List workloads",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workloads, err := Backend.ListWorkloads(workloadNamespace)
+		if err != nil {
+			return err
+		}
+		if workloadJSON {
+			return printJSON(workloads)
+		}
+		if len(workloads) == 0 {
+			fmt.Println("No workloads found")
+			return nil
+		}
+
+		namespaceNames := namespaceNamesByID()
+		tableWriter := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tableWriter, "NAME\tNAMESPACE\tSTATUS\tPID\tRESTARTS\tCOMMAND")
+		for _, workloadRecord := range workloads {
+			namespaceName := "-"
+			if workloadRecord.NsID != nil {
+				namespaceName = displayOrDash(namespaceNames[*workloadRecord.NsID])
+			}
+			processID := "-"
+			if workloadRecord.PID > 0 {
+				processID = fmt.Sprint(workloadRecord.PID)
+			}
+			fmt.Fprintf(tableWriter, "%s\t%s\t%s\t%s\t%d\t%s\n",
+				workloadRecord.Name,
+				namespaceName,
+				workloadRecord.Status,
+				processID,
+				workloadRecord.Restarts,
+				strings.Join(workloadRecord.Command, " "),
+			)
+		}
+		return tableWriter.Flush()
+	},
+}
+
+var workloadStatusCmd = &cobra.Command{
+	Use:   "status <name>",
+	Short: "Show a workload's state and last exit",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workloadRecord, err := Backend.GetWorkload(args[0])
+		if err != nil {
+			return err
+		}
+		if workloadJSON {
+			return printJSON(workloadRecord)
+		}
+
+		printWorkload(workloadRecord)
+		return nil
+	},
+}
+
+var workloadStartCmd = &cobra.Command{
+	Use:   "start <name>",
+	Short: "Start a stopped or ended workload",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workloadRecord, err := Backend.StartWorkload(args[0])
+		if err != nil {
+			return err
+		}
+		return reportWorkloadStarted(workloadRecord)
+	},
+}
+
+var workloadStopCmd = &cobra.Command{
+	Use:   "stop <name>",
+	Short: "Stop a workload",
+	Long: `Stop a workload.
+
+The command gets SIGTERM and, if it is still running 10 seconds later, SIGKILL.
+A stopped workload is not restarted until "workload start".`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workloadRecord, err := Backend.StopWorkload(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Stopped workload %s (%s)\n", workloadRecord.Name, workloadRecord.Status)
+		return nil
+	},
+}
+
+var workloadDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Stop a workload and delete it with its logs",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := Backend.DeleteWorkload(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted workload %s\n", args[0])
+		return nil
+	},
+}
+
+var workloadLogsCmd = &cobra.Command{
+	Use:   "logs <name>",
+	Short: "Print the end of a workload's output",
+	Long: `Print the end of a workload's output.
+
+Shows stdout unless --stderr is given. --follow keeps printing new output until
+interrupted; it reads the log file directly, so it only works without --server.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stream := "stdout"
+		if workloadLogStderr {
+			stream = "stderr"
+		}
+		if workloadLogFollow && serverURL != "" {
+			return fmt.Errorf("--follow reads the log files, so it cannot be used with --server")
+		}
+
+		logs, err := Backend.WorkloadLogs(args[0], stream, workloadLogLines)
+		if err != nil {
+			return err
+		}
+		fmt.Print(logs)
+		if !workloadLogFollow {
+			return nil
+		}
+
+		workloadRecord, err := Backend.GetWorkload(args[0])
+		if err != nil {
+			return err
+		}
+		logName := service.WorkloadStdoutLog
+		if workloadLogStderr {
+			logName = service.WorkloadStderrLog
+		}
+		logPath := filepath.Join(workloadRecord.LogDir, logName)
+		var offset int64
+		if logInfo, err := os.Stat(logPath); err == nil {
+			offset = logInfo.Size()
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return followLog(ctx, logPath, offset)
+	},
+}
+
+var workloadSuperviseCmd = &cobra.Command{
+	Use:    "supervise <name>",
+	Short:  "Run a workload and restart it per its policy (started by run and start)",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if Svc == nil {
+			return fmt.Errorf("supervise runs next to the database, so it cannot be used with --server")
+		}
+
+		// SIGTERM from "workload stop" stops the command and ends supervision
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return Svc.SuperviseWorkload(ctx, args[0])
+	},
+}
+
+// workloadRunName returns the name of the workload "workload run" creates
+// Parameters:
+//   - commandArgs: the command and its arguments
+func workloadRunName(commandArgs []string) string {
+	if workloadName != "" {
+		return workloadName
+	}
+	return workloadNamespace + "-" + filepath.Base(commandArgs[0])
+}
+
+// reportWorkloadStarted prints the state of a workload that was just started
+// Returns an error if the command could not start.
+func reportWorkloadStarted(workloadRecord *db.Workload) error {
+	if workloadRecord.Status == db.WorkloadFailed {
+		return fmt.Errorf("workload %s failed to start: %s", workloadRecord.Name, workloadRecord.Error)
+	}
+	fmt.Printf("Started workload %s (%s", workloadRecord.Name, workloadRecord.Status)
+	if workloadRecord.PID > 0 {
+		fmt.Printf(", pid %d", workloadRecord.PID)
+	}
+	fmt.Println(")")
+	return nil
+}
+
+// printWorkload prints a workload's definition and run state
+func printWorkload(workloadRecord *db.Workload) {
+	namespaceName := "-"
+	if workloadRecord.NsID != nil {
+		namespaceName = displayOrDash(namespaceNamesByID()[*workloadRecord.NsID])
+	}
+	maxRestarts := "unlimited"
+	if workloadRecord.MaxRestarts > 0 {
+		maxRestarts = fmt.Sprint(workloadRecord.MaxRestarts)
+	}
+
+	fmt.Printf("Workload:  %s\n", workloadRecord.Name)
+	fmt.Printf("Namespace: %s\n", namespaceName)
+	fmt.Printf("Command:   %s\n", strings.Join(workloadRecord.Command, " "))
+	fmt.Printf("Status:    %s\n", workloadRecord.Status)
+	fmt.Printf("Restart:   %s (max %s, delay %ds)\n", workloadRecord.RestartPolicy, maxRestarts, workloadRecord.RestartDelay)
+	fmt.Printf("Restarts:  %d\n", workloadRecord.Restarts)
+	if workloadRecord.PID > 0 {
+		fmt.Printf("PID:       %d\n", workloadRecord.PID)
+	}
+	if workloadRecord.ExitCode != nil {
+		fmt.Printf("Exit code: %d\n", *workloadRecord.ExitCode)
+	}
+	if workloadRecord.Error != "" {
+		fmt.Printf("Error:     %s\n", workloadRecord.Error)
+	}
+	if workloadRecord.StartedAt != nil {
+		fmt.Printf("Started:   %s\n", workloadRecord.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if workloadRecord.FinishedAt != nil {
+		fmt.Printf("Finished:  %s\n", workloadRecord.FinishedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("Logs:      %s\n", workloadRecord.LogDir)
+}
+
+// followLog prints what is appended to a log file until ctx is cancelled
+// A log that shrinks was rotated when the command restarted, so it is read
+// again from the start.
+// Parameters:
+//   - ctx: cancelling it stops following
+//   - logPath: log file to follow
+//   - offset: bytes of the log already printed
+func followLog(ctx context.Context, logPath string, offset int64) error {
+	ticker := time.NewTicker(workloadLogPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if logInfo, err := os.Stat(logPath); err == nil {
+			if logInfo.Size() < offset {
+				offset = 0
+			}
+			if logInfo.Size() > offset {
+				copied, err := copyLogFrom(logPath, offset)
+				offset += copied
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// copyLogFrom copies a log file to stdout from an offset and returns the bytes copied
+func copyLogFrom(logPath string, offset int64) (int64, error) {
+	logFile, err := os.Open(logPath)
+	if err != nil {
+		return 0, err
+	}
+	defer logFile.Close()
+
+	if _, err := logFile.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(os.Stdout, logFile)
+}
+
+func init() {
+	rootCmd.AddCommand(workloadCmd)
+	workloadCmd.AddCommand(workloadRunCmd)
+	workloadCmd.AddCommand(workloadListCmd)
+	workloadCmd.AddCommand(workloadStatusCmd)
+	workloadCmd.AddCommand(workloadStartCmd)
+	workloadCmd.AddCommand(workloadStopCmd)
+	workloadCmd.AddCommand(workloadDeleteCmd)
+	workloadCmd.AddCommand(workloadLogsCmd)
+	workloadCmd.AddCommand(workloadSuperviseCmd)
+
+	workloadRunCmd.Flags().StringVar(&workloadNamespace, "ns", "", "namespace to run the command in")
+	workloadRunCmd.Flags().StringVar(&workloadName, "name", "", "workload name (default <namespace>-<command>)")
+	workloadRunCmd.Flags().StringVar(&workloadRestartPolicy, "restart", db.RestartOnFailure, "restart policy: never, on-failure or always")
+	workloadRunCmd.Flags().IntVar(&workloadMaxRestarts, "max-restarts", 0, "give up after this many restarts in a row (0 = unlimited)")
+	workloadRunCmd.Flags().IntVar(&workloadRestartDelay, "restart-delay", 1, "seconds before the first restart")
+	workloadRunCmd.Flags().StringArrayVar(&workloadEnv, "env", nil, "set an environment variable KEY=VALUE (repeatable)")
+	workloadRunCmd.Flags().StringVar(&workloadDir, "dir", "", "working directory (default /)")
+	workloadRunCmd.Flags().BoolVar(&workloadMountNamespace, "mount-ns", true, "run in a private mount namespace with /etc/netns/<namespace> overlays and the namespace's /sys")
+	workloadRunCmd.Flags().BoolVar(&workloadJSON, "json", false, "print the workload as JSON")
+	workloadRunCmd.MarkFlagRequired("ns")
+
+	workloadListCmd.Flags().StringVar(&workloadNamespace, "ns", "", "only list workloads of this namespace")
+	workloadListCmd.Flags().BoolVar(&workloadJSON, "json", false, "print workloads as JSON")
+	workloadStatusCmd.Flags().BoolVar(&workloadJSON, "json", false, "print the workload as JSON")
+
+	workloadLogsCmd.Flags().BoolVar(&workloadLogStderr, "stderr", false, "show stderr instead of stdout")
+	workloadLogsCmd.Flags().IntVarP(&workloadLogLines, "lines", "n", 100, "lines from the end to show (-1 = all)")
+	workloadLogsCmd.Flags().BoolVarP(&workloadLogFollow, "follow", "f", false, "keep printing new output until interrupted")
+}
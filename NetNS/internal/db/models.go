@@ -1,50 +1,64 @@
 package db
 
-import "time"
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
 
 // Namespace represents a network namespace
 type Namespace struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	Metadata  string    `json:"metadata,omitempty"`
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	CreatedAt time.Time         `json:"created_at"`
+	ProjectID *int64            `json:"project_id,omitempty"` // Owning project (nil = none)
+	Labels    map[string]string `json:"labels,omitempty"`
 }
 
 // VethPair represents a virtual ethernet pair
 type VethPair struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	PeerName  string    `json:"peer_name"`
-	NsID      *int64    `json:"ns_id,omitempty"`
-	PeerNsID  *int64    `json:"peer_ns_id,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	PeerName  string            `json:"peer_name"`
+	NsID      *int64            `json:"ns_id,omitempty"`
+	PeerNsID  *int64            `json:"peer_ns_id,omitempty"`
+	ProjectID *int64            `json:"project_id,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
 }
 
 // IPAddress represents an IP address assigned to an interface
 type IPAddress struct {
-	ID            int64     `json:"id"`
-	InterfaceName string    `json:"interface_name"`
-	NsID          *int64    `json:"ns_id,omitempty"`
-	Address       string    `json:"address"` // CIDR format
-	CreatedAt     time.Time `json:"created_at"`
+	ID            int64             `json:"id"`
+	InterfaceName string            `json:"interface_name"`
+	NsID          *int64            `json:"ns_id,omitempty"`
+	Address       string            `json:"address"`                 // CIDR format
+	AddressLabel  string            `json:"address_label,omitempty"` // IPv4 address label (empty = interface name)
+	ProjectID     *int64            `json:"project_id,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
 }
 
 // Route represents a network route
 type Route struct {
-	ID            int64     `json:"id"`
-	NsID          *int64    `json:"ns_id,omitempty"`
-	Destination   string    `json:"destination"` // CIDR or "default"
-	Gateway       string    `json:"gateway,omitempty"`
-	InterfaceName string    `json:"interface_name,omitempty"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID            int64             `json:"id"`
+	NsID          *int64            `json:"ns_id,omitempty"`
+	Destination   string            `json:"destination"` // CIDR or "default"
+	Gateway       string            `json:"gateway,omitempty"`
+	InterfaceName string            `json:"interface_name,omitempty"`
+	ProjectID     *int64            `json:"project_id,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
 }
 
 // Bridge represents a network bridge
 type Bridge struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	NsID      *int64    `json:"ns_id,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	NsID      *int64            `json:"ns_id,omitempty"`
+	ProjectID *int64            `json:"project_id,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
 }
 
 // BridgePort represents a port attached to a bridge
@@ -57,22 +71,373 @@ type BridgePort struct {
 
 // GRETunnel represents a GRE tunnel configuration
 type GRETunnel struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`      // Tunnel interface name (e.g., gre1)
+	LocalIP   string            `json:"local_ip"`  // Local endpoint IP address
+	RemoteIP  string            `json:"remote_ip"` // Remote endpoint IP address
+	Key       uint32            `json:"key"`       // GRE key for multiplexing (0 = no key)
+	TTL       uint8             `json:"ttl"`       // Time to live (0 = inherit)
+	NsID      *int64            `json:"ns_id"`     // Namespace where tunnel is created
+	ProjectID *int64            `json:"project_id,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// MacvlanLink represents a macvlan or ipvlan interface attached to a parent
+type MacvlanLink struct {
+	ID         int64             `json:"id"`
+	Name       string            `json:"name"`
+	Kind       string            `json:"kind"` // "macvlan" or "ipvlan"
+	Mode       string            `json:"mode"` // bridge/vepa/private/passthru or l2/l3/l3s
+	Parent     string            `json:"parent"`
+	ParentNsID *int64            `json:"parent_ns_id,omitempty"` // Namespace where parent exists (nil = host)
+	NsID       *int64            `json:"ns_id,omitempty"`        // Namespace the link was moved into (nil = host)
+	Labels     map[string]string `json:"labels,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// Bond represents a bonded interface aggregating several slaves
+type Bond struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	Mode      string            `json:"mode"`              // active-backup, balance-rr, 802.3ad, ...
+	Miimon    int               `json:"miimon"`            // MII monitoring interval in ms (0 = disabled)
+	Primary   string            `json:"primary,omitempty"` // Preferred slave (active-backup)
+	NsID      *int64            `json:"ns_id,omitempty"`
+	Slaves    []string          `json:"slaves,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// BondSlave represents an interface enslaved to a bond
+type BondSlave struct {
+	ID            int64     `json:"id"`
+	BondID        int64     `json:"bond_id"`
+	InterfaceName string    `json:"interface_name"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// DummyInterface represents a dummy (loopback-style) interface
+type DummyInterface struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	NsID      *int64            `json:"ns_id,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Qdisc represents traffic impairment (netem, optionally under tbf/htb) applied to an interface
+type Qdisc struct {
+	ID               int64     `json:"id"`
+	InterfaceName    string    `json:"interface_name"`
+	NsID             *int64    `json:"ns_id,omitempty"`
+	Shaper           string    `json:"shaper,omitempty"`
+	DelayMs          float64   `json:"delay_ms"`
+	JitterMs         float64   `json:"jitter_ms"`
+	LossPercent      float64   `json:"loss_percent"`
+	ReorderPercent   float64   `json:"reorder_percent"`
+	DuplicatePercent float64   `json:"duplicate_percent"`
+	CorruptPercent   float64   `json:"corrupt_percent"`
+	RateKbit         uint64    `json:"rate_kbit"`
+	CeilKbit         uint64    `json:"ceil_kbit"`
+	BurstBytes       uint32    `json:"burst_bytes"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// LinkProperty represents persisted link properties of an interface
+// Zero values mean the property is not managed.
+type LinkProperty struct {
+	ID            int64     `json:"id"`
+	InterfaceName string    `json:"interface_name"`
+	NsID          *int64    `json:"ns_id,omitempty"`
+	MTU           int       `json:"mtu,omitempty"`
+	MACAddress    string    `json:"mac_address,omitempty"`
+	TxQueueLen    int       `json:"txqueuelen,omitempty"`
+	Alias         string    `json:"alias,omitempty"`
+	State         string    `json:"state,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ManagedInterface identifies an interface created and recorded by netns-mgr
+type ManagedInterface struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"` // Empty = host
+	Kind      string `json:"kind"`
+}
+
+// Audit results
+const (
+	AuditResultSuccess = "success"
+	AuditResultError   = "error"
+)
+
+// AuditResourceTypes maps CLI command names to the API route collections the
+// audit log uses as resource types, so CLI and API entries of a resource match
+var AuditResourceTypes = map[string]string{
+	"ns":       "namespaces",
+	"veth":     "veths",
+	"ip":       "addresses",
+	"route":    "routes",
+	"bridge":   "bridges",
+	"macvlan":  "macvlans",
+	"ipvlan":   "macvlans",
+	"bond":     "bonds",
+	"dummy":    "dummies",
+	"link":     "interfaces",
+	"job":      "jobs",
+	"workload": "workloads",
+	"quota":    "quotas",
+	"project":  "projects",
+	"token":    "tokens",
+}
+
+// AuditResourceType returns the audit resource type of a CLI command name
+// Names that are already resource types (e.g. "gre", "namespaces") are returned as is.
+func AuditResourceType(name string) string {
+	if resourceType, known := AuditResourceTypes[name]; known {
+		return resourceType
+	}
+	return name
+}
+
+// AuditRedactedFields are request fields and CLI flags whose values may hold secrets
+// (command environments and input) and are redacted in the audit log
+var AuditRedactedFields = []string{"env", "stdin"}
+
+// AuditRedactedValue replaces a secret in the audit log
+const AuditRedactedValue = "[redacted]"
+
+// RedactEnvironment returns KEY=value entries with their values redacted, keeping the names
+func RedactEnvironment(environment []string) []string {
+	redacted := make([]string, len(environment))
+	for index, entry := range environment {
+		if name, _, found := strings.Cut(entry, "="); found {
+			redacted[index] = name + "=" + AuditRedactedValue
+		} else {
+			redacted[index] = AuditRedactedValue
+		}
+	}
+	return redacted
+}
+
+// AuditEntry records a mutating operation performed through the CLI or API
+type AuditEntry struct {
+	ID           int64     `json:"id"`
+	Timestamp    time.Time `json:"timestamp"`
+	Actor        string    `json:"actor"`  // CLI user or API principal
+	Source       string    `json:"source"` // "cli" or "api"
+	Operation    string    `json:"operation"`
+	ResourceType string    `json:"resource_type,omitempty"`
+	ResourceName string    `json:"resource_name,omitempty"`
+	Namespace    string    `json:"namespace,omitempty"`
+	Payload      string    `json:"payload,omitempty"`
+	Result       string    `json:"result"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// AuditFilter selects audit entries; zero values match everything
+type AuditFilter struct {
+	Actor        string
+	Source       string
+	Operation    string
+	ResourceType string
+	ResourceName string
+	Namespace    string
+	Result       string
+	Since        time.Time
+	Until        time.Time
+	Limit        int
+	Offset       int
+}
+
+// APIToken is a bearer token accepted by the API server
+// Only the SHA-256 hash of the secret is stored.
+type APIToken struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"` // Principal recorded in the audit log
+	Role       string     `json:"role"` // viewer, operator or admin
+	TokenHash  string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ProjectID  *int64     `json:"project_id,omitempty"` // Project the token is confined to (nil = all)
+	Project    string     `json:"project,omitempty"`    // Name of that project
+}
+
+// Project is a tenant owning namespaces and the resources inside them
+// Kernel object names created for the project start with "<prefix>-".
+type Project struct {
 	ID        int64     `json:"id"`
-	Name      string    `json:"name"`       // Tunnel interface name (e.g., gre1)
-	LocalIP   string    `json:"local_ip"`   // Local endpoint IP address
-	RemoteIP  string    `json:"remote_ip"`  // Remote endpoint IP address
-	Key       uint32    `json:"key"`        // GRE key for multiplexing (0 = no key)
-	TTL       uint8     `json:"ttl"`        // Time to live (0 = inherit)
-	NsID      *int64    `json:"ns_id"`      // Namespace where tunnel is created
+	Name      string    `json:"name"`
+	Prefix    string    `json:"prefix"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// Resource types that carry labels; they match the REST API collection names
+const (
+	LabelNamespaces = "namespaces"
+	LabelVeths      = "veths"
+	LabelAddresses  = "addresses"
+	LabelRoutes     = "routes"
+	LabelBridges    = "bridges"
+	LabelGRETunnels = "gre"
+	LabelMacvlans   = "macvlans"
+	LabelBonds      = "bonds"
+	LabelDummies    = "dummies"
+)
+
+// labelTables maps labelled resource types to the tables holding their records
+var labelTables = map[string]string{
+	LabelNamespaces: "namespaces",
+	LabelVeths:      "veth_pairs",
+	LabelAddresses:  "ip_addresses",
+	LabelRoutes:     "routes",
+	LabelBridges:    "bridges",
+	LabelGRETunnels: "gre_tunnels",
+	LabelMacvlans:   "macvlan_links",
+	LabelBonds:      "bonds",
+	LabelDummies:    "dummy_interfaces",
+}
+
+// IsLabelResource reports whether a resource type carries labels
+func IsLabelResource(resourceType string) bool {
+	_, labelled := labelTables[resourceType]
+	return labelled
+}
+
+// Resources limited by project quotas
+const (
+	QuotaNamespaces = "namespaces"
+	QuotaVeths      = "veths"
+	QuotaBridges    = "bridges"
+	QuotaGRETunnels = "gre_tunnels"
+	QuotaAddresses  = "addresses"
+)
+
+// QuotaResources lists the resources limited by project quotas, in display order
+var QuotaResources = []string{QuotaNamespaces, QuotaVeths, QuotaBridges, QuotaGRETunnels, QuotaAddresses}
+
+// ProjectQuota caps how many resources of one kind a project may own
+// Resources without a quota are unlimited.
+type ProjectQuota struct {
+	ProjectID int64  `json:"project_id"`
+	Resource  string `json:"resource"` // One of QuotaResources
+	Limit     int    `json:"limit"`
+}
+
+// Job statuses
+const (
+	JobQueued      = "queued"
+	JobRunning     = "running"
+	JobSucceeded   = "succeeded"
+	JobFailed      = "failed"
+	JobCancelled   = "cancelled"
+	JobInterrupted = "interrupted" // Queued or running when the server stopped
+)
+
+// Job is an operation the API server runs in the background
+type Job struct {
+	ID         int64           `json:"id"`
+	Operation  string          `json:"operation"` // e.g. "create gre peer"
+	Status     string          `json:"status"`
+	Actor      string          `json:"actor"`                // API principal that submitted the job
+	ProjectID  *int64          `json:"project_id,omitempty"` // Project of the submitting token (nil = none)
+	StepsDone  int             `json:"steps_done"`
+	Result     json.RawMessage `json:"result,omitempty"` // Success response of the operation
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Logs       []JobLog        `json:"logs,omitempty"` // Only filled by GetJob
+}
+
+// IsFinished reports whether the job has reached a final status
+func (job *Job) IsFinished() bool {
+	return job.Status != JobQueued && job.Status != JobRunning
+}
+
+// JobLog is one step logged by a running job
+type JobLog struct {
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+}
+
+// Workload statuses
+const (
+	WorkloadStarting   = "starting" // Supervisor launched, command not started yet
+	WorkloadRunning    = "running"
+	WorkloadRestarting = "restarting" // Waiting to restart the command after it ended
+	WorkloadStopped    = "stopped"    // Stopped on request
+	WorkloadExited     = "exited"     // Ended successfully and not restarted
+	WorkloadFailed     = "failed"     // Failed to start, or ended with an error and not restarted
+	WorkloadLost       = "lost"       // Was active but its supervisor died without recording an end (e.g. a reboot)
+)
+
+// Workload restart policies
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+// Workload is a command kept running inside a namespace by a supervisor process
+type Workload struct {
+	ID             int64      `json:"id"`
+	Name           string     `json:"name"`
+	NsID           *int64     `json:"ns_id"`
+	Command        []string   `json:"command"`
+	Env            []string   `json:"env,omitempty"` // KEY=value entries added to a minimal environment
+	Dir            string     `json:"dir,omitempty"` // Working directory (empty = /)
+	MountNamespace bool       `json:"mount_namespace"`
+	RestartPolicy  string     `json:"restart_policy"` // RestartNever, RestartOnFailure or RestartAlways
+	MaxRestarts    int        `json:"max_restarts"`   // 0 = unlimited
+	RestartDelay   int        `json:"restart_delay"`  // Seconds before a restart; doubles while the command keeps failing quickly
+	LogDir         string     `json:"log_dir"`        // Holds stdout.log and stderr.log
+	Status         string     `json:"status"`
+	SupervisorPID  int        `json:"supervisor_pid,omitempty"`
+	PID            int        `json:"pid,omitempty"`       // Of the running command
+	Restarts       int        `json:"restarts"`            // Since the last start
+	ExitCode       *int       `json:"exit_code,omitempty"` // Of the last run (-1 = ended by a signal)
+	Error          string     `json:"error,omitempty"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// WorkloadProcess identifies a process of a workload
+// The start time (in clock ticks since boot, from /proc/<pid>/stat) tells
+// the recorded process apart from a later one that reused its PID.
+type WorkloadProcess struct {
+	PID       int    // 0 = none
+	StartTime uint64 // 0 = unknown
+}
+
+// IsActive reports whether the workload's supervisor is meant to be running
+func (workload *Workload) IsActive() bool {
+	return workload.Status == WorkloadStarting || workload.Status == WorkloadRunning || workload.Status == WorkloadRestarting
+}
+
+// IdempotencyKeyTTL is how long the response to an Idempotency-Key is kept for replay
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotentResponse is the stored response to a request sent with an Idempotency-Key
+type IdempotentResponse struct {
+	RequestHash string            // Hash of the method, URL and body of the first request
+	StatusCode  int               // 0 while the first request is in progress
+	Headers     map[string]string // Response headers replayed with the body
+	Body        []byte
+	CreatedAt   time.Time
+}
+
 // NamespaceWithDetails includes related resources
 type NamespaceWithDetails struct {
 	Namespace
-	VethPairs   []VethPair  `json:"veth_pairs,omitempty"`
-	IPAddresses []IPAddress `json:"ip_addresses,omitempty"`
-	Routes      []Route     `json:"routes,omitempty"`
-	Bridges     []Bridge    `json:"bridges,omitempty"`
-	GRETunnels  []GRETunnel `json:"gre_tunnels,omitempty"`
+	VethPairs   []VethPair       `json:"veth_pairs,omitempty"`
+	IPAddresses []IPAddress      `json:"ip_addresses,omitempty"`
+	Routes      []Route          `json:"routes,omitempty"`
+	Bridges     []Bridge         `json:"bridges,omitempty"`
+	GRETunnels  []GRETunnel      `json:"gre_tunnels,omitempty"`
+	Bonds       []Bond           `json:"bonds,omitempty"`
+	Macvlans    []MacvlanLink    `json:"macvlans,omitempty"`
+	Dummies     []DummyInterface `json:"dummies,omitempty"`
+	Qdiscs      []Qdisc          `json:"qdiscs,omitempty"`
 }
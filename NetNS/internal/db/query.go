@@ -0,0 +1,436 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxListLimit is the largest page a list query returns
+const MaxListLimit = 1000
+
+// createdAtLayout is the layout SQLite's CURRENT_TIMESTAMP stores created_at in (UTC)
+const createdAtLayout = "2006-01-02 15:04:05"
+
+// ListQuery narrows, sorts and pages the records returned by the Query* operations
+// Every filter is optional; the zero value returns every record in the default order.
+type ListQuery struct {
+	NamespaceID   *int64             // Only records in this namespace (veths: at either end)
+	ProjectID     *int64             // Only records owned by this project
+	Interface     string             // Only records on this interface (veths: either end, GRE tunnels: this tunnel)
+	Within        *net.IPNet         // Only addresses, route destinations or tunnel endpoints inside this network
+	CreatedAfter  time.Time          // Only records created at or after this time
+	CreatedBefore time.Time          // Only records created before this time
+	Labels        []LabelRequirement // Only records whose labels match every requirement
+	Sort          string             // Sort field, "-" prefix for descending (empty = the resource's default)
+	Limit         int                // Page size (0 = no limit)
+	Cursor        string             // Cursor of the page to return, from the previous page
+}
+
+// LabelRequirement is one term of a label selector
+type LabelRequirement struct {
+	Key     string
+	Value   string
+	Exists  bool // Tests presence only ("key" and "!key")
+	Negated bool // "!=" and "!key"
+}
+
+// InvalidQueryError reports a list query the resource cannot run
+type InvalidQueryError struct {
+	Message string
+}
+
+func (e *InvalidQueryError) Error() string {
+	return e.Message
+}
+
+// invalidQueryf builds an InvalidQueryError
+func invalidQueryf(format string, args ...any) error {
+	return &InvalidQueryError{Message: fmt.Sprintf(format, args...)}
+}
+
+// listCursor is the decoded position a page starts after
+type listCursor struct {
+	Sort  string `json:"sort"`
+	Value string `json:"value"` // Sort column of the last record, as text
+	ID    int64  `json:"id"`    // ID of the last record; breaks ties
+}
+
+// encodeListCursor makes the opaque cursor of the page after a record
+func encodeListCursor(cursor listCursor) string {
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeListCursor reads a cursor made by encodeListCursor
+func decodeListCursor(encoded string) (listCursor, error) {
+	var cursor listCursor
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err == nil {
+		err = json.Unmarshal(decoded, &cursor)
+	}
+	if err != nil {
+		return cursor, invalidQueryf("invalid cursor %q", encoded)
+	}
+	return cursor, nil
+}
+
+// listTable describes how a list query filters and sorts one table
+type listTable struct {
+	resourceType     string            // Label resource type of the records
+	table            string            // Table holding the records
+	columns          string            // Selected columns, in the order the scan function reads them
+	sortColumns      map[string]string // Sort field -> column
+	defaultSort      string            // Sort field used when the query has none
+	namespaceColumns []string          // Columns matched by NamespaceID
+	interfaceColumns []string          // Columns matched by Interface
+	addressColumns   []string          // Columns holding one IP address, matched by Within
+	networkColumns   []string          // Columns holding a network (CIDR), matched by Within
+}
+
+// SortFields returns the fields the records of a resource type sort by, in alphabetical order
+func SortFields(resourceType string) []string {
+	var fields []string
+	if table, ok := listTables[resourceType]; ok {
+		for field := range table.sortColumns {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// listTables describes the tables of the resources with Query* operations
+var listTables = map[string]listTable{
+	LabelNamespaces: {
+		resourceType: LabelNamespaces,
+		table:        "namespaces",
+		columns:      "id, name, created_at, project_id",
+		sortColumns:  map[string]string{"name": "name", "created_at": "created_at", "id": "id"},
+		defaultSort:  "name",
+	},
+	LabelVeths: {
+		resourceType:     LabelVeths,
+		table:            "veth_pairs",
+		columns:          "id, name, peer_name, ns_id, peer_ns_id, project_id, created_at",
+		sortColumns:      map[string]string{"name": "name", "created_at": "created_at", "id": "id"},
+		defaultSort:      "name",
+		namespaceColumns: []string{"ns_id", "peer_ns_id"},
+		interfaceColumns: []string{"name", "peer_name"},
+	},
+	LabelAddresses: {
+		resourceType:     LabelAddresses,
+		table:            "ip_addresses",
+		columns:          "id, interface_name, ns_id, address, address_label, project_id, created_at",
+		sortColumns:      map[string]string{"interface": "interface_name", "address": "address", "created_at": "created_at", "id": "id"},
+		defaultSort:      "interface",
+		namespaceColumns: []string{"ns_id"},
+		interfaceColumns: []string{"interface_name"},
+		addressColumns:   []string{"address"},
+	},
+	LabelRoutes: {
+		resourceType:     LabelRoutes,
+		table:            "routes",
+		columns:          "id, ns_id, destination, COALESCE(gateway, ''), COALESCE(interface_name, ''), project_id, created_at",
+		sortColumns:      map[string]string{"destination": "destination", "created_at": "created_at", "id": "id"},
+		defaultSort:      "destination",
+		namespaceColumns: []string{"ns_id"},
+		interfaceColumns: []string{"interface_name"},
+		networkColumns:   []string{"destination"},
+	},
+	LabelGRETunnels: {
+		resourceType:     LabelGRETunnels,
+		table:            "gre_tunnels",
+		columns:          "id, name, local_ip, remote_ip, gre_key, ttl, ns_id, project_id, created_at",
+		sortColumns:      map[string]string{"name": "name", "created_at": "created_at", "id": "id"},
+		defaultSort:      "name",
+		namespaceColumns: []string{"ns_id"},
+		interfaceColumns: []string{"name"},
+		addressColumns:   []string{"local_ip", "remote_ip"},
+	},
+}
+
+// anyColumnEquals builds "(a = ? OR b = ?)" for columns, appending the value once per column
+func anyColumnEquals(columns []string, value any, args *[]any) string {
+	terms := make([]string, len(columns))
+	for columnIndex, column := range columns {
+		terms[columnIndex] = column + " = ?"
+		*args = append(*args, value)
+	}
+	return "(" + strings.Join(terms, " OR ") + ")"
+}
+
+// buildListQuery builds the SELECT of one page of a list query
+// The first two selected columns are the record ID and the sort column as text;
+// the table's columns follow. One row more than the limit is selected to tell
+// whether a next page exists.
+// Parameters:
+//   - table: table to query
+//   - query: filters, sort and page of the query
+//
+// Returns the SQL, its arguments and the sort the page's cursors are made for.
+func buildListQuery(table listTable, query ListQuery) (string, []any, string, error) {
+	sortField := strings.TrimPrefix(query.Sort, "-")
+	descending := strings.HasPrefix(query.Sort, "-")
+	if sortField == "" {
+		sortField = table.defaultSort
+	}
+	sortColumn, ok := table.sortColumns[sortField]
+	if !ok {
+		return "", nil, "", invalidQueryf("cannot sort %s by %q (sort fields: %s)", table.resourceType, sortField, strings.Join(SortFields(table.resourceType), ", "))
+	}
+	if query.Limit < 0 || query.Limit > MaxListLimit {
+		return "", nil, "", invalidQueryf("limit must be between 0 (no limit) and %d", MaxListLimit)
+	}
+
+	var conditions []string
+	var args []any
+	if query.NamespaceID != nil {
+		if len(table.namespaceColumns) == 0 {
+			return "", nil, "", invalidQueryf("%s cannot be filtered by namespace", table.resourceType)
+		}
+		conditions = append(conditions, anyColumnEquals(table.namespaceColumns, *query.NamespaceID, &args))
+	}
+	if query.ProjectID != nil {
+		conditions = append(conditions, "project_id = ?")
+		args = append(args, *query.ProjectID)
+	}
+	if query.Interface != "" {
+		if len(table.interfaceColumns) == 0 {
+			return "", nil, "", invalidQueryf("%s cannot be filtered by interface", table.resourceType)
+		}
+		conditions = append(conditions, anyColumnEquals(table.interfaceColumns, query.Interface, &args))
+	}
+	if query.Within != nil {
+		var terms []string
+		for _, column := range table.addressColumns {
+			terms = append(terms, "ip_within("+column+", ?)")
+			args = append(args, query.Within.String())
+		}
+		for _, column := range table.networkColumns {
+			terms = append(terms, "cidr_within("+column+", ?)")
+			args = append(args, query.Within.String())
+		}
+		if len(terms) == 0 {
+			return "", nil, "", invalidQueryf("%s cannot be filtered by network", table.resourceType)
+		}
+		conditions = append(conditions, "("+strings.Join(terms, " OR ")+")")
+	}
+	if !query.CreatedAfter.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, query.CreatedAfter.UTC().Format(createdAtLayout))
+	}
+	if !query.CreatedBefore.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, query.CreatedBefore.UTC().Format(createdAtLayout))
+	}
+	for _, requirement := range query.Labels {
+		labelCondition := "SELECT 1 FROM labels WHERE labels.resource_type = ? AND labels.resource_id = " + table.table + ".id AND labels.key = ?"
+		args = append(args, table.resourceType, requirement.Key)
+		if !requirement.Exists {
+			labelCondition += " AND labels.value = ?"
+			args = append(args, requirement.Value)
+		}
+		if requirement.Negated {
+			conditions = append(conditions, "NOT EXISTS ("+labelCondition+")")
+		} else {
+			conditions = append(conditions, "EXISTS ("+labelCondition+")")
+		}
+	}
+
+	// Keyset pagination: continue after the (sort column, id) of the previous page's last record
+	comparison, direction, resolvedSort := ">", "ASC", sortField
+	if descending {
+		comparison, direction, resolvedSort = "<", "DESC", "-"+sortField
+	}
+	if query.Cursor != "" {
+		cursor, err := decodeListCursor(query.Cursor)
+		if err != nil {
+			return "", nil, "", err
+		}
+		if cursor.Sort != resolvedSort {
+			return "", nil, "", invalidQueryf("cursor was made for sort %q, not %q", cursor.Sort, resolvedSort)
+		}
+		if sortColumn == "id" {
+			conditions = append(conditions, "id "+comparison+" ?")
+			args = append(args, cursor.ID)
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", sortColumn, comparison))
+			args = append(args, cursor.Value, cursor.Value, cursor.ID)
+		}
+	}
+
+	statement := fmt.Sprintf("SELECT id, CAST(%s AS TEXT), %s FROM %s", sortColumn, table.columns, table.table)
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	if sortColumn == "id" {
+		statement += " ORDER BY id " + direction
+	} else {
+		statement += fmt.Sprintf(" ORDER BY %s %s, id %s", sortColumn, direction, direction)
+	}
+	if query.Limit > 0 {
+		statement += " LIMIT ?"
+		args = append(args, query.Limit+1)
+	}
+	return statement, args, resolvedSort, nil
+}
+
+// queryRecords runs a list query and labels the records of the page
+// Parameters:
+//   - resourceType: label resource type of the records
+//   - query: filters, sort and page of the query
+//   - scanTarget: returns the fields of a record, in the order of the table's columns
+//   - labelTarget: returns the labels field of a record
+//
+// Returns the page and the cursor of the next one (empty = last page).
+func queryRecords[Record any](r *Repository, resourceType string, query ListQuery, scanTarget func(*Record) []any, labelTarget func(*Record) *map[string]string) ([]Record, string, error) {
+	statement, args, sort, err := buildListQuery(listTables[resourceType], query)
+	if err != nil {
+		return nil, "", err
+	}
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	records := make([]Record, 0) // Encodes as [] when nothing matches
+	var cursors []listCursor
+	var recordIDs []any
+	for rows.Next() {
+		var record Record
+		var position listCursor
+		if err := rows.Scan(append([]any{&position.ID, &position.Value}, scanTarget(&record)...)...); err != nil {
+			return nil, "", err
+		}
+		position.Sort = sort
+		records = append(records, record)
+		cursors = append(cursors, position)
+		recordIDs = append(recordIDs, position.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if query.Limit > 0 && len(records) > query.Limit {
+		records, recordIDs = records[:query.Limit], recordIDs[:query.Limit]
+		nextCursor = encodeListCursor(cursors[query.Limit-1])
+	}
+	if len(records) == 0 {
+		return records, "", nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(recordIDs)), ", ")
+	labelsByResource, err := r.queryLabels(
+		"SELECT resource_id, key, value FROM labels WHERE resource_type = ? AND resource_id IN ("+placeholders+")",
+		append([]any{resourceType}, recordIDs...)...,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	for recordIndex := range records {
+		*labelTarget(&records[recordIndex]) = labelsByResource[cursors[recordIndex].ID]
+	}
+	return records, nextCursor, nil
+}
+
+// ipWithin is the SQL function ip_within(address, network)
+// It reports whether the IP of an address (with or without a prefix length) lies inside a network.
+func ipWithin(address, network string) bool {
+	_, parentNetwork, err := net.ParseCIDR(network)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		if ip, _, err = net.ParseCIDR(address); err != nil {
+			return false
+		}
+	}
+	return parentNetwork.Contains(ip)
+}
+
+// cidrWithin is the SQL function cidr_within(destination, network)
+// It reports whether a route destination (a CIDR, an IP or "default") lies entirely inside a network.
+func cidrWithin(destination, network string) bool {
+	_, parentNetwork, err := net.ParseCIDR(network)
+	if err != nil {
+		return false
+	}
+	parentPrefixLength, parentBits := parentNetwork.Mask.Size()
+
+	if destination == "default" {
+		return parentPrefixLength == 0
+	}
+	_, destinationNetwork, err := net.ParseCIDR(destination)
+	if err != nil {
+		ip := net.ParseIP(destination)
+		if ip == nil {
+			return false
+		}
+		return parentNetwork.Contains(ip)
+	}
+	prefixLength, bits := destinationNetwork.Mask.Size()
+	return bits == parentBits && prefixLength >= parentPrefixLength && parentNetwork.Contains(destinationNetwork.IP)
+}
+
+// === Paged List Operations ===
+
+// QueryNamespaces returns one page of the namespaces matching a list query
+// Returns the cursor of the next page (empty = last page).
+func (r *Repository) QueryNamespaces(query ListQuery) ([]Namespace, string, error) {
+	return queryRecords(r, LabelNamespaces, query,
+		func(ns *Namespace) []any { return []any{&ns.ID, &ns.Name, &ns.CreatedAt, &ns.ProjectID} },
+		func(ns *Namespace) *map[string]string { return &ns.Labels },
+	)
+}
+
+// QueryVethPairs returns one page of the veth pairs matching a list query
+// Returns the cursor of the next page (empty = last page).
+func (r *Repository) QueryVethPairs(query ListQuery) ([]VethPair, string, error) {
+	return queryRecords(r, LabelVeths, query,
+		func(v *VethPair) []any {
+			return []any{&v.ID, &v.Name, &v.PeerName, &v.NsID, &v.PeerNsID, &v.ProjectID, &v.CreatedAt}
+		},
+		func(v *VethPair) *map[string]string { return &v.Labels },
+	)
+}
+
+// QueryIPAddresses returns one page of the IP addresses matching a list query
+// Returns the cursor of the next page (empty = last page).
+func (r *Repository) QueryIPAddresses(query ListQuery) ([]IPAddress, string, error) {
+	return queryRecords(r, LabelAddresses, query,
+		func(ip *IPAddress) []any {
+			return []any{&ip.ID, &ip.InterfaceName, &ip.NsID, &ip.Address, &ip.AddressLabel, &ip.ProjectID, &ip.CreatedAt}
+		},
+		func(ip *IPAddress) *map[string]string { return &ip.Labels },
+	)
+}
+
+// QueryRoutes returns one page of the routes matching a list query
+// Returns the cursor of the next page (empty = last page).
+func (r *Repository) QueryRoutes(query ListQuery) ([]Route, string, error) {
+	return queryRecords(r, LabelRoutes, query,
+		func(rt *Route) []any {
+			return []any{&rt.ID, &rt.NsID, &rt.Destination, &rt.Gateway, &rt.InterfaceName, &rt.ProjectID, &rt.CreatedAt}
+		},
+		func(rt *Route) *map[string]string { return &rt.Labels },
+	)
+}
+
+// QueryGRETunnels returns one page of the GRE tunnels matching a list query
+// Returns the cursor of the next page (empty = last page).
+func (r *Repository) QueryGRETunnels(query ListQuery) ([]GRETunnel, string, error) {
+	return queryRecords(r, LabelGRETunnels, query,
+		func(t *GRETunnel) []any {
+			return []any{&t.ID, &t.Name, &t.LocalIP, &t.RemoteIP, &t.Key, &t.TTL, &t.NsID, &t.ProjectID, &t.CreatedAt}
+		},
+		func(t *GRETunnel) *map[string]string { return &t.Labels },
+	)
+}
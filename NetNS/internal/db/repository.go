@@ -2,26 +2,99 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 )
 
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 // Repository handles database operations
 type Repository struct {
-	db *DB
+	db   querier
+	conn *DB // nil when the repository is bound to a transaction
 }
 
 // NewRepository creates a new repository
 func NewRepository(db *DB) *Repository {
-	return &Repository{db: db}
+	return &Repository{db: db, conn: db}
+}
+
+// DatabasePath returns the file of the database (empty for a repository bound to a transaction)
+func (r *Repository) DatabasePath() string {
+	if r.conn == nil {
+		return ""
+	}
+	return r.conn.Path()
+}
+
+// WithTx runs fn with a repository bound to a single SQL transaction
+// The transaction is committed if fn returns nil and rolled back otherwise.
+// Calls on a repository that is already bound to a transaction join it.
+// Parameters:
+//   - fn: function performing the database changes through txRepository
+func (r *Repository) WithTx(fn func(txRepository *Repository) error) error {
+	if r.conn == nil {
+		return fn(r)
+	}
+
+	tx, err := r.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Repository{db: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// NamespaceIDByName returns the ID of a managed namespace
+// Returns nil for the host namespace ("") and for namespaces that are not recorded.
+func (r *Repository) NamespaceIDByName(name string) (*int64, error) {
+	if name == "" {
+		return nil, nil
+	}
+	ns, err := r.GetNamespaceByName(name)
+	if err != nil || ns == nil {
+		return nil, err
+	}
+	return &ns.ID, nil
+}
+
+// NamespaceNameByID returns the name of a managed namespace
+// Returns "" for the host namespace (nil ID).
+func (r *Repository) NamespaceNameByID(id *int64) (string, error) {
+	if id == nil {
+		return "", nil
+	}
+	ns, err := r.GetNamespace(*id)
+	if err != nil {
+		return "", err
+	}
+	if ns == nil {
+		return "", fmt.Errorf("namespace %d not found", *id)
+	}
+	return ns.Name, nil
 }
 
 // === Namespace Operations ===
 
 // CreateNamespace creates a new namespace record
-func (r *Repository) CreateNamespace(name, metadata string) (*Namespace, error) {
+func (r *Repository) CreateNamespace(name string, projectID *int64) (*Namespace, error) {
 	result, err := r.db.Exec(
-		"INSERT INTO namespaces (name, metadata) VALUES (?, ?)",
-		name, metadata,
+		"INSERT INTO namespaces (name, project_id) VALUES (?, ?)",
+		name, projectID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create namespace: %w", err)
@@ -35,9 +108,9 @@ func (r *Repository) CreateNamespace(name, metadata string) (*Namespace, error)
 func (r *Repository) GetNamespace(id int64) (*Namespace, error) {
 	ns := &Namespace{}
 	err := r.db.QueryRow(
-		"SELECT id, name, created_at, COALESCE(metadata, '') FROM namespaces WHERE id = ?",
+		"SELECT id, name, created_at, project_id FROM namespaces WHERE id = ?",
 		id,
-	).Scan(&ns.ID, &ns.Name, &ns.CreatedAt, &ns.Metadata)
+	).Scan(&ns.ID, &ns.Name, &ns.CreatedAt, &ns.ProjectID)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -51,9 +124,9 @@ func (r *Repository) GetNamespace(id int64) (*Namespace, error) {
 func (r *Repository) GetNamespaceByName(name string) (*Namespace, error) {
 	ns := &Namespace{}
 	err := r.db.QueryRow(
-		"SELECT id, name, created_at, COALESCE(metadata, '') FROM namespaces WHERE name = ?",
+		"SELECT id, name, created_at, project_id FROM namespaces WHERE name = ?",
 		name,
-	).Scan(&ns.ID, &ns.Name, &ns.CreatedAt, &ns.Metadata)
+	).Scan(&ns.ID, &ns.Name, &ns.CreatedAt, &ns.ProjectID)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -65,7 +138,7 @@ func (r *Repository) GetNamespaceByName(name string) (*Namespace, error) {
 
 // ListNamespaces returns all namespaces
 func (r *Repository) ListNamespaces() ([]Namespace, error) {
-	rows, err := r.db.Query("SELECT id, name, created_at, COALESCE(metadata, '') FROM namespaces ORDER BY name")
+	rows, err := r.db.Query("SELECT id, name, created_at, project_id FROM namespaces ORDER BY name")
 	if err != nil {
 		return nil, err
 	}
@@ -74,7 +147,7 @@ func (r *Repository) ListNamespaces() ([]Namespace, error) {
 	var namespaces []Namespace
 	for rows.Next() {
 		var ns Namespace
-		if err := rows.Scan(&ns.ID, &ns.Name, &ns.CreatedAt, &ns.Metadata); err != nil {
+		if err := rows.Scan(&ns.ID, &ns.Name, &ns.CreatedAt, &ns.ProjectID); err != nil {
 			return nil, err
 		}
 		namespaces = append(namespaces, ns)
@@ -82,6 +155,69 @@ func (r *Repository) ListNamespaces() ([]Namespace, error) {
 	return namespaces, rows.Err()
 }
 
+// GetNamespaceDetails retrieves a namespace by name with all resources recorded in it
+func (r *Repository) GetNamespaceDetails(name string) (*NamespaceWithDetails, error) {
+	ns, err := r.GetNamespaceByName(name)
+	if err != nil || ns == nil {
+		return nil, err
+	}
+
+	details := &NamespaceWithDetails{Namespace: *ns}
+
+	vethPairs, err := r.ListVethPairs()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vethPairs {
+		if (v.NsID != nil && *v.NsID == ns.ID) || (v.PeerNsID != nil && *v.PeerNsID == ns.ID) {
+			details.VethPairs = append(details.VethPairs, v)
+		}
+	}
+
+	if details.IPAddresses, err = r.ListIPAddresses(&ns.ID); err != nil {
+		return nil, err
+	}
+	if details.Routes, err = r.ListRoutes(&ns.ID); err != nil {
+		return nil, err
+	}
+
+	bridges, err := r.ListBridges()
+	if err != nil {
+		return nil, err
+	}
+	for _, br := range bridges {
+		if br.NsID != nil && *br.NsID == ns.ID {
+			details.Bridges = append(details.Bridges, br)
+		}
+	}
+
+	if details.GRETunnels, err = r.ListGRETunnels(&ns.ID); err != nil {
+		return nil, err
+	}
+	if details.Bonds, err = r.ListBonds(&ns.ID); err != nil {
+		return nil, err
+	}
+
+	macvlans, err := r.ListMacvlanLinks("")
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range macvlans {
+		if l.NsID != nil && *l.NsID == ns.ID {
+			details.Macvlans = append(details.Macvlans, l)
+		}
+	}
+
+	if details.Dummies, err = r.ListDummyInterfaces(&ns.ID); err != nil {
+		return nil, err
+	}
+	if details.Qdiscs, err = r.ListQdiscs(&ns.ID); err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
+
 // DeleteNamespace deletes a namespace by name
 func (r *Repository) DeleteNamespace(name string) error {
 	result, err := r.db.Exec("DELETE FROM namespaces WHERE name = ?", name)
@@ -98,10 +234,10 @@ func (r *Repository) DeleteNamespace(name string) error {
 // === VethPair Operations ===
 
 // CreateVethPair creates a new veth pair record
-func (r *Repository) CreateVethPair(name, peerName string, nsID, peerNsID *int64) (*VethPair, error) {
+func (r *Repository) CreateVethPair(name, peerName string, nsID, peerNsID, projectID *int64) (*VethPair, error) {
 	result, err := r.db.Exec(
-		"INSERT INTO veth_pairs (name, peer_name, ns_id, peer_ns_id) VALUES (?, ?, ?, ?)",
-		name, peerName, nsID, peerNsID,
+		"INSERT INTO veth_pairs (name, peer_name, ns_id, peer_ns_id, project_id) VALUES (?, ?, ?, ?, ?)",
+		name, peerName, nsID, peerNsID, projectID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create veth pair: %w", err)
@@ -115,9 +251,9 @@ func (r *Repository) CreateVethPair(name, peerName string, nsID, peerNsID *int64
 func (r *Repository) GetVethPair(id int64) (*VethPair, error) {
 	veth := &VethPair{}
 	err := r.db.QueryRow(
-		"SELECT id, name, peer_name, ns_id, peer_ns_id, created_at FROM veth_pairs WHERE id = ?",
+		"SELECT id, name, peer_name, ns_id, peer_ns_id, project_id, created_at FROM veth_pairs WHERE id = ?",
 		id,
-	).Scan(&veth.ID, &veth.Name, &veth.PeerName, &veth.NsID, &veth.PeerNsID, &veth.CreatedAt)
+	).Scan(&veth.ID, &veth.Name, &veth.PeerName, &veth.NsID, &veth.PeerNsID, &veth.ProjectID, &veth.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -131,9 +267,9 @@ func (r *Repository) GetVethPair(id int64) (*VethPair, error) {
 func (r *Repository) GetVethPairByName(name string) (*VethPair, error) {
 	veth := &VethPair{}
 	err := r.db.QueryRow(
-		"SELECT id, name, peer_name, ns_id, peer_ns_id, created_at FROM veth_pairs WHERE name = ?",
+		"SELECT id, name, peer_name, ns_id, peer_ns_id, project_id, created_at FROM veth_pairs WHERE name = ?",
 		name,
-	).Scan(&veth.ID, &veth.Name, &veth.PeerName, &veth.NsID, &veth.PeerNsID, &veth.CreatedAt)
+	).Scan(&veth.ID, &veth.Name, &veth.PeerName, &veth.NsID, &veth.PeerNsID, &veth.ProjectID, &veth.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -145,7 +281,7 @@ func (r *Repository) GetVethPairByName(name string) (*VethPair, error) {
 
 // ListVethPairs returns all veth pairs
 func (r *Repository) ListVethPairs() ([]VethPair, error) {
-	rows, err := r.db.Query("SELECT id, name, peer_name, ns_id, peer_ns_id, created_at FROM veth_pairs ORDER BY name")
+	rows, err := r.db.Query("SELECT id, name, peer_name, ns_id, peer_ns_id, project_id, created_at FROM veth_pairs ORDER BY name")
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +290,7 @@ func (r *Repository) ListVethPairs() ([]VethPair, error) {
 	var pairs []VethPair
 	for rows.Next() {
 		var v VethPair
-		if err := rows.Scan(&v.ID, &v.Name, &v.PeerName, &v.NsID, &v.PeerNsID, &v.CreatedAt); err != nil {
+		if err := rows.Scan(&v.ID, &v.Name, &v.PeerName, &v.NsID, &v.PeerNsID, &v.ProjectID, &v.CreatedAt); err != nil {
 			return nil, err
 		}
 		pairs = append(pairs, v)
@@ -162,6 +298,22 @@ func (r *Repository) ListVethPairs() ([]VethPair, error) {
 	return pairs, rows.Err()
 }
 
+// GetVethPairByInterface retrieves the veth pair that has the given interface as either end
+func (r *Repository) GetVethPairByInterface(interfaceName string) (*VethPair, error) {
+	veth := &VethPair{}
+	err := r.db.QueryRow(
+		"SELECT id, name, peer_name, ns_id, peer_ns_id, project_id, created_at FROM veth_pairs WHERE name = ? OR peer_name = ?",
+		interfaceName, interfaceName,
+	).Scan(&veth.ID, &veth.Name, &veth.PeerName, &veth.NsID, &veth.PeerNsID, &veth.ProjectID, &veth.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return veth, nil
+}
+
 // DeleteVethPair deletes a veth pair by name
 func (r *Repository) DeleteVethPair(name string) error {
 	result, err := r.db.Exec("DELETE FROM veth_pairs WHERE name = ?", name)
@@ -178,10 +330,10 @@ func (r *Repository) DeleteVethPair(name string) error {
 // === IPAddress Operations ===
 
 // CreateIPAddress creates a new IP address record
-func (r *Repository) CreateIPAddress(interfaceName string, nsID *int64, address string) (*IPAddress, error) {
+func (r *Repository) CreateIPAddress(interfaceName string, nsID *int64, address string, projectID *int64) (*IPAddress, error) {
 	result, err := r.db.Exec(
-		"INSERT INTO ip_addresses (interface_name, ns_id, address) VALUES (?, ?, ?)",
-		interfaceName, nsID, address,
+		"INSERT INTO ip_addresses (interface_name, ns_id, address, project_id) VALUES (?, ?, ?, ?)",
+		interfaceName, nsID, address, projectID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create IP address: %w", err)
@@ -195,9 +347,9 @@ func (r *Repository) CreateIPAddress(interfaceName string, nsID *int64, address
 func (r *Repository) GetIPAddress(id int64) (*IPAddress, error) {
 	ip := &IPAddress{}
 	err := r.db.QueryRow(
-		"SELECT id, interface_name, ns_id, address, created_at FROM ip_addresses WHERE id = ?",
+		"SELECT id, interface_name, ns_id, address, address_label, project_id, created_at FROM ip_addresses WHERE id = ?",
 		id,
-	).Scan(&ip.ID, &ip.InterfaceName, &ip.NsID, &ip.Address, &ip.CreatedAt)
+	).Scan(&ip.ID, &ip.InterfaceName, &ip.NsID, &ip.Address, &ip.AddressLabel, &ip.ProjectID, &ip.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -214,11 +366,11 @@ func (r *Repository) ListIPAddresses(nsID *int64) ([]IPAddress, error) {
 
 	if nsID != nil {
 		rows, err = r.db.Query(
-			"SELECT id, interface_name, ns_id, address, created_at FROM ip_addresses WHERE ns_id = ? ORDER BY interface_name",
+			"SELECT id, interface_name, ns_id, address, address_label, project_id, created_at FROM ip_addresses WHERE ns_id = ? ORDER BY interface_name",
 			*nsID,
 		)
 	} else {
-		rows, err = r.db.Query("SELECT id, interface_name, ns_id, address, created_at FROM ip_addresses ORDER BY interface_name")
+		rows, err = r.db.Query("SELECT id, interface_name, ns_id, address, address_label, project_id, created_at FROM ip_addresses ORDER BY interface_name")
 	}
 	if err != nil {
 		return nil, err
@@ -228,7 +380,7 @@ func (r *Repository) ListIPAddresses(nsID *int64) ([]IPAddress, error) {
 	var addresses []IPAddress
 	for rows.Next() {
 		var ip IPAddress
-		if err := rows.Scan(&ip.ID, &ip.InterfaceName, &ip.NsID, &ip.Address, &ip.CreatedAt); err != nil {
+		if err := rows.Scan(&ip.ID, &ip.InterfaceName, &ip.NsID, &ip.Address, &ip.AddressLabel, &ip.ProjectID, &ip.CreatedAt); err != nil {
 			return nil, err
 		}
 		addresses = append(addresses, ip)
@@ -236,6 +388,30 @@ func (r *Repository) ListIPAddresses(nsID *int64) ([]IPAddress, error) {
 	return addresses, rows.Err()
 }
 
+// UpdateIPAddress changes the address label of an IP address record
+func (r *Repository) UpdateIPAddress(id int64, addressLabel string) error {
+	result, err := r.db.Exec("UPDATE ip_addresses SET address_label = ? WHERE id = ?", addressLabel, id)
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("IP address %d not found", id)
+	}
+	return nil
+}
+
+// DeleteIPAddressesByInterface deletes all IP address records for an interface in a namespace
+func (r *Repository) DeleteIPAddressesByInterface(interfaceName string, nsID *int64) error {
+	var err error
+	if nsID != nil {
+		_, err = r.db.Exec("DELETE FROM ip_addresses WHERE interface_name = ? AND ns_id = ?", interfaceName, *nsID)
+	} else {
+		_, err = r.db.Exec("DELETE FROM ip_addresses WHERE interface_name = ? AND ns_id IS NULL", interfaceName)
+	}
+	return err
+}
+
 // DeleteIPAddress deletes an IP address by ID
 func (r *Repository) DeleteIPAddress(id int64) error {
 	result, err := r.db.Exec("DELETE FROM ip_addresses WHERE id = ?", id)
@@ -252,10 +428,10 @@ func (r *Repository) DeleteIPAddress(id int64) error {
 // === Route Operations ===
 
 // CreateRoute creates a new route record
-func (r *Repository) CreateRoute(nsID *int64, destination, gateway, interfaceName string) (*Route, error) {
+func (r *Repository) CreateRoute(nsID *int64, destination, gateway, interfaceName string, projectID *int64) (*Route, error) {
 	result, err := r.db.Exec(
-		"INSERT INTO routes (ns_id, destination, gateway, interface_name) VALUES (?, ?, ?, ?)",
-		nsID, destination, gateway, interfaceName,
+		"INSERT INTO routes (ns_id, destination, gateway, interface_name, project_id) VALUES (?, ?, ?, ?, ?)",
+		nsID, destination, gateway, interfaceName, projectID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create route: %w", err)
@@ -269,9 +445,9 @@ func (r *Repository) CreateRoute(nsID *int64, destination, gateway, interfaceNam
 func (r *Repository) GetRoute(id int64) (*Route, error) {
 	route := &Route{}
 	err := r.db.QueryRow(
-		"SELECT id, ns_id, destination, COALESCE(gateway, ''), COALESCE(interface_name, ''), created_at FROM routes WHERE id = ?",
+		"SELECT id, ns_id, destination, COALESCE(gateway, ''), COALESCE(interface_name, ''), project_id, created_at FROM routes WHERE id = ?",
 		id,
-	).Scan(&route.ID, &route.NsID, &route.Destination, &route.Gateway, &route.InterfaceName, &route.CreatedAt)
+	).Scan(&route.ID, &route.NsID, &route.Destination, &route.Gateway, &route.InterfaceName, &route.ProjectID, &route.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -288,11 +464,11 @@ func (r *Repository) ListRoutes(nsID *int64) ([]Route, error) {
 
 	if nsID != nil {
 		rows, err = r.db.Query(
-			"SELECT id, ns_id, destination, COALESCE(gateway, ''), COALESCE(interface_name, ''), created_at FROM routes WHERE ns_id = ? ORDER BY destination",
+			"SELECT id, ns_id, destination, COALESCE(gateway, ''), COALESCE(interface_name, ''), project_id, created_at FROM routes WHERE ns_id = ? ORDER BY destination",
 			*nsID,
 		)
 	} else {
-		rows, err = r.db.Query("SELECT id, ns_id, destination, COALESCE(gateway, ''), COALESCE(interface_name, ''), created_at FROM routes ORDER BY destination")
+		rows, err = r.db.Query("SELECT id, ns_id, destination, COALESCE(gateway, ''), COALESCE(interface_name, ''), project_id, created_at FROM routes ORDER BY destination")
 	}
 	if err != nil {
 		return nil, err
@@ -302,7 +478,7 @@ func (r *Repository) ListRoutes(nsID *int64) ([]Route, error) {
 	var routes []Route
 	for rows.Next() {
 		var rt Route
-		if err := rows.Scan(&rt.ID, &rt.NsID, &rt.Destination, &rt.Gateway, &rt.InterfaceName, &rt.CreatedAt); err != nil {
+		if err := rows.Scan(&rt.ID, &rt.NsID, &rt.Destination, &rt.Gateway, &rt.InterfaceName, &rt.ProjectID, &rt.CreatedAt); err != nil {
 			return nil, err
 		}
 		routes = append(routes, rt)
@@ -310,6 +486,26 @@ func (r *Repository) ListRoutes(nsID *int64) ([]Route, error) {
 	return routes, rows.Err()
 }
 
+// UpdateRoute changes the gateway and output interface of a route record
+// Parameters:
+//   - id: route record ID
+//   - gateway: new gateway IP address (empty = directly connected)
+//   - interfaceName: new output interface name (empty = chosen by the kernel)
+func (r *Repository) UpdateRoute(id int64, gateway, interfaceName string) error {
+	result, err := r.db.Exec(
+		"UPDATE routes SET gateway = ?, interface_name = ? WHERE id = ?",
+		gateway, interfaceName, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update route: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("route with ID %d not found", id)
+	}
+	return nil
+}
+
 // DeleteRoute deletes a route by ID
 func (r *Repository) DeleteRoute(id int64) error {
 	result, err := r.db.Exec("DELETE FROM routes WHERE id = ?", id)
@@ -326,10 +522,10 @@ func (r *Repository) DeleteRoute(id int64) error {
 // === Bridge Operations ===
 
 // CreateBridge creates a new bridge record
-func (r *Repository) CreateBridge(name string, nsID *int64) (*Bridge, error) {
+func (r *Repository) CreateBridge(name string, nsID, projectID *int64) (*Bridge, error) {
 	result, err := r.db.Exec(
-		"INSERT INTO bridges (name, ns_id) VALUES (?, ?)",
-		name, nsID,
+		"INSERT INTO bridges (name, ns_id, project_id) VALUES (?, ?, ?)",
+		name, nsID, projectID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bridge: %w", err)
@@ -343,9 +539,9 @@ func (r *Repository) CreateBridge(name string, nsID *int64) (*Bridge, error) {
 func (r *Repository) GetBridge(id int64) (*Bridge, error) {
 	br := &Bridge{}
 	err := r.db.QueryRow(
-		"SELECT id, name, ns_id, created_at FROM bridges WHERE id = ?",
+		"SELECT id, name, ns_id, project_id, created_at FROM bridges WHERE id = ?",
 		id,
-	).Scan(&br.ID, &br.Name, &br.NsID, &br.CreatedAt)
+	).Scan(&br.ID, &br.Name, &br.NsID, &br.ProjectID, &br.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -359,9 +555,9 @@ func (r *Repository) GetBridge(id int64) (*Bridge, error) {
 func (r *Repository) GetBridgeByName(name string) (*Bridge, error) {
 	br := &Bridge{}
 	err := r.db.QueryRow(
-		"SELECT id, name, ns_id, created_at FROM bridges WHERE name = ?",
+		"SELECT id, name, ns_id, project_id, created_at FROM bridges WHERE name = ?",
 		name,
-	).Scan(&br.ID, &br.Name, &br.NsID, &br.CreatedAt)
+	).Scan(&br.ID, &br.Name, &br.NsID, &br.ProjectID, &br.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -373,7 +569,7 @@ func (r *Repository) GetBridgeByName(name string) (*Bridge, error) {
 
 // ListBridges returns all bridges
 func (r *Repository) ListBridges() ([]Bridge, error) {
-	rows, err := r.db.Query("SELECT id, name, ns_id, created_at FROM bridges ORDER BY name")
+	rows, err := r.db.Query("SELECT id, name, ns_id, project_id, created_at FROM bridges ORDER BY name")
 	if err != nil {
 		return nil, err
 	}
@@ -382,7 +578,7 @@ func (r *Repository) ListBridges() ([]Bridge, error) {
 	var bridges []Bridge
 	for rows.Next() {
 		var br Bridge
-		if err := rows.Scan(&br.ID, &br.Name, &br.NsID, &br.CreatedAt); err != nil {
+		if err := rows.Scan(&br.ID, &br.Name, &br.NsID, &br.ProjectID, &br.CreatedAt); err != nil {
 			return nil, err
 		}
 		bridges = append(bridges, br)
@@ -475,10 +671,11 @@ func (r *Repository) RemoveBridgePort(bridgeID int64, interfaceName string) erro
 //   - key: GRE key for multiplexing (0 = no key)
 //   - ttl: time to live (0 = inherit from inner packet)
 //   - nsID: namespace ID where tunnel is created (nil = host)
-func (r *Repository) CreateGRETunnel(name, localIP, remoteIP string, key uint32, ttl uint8, nsID *int64) (*GRETunnel, error) {
+//   - projectID: owning project (nil = none)
+func (r *Repository) CreateGRETunnel(name, localIP, remoteIP string, key uint32, ttl uint8, nsID, projectID *int64) (*GRETunnel, error) {
 	result, err := r.db.Exec(
-		"INSERT INTO gre_tunnels (name, local_ip, remote_ip, gre_key, ttl, ns_id) VALUES (?, ?, ?, ?, ?, ?)",
-		name, localIP, remoteIP, key, ttl, nsID,
+		"INSERT INTO gre_tunnels (name, local_ip, remote_ip, gre_key, ttl, ns_id, project_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		name, localIP, remoteIP, key, ttl, nsID, projectID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GRE tunnel: %w", err)
@@ -492,9 +689,9 @@ func (r *Repository) CreateGRETunnel(name, localIP, remoteIP string, key uint32,
 func (r *Repository) GetGRETunnel(id int64) (*GRETunnel, error) {
 	tunnel := &GRETunnel{}
 	err := r.db.QueryRow(
-		"SELECT id, name, local_ip, remote_ip, gre_key, ttl, ns_id, created_at FROM gre_tunnels WHERE id = ?",
+		"SELECT id, name, local_ip, remote_ip, gre_key, ttl, ns_id, project_id, created_at FROM gre_tunnels WHERE id = ?",
 		id,
-	).Scan(&tunnel.ID, &tunnel.Name, &tunnel.LocalIP, &tunnel.RemoteIP, &tunnel.Key, &tunnel.TTL, &tunnel.NsID, &tunnel.CreatedAt)
+	).Scan(&tunnel.ID, &tunnel.Name, &tunnel.LocalIP, &tunnel.RemoteIP, &tunnel.Key, &tunnel.TTL, &tunnel.NsID, &tunnel.ProjectID, &tunnel.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -508,9 +705,9 @@ func (r *Repository) GetGRETunnel(id int64) (*GRETunnel, error) {
 func (r *Repository) GetGRETunnelByName(name string) (*GRETunnel, error) {
 	tunnel := &GRETunnel{}
 	err := r.db.QueryRow(
-		"SELECT id, name, local_ip, remote_ip, gre_key, ttl, ns_id, created_at FROM gre_tunnels WHERE name = ?",
+		"SELECT id, name, local_ip, remote_ip, gre_key, ttl, ns_id, project_id, created_at FROM gre_tunnels WHERE name = ?",
 		name,
-	).Scan(&tunnel.ID, &tunnel.Name, &tunnel.LocalIP, &tunnel.RemoteIP, &tunnel.Key, &tunnel.TTL, &tunnel.NsID, &tunnel.CreatedAt)
+	).Scan(&tunnel.ID, &tunnel.Name, &tunnel.LocalIP, &tunnel.RemoteIP, &tunnel.Key, &tunnel.TTL, &tunnel.NsID, &tunnel.ProjectID, &tunnel.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -520,6 +717,28 @@ func (r *Repository) GetGRETunnelByName(name string) (*GRETunnel, error) {
 	return tunnel, nil
 }
 
+// UpdateGRETunnel changes the endpoints, key and TTL of a GRE tunnel record
+// Parameters:
+//   - id: tunnel record ID
+//   - localIP: local endpoint IP address
+//   - remoteIP: remote endpoint IP address
+//   - key: GRE key for multiplexing (0 = no key)
+//   - ttl: time to live (0 = inherit from inner packet)
+func (r *Repository) UpdateGRETunnel(id int64, localIP, remoteIP string, key uint32, ttl uint8) error {
+	result, err := r.db.Exec(
+		"UPDATE gre_tunnels SET local_ip = ?, remote_ip = ?, gre_key = ?, ttl = ? WHERE id = ?",
+		localIP, remoteIP, key, ttl, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update GRE tunnel: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("GRE tunnel with ID %d not found", id)
+	}
+	return nil
+}
+
 // ListGRETunnels returns all GRE tunnels, optionally filtered by namespace
 func (r *Repository) ListGRETunnels(nsID *int64) ([]GRETunnel, error) {
 	var rows *sql.Rows
@@ -527,11 +746,11 @@ func (r *Repository) ListGRETunnels(nsID *int64) ([]GRETunnel, error) {
 
 	if nsID != nil {
 		rows, err = r.db.Query(
-			"SELECT id, name, local_ip, remote_ip, gre_key, ttl, ns_id, created_at FROM gre_tunnels WHERE ns_id = ? ORDER BY name",
+			"SELECT id, name, local_ip, remote_ip, gre_key, ttl, ns_id, project_id, created_at FROM gre_tunnels WHERE ns_id = ? ORDER BY name",
 			*nsID,
 		)
 	} else {
-		rows, err = r.db.Query("SELECT id, name, local_ip, remote_ip, gre_key, ttl, ns_id, created_at FROM gre_tunnels ORDER BY name")
+		rows, err = r.db.Query("SELECT id, name, local_ip, remote_ip, gre_key, ttl, ns_id, project_id, created_at FROM gre_tunnels ORDER BY name")
 	}
 	if err != nil {
 		return nil, err
@@ -541,7 +760,7 @@ func (r *Repository) ListGRETunnels(nsID *int64) ([]GRETunnel, error) {
 	var tunnels []GRETunnel
 	for rows.Next() {
 		var t GRETunnel
-		if err := rows.Scan(&t.ID, &t.Name, &t.LocalIP, &t.RemoteIP, &t.Key, &t.TTL, &t.NsID, &t.CreatedAt); err != nil {
+		if err := rows.Scan(&t.ID, &t.Name, &t.LocalIP, &t.RemoteIP, &t.Key, &t.TTL, &t.NsID, &t.ProjectID, &t.CreatedAt); err != nil {
 			return nil, err
 		}
 		tunnels = append(tunnels, t)
@@ -561,3 +780,1459 @@ func (r *Repository) DeleteGRETunnel(name string) error {
 	}
 	return nil
 }
+
+// === Macvlan/IPVlan Operations ===
+
+// CreateMacvlanLink creates a new macvlan or ipvlan link record
+// Parameters:
+//   - name: link interface name
+//   - kind: "macvlan" or "ipvlan"
+//   - mode: link mode (bridge/vepa/private/passthru or l2/l3/l3s)
+//   - parent: parent interface name
+//   - parentNsID: namespace ID where parent exists (nil = host)
+//   - nsID: namespace ID the link was moved into (nil = host)
+func (r *Repository) CreateMacvlanLink(name, kind, mode, parent string, parentNsID, nsID *int64) (*MacvlanLink, error) {
+	result, err := r.db.Exec(
+		"INSERT INTO macvlan_links (name, kind, mode, parent, parent_ns_id, ns_id) VALUES (?, ?, ?, ?, ?, ?)",
+		name, kind, mode, parent, parentNsID, nsID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s link: %w", kind, err)
+	}
+
+	id, _ := result.LastInsertId()
+	return r.GetMacvlanLink(id)
+}
+
+// GetMacvlanLink retrieves a macvlan or ipvlan link by ID
+func (r *Repository) GetMacvlanLink(id int64) (*MacvlanLink, error) {
+	link := &MacvlanLink{}
+	err := r.db.QueryRow(
+		"SELECT id, name, kind, mode, parent, parent_ns_id, ns_id, created_at FROM macvlan_links WHERE id = ?",
+		id,
+	).Scan(&link.ID, &link.Name, &link.Kind, &link.Mode, &link.Parent, &link.ParentNsID, &link.NsID, &link.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// GetMacvlanLinkByName retrieves a macvlan or ipvlan link by name
+func (r *Repository) GetMacvlanLinkByName(name string) (*MacvlanLink, error) {
+	link := &MacvlanLink{}
+	err := r.db.QueryRow(
+		"SELECT id, name, kind, mode, parent, parent_ns_id, ns_id, created_at FROM macvlan_links WHERE name = ?",
+		name,
+	).Scan(&link.ID, &link.Name, &link.Kind, &link.Mode, &link.Parent, &link.ParentNsID, &link.NsID, &link.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// ListMacvlanLinks returns all macvlan and ipvlan links, optionally filtered by kind
+func (r *Repository) ListMacvlanLinks(kind string) ([]MacvlanLink, error) {
+	var rows *sql.Rows
+	var err error
+
+	if kind != "" {
+		rows, err = r.db.Query(
+			"SELECT id, name, kind, mode, parent, parent_ns_id, ns_id, created_at FROM macvlan_links WHERE kind = ? ORDER BY name",
+			kind,
+		)
+	} else {
+		rows, err = r.db.Query("SELECT id, name, kind, mode, parent, parent_ns_id, ns_id, created_at FROM macvlan_links ORDER BY name")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []MacvlanLink
+	for rows.Next() {
+		var l MacvlanLink
+		if err := rows.Scan(&l.ID, &l.Name, &l.Kind, &l.Mode, &l.Parent, &l.ParentNsID, &l.NsID, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// DeleteMacvlanLink deletes a macvlan or ipvlan link by name
+func (r *Repository) DeleteMacvlanLink(name string) error {
+	result, err := r.db.Exec("DELETE FROM macvlan_links WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("link %q not found", name)
+	}
+	return nil
+}
+
+// === Bond Operations ===
+
+// CreateBond creates a new bond record together with its slaves
+// Parameters:
+//   - name: bond interface name
+//   - mode: bond mode (active-backup, balance-rr, 802.3ad, ...)
+//   - miimon: MII monitoring interval in ms (0 = disabled)
+//   - primary: preferred slave (empty = none)
+//   - slaves: slave interface names
+//   - nsID: namespace ID where bond is created (nil = host)
+func (r *Repository) CreateBond(name, mode string, miimon int, primary string, slaves []string, nsID *int64) (*Bond, error) {
+	var bond *Bond
+	err := r.WithTx(func(txRepository *Repository) error {
+		result, err := txRepository.db.Exec(
+			"INSERT INTO bonds (name, mode, miimon, primary_slave, ns_id) VALUES (?, ?, ?, ?, ?)",
+			name, mode, miimon, primary, nsID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create bond: %w", err)
+		}
+
+		id, _ := result.LastInsertId()
+		for _, slave := range slaves {
+			if _, err := txRepository.db.Exec("INSERT INTO bond_slaves (bond_id, interface_name) VALUES (?, ?)", id, slave); err != nil {
+				return fmt.Errorf("failed to add bond slave: %w", err)
+			}
+		}
+
+		bond, err = txRepository.GetBond(id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bond, nil
+}
+
+// GetBond retrieves a bond by ID
+func (r *Repository) GetBond(id int64) (*Bond, error) {
+	bond := &Bond{}
+	err := r.db.QueryRow(
+		"SELECT id, name, mode, miimon, COALESCE(primary_slave, ''), ns_id, created_at FROM bonds WHERE id = ?",
+		id,
+	).Scan(&bond.ID, &bond.Name, &bond.Mode, &bond.Miimon, &bond.Primary, &bond.NsID, &bond.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	bond.Slaves, err = r.listBondSlaveNames(bond.ID)
+	if err != nil {
+		return nil, err
+	}
+	return bond, nil
+}
+
+// GetBondByName retrieves a bond by name
+func (r *Repository) GetBondByName(name string) (*Bond, error) {
+	var id int64
+	err := r.db.QueryRow("SELECT id FROM bonds WHERE name = ?", name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetBond(id)
+}
+
+// ListBonds returns all bonds, optionally filtered by namespace
+func (r *Repository) ListBonds(nsID *int64) ([]Bond, error) {
+	var rows *sql.Rows
+	var err error
+
+	if nsID != nil {
+		rows, err = r.db.Query(
+			"SELECT id, name, mode, miimon, COALESCE(primary_slave, ''), ns_id, created_at FROM bonds WHERE ns_id = ? ORDER BY name",
+			*nsID,
+		)
+	} else {
+		rows, err = r.db.Query("SELECT id, name, mode, miimon, COALESCE(primary_slave, ''), ns_id, created_at FROM bonds ORDER BY name")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var bonds []Bond
+	for rows.Next() {
+		var b Bond
+		if err := rows.Scan(&b.ID, &b.Name, &b.Mode, &b.Miimon, &b.Primary, &b.NsID, &b.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		bonds = append(bonds, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range bonds {
+		bonds[i].Slaves, err = r.listBondSlaveNames(bonds[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bonds, nil
+}
+
+// ListBondSlaves returns all slaves for a bond
+func (r *Repository) ListBondSlaves(bondID int64) ([]BondSlave, error) {
+	rows, err := r.db.Query(
+		"SELECT id, bond_id, interface_name, created_at FROM bond_slaves WHERE bond_id = ? ORDER BY id",
+		bondID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slaves []BondSlave
+	for rows.Next() {
+		var s BondSlave
+		if err := rows.Scan(&s.ID, &s.BondID, &s.InterfaceName, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		slaves = append(slaves, s)
+	}
+	return slaves, rows.Err()
+}
+
+// listBondSlaveNames returns slave interface names for a bond
+func (r *Repository) listBondSlaveNames(bondID int64) ([]string, error) {
+	slaves, err := r.ListBondSlaves(bondID)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, slave := range slaves {
+		names = append(names, slave.InterfaceName)
+	}
+	return names, nil
+}
+
+// DeleteBond deletes a bond by name (slaves are removed by cascade)
+func (r *Repository) DeleteBond(name string) error {
+	result, err := r.db.Exec("DELETE FROM bonds WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("bond %q not found", name)
+	}
+	return nil
+}
+
+// === Dummy Interface Operations ===
+
+// CreateDummyInterface creates a new dummy interface record
+func (r *Repository) CreateDummyInterface(name string, nsID *int64) (*DummyInterface, error) {
+	result, err := r.db.Exec(
+		"INSERT INTO dummy_interfaces (name, ns_id) VALUES (?, ?)",
+		name, nsID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dummy interface: %w", err)
+	}
+
+	id, _ := result.LastInsertId()
+	return r.GetDummyInterface(id)
+}
+
+// GetDummyInterface retrieves a dummy interface by ID
+func (r *Repository) GetDummyInterface(id int64) (*DummyInterface, error) {
+	dummy := &DummyInterface{}
+	err := r.db.QueryRow(
+		"SELECT id, name, ns_id, created_at FROM dummy_interfaces WHERE id = ?",
+		id,
+	).Scan(&dummy.ID, &dummy.Name, &dummy.NsID, &dummy.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dummy, nil
+}
+
+// GetDummyInterfaceByName retrieves a dummy interface by name
+func (r *Repository) GetDummyInterfaceByName(name string) (*DummyInterface, error) {
+	dummy := &DummyInterface{}
+	err := r.db.QueryRow(
+		"SELECT id, name, ns_id, created_at FROM dummy_interfaces WHERE name = ?",
+		name,
+	).Scan(&dummy.ID, &dummy.Name, &dummy.NsID, &dummy.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dummy, nil
+}
+
+// ListDummyInterfaces returns all dummy interfaces, optionally filtered by namespace
+func (r *Repository) ListDummyInterfaces(nsID *int64) ([]DummyInterface, error) {
+	var rows *sql.Rows
+	var err error
+
+	if nsID != nil {
+		rows, err = r.db.Query(
+			"SELECT id, name, ns_id, created_at FROM dummy_interfaces WHERE ns_id = ? ORDER BY name",
+			*nsID,
+		)
+	} else {
+		rows, err = r.db.Query("SELECT id, name, ns_id, created_at FROM dummy_interfaces ORDER BY name")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dummies []DummyInterface
+	for rows.Next() {
+		var d DummyInterface
+		if err := rows.Scan(&d.ID, &d.Name, &d.NsID, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		dummies = append(dummies, d)
+	}
+	return dummies, rows.Err()
+}
+
+// DeleteDummyInterface deletes a dummy interface by name
+func (r *Repository) DeleteDummyInterface(name string) error {
+	result, err := r.db.Exec("DELETE FROM dummy_interfaces WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("dummy interface %q not found", name)
+	}
+	return nil
+}
+
+// === Qdisc Operations ===
+
+const qdiscColumns = `id, interface_name, ns_id, shaper, delay_ms, jitter_ms, loss_percent, reorder_percent,
+	duplicate_percent, corrupt_percent, rate_kbit, ceil_kbit, burst_bytes, created_at`
+
+// scanQdisc scans a qdisc row selected with qdiscColumns
+func scanQdisc(scanner interface{ Scan(...any) error }) (*Qdisc, error) {
+	q := &Qdisc{}
+	err := scanner.Scan(&q.ID, &q.InterfaceName, &q.NsID, &q.Shaper, &q.DelayMs, &q.JitterMs, &q.LossPercent,
+		&q.ReorderPercent, &q.DuplicatePercent, &q.CorruptPercent, &q.RateKbit, &q.CeilKbit, &q.BurstBytes, &q.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// SetQdisc records the impairment applied to an interface, replacing any previous record
+func (r *Repository) SetQdisc(q *Qdisc) (*Qdisc, error) {
+	var qdisc *Qdisc
+	err := r.WithTx(func(txRepository *Repository) error {
+		var err error
+		if q.NsID != nil {
+			_, err = txRepository.db.Exec("DELETE FROM qdiscs WHERE interface_name = ? AND ns_id = ?", q.InterfaceName, *q.NsID)
+		} else {
+			_, err = txRepository.db.Exec("DELETE FROM qdiscs WHERE interface_name = ? AND ns_id IS NULL", q.InterfaceName)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to replace qdisc: %w", err)
+		}
+
+		result, err := txRepository.db.Exec(
+			`INSERT INTO qdiscs (interface_name, ns_id, shaper, delay_ms, jitter_ms, loss_percent, reorder_percent,
+				duplicate_percent, corrupt_percent, rate_kbit, ceil_kbit, burst_bytes)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			q.InterfaceName, q.NsID, q.Shaper, q.DelayMs, q.JitterMs, q.LossPercent, q.ReorderPercent,
+			q.DuplicatePercent, q.CorruptPercent, q.RateKbit, q.CeilKbit, q.BurstBytes,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create qdisc: %w", err)
+		}
+
+		id, _ := result.LastInsertId()
+		qdisc, err = txRepository.GetQdisc(id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return qdisc, nil
+}
+
+// GetQdisc retrieves a qdisc by ID
+func (r *Repository) GetQdisc(id int64) (*Qdisc, error) {
+	q, err := scanQdisc(r.db.QueryRow("SELECT "+qdiscColumns+" FROM qdiscs WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return q, err
+}
+
+// GetQdiscByInterface retrieves the qdisc recorded for an interface in a namespace
+func (r *Repository) GetQdiscByInterface(interfaceName string, nsID *int64) (*Qdisc, error) {
+	var row *sql.Row
+	if nsID != nil {
+		row = r.db.QueryRow("SELECT "+qdiscColumns+" FROM qdiscs WHERE interface_name = ? AND ns_id = ?", interfaceName, *nsID)
+	} else {
+		row = r.db.QueryRow("SELECT "+qdiscColumns+" FROM qdiscs WHERE interface_name = ? AND ns_id IS NULL", interfaceName)
+	}
+
+	q, err := scanQdisc(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return q, err
+}
+
+// ListQdiscs returns all recorded qdiscs, optionally filtered by namespace
+func (r *Repository) ListQdiscs(nsID *int64) ([]Qdisc, error) {
+	var rows *sql.Rows
+	var err error
+
+	if nsID != nil {
+		rows, err = r.db.Query("SELECT "+qdiscColumns+" FROM qdiscs WHERE ns_id = ? ORDER BY interface_name", *nsID)
+	} else {
+		rows, err = r.db.Query("SELECT " + qdiscColumns + " FROM qdiscs ORDER BY interface_name")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var qdiscs []Qdisc
+	for rows.Next() {
+		q, err := scanQdisc(rows)
+		if err != nil {
+			return nil, err
+		}
+		qdiscs = append(qdiscs, *q)
+	}
+	return qdiscs, rows.Err()
+}
+
+// DeleteQdisc deletes the qdisc recorded for an interface in a namespace
+func (r *Repository) DeleteQdisc(interfaceName string, nsID *int64) error {
+	var result sql.Result
+	var err error
+	if nsID != nil {
+		result, err = r.db.Exec("DELETE FROM qdiscs WHERE interface_name = ? AND ns_id = ?", interfaceName, *nsID)
+	} else {
+		result, err = r.db.Exec("DELETE FROM qdiscs WHERE interface_name = ? AND ns_id IS NULL", interfaceName)
+	}
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("no qdisc recorded for %q", interfaceName)
+	}
+	return nil
+}
+
+// === Link Property Operations ===
+
+const linkPropertyColumns = "id, interface_name, ns_id, mtu, mac_address, txqueuelen, alias, state, updated_at"
+
+// scanLinkProperty scans a link property row selected with linkPropertyColumns
+func scanLinkProperty(scanner interface{ Scan(...any) error }) (*LinkProperty, error) {
+	lp := &LinkProperty{}
+	err := scanner.Scan(&lp.ID, &lp.InterfaceName, &lp.NsID, &lp.MTU, &lp.MACAddress, &lp.TxQueueLen, &lp.Alias, &lp.State, &lp.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return lp, nil
+}
+
+// SetLinkProperties records the properties of an interface, replacing its previous record
+// Zero values mean the property is not managed (an empty alias is recorded
+// as no alias).
+func (r *Repository) SetLinkProperties(lp *LinkProperty) (*LinkProperty, error) {
+	existing, err := r.GetLinkProperties(lp.InterfaceName, lp.NsID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		result, err := r.db.Exec(
+			"INSERT INTO link_properties (interface_name, ns_id, mtu, mac_address, txqueuelen, alias, state) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			lp.InterfaceName, lp.NsID, lp.MTU, lp.MACAddress, lp.TxQueueLen, lp.Alias, lp.State,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create link properties: %w", err)
+		}
+		id, _ := result.LastInsertId()
+		return r.getLinkPropertiesByID(id)
+	}
+
+	_, err = r.db.Exec(
+		"UPDATE link_properties SET mtu = ?, mac_address = ?, txqueuelen = ?, alias = ?, state = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		lp.MTU, lp.MACAddress, lp.TxQueueLen, lp.Alias, lp.State, existing.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update link properties: %w", err)
+	}
+	return r.getLinkPropertiesByID(existing.ID)
+}
+
+// getLinkPropertiesByID retrieves link properties by ID
+func (r *Repository) getLinkPropertiesByID(id int64) (*LinkProperty, error) {
+	lp, err := scanLinkProperty(r.db.QueryRow("SELECT "+linkPropertyColumns+" FROM link_properties WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return lp, err
+}
+
+// GetLinkProperties retrieves the properties recorded for an interface in a namespace
+func (r *Repository) GetLinkProperties(interfaceName string, nsID *int64) (*LinkProperty, error) {
+	var row *sql.Row
+	if nsID != nil {
+		row = r.db.QueryRow("SELECT "+linkPropertyColumns+" FROM link_properties WHERE interface_name = ? AND ns_id = ?", interfaceName, *nsID)
+	} else {
+		row = r.db.QueryRow("SELECT "+linkPropertyColumns+" FROM link_properties WHERE interface_name = ? AND ns_id IS NULL", interfaceName)
+	}
+
+	lp, err := scanLinkProperty(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return lp, err
+}
+
+// ListLinkProperties returns all recorded link properties, optionally filtered by namespace
+func (r *Repository) ListLinkProperties(nsID *int64) ([]LinkProperty, error) {
+	var rows *sql.Rows
+	var err error
+
+	if nsID != nil {
+		rows, err = r.db.Query("SELECT "+linkPropertyColumns+" FROM link_properties WHERE ns_id = ? ORDER BY interface_name", *nsID)
+	} else {
+		rows, err = r.db.Query("SELECT " + linkPropertyColumns + " FROM link_properties ORDER BY interface_name")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var properties []LinkProperty
+	for rows.Next() {
+		lp, err := scanLinkProperty(rows)
+		if err != nil {
+			return nil, err
+		}
+		properties = append(properties, *lp)
+	}
+	return properties, rows.Err()
+}
+
+// DeleteLinkProperties deletes the properties recorded for an interface in a namespace
+func (r *Repository) DeleteLinkProperties(interfaceName string, nsID *int64) error {
+	var err error
+	if nsID != nil {
+		_, err = r.db.Exec("DELETE FROM link_properties WHERE interface_name = ? AND ns_id = ?", interfaceName, *nsID)
+	} else {
+		_, err = r.db.Exec("DELETE FROM link_properties WHERE interface_name = ? AND ns_id IS NULL", interfaceName)
+	}
+	return err
+}
+
+// === Inventory Operations ===
+
+// ListManagedInterfaces returns every interface recorded in the database with its namespace name
+func (r *Repository) ListManagedInterfaces() ([]ManagedInterface, error) {
+	rows, err := r.db.Query(`
+		SELECT v.name, COALESCE(n.name, ''), 'veth' FROM veth_pairs v LEFT JOIN namespaces n ON n.id = v.ns_id
+		UNION ALL
+		SELECT v.peer_name, COALESCE(n.name, ''), 'veth' FROM veth_pairs v LEFT JOIN namespaces n ON n.id = v.peer_ns_id
+		UNION ALL
+		SELECT b.name, COALESCE(n.name, ''), 'bridge' FROM bridges b LEFT JOIN namespaces n ON n.id = b.ns_id
+		UNION ALL
+		SELECT g.name, COALESCE(n.name, ''), 'gre' FROM gre_tunnels g LEFT JOIN namespaces n ON n.id = g.ns_id
+		UNION ALL
+		SELECT m.name, COALESCE(n.name, ''), m.kind FROM macvlan_links m LEFT JOIN namespaces n ON n.id = m.ns_id
+		UNION ALL
+		SELECT b.name, COALESCE(n.name, ''), 'bond' FROM bonds b LEFT JOIN namespaces n ON n.id = b.ns_id
+		UNION ALL
+		SELECT d.name, COALESCE(n.name, ''), 'dummy' FROM dummy_interfaces d LEFT JOIN namespaces n ON n.id = d.ns_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var managed []ManagedInterface
+	for rows.Next() {
+		var m ManagedInterface
+		if err := rows.Scan(&m.Name, &m.Namespace, &m.Kind); err != nil {
+			return nil, err
+		}
+		managed = append(managed, m)
+	}
+	return managed, rows.Err()
+}
+
+// managedTables lists the tables counted as managed resources
+var managedTables = []string{
+	"namespaces", "veth_pairs", "ip_addresses", "routes", "bridges", "bridge_ports", "gre_tunnels",
+	"macvlan_links", "bonds", "dummy_interfaces", "qdiscs", "link_properties", "workloads",
+}
+
+// CountResources returns the number of records per managed table
+func (r *Repository) CountResources() (map[string]int64, error) {
+	counts := make(map[string]int64, len(managedTables))
+	for _, table := range managedTables {
+		var count int64
+		if err := r.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", table, err)
+		}
+		counts[table] = count
+	}
+	return counts, nil
+}
+
+// === Audit Log Operations ===
+
+// DefaultAuditLimit is the page size used when a filter has no limit
+const DefaultAuditLimit = 50
+
+// CreateAuditEntry records an audit entry, stamping the current time if unset
+func (r *Repository) CreateAuditEntry(entry *AuditEntry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	result, err := r.db.Exec(
+		`INSERT INTO audit_log (timestamp, actor, source, operation, resource_type, resource_name, namespace, payload, result, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Timestamp, entry.Actor, entry.Source, entry.Operation, entry.ResourceType, entry.ResourceName,
+		entry.Namespace, entry.Payload, entry.Result, entry.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	entry.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ListAuditEntries returns audit entries matching a filter, newest first, and the total match count
+func (r *Repository) ListAuditEntries(filter AuditFilter) ([]AuditEntry, int64, error) {
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(column, value string) {
+		if value != "" {
+			conditions = append(conditions, column+" = ?")
+			args = append(args, value)
+		}
+	}
+	addCondition("actor", filter.Actor)
+	addCondition("source", filter.Source)
+	addCondition("operation", filter.Operation)
+	addCondition("resource_type", filter.ResourceType)
+	addCondition("resource_name", filter.ResourceName)
+	addCondition("namespace", filter.Namespace)
+	addCondition("result", filter.Result)
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, filter.Until.UTC())
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultAuditLimit
+	}
+
+	rows, err := r.db.Query(
+		`SELECT id, timestamp, actor, source, operation, resource_type, resource_name, namespace, payload, result, error
+		FROM audit_log`+where+" ORDER BY id DESC LIMIT ? OFFSET ?",
+		append(args, limit, filter.Offset)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Actor, &e.Source, &e.Operation, &e.ResourceType,
+			&e.ResourceName, &e.Namespace, &e.Payload, &e.Result, &e.Error); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+// === Project Operations ===
+
+// CreateProject creates a new project record
+// Parameters:
+//   - name: unique project name
+//   - prefix: unique prefix of the project's kernel object names
+func (r *Repository) CreateProject(name, prefix string) (*Project, error) {
+	result, err := r.db.Exec("INSERT INTO projects (name, prefix) VALUES (?, ?)", name, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	id, _ := result.LastInsertId()
+	return r.GetProject(id)
+}
+
+// GetProject retrieves a project by ID
+func (r *Repository) GetProject(id int64) (*Project, error) {
+	return r.getProject("id = ?", id)
+}
+
+// GetProjectByName retrieves a project by name
+func (r *Repository) GetProjectByName(name string) (*Project, error) {
+	return r.getProject("name = ?", name)
+}
+
+// GetProjectByPrefix retrieves the project using a name prefix
+func (r *Repository) GetProjectByPrefix(prefix string) (*Project, error) {
+	return r.getProject("prefix = ?", prefix)
+}
+
+// getProject retrieves the project matching a condition, or nil if none does
+func (r *Repository) getProject(condition string, arg interface{}) (*Project, error) {
+	project := &Project{}
+	err := r.db.QueryRow(
+		"SELECT id, name, prefix, created_at FROM projects WHERE "+condition,
+		arg,
+	).Scan(&project.ID, &project.Name, &project.Prefix, &project.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// ListProjects returns all projects
+func (r *Repository) ListProjects() ([]Project, error) {
+	rows, err := r.db.Query("SELECT id, name, prefix, created_at FROM projects ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []Project{}
+	for rows.Next() {
+		var project Project
+		if err := rows.Scan(&project.ID, &project.Name, &project.Prefix, &project.CreatedAt); err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+// DeleteProject deletes a project by name
+// Tokens scoped to the project are deleted with it.
+func (r *Repository) DeleteProject(name string) error {
+	result, err := r.db.Exec("DELETE FROM projects WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project %q not found", name)
+	}
+	return nil
+}
+
+// === Label Operations ===
+
+// SetLabels adds labels to a resource, replacing the values of existing keys
+// Parameters:
+//   - resourceType: one of the Label* resource types
+//   - resourceID: ID of the resource record
+//   - labels: keys and values to set
+func (r *Repository) SetLabels(resourceType string, resourceID int64, labels map[string]string) error {
+	for key, value := range labels {
+		_, err := r.db.Exec(
+			`INSERT INTO labels (resource_type, resource_id, key, value) VALUES (?, ?, ?, ?)
+			ON CONFLICT(resource_type, resource_id, key) DO UPDATE SET value = excluded.value`,
+			resourceType, resourceID, key, value,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to set label %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// ReplaceLabels replaces all labels of a resource
+// Parameters:
+//   - resourceType: one of the Label* resource types
+//   - resourceID: ID of the resource record
+//   - labels: the complete new set of labels (empty = remove all)
+func (r *Repository) ReplaceLabels(resourceType string, resourceID int64, labels map[string]string) error {
+	_, err := r.db.Exec("DELETE FROM labels WHERE resource_type = ? AND resource_id = ?", resourceType, resourceID)
+	if err != nil {
+		return err
+	}
+	return r.SetLabels(resourceType, resourceID, labels)
+}
+
+// RemoveLabels removes labels from a resource; missing keys are ignored
+func (r *Repository) RemoveLabels(resourceType string, resourceID int64, keys []string) error {
+	for _, key := range keys {
+		_, err := r.db.Exec(
+			"DELETE FROM labels WHERE resource_type = ? AND resource_id = ? AND key = ?",
+			resourceType, resourceID, key,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLabels returns the labels of a resource (nil if it has none)
+func (r *Repository) GetLabels(resourceType string, resourceID int64) (map[string]string, error) {
+	labelsByResource, err := r.queryLabels(
+		"SELECT resource_id, key, value FROM labels WHERE resource_type = ? AND resource_id = ?",
+		resourceType, resourceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return labelsByResource[resourceID], nil
+}
+
+// ListLabels returns the labels of every resource of a type, keyed by resource ID
+func (r *Repository) ListLabels(resourceType string) (map[int64]map[string]string, error) {
+	return r.queryLabels("SELECT resource_id, key, value FROM labels WHERE resource_type = ?", resourceType)
+}
+
+// queryLabels groups the (resource_id, key, value) rows of a label query by resource
+func (r *Repository) queryLabels(query string, args ...any) (map[int64]map[string]string, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labelsByResource := make(map[int64]map[string]string)
+	for rows.Next() {
+		var resourceID int64
+		var key, value string
+		if err := rows.Scan(&resourceID, &key, &value); err != nil {
+			return nil, err
+		}
+		if labelsByResource[resourceID] == nil {
+			labelsByResource[resourceID] = make(map[string]string)
+		}
+		labelsByResource[resourceID][key] = value
+	}
+	return labelsByResource, rows.Err()
+}
+
+// === Project Quota Operations ===
+
+// quotaTables maps quota resources to the tables holding their records
+var quotaTables = map[string]string{
+	QuotaNamespaces: "namespaces",
+	QuotaVeths:      "veth_pairs",
+	QuotaBridges:    "bridges",
+	QuotaGRETunnels: "gre_tunnels",
+	QuotaAddresses:  "ip_addresses",
+}
+
+// SetProjectQuota creates or replaces the quota of a project for one resource
+// Parameters:
+//   - projectID: project the quota applies to
+//   - resource: one of QuotaResources
+//   - limit: maximum number of records the project may own
+func (r *Repository) SetProjectQuota(projectID int64, resource string, limit int) error {
+	_, err := r.db.Exec(
+		`INSERT INTO project_quotas (project_id, resource, max_count) VALUES (?, ?, ?)
+		ON CONFLICT(project_id, resource) DO UPDATE SET max_count = excluded.max_count`,
+		projectID, resource, limit,
+	)
+	return err
+}
+
+// ListProjectQuotas returns the quotas of a project
+func (r *Repository) ListProjectQuotas(projectID int64) ([]ProjectQuota, error) {
+	rows, err := r.db.Query(
+		"SELECT project_id, resource, max_count FROM project_quotas WHERE project_id = ? ORDER BY resource",
+		projectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var quotas []ProjectQuota
+	for rows.Next() {
+		var quota ProjectQuota
+		if err := rows.Scan(&quota.ProjectID, &quota.Resource, &quota.Limit); err != nil {
+			return nil, err
+		}
+		quotas = append(quotas, quota)
+	}
+	return quotas, rows.Err()
+}
+
+// DeleteProjectQuota removes the quota of a project for one resource
+func (r *Repository) DeleteProjectQuota(projectID int64, resource string) error {
+	result, err := r.db.Exec("DELETE FROM project_quotas WHERE project_id = ? AND resource = ?", projectID, resource)
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("quota for %s not found", resource)
+	}
+	return nil
+}
+
+// CountProjectResources returns how many records of a quota resource a project owns
+func (r *Repository) CountProjectResources(projectID int64, resource string) (int, error) {
+	table, known := quotaTables[resource]
+	if !known {
+		return 0, fmt.Errorf("unknown quota resource %q", resource)
+	}
+
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE project_id = ?", projectID).Scan(&count)
+	return count, err
+}
+
+// === API Token Operations ===
+
+// CreateAPIToken records a new API token
+// Parameters:
+//   - name: unique token name, used as the API principal
+//   - role: role granted to the token
+//   - tokenHash: hex SHA-256 hash of the token secret
+//   - projectID: project the token is confined to (nil = all projects)
+func (r *Repository) CreateAPIToken(name, role, tokenHash string, projectID *int64) (*APIToken, error) {
+	result, err := r.db.Exec(
+		"INSERT INTO api_tokens (name, role, token_hash, project_id) VALUES (?, ?, ?, ?)",
+		name, role, tokenHash, projectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := result.LastInsertId()
+	return r.getAPIToken("t.id = ?", id)
+}
+
+// GetAPITokenByName retrieves an API token by name
+func (r *Repository) GetAPITokenByName(name string) (*APIToken, error) {
+	return r.getAPIToken("t.name = ?", name)
+}
+
+// GetAPITokenByHash retrieves the API token with a given secret hash
+func (r *Repository) GetAPITokenByHash(tokenHash string) (*APIToken, error) {
+	return r.getAPIToken("t.token_hash = ?", tokenHash)
+}
+
+// apiTokenColumns are the api_tokens columns (aliased t) with the project name (aliased p)
+const apiTokenColumns = "t.id, t.name, t.role, t.token_hash, t.created_at, t.last_used_at, t.project_id, COALESCE(p.name, '')"
+
+// getAPIToken retrieves the API token matching a condition, or nil if none does
+func (r *Repository) getAPIToken(condition string, arg interface{}) (*APIToken, error) {
+	token := &APIToken{}
+	err := r.db.QueryRow(
+		"SELECT "+apiTokenColumns+" FROM api_tokens t LEFT JOIN projects p ON p.id = t.project_id WHERE "+condition,
+		arg,
+	).Scan(&token.ID, &token.Name, &token.Role, &token.TokenHash, &token.CreatedAt, &token.LastUsedAt, &token.ProjectID, &token.Project)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// ListAPITokens returns all API tokens
+func (r *Repository) ListAPITokens() ([]APIToken, error) {
+	rows, err := r.db.Query(
+		"SELECT " + apiTokenColumns + " FROM api_tokens t LEFT JOIN projects p ON p.id = t.project_id ORDER BY t.name",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []APIToken{}
+	for rows.Next() {
+		var token APIToken
+		if err := rows.Scan(&token.ID, &token.Name, &token.Role, &token.TokenHash, &token.CreatedAt, &token.LastUsedAt, &token.ProjectID, &token.Project); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// TouchAPIToken records that an API token was just used
+func (r *Repository) TouchAPIToken(id int64) error {
+	_, err := r.db.Exec("UPDATE api_tokens SET last_used_at = ? WHERE id = ?", time.Now().UTC(), id)
+	return err
+}
+
+// DeleteAPIToken deletes an API token by name
+func (r *Repository) DeleteAPIToken(name string) error {
+	result, err := r.db.Exec("DELETE FROM api_tokens WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("API token %q not found", name)
+	}
+	return nil
+}
+
+// === Job Operations ===
+
+// jobColumns are the columns read by scanJob
+const jobColumns = "id, operation, status, actor, project_id, steps_done, result, error, created_at, started_at, finished_at"
+
+// scanJob reads a jobs row selected with jobColumns
+func scanJob(scanner interface{ Scan(...any) error }) (*Job, error) {
+	job := &Job{}
+	var result string
+	err := scanner.Scan(&job.ID, &job.Operation, &job.Status, &job.Actor, &job.ProjectID, &job.StepsDone,
+		&result, &job.Error, &job.CreatedAt, &job.StartedAt, &job.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if result != "" {
+		job.Result = json.RawMessage(result)
+	}
+	return job, nil
+}
+
+// CreateJob records a new queued job
+// Parameters:
+//   - operation: what the job does, e.g. "create gre peer"
+//   - actor: principal that submitted the job
+//   - projectID: project of the submitting token (nil = none)
+func (r *Repository) CreateJob(operation, actor string, projectID *int64) (*Job, error) {
+	result, err := r.db.Exec(
+		"INSERT INTO jobs (operation, status, actor, project_id, created_at) VALUES (?, ?, ?, ?, ?)",
+		operation, JobQueued, actor, projectID, time.Now().UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	id, _ := result.LastInsertId()
+	return r.GetJob(id)
+}
+
+// GetJob retrieves a job with its logs, or nil if it does not exist
+func (r *Repository) GetJob(id int64) (*Job, error) {
+	job, err := scanJob(r.db.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query("SELECT timestamp, message FROM job_logs WHERE job_id = ? ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var jobLog JobLog
+		if err := rows.Scan(&jobLog.Timestamp, &jobLog.Message); err != nil {
+			return nil, err
+		}
+		job.Logs = append(job.Logs, jobLog)
+	}
+	return job, rows.Err()
+}
+
+// DefaultJobLimit is the number of jobs listed when no limit is given
+const DefaultJobLimit = 50
+
+// ListJobs returns jobs without their logs, newest first
+// Parameters:
+//   - projectID: only list this project's jobs (nil = every job)
+//   - limit: maximum number of jobs (0 = DefaultJobLimit)
+func (r *Repository) ListJobs(projectID *int64, limit int) ([]Job, error) {
+	if limit <= 0 {
+		limit = DefaultJobLimit
+	}
+
+	query := "SELECT " + jobColumns + " FROM jobs"
+	var args []any
+	if projectID != nil {
+		query += " WHERE project_id = ?"
+		args = append(args, *projectID)
+	}
+	rows, err := r.db.Query(query+" ORDER BY id DESC LIMIT ?", append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// StartJob marks a queued job as running
+func (r *Repository) StartJob(id int64) error {
+	_, err := r.db.Exec("UPDATE jobs SET status = ?, started_at = ? WHERE id = ?", JobRunning, time.Now().UTC(), id)
+	return err
+}
+
+// AddJobLog stores a step of a job and counts it as done
+func (r *Repository) AddJobLog(id int64, jobLog JobLog) error {
+	if _, err := r.db.Exec("INSERT INTO job_logs (job_id, timestamp, message) VALUES (?, ?, ?)", id, jobLog.Timestamp, jobLog.Message); err != nil {
+		return err
+	}
+	_, err := r.db.Exec("UPDATE jobs SET steps_done = steps_done + 1 WHERE id = ?", id)
+	return err
+}
+
+// FinishJob records the final status of a job
+// Parameters:
+//   - id: job ID
+//   - status: JobSucceeded, JobFailed or JobCancelled
+//   - result: JSON success response (nil = none)
+//   - errorMessage: why the job failed or was cancelled
+func (r *Repository) FinishJob(id int64, status string, result []byte, errorMessage string) error {
+	_, err := r.db.Exec(
+		"UPDATE jobs SET status = ?, result = ?, error = ?, finished_at = ? WHERE id = ?",
+		status, string(result), errorMessage, time.Now().UTC(), id,
+	)
+	return err
+}
+
+// InterruptUnfinishedJobs marks jobs left queued or running by a stopped server as interrupted
+// Returns the number of jobs marked.
+func (r *Repository) InterruptUnfinishedJobs() (int64, error) {
+	result, err := r.db.Exec(
+		"UPDATE jobs SET status = ?, error = ?, finished_at = ? WHERE status IN (?, ?)",
+		JobInterrupted, "server stopped before the job finished", time.Now().UTC(), JobQueued, JobRunning,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// === Idempotency Key Operations ===
+
+// idempotencyPendingTimeout is how long a key stays claimed by a request that never completed
+const idempotencyPendingTimeout = 10 * time.Minute
+
+// ReserveIdempotencyKey claims an Idempotency-Key for a request about to run
+// Expired keys, and keys claimed by requests that never completed, are purged
+// first. Returns nil if the key was claimed, or the stored response (with
+// StatusCode 0 while the first request is still running) if it was taken.
+// Parameters:
+//   - principal: API principal the key belongs to
+//   - key: value of the Idempotency-Key header
+//   - requestHash: hash identifying the request
+func (r *Repository) ReserveIdempotencyKey(principal, key, requestHash string) (*IdempotentResponse, error) {
+	now := time.Now().UTC()
+	_, err := r.db.Exec(
+		"DELETE FROM idempotency_keys WHERE created_at < ? OR (status_code = 0 AND created_at < ?)",
+		now.Add(-IdempotencyKeyTTL), now.Add(-idempotencyPendingTimeout),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	result, err := r.db.Exec(
+		"INSERT OR IGNORE INTO idempotency_keys (principal, idempotency_key, request_hash, created_at) VALUES (?, ?, ?, ?)",
+		principal, key, requestHash, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if claimed, _ := result.RowsAffected(); claimed == 1 {
+		return nil, nil
+	}
+
+	var response IdempotentResponse
+	var headers string
+	err = r.db.QueryRow(
+		"SELECT request_hash, status_code, headers, body, created_at FROM idempotency_keys WHERE principal = ? AND idempotency_key = ?",
+		principal, key,
+	).Scan(&response.RequestHash, &response.StatusCode, &headers, &response.Body, &response.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(headers), &response.Headers); err != nil {
+		return nil, fmt.Errorf("invalid stored headers: %w", err)
+	}
+	return &response, nil
+}
+
+// StoreIdempotentResponse records the response to a request whose Idempotency-Key was reserved
+// Parameters:
+//   - principal: API principal the key belongs to
+//   - key: value of the Idempotency-Key header
+//   - statusCode: HTTP status of the response
+//   - headers: response headers to replay
+//   - body: response body
+func (r *Repository) StoreIdempotentResponse(principal, key string, statusCode int, headers map[string]string, body []byte) error {
+	headersJSON, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(
+		"UPDATE idempotency_keys SET status_code = ?, headers = ?, body = ? WHERE principal = ? AND idempotency_key = ?",
+		statusCode, string(headersJSON), body, principal, key,
+	)
+	return err
+}
+
+// ReleaseIdempotencyKey forgets a reserved Idempotency-Key so the request can be retried
+func (r *Repository) ReleaseIdempotencyKey(principal, key string) error {
+	_, err := r.db.Exec("DELETE FROM idempotency_keys WHERE principal = ? AND idempotency_key = ?", principal, key)
+	return err
+}
+
+// === Workload Operations ===
+
+// workloadColumns are the columns read by scanWorkload
+const workloadColumns = `id, name, ns_id, command, env, dir, mount_namespace, restart_policy, max_restarts, restart_delay,
+	log_dir, status, supervisor_pid, pid, restarts, exit_code, error, started_at, finished_at, created_at`
+
+// scanWorkload reads a workloads row selected with workloadColumns
+func scanWorkload(scanner interface{ Scan(...any) error }) (*Workload, error) {
+	workload := &Workload{}
+	var command, env string
+	err := scanner.Scan(&workload.ID, &workload.Name, &workload.NsID, &command, &env, &workload.Dir, &workload.MountNamespace,
+		&workload.RestartPolicy, &workload.MaxRestarts, &workload.RestartDelay, &workload.LogDir, &workload.Status,
+		&workload.SupervisorPID, &workload.PID, &workload.Restarts, &workload.ExitCode, &workload.Error,
+		&workload.StartedAt, &workload.FinishedAt, &workload.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(command), &workload.Command); err != nil {
+		return nil, fmt.Errorf("invalid stored command of workload %q: %w", workload.Name, err)
+	}
+	if err := json.Unmarshal([]byte(env), &workload.Env); err != nil {
+		return nil, fmt.Errorf("invalid stored environment of workload %q: %w", workload.Name, err)
+	}
+	return workload, nil
+}
+
+// CreateWorkload records a new workload as stopped
+// The supervisor changes its status once it is started.
+func (r *Repository) CreateWorkload(workload *Workload) (*Workload, error) {
+	command, err := json.Marshal(workload.Command)
+	if err != nil {
+		return nil, err
+	}
+	env := []byte("[]")
+	if len(workload.Env) > 0 {
+		if env, err = json.Marshal(workload.Env); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := r.db.Exec(`
+	INSERT INTO workloads (name, ns_id, command, env, dir, mount_namespace, restart_policy, max_restarts, restart_delay, log_dir, status)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		workload.Name, workload.NsID, string(command), string(env), workload.Dir, workload.MountNamespace,
+		workload.RestartPolicy, workload.MaxRestarts, workload.RestartDelay, workload.LogDir, WorkloadStopped,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workload: %w", err)
+	}
+
+	id, _ := result.LastInsertId()
+	return r.GetWorkload(id)
+}
+
+// GetWorkload retrieves a workload by ID, or nil if it does not exist
+func (r *Repository) GetWorkload(id int64) (*Workload, error) {
+	workload, err := scanWorkload(r.db.QueryRow("SELECT "+workloadColumns+" FROM workloads WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return workload, err
+}
+
+// GetWorkloadByName retrieves a workload by name, or nil if it does not exist
+func (r *Repository) GetWorkloadByName(name string) (*Workload, error) {
+	workload, err := scanWorkload(r.db.QueryRow("SELECT "+workloadColumns+" FROM workloads WHERE name = ?", name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return workload, err
+}
+
+// ListWorkloads returns all workloads, optionally filtered by namespace
+func (r *Repository) ListWorkloads(nsID *int64) ([]Workload, error) {
+	query := "SELECT " + workloadColumns + " FROM workloads"
+	var args []any
+	if nsID != nil {
+		query += " WHERE ns_id = ?"
+		args = append(args, *nsID)
+	}
+	rows, err := r.db.Query(query+" ORDER BY name", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workloads := []Workload{}
+	for rows.Next() {
+		workload, err := scanWorkload(rows)
+		if err != nil {
+			return nil, err
+		}
+		workloads = append(workloads, *workload)
+	}
+	return workloads, rows.Err()
+}
+
+// DeleteWorkload deletes a workload by name
+func (r *Repository) DeleteWorkload(name string) error {
+	result, err := r.db.Exec("DELETE FROM workloads WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("workload %q not found", name)
+	}
+	return nil
+}
+
+// ResetWorkloadForStart marks a workload as starting and clears the state of its previous start
+func (r *Repository) ResetWorkloadForStart(id int64) error {
+	_, err := r.db.Exec(`
+	UPDATE workloads SET status = ?, supervisor_pid = 0, supervisor_start_time = 0, pid = 0, pid_start_time = 0,
+		restarts = 0, exit_code = NULL, error = '', started_at = ?, finished_at = NULL
+	WHERE id = ?`,
+		WorkloadStarting, time.Now().UTC(), id,
+	)
+	return err
+}
+
+// SetWorkloadSupervisor records the supervisor process of a workload
+func (r *Repository) SetWorkloadSupervisor(id int64, supervisor WorkloadProcess) error {
+	_, err := r.db.Exec(
+		"UPDATE workloads SET supervisor_pid = ?, supervisor_start_time = ? WHERE id = ?",
+		supervisor.PID, supervisor.StartTime, id,
+	)
+	return err
+}
+
+// SetWorkloadRunning records that a workload's command is running
+// Parameters:
+//   - id: workload ID
+//   - command: process of the command
+func (r *Repository) SetWorkloadRunning(id int64, command WorkloadProcess) error {
+	_, err := r.db.Exec(
+		"UPDATE workloads SET status = ?, pid = ?, pid_start_time = ? WHERE id = ?",
+		WorkloadRunning, command.PID, command.StartTime, id,
+	)
+	return err
+}
+
+// GetWorkloadProcesses returns the recorded supervisor and command processes of a workload
+// Both are zero once the workload has ended.
+func (r *Repository) GetWorkloadProcesses(id int64) (supervisor, command WorkloadProcess, err error) {
+	err = r.db.QueryRow(
+		"SELECT supervisor_pid, supervisor_start_time, pid, pid_start_time FROM workloads WHERE id = ?", id,
+	).Scan(&supervisor.PID, &supervisor.StartTime, &command.PID, &command.StartTime)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return supervisor, command, err
+}
+
+// SetWorkloadLost records that a workload's supervisor died without recording an end
+// Only changes the workload while it is still active with the given supervisor,
+// so a concurrent restart is left alone. Both processes are forgotten.
+// Parameters:
+//   - id: workload ID
+//   - supervisorPID: supervisor PID the caller found dead
+func (r *Repository) SetWorkloadLost(id int64, supervisorPID int) error {
+	_, err := r.db.Exec(`
+	UPDATE workloads SET status = ?, supervisor_pid = 0, supervisor_start_time = 0, pid = 0, pid_start_time = 0,
+		finished_at = ?
+	WHERE id = ? AND supervisor_pid = ? AND status IN (?, ?, ?)`,
+		WorkloadLost, time.Now().UTC(), id, supervisorPID, WorkloadStarting, WorkloadRunning, WorkloadRestarting,
+	)
+	return err
+}
+
+// SetWorkloadEnded records that a workload's command ended
+// Every status but WorkloadRestarting also ends the supervisor.
+// Parameters:
+//   - id: workload ID
+//   - status: WorkloadRestarting, WorkloadStopped, WorkloadExited or WorkloadFailed
+//   - exitCode: exit code of the command (nil = it never started)
+//   - errorMessage: why the command failed (empty = none)
+//   - restarts: number of restarts since the last start
+func (r *Repository) SetWorkloadEnded(id int64, status string, exitCode *int, errorMessage string, restarts int) error {
+	if status == WorkloadRestarting {
+		_, err := r.db.Exec(
+			"UPDATE workloads SET status = ?, pid = 0, pid_start_time = 0, exit_code = ?, error = ?, restarts = ? WHERE id = ?",
+			status, exitCode, errorMessage, restarts, id,
+		)
+		return err
+	}
+	_, err := r.db.Exec(`
+	UPDATE workloads SET status = ?, supervisor_pid = 0, supervisor_start_time = 0, pid = 0, pid_start_time = 0,
+		exit_code = ?, error = ?, restarts = ?, finished_at = ?
+	WHERE id = ?`,
+		status, exitCode, errorMessage, restarts, time.Now().UTC(), id,
+	)
+	return err
+}
@@ -6,12 +6,32 @@ import (
 	"os"
 	"path/filepath"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
+// driverName is the SQLite driver with the functions list queries filter with
+const driverName = "sqlite3_netns"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("ip_within", ipWithin, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("cidr_within", cidrWithin, true)
+		},
+	})
+}
+
 // DB wraps the SQL database connection
 type DB struct {
 	*sql.DB
+	path string
+}
+
+// Path returns the file the database was opened from
+func (db *DB) Path() string {
+	return db.path
 }
 
 // DefaultDBPath returns the default database path
@@ -31,7 +51,8 @@ func Open(dbPath string) (*DB, error) {
 		dbPath = DefaultDBPath()
 	}
 
-	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on")
+	// Wait for locks instead of failing: API requests and background jobs write concurrently
+	db, err := sql.Open(driverName, dbPath+"?_foreign_keys=on&_busy_timeout=5000")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -40,7 +61,7 @@ func Open(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	wrapper := &DB{db}
+	wrapper := &DB{DB: db, path: dbPath}
 	if err := wrapper.migrate(); err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
@@ -51,11 +72,35 @@ func Open(dbPath string) (*DB, error) {
 // migrate creates the database schema
 func (db *DB) migrate() error {
 	schema := `
+	CREATE TABLE IF NOT EXISTS projects (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		prefix TEXT UNIQUE NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS project_quotas (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+		resource TEXT NOT NULL,
+		max_count INTEGER NOT NULL,
+		UNIQUE(project_id, resource)
+	);
+
+	CREATE TABLE IF NOT EXISTS labels (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		resource_type TEXT NOT NULL,
+		resource_id INTEGER NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		UNIQUE(resource_type, resource_id, key)
+	);
+
 	CREATE TABLE IF NOT EXISTS namespaces (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		name TEXT UNIQUE NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		metadata TEXT
+		project_id INTEGER REFERENCES projects(id)
 	);
 
 	CREATE TABLE IF NOT EXISTS veth_pairs (
@@ -64,6 +109,7 @@ func (db *DB) migrate() error {
 		peer_name TEXT NOT NULL,
 		ns_id INTEGER REFERENCES namespaces(id) ON DELETE CASCADE,
 		peer_ns_id INTEGER REFERENCES namespaces(id) ON DELETE SET NULL,
+		project_id INTEGER REFERENCES projects(id),
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
@@ -72,6 +118,8 @@ func (db *DB) migrate() error {
 		interface_name TEXT NOT NULL,
 		ns_id INTEGER REFERENCES namespaces(id) ON DELETE CASCADE,
 		address TEXT NOT NULL,
+		address_label TEXT NOT NULL DEFAULT '',
+		project_id INTEGER REFERENCES projects(id),
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
@@ -81,6 +129,7 @@ func (db *DB) migrate() error {
 		destination TEXT NOT NULL,
 		gateway TEXT,
 		interface_name TEXT,
+		project_id INTEGER REFERENCES projects(id),
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
@@ -88,6 +137,7 @@ func (db *DB) migrate() error {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		name TEXT UNIQUE NOT NULL,
 		ns_id INTEGER REFERENCES namespaces(id) ON DELETE CASCADE,
+		project_id INTEGER REFERENCES projects(id),
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
@@ -106,6 +156,154 @@ func (db *DB) migrate() error {
 		gre_key INTEGER DEFAULT 0,
 		ttl INTEGER DEFAULT 0,
 		ns_id INTEGER REFERENCES namespaces(id) ON DELETE CASCADE,
+		project_id INTEGER REFERENCES projects(id),
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS macvlan_links (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		kind TEXT NOT NULL,
+		mode TEXT NOT NULL,
+		parent TEXT NOT NULL,
+		parent_ns_id INTEGER REFERENCES namespaces(id) ON DELETE SET NULL,
+		ns_id INTEGER REFERENCES namespaces(id) ON DELETE CASCADE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS bonds (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		mode TEXT NOT NULL,
+		miimon INTEGER DEFAULT 0,
+		primary_slave TEXT,
+		ns_id INTEGER REFERENCES namespaces(id) ON DELETE CASCADE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS bond_slaves (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		bond_id INTEGER REFERENCES bonds(id) ON DELETE CASCADE,
+		interface_name TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS dummy_interfaces (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		ns_id INTEGER REFERENCES namespaces(id) ON DELETE CASCADE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS qdiscs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		interface_name TEXT NOT NULL,
+		ns_id INTEGER REFERENCES namespaces(id) ON DELETE CASCADE,
+		shaper TEXT NOT NULL DEFAULT '',
+		delay_ms REAL NOT NULL DEFAULT 0,
+		jitter_ms REAL NOT NULL DEFAULT 0,
+		loss_percent REAL NOT NULL DEFAULT 0,
+		reorder_percent REAL NOT NULL DEFAULT 0,
+		duplicate_percent REAL NOT NULL DEFAULT 0,
+		corrupt_percent REAL NOT NULL DEFAULT 0,
+		rate_kbit INTEGER NOT NULL DEFAULT 0,
+		ceil_kbit INTEGER NOT NULL DEFAULT 0,
+		burst_bytes INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(interface_name, ns_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS link_properties (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		interface_name TEXT NOT NULL,
+		ns_id INTEGER REFERENCES namespaces(id) ON DELETE CASCADE,
+		mtu INTEGER NOT NULL DEFAULT 0,
+		mac_address TEXT NOT NULL DEFAULT '',
+		txqueuelen INTEGER NOT NULL DEFAULT 0,
+		alias TEXT NOT NULL DEFAULT '',
+		state TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(interface_name, ns_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		actor TEXT NOT NULL,
+		source TEXT NOT NULL,
+		operation TEXT NOT NULL,
+		resource_type TEXT NOT NULL DEFAULT '',
+		resource_name TEXT NOT NULL DEFAULT '',
+		namespace TEXT NOT NULL DEFAULT '',
+		payload TEXT NOT NULL DEFAULT '',
+		result TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS api_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		role TEXT NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_used_at DATETIME,
+		project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		operation TEXT NOT NULL,
+		status TEXT NOT NULL,
+		actor TEXT NOT NULL DEFAULT '',
+		project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+		steps_done INTEGER NOT NULL DEFAULT 0,
+		result TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		started_at DATETIME,
+		finished_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		principal TEXT NOT NULL,
+		idempotency_key TEXT NOT NULL,
+		request_hash TEXT NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		headers TEXT NOT NULL DEFAULT '{}',
+		body BLOB,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (principal, idempotency_key)
+	);
+
+	CREATE TABLE IF NOT EXISTS job_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id INTEGER NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+		timestamp DATETIME NOT NULL,
+		message TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS workloads (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		ns_id INTEGER REFERENCES namespaces(id) ON DELETE CASCADE,
+		command TEXT NOT NULL,
+		env TEXT NOT NULL DEFAULT '[]',
+		dir TEXT NOT NULL DEFAULT '',
+		mount_namespace INTEGER NOT NULL DEFAULT 1,
+		restart_policy TEXT NOT NULL,
+		max_restarts INTEGER NOT NULL DEFAULT 0,
+		restart_delay INTEGER NOT NULL DEFAULT 1,
+		log_dir TEXT NOT NULL,
+		status TEXT NOT NULL,
+		supervisor_pid INTEGER NOT NULL DEFAULT 0,
+		supervisor_start_time INTEGER NOT NULL DEFAULT 0,
+		pid INTEGER NOT NULL DEFAULT 0,
+		pid_start_time INTEGER NOT NULL DEFAULT 0,
+		restarts INTEGER NOT NULL DEFAULT 0,
+		exit_code INTEGER,
+		error TEXT NOT NULL DEFAULT '',
+		started_at DATETIME,
+		finished_at DATETIME,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
@@ -116,8 +314,151 @@ func (db *DB) migrate() error {
 	CREATE INDEX IF NOT EXISTS idx_bridges_ns ON bridges(ns_id);
 	CREATE INDEX IF NOT EXISTS idx_bridge_ports_bridge ON bridge_ports(bridge_id);
 	CREATE INDEX IF NOT EXISTS idx_gre_tunnels_ns ON gre_tunnels(ns_id);
+	CREATE INDEX IF NOT EXISTS idx_macvlan_links_ns ON macvlan_links(ns_id);
+	CREATE INDEX IF NOT EXISTS idx_bonds_ns ON bonds(ns_id);
+	CREATE INDEX IF NOT EXISTS idx_bond_slaves_bond ON bond_slaves(bond_id);
+	CREATE INDEX IF NOT EXISTS idx_dummy_interfaces_ns ON dummy_interfaces(ns_id);
+	CREATE INDEX IF NOT EXISTS idx_qdiscs_ns ON qdiscs(ns_id);
+	CREATE INDEX IF NOT EXISTS idx_link_properties_ns ON link_properties(ns_id);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_labels_key ON labels(resource_type, key, value);
+	CREATE INDEX IF NOT EXISTS idx_job_logs_job ON job_logs(job_id);
+	CREATE INDEX IF NOT EXISTS idx_workloads_ns ON workloads(ns_id);
 	`
 
-	_, err := db.Exec(schema)
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+
+	// Columns added after the first release; CREATE TABLE IF NOT EXISTS
+	// leaves tables of older databases untouched
+	addedColumns := []struct{ table, column, definition string }{
+		{"namespaces", "project_id", "INTEGER REFERENCES projects(id)"},
+		{"veth_pairs", "project_id", "INTEGER REFERENCES projects(id)"},
+		{"ip_addresses", "project_id", "INTEGER REFERENCES projects(id)"},
+		{"routes", "project_id", "INTEGER REFERENCES projects(id)"},
+		{"bridges", "project_id", "INTEGER REFERENCES projects(id)"},
+		{"gre_tunnels", "project_id", "INTEGER REFERENCES projects(id)"},
+		{"api_tokens", "project_id", "INTEGER REFERENCES projects(id) ON DELETE CASCADE"},
+		{"ip_addresses", "address_label", "TEXT NOT NULL DEFAULT ''"},
+		{"workloads", "supervisor_start_time", "INTEGER NOT NULL DEFAULT 0"},
+		{"workloads", "pid_start_time", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, added := range addedColumns {
+		if err := db.addColumnIfMissing(added.table, added.column, added.definition); err != nil {
+			return err
+		}
+	}
+
+	if err := db.migrateNamespaceMetadata(); err != nil {
+		return err
+	}
+	if err := db.migrateAuditResourceTypes(); err != nil {
+		return err
+	}
+	for _, table := range []string{"qdiscs", "link_properties"} {
+		if err := db.migrateUniqueInterfaceRecords(table); err != nil {
+			return err
+		}
+	}
+
+	// Labels reference their resource by type and ID, so a trigger per table
+	// removes them when the resource (or its namespace) is deleted
+	for resourceType, table := range labelTables {
+		_, err := db.Exec(fmt.Sprintf(`
+		CREATE TRIGGER IF NOT EXISTS delete_%s_labels AFTER DELETE ON %s BEGIN
+			DELETE FROM labels WHERE resource_type = '%s' AND resource_id = OLD.id;
+		END`, table, table, resourceType))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := db.Exec(`
+	CREATE INDEX IF NOT EXISTS idx_namespaces_project ON namespaces(project_id);
+	CREATE INDEX IF NOT EXISTS idx_api_tokens_project ON api_tokens(project_id);
+	`)
+	return err
+}
+
+// migrateNamespaceMetadata moves the metadata column of older databases into labels
+// A non-empty metadata string becomes the "metadata" label of its namespace.
+func (db *DB) migrateNamespaceMetadata() error {
+	hasMetadata, err := db.hasColumn("namespaces", "metadata")
+	if err != nil || !hasMetadata {
+		return err
+	}
+
+	_, err = db.Exec(`
+	INSERT OR IGNORE INTO labels (resource_type, resource_id, key, value)
+		SELECT ?, id, 'metadata', metadata FROM namespaces WHERE metadata IS NOT NULL AND metadata != ''`,
+		LabelNamespaces,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("ALTER TABLE namespaces DROP COLUMN metadata")
 	return err
 }
+
+// migrateAuditResourceTypes renames the resource types of older CLI audit entries
+// They were recorded under the command name ("ns"); the API's collection
+// names ("namespaces") are used for both sources now.
+func (db *DB) migrateAuditResourceTypes() error {
+	for commandName, resourceType := range AuditResourceTypes {
+		_, err := db.Exec("UPDATE audit_log SET resource_type = ? WHERE source = 'cli' AND resource_type = ?", resourceType, commandName)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateUniqueInterfaceRecords keeps one record per interface in a table keyed by interface and namespace
+// Older databases lack the UNIQUE constraint and may hold duplicates; the
+// newest record of each interface is kept. UNIQUE treats NULLs as distinct,
+// so an index on IFNULL(ns_id, 0) also covers interfaces on the host.
+// Parameters:
+//   - table: qdiscs or link_properties
+func (db *DB) migrateUniqueInterfaceRecords(table string) error {
+	_, err := db.Exec(fmt.Sprintf(`
+	DELETE FROM %[1]s WHERE id NOT IN (SELECT MAX(id) FROM %[1]s GROUP BY interface_name, IFNULL(ns_id, 0));
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_%[1]s_interface ON %[1]s(interface_name, IFNULL(ns_id, 0));
+	`, table))
+	return err
+}
+
+// addColumnIfMissing adds a column to an existing table unless it is already there
+// Parameters:
+//   - table: table name
+//   - column: column name
+//   - definition: column type and constraints
+func (db *DB) addColumnIfMissing(table, column, definition string) error {
+	exists, err := db.hasColumn(table, column)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// hasColumn reports whether a table has a column
+func (db *DB) hasColumn(table, column string) (bool, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var columnName string
+		if err := rows.Scan(&columnName); err != nil {
+			return false, err
+		}
+		if columnName == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
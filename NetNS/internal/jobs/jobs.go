@@ -0,0 +1,260 @@
+// Package jobs runs long operations of the API server in the background.
+//
+// A submitted job is recorded in the database and run by a fixed pool of
+// workers. Each kernel change it makes is logged as a step, and a cancelled
+// job stops before its next step, reverting the changes of the transaction
+// in progress. Steps are kept in memory while the job runs, because a step
+// may happen inside an open SQL transaction, and are stored when it finishes.
+// Jobs still queued or running when the server stopped are marked
+// interrupted on the next start; they are not resumed.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/txn"
+)
+
+// DefaultWorkers is the number of jobs run at the same time by default
+const DefaultWorkers = 4
+
+// queueCapacity is how many jobs may wait for a worker
+const queueCapacity = 1024
+
+// ErrCancelled is returned by a step of a job that has been cancelled
+var ErrCancelled = errors.New("job cancelled")
+
+// ErrFinished is returned when cancelling a job that has already finished
+var ErrFinished = errors.New("job already finished")
+
+// Func is the work of a job
+// Every kernel change must be reported through step (e.g. with
+// service.WithStepObserver), which fails with ErrCancelled once the job is
+// cancelled. The result is stored as JSON.
+type Func func(step txn.StepObserver) (any, error)
+
+// Manager queues jobs and runs them on a pool of workers
+type Manager struct {
+	repository *db.Repository
+	queue      chan *activeJob
+
+	mutex  sync.Mutex
+	active map[int64]*activeJob // Queued or running jobs
+}
+
+// activeJob is a queued or running job
+type activeJob struct {
+	id      int64
+	run     Func
+	context context.Context
+	cancel  context.CancelFunc
+	started bool
+	logs    []db.JobLog
+}
+
+// NewManager creates a job manager; no job runs until Start is called
+// Parameters:
+//   - repository: repository the jobs are recorded in
+func NewManager(repository *db.Repository) *Manager {
+	return &Manager{
+		repository: repository,
+		queue:      make(chan *activeJob, queueCapacity),
+		active:     make(map[int64]*activeJob),
+	}
+}
+
+// Start marks jobs left unfinished by a previous server as interrupted and starts the workers
+// Parameters:
+//   - workerCount: number of jobs run at the same time
+func (manager *Manager) Start(workerCount int) error {
+	if workerCount < 1 {
+		return fmt.Errorf("at least one job worker is required")
+	}
+
+	interruptedCount, err := manager.repository.InterruptUnfinishedJobs()
+	if err != nil {
+		return fmt.Errorf("failed to mark unfinished jobs: %w", err)
+	}
+	if interruptedCount > 0 {
+		log.Printf("jobs: marked %d unfinished jobs as interrupted", interruptedCount)
+	}
+
+	for workerIndex := 0; workerIndex < workerCount; workerIndex++ {
+		go manager.work()
+	}
+	return nil
+}
+
+// Submit records a job and queues it
+// Parameters:
+//   - operation: what the job does, e.g. "create gre peer"
+//   - actor: principal submitting the job
+//   - projectID: project of the submitting token (nil = none)
+//   - run: the work of the job
+func (manager *Manager) Submit(operation, actor string, projectID *int64, run Func) (*db.Job, error) {
+	job, err := manager.repository.CreateJob(operation, actor, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	jobContext, cancel := context.WithCancel(context.Background())
+	queued := &activeJob{id: job.ID, run: run, context: jobContext, cancel: cancel}
+
+	manager.mutex.Lock()
+	manager.active[job.ID] = queued
+	manager.mutex.Unlock()
+
+	select {
+	case manager.queue <- queued:
+		return job, nil
+	default:
+		manager.finish(queued, db.JobFailed, nil, "job queue is full")
+		return nil, fmt.Errorf("job queue is full (%d jobs waiting)", queueCapacity)
+	}
+}
+
+// Get returns a job with its logs, or nil if it does not exist
+// Steps of a running job are taken from memory.
+func (manager *Manager) Get(jobID int64) (*db.Job, error) {
+	job, err := manager.repository.GetJob(jobID)
+	if err != nil || job == nil {
+		return job, err
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if running := manager.active[jobID]; running != nil && !job.IsFinished() {
+		job.Logs = append([]db.JobLog(nil), running.logs...)
+		job.StepsDone = len(running.logs)
+	}
+	return job, nil
+}
+
+// List returns jobs without their logs, newest first
+// Parameters:
+//   - projectID: only list this project's jobs (nil = every job)
+//   - limit: maximum number of jobs (0 = db.DefaultJobLimit)
+func (manager *Manager) List(projectID *int64, limit int) ([]db.Job, error) {
+	jobs, err := manager.repository.ListJobs(projectID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	for jobIndex := range jobs {
+		if running := manager.active[jobs[jobIndex].ID]; running != nil && !jobs[jobIndex].IsFinished() {
+			jobs[jobIndex].StepsDone = len(running.logs)
+		}
+	}
+	return jobs, nil
+}
+
+// Cancel cancels a job
+// A queued job is cancelled at once; a running job stops before its next
+// step. Returns ErrFinished if the job has already finished.
+func (manager *Manager) Cancel(jobID int64) error {
+	manager.mutex.Lock()
+	cancelled := manager.active[jobID]
+	if cancelled == nil {
+		manager.mutex.Unlock()
+		return ErrFinished
+	}
+	cancelled.cancel()
+	started := cancelled.started
+	if !started {
+		delete(manager.active, jobID)
+	}
+	manager.mutex.Unlock()
+
+	if !started {
+		return manager.repository.FinishJob(jobID, db.JobCancelled, nil, ErrCancelled.Error())
+	}
+	return nil
+}
+
+// work runs queued jobs until the process exits
+func (manager *Manager) work() {
+	for queued := range manager.queue {
+		manager.mutex.Lock()
+		_, stillQueued := manager.active[queued.id]
+		queued.started = stillQueued
+		manager.mutex.Unlock()
+
+		// Cancelled while waiting
+		if !stillQueued {
+			continue
+		}
+		manager.runJob(queued)
+	}
+}
+
+// runJob runs one job and records its outcome
+func (manager *Manager) runJob(running *activeJob) {
+	if err := manager.repository.StartJob(running.id); err != nil {
+		log.Printf("jobs: failed to start job %d: %v", running.id, err)
+	}
+
+	step := func(description string) error {
+		if running.context.Err() != nil {
+			return ErrCancelled
+		}
+		manager.mutex.Lock()
+		running.logs = append(running.logs, db.JobLog{Timestamp: time.Now().UTC(), Message: description})
+		manager.mutex.Unlock()
+		return nil
+	}
+
+	result, err := manager.safeRun(running.run, step)
+	switch {
+	case errors.Is(err, ErrCancelled):
+		manager.finish(running, db.JobCancelled, nil, err.Error())
+	case err != nil:
+		manager.finish(running, db.JobFailed, nil, err.Error())
+	default:
+		resultJSON, marshalErr := json.Marshal(result)
+		if marshalErr != nil {
+			manager.finish(running, db.JobFailed, nil, fmt.Sprintf("failed to encode result: %v", marshalErr))
+			return
+		}
+		manager.finish(running, db.JobSucceeded, resultJSON, "")
+	}
+}
+
+// safeRun runs a job function, turning a panic into an error so the worker survives
+func (manager *Manager) safeRun(run Func, step txn.StepObserver) (result any, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return run(step)
+}
+
+// finish stores the logs and final status of a job and forgets it
+func (manager *Manager) finish(finished *activeJob, status string, result []byte, errorMessage string) {
+	manager.mutex.Lock()
+	logs := append([]db.JobLog(nil), finished.logs...)
+	manager.mutex.Unlock()
+
+	for _, jobLog := range logs {
+		if err := manager.repository.AddJobLog(finished.id, jobLog); err != nil {
+			log.Printf("jobs: failed to store log of job %d: %v", finished.id, err)
+		}
+	}
+	if err := manager.repository.FinishJob(finished.id, status, result, errorMessage); err != nil {
+		log.Printf("jobs: failed to finish job %d: %v", finished.id, err)
+	}
+
+	manager.mutex.Lock()
+	delete(manager.active, finished.id)
+	manager.mutex.Unlock()
+	finished.cancel()
+}
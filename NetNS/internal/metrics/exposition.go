@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the Prometheus text exposition format content type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metric types
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// label is a single metric label
+type label struct {
+	name  string
+	value string
+}
+
+// sample is a single value of a metric family
+type sample struct {
+	suffix string // Appended to the family name (e.g. "_bucket")
+	labels []label
+	value  float64
+}
+
+// family is a group of samples sharing a name, help text and type
+type family struct {
+	name       string
+	help       string
+	metricType string
+	samples    []sample
+}
+
+// add appends a sample with the given labels (name/value pairs)
+func (metricFamily *family) add(value float64, labelPairs ...string) {
+	metricFamily.addWithSuffix("", value, labelPairs...)
+}
+
+// addWithSuffix appends a sample with a name suffix and labels (name/value pairs)
+func (metricFamily *family) addWithSuffix(suffix string, value float64, labelPairs ...string) {
+	labels := make([]label, 0, len(labelPairs)/2)
+	for index := 0; index+1 < len(labelPairs); index += 2 {
+		labels = append(labels, label{name: labelPairs[index], value: labelPairs[index+1]})
+	}
+	metricFamily.samples = append(metricFamily.samples, sample{suffix: suffix, labels: labels, value: value})
+}
+
+// writeTo writes the family in Prometheus text format
+func (metricFamily *family) writeTo(writer io.Writer) error {
+	if len(metricFamily.samples) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s %s\n",
+		metricFamily.name, metricFamily.help, metricFamily.name, metricFamily.metricType); err != nil {
+		return err
+	}
+
+	for _, metricSample := range metricFamily.samples {
+		var line strings.Builder
+		line.WriteString(metricFamily.name)
+		line.WriteString(metricSample.suffix)
+
+		if len(metricSample.labels) > 0 {
+			line.WriteByte('{')
+			for index, metricLabel := range metricSample.labels {
+				if index > 0 {
+					line.WriteByte(',')
+				}
+				line.WriteString(metricLabel.name)
+				line.WriteString(`="`)
+				line.WriteString(escapeLabelValue(metricLabel.value))
+				line.WriteByte('"')
+			}
+			line.WriteByte('}')
+		}
+
+		line.WriteByte(' ')
+		line.WriteString(formatValue(metricSample.value))
+		line.WriteByte('\n')
+
+		if _, err := io.WriteString(writer, line.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeLabelValue escapes backslashes, quotes and newlines in label values
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatValue formats a sample value, using Prometheus notation for infinity
+func formatValue(value float64) string {
+	switch {
+	case value > 1e308:
+		return "+Inf"
+	case value < -1e308:
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// sortedKeys returns map keys in a stable order
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
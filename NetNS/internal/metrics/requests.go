@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// requestDurationBuckets are the upper bounds (seconds) of the request duration histogram
+var requestDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// requestKey identifies a request counter
+type requestKey struct {
+	method string
+	route  string
+	status int
+}
+
+// durationKey identifies a request duration histogram
+type durationKey struct {
+	method string
+	route  string
+}
+
+// durationHistogram holds cumulative bucket counts, sum and count
+type durationHistogram struct {
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+// RequestMetrics records API request counts and durations
+type RequestMetrics struct {
+	mutex     sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[durationKey]*durationHistogram
+}
+
+// NewRequestMetrics creates an empty request metrics recorder
+func NewRequestMetrics() *RequestMetrics {
+	return &RequestMetrics{
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[durationKey]*durationHistogram),
+	}
+}
+
+// Observe records a completed request
+// Parameters:
+//   - method: HTTP method
+//   - route: matched route pattern (e.g., "/api/v1/namespaces/:name"), not the raw path
+//   - status: HTTP status code
+//   - duration: time taken to serve the request
+func (requestMetrics *RequestMetrics) Observe(method, route string, status int, duration time.Duration) {
+	requestMetrics.mutex.Lock()
+	defer requestMetrics.mutex.Unlock()
+
+	requestMetrics.requests[requestKey{method: method, route: route, status: status}]++
+
+	key := durationKey{method: method, route: route}
+	histogram, ok := requestMetrics.durations[key]
+	if !ok {
+		histogram = &durationHistogram{buckets: make([]uint64, len(requestDurationBuckets))}
+		requestMetrics.durations[key] = histogram
+	}
+
+	seconds := duration.Seconds()
+	for index, upperBound := range requestDurationBuckets {
+		if seconds <= upperBound {
+			histogram.buckets[index]++
+		}
+	}
+	histogram.sum += seconds
+	histogram.count++
+}
+
+// Write writes the request metrics in Prometheus text format
+func (requestMetrics *RequestMetrics) Write(writer io.Writer) error {
+	requestMetrics.mutex.Lock()
+	defer requestMetrics.mutex.Unlock()
+
+	requestsFamily := &family{
+		name:       "netns_api_requests_total",
+		help:       "Total API requests by method, route and status.",
+		metricType: typeCounter,
+	}
+	for key, count := range requestMetrics.requests {
+		requestsFamily.add(float64(count), "method", key.method, "route", key.route, "status", strconv.Itoa(key.status))
+	}
+
+	durationFamily := &family{
+		name:       "netns_api_request_duration_seconds",
+		help:       "API request duration in seconds by method and route.",
+		metricType: typeHistogram,
+	}
+	for key, histogram := range requestMetrics.durations {
+		for index, upperBound := range requestDurationBuckets {
+			durationFamily.addWithSuffix("_bucket", float64(histogram.buckets[index]),
+				"method", key.method, "route", key.route, "le", formatValue(upperBound))
+		}
+		durationFamily.addWithSuffix("_bucket", float64(histogram.count),
+			"method", key.method, "route", key.route, "le", formatValue(math.Inf(1)))
+		durationFamily.addWithSuffix("_sum", histogram.sum, "method", key.method, "route", key.route)
+		durationFamily.addWithSuffix("_count", float64(histogram.count), "method", key.method, "route", key.route)
+	}
+
+	if err := requestsFamily.writeTo(writer); err != nil {
+		return err
+	}
+	return durationFamily.writeTo(writer)
+}
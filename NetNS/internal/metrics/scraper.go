@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+)
+
+// Scraper periodically collects interface counters and resource counts
+// Collection runs in the background so /metrics never blocks on netlink.
+type Scraper struct {
+	namespaceManager *netns.Manager
+	linkManager      *netns.LinkManager
+	repository       *db.Repository
+
+	mutex          sync.RWMutex
+	statistics     []netns.InterfaceStatistics
+	resourceCounts map[string]int64
+	lastScrape     time.Time
+	scrapeDuration time.Duration
+	scrapeErrors   uint64
+
+	stopChannel chan struct{}
+	stopOnce    sync.Once
+}
+
+// NewScraper creates a new metrics scraper
+func NewScraper(namespaceManager *netns.Manager, repository *db.Repository) *Scraper {
+	return &Scraper{
+		namespaceManager: namespaceManager,
+		linkManager:      netns.NewLinkManager(namespaceManager),
+		repository:       repository,
+		resourceCounts:   make(map[string]int64),
+		stopChannel:      make(chan struct{}),
+	}
+}
+
+// Start scrapes immediately and then every interval until Stop is called
+// Parameters:
+//   - interval: time between scrapes
+func (scraper *Scraper) Start(interval time.Duration) {
+	scraper.Scrape()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				scraper.Scrape()
+			case <-scraper.stopChannel:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background scraper
+func (scraper *Scraper) Stop() {
+	scraper.stopOnce.Do(func() {
+		close(scraper.stopChannel)
+	})
+}
+
+// Scrape collects counters from the host and every namespace, and resource counts from the database
+func (scraper *Scraper) Scrape() {
+	startTime := time.Now()
+	var scrapeErrors uint64
+
+	statistics, err := scraper.linkManager.Statistics("")
+	if err != nil {
+		log.Printf("metrics: failed to read host statistics: %v", err)
+		scrapeErrors++
+	}
+
+	namespaceNames, err := scraper.namespaceManager.List()
+	if err != nil {
+		log.Printf("metrics: failed to list namespaces: %v", err)
+		scrapeErrors++
+	}
+	for _, namespaceName := range namespaceNames {
+		namespaceStatistics, err := scraper.linkManager.Statistics(namespaceName)
+		if err != nil {
+			log.Printf("metrics: failed to read statistics in %s: %v", namespaceName, err)
+			scrapeErrors++
+			continue
+		}
+		statistics = append(statistics, namespaceStatistics...)
+	}
+
+	resourceCounts, err := scraper.repository.CountResources()
+	if err != nil {
+		log.Printf("metrics: failed to count resources: %v", err)
+		scrapeErrors++
+	}
+
+	scraper.mutex.Lock()
+	defer scraper.mutex.Unlock()
+
+	scraper.statistics = statistics
+	if resourceCounts != nil {
+		scraper.resourceCounts = resourceCounts
+	}
+	scraper.lastScrape = time.Now()
+	scraper.scrapeDuration = time.Since(startTime)
+	scraper.scrapeErrors += scrapeErrors
+}
+
+// Write writes the most recent scrape in Prometheus text format
+func (scraper *Scraper) Write(writer io.Writer) error {
+	scraper.mutex.RLock()
+	defer scraper.mutex.RUnlock()
+
+	counterFamily := func(name, help string) *family {
+		return &family{name: name, help: help, metricType: typeCounter}
+	}
+
+	rxBytes := counterFamily("netns_interface_receive_bytes_total", "Bytes received by interface.")
+	rxPackets := counterFamily("netns_interface_receive_packets_total", "Packets received by interface.")
+	rxErrors := counterFamily("netns_interface_receive_errors_total", "Receive errors by interface.")
+	rxDropped := counterFamily("netns_interface_receive_drops_total", "Received packets dropped by interface.")
+	txBytes := counterFamily("netns_interface_transmit_bytes_total", "Bytes transmitted by interface.")
+	txPackets := counterFamily("netns_interface_transmit_packets_total", "Packets transmitted by interface.")
+	txErrors := counterFamily("netns_interface_transmit_errors_total", "Transmit errors by interface.")
+	txDropped := counterFamily("netns_interface_transmit_drops_total", "Transmitted packets dropped by interface.")
+	interfaceUp := &family{
+		name:       "netns_interface_up",
+		help:       "Whether the interface is up (1) or down (0).",
+		metricType: typeGauge,
+	}
+	greUp := &family{
+		name:       "netns_gre_tunnel_up",
+		help:       "Whether the GRE tunnel is up (1) or down (0).",
+		metricType: typeGauge,
+	}
+
+	for _, interfaceStatistics := range scraper.statistics {
+		labels := []string{"namespace", interfaceStatistics.Namespace, "interface", interfaceStatistics.Name}
+
+		rxBytes.add(float64(interfaceStatistics.RxBytes), labels...)
+		rxPackets.add(float64(interfaceStatistics.RxPackets), labels...)
+		rxErrors.add(float64(interfaceStatistics.RxErrors), labels...)
+		rxDropped.add(float64(interfaceStatistics.RxDropped), labels...)
+		txBytes.add(float64(interfaceStatistics.TxBytes), labels...)
+		txPackets.add(float64(interfaceStatistics.TxPackets), labels...)
+		txErrors.add(float64(interfaceStatistics.TxErrors), labels...)
+		txDropped.add(float64(interfaceStatistics.TxDropped), labels...)
+
+		upValue := 0.0
+		if interfaceStatistics.Up {
+			upValue = 1
+		}
+		interfaceUp.add(upValue, append(labels, "type", interfaceStatistics.Type)...)
+
+		if interfaceStatistics.Type == "gre" || interfaceStatistics.Type == "gretap" {
+			greUp.add(upValue, "namespace", interfaceStatistics.Namespace, "tunnel", interfaceStatistics.Name)
+		}
+	}
+
+	resources := &family{
+		name:       "netns_managed_resources",
+		help:       "Number of managed resources recorded in the database, by table.",
+		metricType: typeGauge,
+	}
+	for _, table := range sortedKeys(scraper.resourceCounts) {
+		resources.add(float64(scraper.resourceCounts[table]), "resource", table)
+	}
+
+	scrapeDuration := &family{
+		name:       "netns_scrape_duration_seconds",
+		help:       "Duration of the last metrics scrape.",
+		metricType: typeGauge,
+	}
+	scrapeErrors := counterFamily("netns_scrape_errors_total", "Errors encountered while scraping.")
+	lastScrape := &family{
+		name:       "netns_last_scrape_timestamp_seconds",
+		help:       "Unix time of the last metrics scrape.",
+		metricType: typeGauge,
+	}
+	if !scraper.lastScrape.IsZero() {
+		scrapeDuration.add(scraper.scrapeDuration.Seconds())
+		scrapeErrors.add(float64(scraper.scrapeErrors))
+		lastScrape.add(float64(scraper.lastScrape.UnixNano()) / 1e9)
+	}
+
+	for _, metricFamily := range []*family{
+		rxBytes, rxPackets, rxErrors, rxDropped,
+		txBytes, txPackets, txErrors, txDropped,
+		interfaceUp, greUp, resources,
+		scrapeDuration, scrapeErrors, lastScrape,
+	} {
+		if err := metricFamily.writeTo(writer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
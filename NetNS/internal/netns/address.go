@@ -12,6 +12,12 @@ const (
 	familyAll = 0 // AF_UNSPEC - matches both IPv4 and IPv6
 )
 
+// addressFlagSecondary marks the secondary addresses of a subnet (IFA_F_SECONDARY)
+const addressFlagSecondary = 0x01
+
+// infiniteLifetime is the kernel's lifetime of permanent addresses (INFINITY_LIFE_TIME)
+const infiniteLifetime = 0xFFFFFFFF
+
 // AddressManager handles IP address operations
 type AddressManager struct {
 	namespaceManager *Manager
@@ -88,6 +94,85 @@ func (addressManager *AddressManager) Delete(address, interfaceName, namespaceNa
 	return netlinkHandle.AddrDel(networkLink, parsedAddress)
 }
 
+// AddressOptions are the mutable settings of an assigned address
+type AddressOptions struct {
+	Label             string // IPv4 address label, e.g. "eth0:web" (empty = interface name)
+	ValidLifetime     int    // Seconds until the address is removed (0 = forever)
+	PreferredLifetime int    // Seconds until the address is deprecated (0 = when it is removed)
+}
+
+// Update changes the label and lifetimes of an address assigned to an interface
+// Lifetimes are replaced in place. The kernel cannot relabel an address, so a new
+// label re-adds the address, which briefly removes it from the interface.
+// Parameters:
+//   - address: IP address in CIDR format (e.g., "10.0.0.1/24")
+//   - interfaceName: name of the interface holding the address
+//   - namespaceName: namespace where interface exists (empty = host)
+//   - addressOptions: new label and lifetimes
+func (addressManager *AddressManager) Update(address, interfaceName, namespaceName string, addressOptions AddressOptions) error {
+	parsedAddress, err := netlink.ParseAddr(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", address, err)
+	}
+
+	netlinkHandle, err := addressManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return err
+	}
+	defer netlinkHandle.Close()
+
+	networkLink, err := netlinkHandle.LinkByName(interfaceName)
+	if err != nil {
+		return fmt.Errorf("failed to find interface %q: %w", interfaceName, err)
+	}
+
+	// Find the assigned address for its current label
+	assignedAddresses, err := netlinkHandle.AddrList(networkLink, familyAll)
+	if err != nil {
+		return err
+	}
+	var assignedAddress *netlink.Addr
+	for index := range assignedAddresses {
+		if assignedAddresses[index].Equal(*parsedAddress) {
+			assignedAddress = &assignedAddresses[index]
+			break
+		}
+	}
+	if assignedAddress == nil {
+		return fmt.Errorf("address %s is not assigned to interface %q", address, interfaceName)
+	}
+
+	// Netlink sends lifetimes only if one is set, and then takes 0 literally
+	parsedAddress.ValidLft = addressOptions.ValidLifetime
+	parsedAddress.PreferedLft = addressOptions.PreferredLifetime
+	if parsedAddress.ValidLft > 0 && parsedAddress.PreferedLft == 0 {
+		parsedAddress.PreferedLft = parsedAddress.ValidLft
+	}
+	if parsedAddress.IP.To4() != nil {
+		parsedAddress.Label = addressOptions.Label
+		if parsedAddress.Label == "" {
+			parsedAddress.Label = interfaceName
+		}
+	}
+
+	if parsedAddress.Label != assignedAddress.Label {
+		// Deleting a primary address also deletes the secondaries of its subnet
+		if assignedAddress.Flags&addressFlagSecondary == 0 {
+			for _, otherAddress := range assignedAddresses {
+				if otherAddress.Flags&addressFlagSecondary != 0 && assignedAddress.Contains(otherAddress.IP) {
+					return fmt.Errorf("cannot relabel %s: re-adding a primary address would remove the secondary address %s of its subnet",
+						address, otherAddress.IPNet.String())
+				}
+			}
+		}
+		if err := netlinkHandle.AddrDel(networkLink, assignedAddress); err != nil {
+			return fmt.Errorf("failed to relabel address %s: %w", address, err)
+		}
+		return netlinkHandle.AddrAdd(networkLink, parsedAddress)
+	}
+	return addrReplace(netlinkHandle, networkLink, parsedAddress)
+}
+
 // List lists all addresses on an interface
 // Parameters:
 //   - interfaceName: name of the interface to list addresses for
@@ -165,10 +250,13 @@ func (addressManager *AddressManager) ListAll(namespaceName string) (map[string]
 
 // AddressInfo contains formatted address information
 type AddressInfo struct {
-	Interface string `json:"interface"`
-	Address   string `json:"address"`
-	Family    string `json:"family"`
-	Scope     string `json:"scope"`
+	Interface         string `json:"interface"`
+	Address           string `json:"address"`
+	Family            string `json:"family"`
+	Scope             string `json:"scope"`
+	Label             string `json:"label,omitempty"`              // IPv4 address label
+	ValidLifetime     int    `json:"valid_lifetime,omitempty"`     // Seconds left (0 = forever)
+	PreferredLifetime int    `json:"preferred_lifetime,omitempty"` // Seconds left (0 = forever)
 }
 
 // GetAddressInfos returns formatted address information
@@ -191,10 +279,13 @@ func (addressManager *AddressManager) GetAddressInfos(namespaceName string) ([]A
 			addressScope := scopeToString(address.Scope)
 
 			addressInfoList = append(addressInfoList, AddressInfo{
-				Interface: interfaceName,
-				Address:   address.IPNet.String(),
-				Family:    addressFamily,
-				Scope:     addressScope,
+				Interface:         interfaceName,
+				Address:           address.IPNet.String(),
+				Family:            addressFamily,
+				Scope:             addressScope,
+				Label:             address.Label,
+				ValidLifetime:     finiteLifetime(address.ValidLft),
+				PreferredLifetime: finiteLifetime(address.PreferedLft),
 			})
 		}
 	}
@@ -202,6 +293,14 @@ func (addressManager *AddressManager) GetAddressInfos(namespaceName string) ([]A
 	return addressInfoList, nil
 }
 
+// finiteLifetime converts a kernel address lifetime to seconds, with 0 for forever
+func finiteLifetime(lifetime int) int {
+	if lifetime < 0 || uint32(lifetime) == infiniteLifetime {
+		return 0
+	}
+	return lifetime
+}
+
 func scopeToString(scopeValue int) string {
 	switch scopeValue {
 	case 0:
@@ -0,0 +1,212 @@
+package netns
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+)
+
+// BondManager handles bond (link aggregation) operations
+type BondManager struct {
+	namespaceManager *Manager
+}
+
+// NewBondManager creates a new bond manager
+func NewBondManager(namespaceManager *Manager) *BondManager {
+	return &BondManager{namespaceManager: namespaceManager}
+}
+
+// Bond represents a bond configuration
+type Bond struct {
+	Name      string   // Bond interface name (e.g., bond0)
+	Mode      string   // Bond mode (active-backup, balance-rr, 802.3ad, ...)
+	Miimon    int      // MII link monitoring interval in milliseconds (0 = disabled)
+	Primary   string   // Preferred slave for active-backup (empty = none)
+	Slaves    []string // Slave interface names
+	Namespace string   // Namespace where bond and slaves exist (empty = host)
+}
+
+// Create creates a bond and enslaves the given interfaces
+func (bondManager *BondManager) Create(bondConfig Bond) error {
+	bondMode := netlink.StringToBondMode(bondConfig.Mode)
+	if bondMode == netlink.BOND_MODE_UNKNOWN {
+		return fmt.Errorf("invalid bond mode %q", bondConfig.Mode)
+	}
+
+	netlinkHandle, err := bondManager.namespaceManager.GetNetlinkHandleOrHost(bondConfig.Namespace)
+	if err != nil {
+		return err
+	}
+	defer netlinkHandle.Close()
+
+	// Resolve slaves before creating anything
+	var slaveLinks []netlink.Link
+	for _, slaveName := range bondConfig.Slaves {
+		slaveLink, err := netlinkHandle.LinkByName(slaveName)
+		if err != nil {
+			return fmt.Errorf("failed to find slave interface %q: %w", slaveName, err)
+		}
+		slaveLinks = append(slaveLinks, slaveLink)
+	}
+
+	bondLink := netlink.NewLinkBond(netlink.LinkAttrs{Name: bondConfig.Name})
+	bondLink.Mode = bondMode
+	if bondConfig.Miimon > 0 {
+		bondLink.Miimon = bondConfig.Miimon
+	}
+
+	if err := netlinkHandle.LinkAdd(bondLink); err != nil {
+		return fmt.Errorf("failed to create bond: %w", err)
+	}
+
+	createdBond, err := netlinkHandle.LinkByName(bondConfig.Name)
+	if err != nil {
+		netlinkHandle.LinkDel(bondLink)
+		return fmt.Errorf("failed to find created bond %q: %w", bondConfig.Name, err)
+	}
+
+	// Enslave interfaces (slaves must be down to join a bond)
+	for _, slaveLink := range slaveLinks {
+		if err := netlinkHandle.LinkSetDown(slaveLink); err != nil {
+			netlinkHandle.LinkDel(createdBond)
+			return fmt.Errorf("failed to bring down %q: %w", slaveLink.Attrs().Name, err)
+		}
+		if err := netlinkHandle.LinkSetMaster(slaveLink, createdBond); err != nil {
+			netlinkHandle.LinkDel(createdBond)
+			return fmt.Errorf("failed to enslave %q: %w", slaveLink.Attrs().Name, err)
+		}
+		if err := netlinkHandle.LinkSetUp(slaveLink); err != nil {
+			netlinkHandle.LinkDel(createdBond)
+			return fmt.Errorf("failed to bring up %q: %w", slaveLink.Attrs().Name, err)
+		}
+	}
+
+	// Set primary slave once it is enslaved
+	if bondConfig.Primary != "" {
+		primaryLink, err := netlinkHandle.LinkByName(bondConfig.Primary)
+		if err != nil {
+			netlinkHandle.LinkDel(createdBond)
+			return fmt.Errorf("failed to find primary interface %q: %w", bondConfig.Primary, err)
+		}
+
+		primaryUpdate := netlink.NewLinkBond(netlink.LinkAttrs{
+			Name:  bondConfig.Name,
+			Index: createdBond.Attrs().Index,
+		})
+		primaryUpdate.Primary = primaryLink.Attrs().Index
+		if err := linkModify(netlinkHandle, primaryUpdate); err != nil {
+			netlinkHandle.LinkDel(createdBond)
+			return fmt.Errorf("failed to set primary %q: %w", bondConfig.Primary, err)
+		}
+	}
+
+	if err := netlinkHandle.LinkSetUp(createdBond); err != nil {
+		netlinkHandle.LinkDel(createdBond)
+		return fmt.Errorf("failed to bring up bond: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a bond (slaves are released by the kernel)
+// Parameters:
+//   - bondName: name of the bond to delete
+//   - namespaceName: namespace where bond exists (empty = host)
+func (bondManager *BondManager) Delete(bondName, namespaceName string) error {
+	netlinkHandle, err := bondManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return err
+	}
+	defer netlinkHandle.Close()
+
+	bondLink, err := netlinkHandle.LinkByName(bondName)
+	if err != nil {
+		return fmt.Errorf("bond %q not found: %w", bondName, err)
+	}
+
+	if bondLink.Type() != "bond" {
+		return fmt.Errorf("link %q is a %s, not a bond", bondName, bondLink.Type())
+	}
+
+	return netlinkHandle.LinkDel(bondLink)
+}
+
+// BondSlaveInfo contains per-slave bond information
+type BondSlaveInfo struct {
+	Name         string `json:"name"`
+	State        string `json:"state"`      // active or backup
+	MiiStatus    string `json:"mii_status"` // up, going_down, down, going_back
+	LinkFailures uint32 `json:"link_failures"`
+}
+
+// BondInfo contains bond information with slave states
+type BondInfo struct {
+	Name        string          `json:"name"`
+	Mode        string          `json:"mode"`
+	Miimon      int             `json:"miimon"`
+	Primary     string          `json:"primary,omitempty"`
+	ActiveSlave string          `json:"active_slave,omitempty"`
+	State       string          `json:"state"`
+	Slaves      []BondSlaveInfo `json:"slaves"`
+}
+
+// List returns all bonds in a namespace (or host if empty)
+// Parameters:
+//   - namespaceName: namespace to list bonds from (empty = host)
+func (bondManager *BondManager) List(namespaceName string) ([]BondInfo, error) {
+	netlinkHandle, err := bondManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	defer netlinkHandle.Close()
+
+	networkLinks, err := netlinkHandle.LinkList()
+	if err != nil {
+		return nil, err
+	}
+
+	// Index links for resolving active slave, primary and members
+	linkNamesByIndex := make(map[int]string)
+	for _, networkLink := range networkLinks {
+		linkNamesByIndex[networkLink.Attrs().Index] = networkLink.Attrs().Name
+	}
+
+	var bondInfoList []BondInfo
+	for _, networkLink := range networkLinks {
+		bondLink, ok := networkLink.(*netlink.Bond)
+		if !ok {
+			continue
+		}
+
+		bondInfo := BondInfo{
+			Name:        bondLink.Name,
+			Mode:        bondLink.Mode.String(),
+			Miimon:      bondLink.Miimon,
+			Primary:     linkNamesByIndex[bondLink.Primary],
+			ActiveSlave: linkNamesByIndex[bondLink.ActiveSlave],
+			State:       "down",
+			Slaves:      []BondSlaveInfo{},
+		}
+		if bondLink.Flags&1 != 0 { // IFF_UP
+			bondInfo.State = "up"
+		}
+
+		for _, memberLink := range networkLinks {
+			if memberLink.Attrs().MasterIndex != bondLink.Index {
+				continue
+			}
+
+			slaveInfo := BondSlaveInfo{Name: memberLink.Attrs().Name}
+			if bondSlave, ok := memberLink.Attrs().Slave.(*netlink.BondSlave); ok {
+				slaveInfo.State = strings.ToLower(bondSlave.State.String())
+				slaveInfo.MiiStatus = strings.ToLower(bondSlave.MiiStatus.String())
+				slaveInfo.LinkFailures = bondSlave.LinkFailureCount
+			}
+			bondInfo.Slaves = append(bondInfo.Slaves, slaveInfo)
+		}
+
+		bondInfoList = append(bondInfoList, bondInfo)
+	}
+
+	return bondInfoList, nil
+}
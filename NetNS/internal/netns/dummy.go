@@ -0,0 +1,140 @@
+package netns
+
+import (
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+)
+
+// DummyManager handles dummy interface operations
+type DummyManager struct {
+	namespaceManager *Manager
+	addressManager   *AddressManager
+}
+
+// NewDummyManager creates a new dummy interface manager
+func NewDummyManager(namespaceManager *Manager) *DummyManager {
+	return &DummyManager{
+		namespaceManager: namespaceManager,
+		addressManager:   NewAddressManager(namespaceManager),
+	}
+}
+
+// Create creates a dummy interface, brings it up and assigns addresses
+// Dummy interfaces are always up and never lose carrier, which makes them
+// suitable for router IDs, BGP update-source and anycast VIPs.
+// Parameters:
+//   - interfaceName: name of the dummy interface (e.g., "lo1")
+//   - addresses: IP addresses in CIDR format to assign (may be empty)
+//   - namespaceName: namespace to create interface in (empty = host)
+func (dummyManager *DummyManager) Create(interfaceName string, addresses []string, namespaceName string) error {
+	netlinkHandle, err := dummyManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return err
+	}
+	defer netlinkHandle.Close()
+
+	dummyLink := &netlink.Dummy{
+		LinkAttrs: netlink.LinkAttrs{
+			Name: interfaceName,
+		},
+	}
+
+	if err := netlinkHandle.LinkAdd(dummyLink); err != nil {
+		return fmt.Errorf("failed to create dummy interface: %w", err)
+	}
+
+	// Get the link again to set it up
+	networkLink, err := netlinkHandle.LinkByName(interfaceName)
+	if err != nil {
+		return err
+	}
+
+	if err := netlinkHandle.LinkSetUp(networkLink); err != nil {
+		netlinkHandle.LinkDel(networkLink)
+		return err
+	}
+
+	for _, address := range addresses {
+		if err := dummyManager.addressManager.Add(address, interfaceName, namespaceName); err != nil {
+			// Cleanup on failure
+			netlinkHandle.LinkDel(networkLink)
+			return fmt.Errorf("failed to assign %s to %s: %w", address, interfaceName, err)
+		}
+	}
+
+	return nil
+}
+
+// Delete removes a dummy interface (its addresses go with it)
+// Parameters:
+//   - interfaceName: name of the dummy interface to delete
+//   - namespaceName: namespace where interface exists (empty = host)
+func (dummyManager *DummyManager) Delete(interfaceName, namespaceName string) error {
+	netlinkHandle, err := dummyManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return err
+	}
+	defer netlinkHandle.Close()
+
+	networkLink, err := netlinkHandle.LinkByName(interfaceName)
+	if err != nil {
+		return fmt.Errorf("dummy interface %q not found: %w", interfaceName, err)
+	}
+
+	if networkLink.Type() != "dummy" {
+		return fmt.Errorf("link %q is a %s, not a dummy interface", interfaceName, networkLink.Type())
+	}
+
+	return netlinkHandle.LinkDel(networkLink)
+}
+
+// DummyInfo contains dummy interface information with its addresses
+type DummyInfo struct {
+	Name      string   `json:"name"`
+	State     string   `json:"state"`
+	Addresses []string `json:"addresses"`
+}
+
+// List returns all dummy interfaces in a namespace (or host if empty)
+// Parameters:
+//   - namespaceName: namespace to list interfaces from (empty = host)
+func (dummyManager *DummyManager) List(namespaceName string) ([]DummyInfo, error) {
+	netlinkHandle, err := dummyManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	defer netlinkHandle.Close()
+
+	networkLinks, err := netlinkHandle.LinkList()
+	if err != nil {
+		return nil, err
+	}
+
+	var dummyInfoList []DummyInfo
+	for _, networkLink := range networkLinks {
+		if networkLink.Type() != "dummy" {
+			continue
+		}
+
+		dummyInfo := DummyInfo{
+			Name:      networkLink.Attrs().Name,
+			State:     "down",
+			Addresses: []string{},
+		}
+		if networkLink.Attrs().Flags&1 != 0 { // IFF_UP
+			dummyInfo.State = "up"
+		}
+
+		addresses, err := netlinkHandle.AddrList(networkLink, familyAll)
+		if err == nil {
+			for _, address := range addresses {
+				dummyInfo.Addresses = append(dummyInfo.Addresses, address.IPNet.String())
+			}
+		}
+
+		dummyInfoList = append(dummyInfoList, dummyInfo)
+	}
+
+	return dummyInfoList, nil
+}
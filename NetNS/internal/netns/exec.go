@@ -0,0 +1,58 @@
+package netns
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/vishvananda/netns"
+)
+
+// etcOverlayPath holds per-namespace files bind mounted over /etc, as with ip netns exec
+const etcOverlayPath = "/etc/netns"
+
+// StartCommand starts a prepared command inside a namespace without waiting for it
+// The command is forked from a thread that enters the namespace and is discarded
+// afterwards, so the caller's namespaces are never changed. Pdeathsig must not be
+// set on the command: it would fire as soon as that thread ends.
+// Parameters:
+//   - command: command to start, with its arguments, environment and I/O set up
+//   - namespaceName: namespace to run the command in
+//   - mountNamespace: enter a private mount namespace as ip netns exec does
+func (namespaceManager *Manager) StartCommand(command *exec.Cmd, namespaceName string, mountNamespace bool) error {
+	startResult := make(chan error, 1)
+	go func() {
+		// Never unlocked: the runtime ends the thread with the goroutine
+		runtime.LockOSThread()
+
+		if err := namespaceManager.enterNamespace(namespaceName, mountNamespace); err != nil {
+			startResult <- err
+			return
+		}
+		startResult <- command.Start()
+	}()
+	return <-startResult
+}
+
+// enterNamespace moves the calling (locked) thread into a namespace
+// Parameters:
+//   - namespaceName: namespace to enter
+//   - mountNamespace: also unshare the mount namespace and set it up for the namespace
+func (namespaceManager *Manager) enterNamespace(namespaceName string, mountNamespace bool) error {
+	targetNamespace, err := namespaceManager.GetHandle(namespaceName)
+	if err != nil {
+		return fmt.Errorf("failed to get namespace %q: %w", namespaceName, err)
+	}
+	defer targetNamespace.Close()
+
+	if err := netns.Set(targetNamespace); err != nil {
+		return fmt.Errorf("failed to enter namespace %q: %w", namespaceName, err)
+	}
+
+	if mountNamespace {
+		if err := setupMountNamespace(namespaceName); err != nil {
+			return fmt.Errorf("failed to set up mount namespace for %q: %w", namespaceName, err)
+		}
+	}
+	return nil
+}
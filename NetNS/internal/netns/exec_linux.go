@@ -0,0 +1,58 @@
+//go:build linux
+
+package netns
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// statfsReadOnly is the ST_RDONLY flag of statfs
+const statfsReadOnly = 0x1
+
+// setupMountNamespace gives the calling thread a private mount namespace for a network namespace
+// Mount changes stay private, sysfs is remounted so /sys/class/net lists the namespace's
+// interfaces, and every file in /etc/netns/<name> is bind mounted over its /etc counterpart.
+// Parameters:
+//   - namespaceName: network namespace the thread has entered
+func setupMountNamespace(namespaceName string) error {
+	if err := syscall.Unshare(syscall.CLONE_NEWNS); err != nil {
+		return fmt.Errorf("unshare: %w", err)
+	}
+
+	// Keep the mounts below from propagating back to the host
+	if err := syscall.Mount("", "/", "none", syscall.MS_SLAVE|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("failed to make / a slave mount: %w", err)
+	}
+
+	// Sysfs reflects the network namespace of whoever mounts it
+	var sysfsStat syscall.Statfs_t
+	var sysfsFlags uintptr
+	if err := syscall.Statfs("/sys", &sysfsStat); err == nil && sysfsStat.Flags&statfsReadOnly != 0 {
+		sysfsFlags = syscall.MS_RDONLY
+	}
+	if err := syscall.Unmount("/sys", syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("failed to unmount /sys: %w", err)
+	}
+	if err := syscall.Mount(namespaceName, "/sys", "sysfs", sysfsFlags, ""); err != nil {
+		return fmt.Errorf("failed to mount /sys: %w", err)
+	}
+
+	overlayEntries, err := os.ReadDir(filepath.Join(etcOverlayPath, namespaceName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, overlayEntry := range overlayEntries {
+		overlaySource := filepath.Join(etcOverlayPath, namespaceName, overlayEntry.Name())
+		overlayTarget := filepath.Join("/etc", overlayEntry.Name())
+		if err := syscall.Mount(overlaySource, overlayTarget, "none", syscall.MS_BIND, ""); err != nil {
+			return fmt.Errorf("failed to bind %s over %s: %w", overlaySource, overlayTarget, err)
+		}
+	}
+	return nil
+}
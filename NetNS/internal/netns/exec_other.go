@@ -0,0 +1,8 @@
+//go:build !linux
+
+package netns
+
+// setupMountNamespace is not supported on non-Linux platforms
+func setupMountNamespace(namespaceName string) error {
+	return errNotLinux
+}
@@ -102,6 +102,63 @@ func (greManager *GREManager) CreateWithOptions(tunnelConfig GRETunnel) error {
 	return netlinkHandle.LinkSetUp(tunnelLink)
 }
 
+// Update changes the endpoints, key and TTL of an existing GRE tunnel in place
+// The tunnel interface keeps its index, addresses and routes; a key of 0 removes the key
+// and a TTL of 0 inherits the TTL of the inner packet.
+// Parameters:
+//   - tunnelConfig: tunnel name and namespace, with the new settings
+func (greManager *GREManager) Update(tunnelConfig GRETunnel) error {
+	localIPAddress := net.ParseIP(tunnelConfig.LocalIP)
+	if localIPAddress == nil {
+		return fmt.Errorf("invalid local IP: %s", tunnelConfig.LocalIP)
+	}
+
+	remoteIPAddress := net.ParseIP(tunnelConfig.RemoteIP)
+	if remoteIPAddress == nil {
+		return fmt.Errorf("invalid remote IP: %s", tunnelConfig.RemoteIP)
+	}
+
+	netlinkHandle, err := greManager.namespaceManager.GetNetlinkHandleOrHost(tunnelConfig.Namespace)
+	if err != nil {
+		return err
+	}
+	defer netlinkHandle.Close()
+
+	tunnelLink, err := netlinkHandle.LinkByName(tunnelConfig.Name)
+	if err != nil {
+		return fmt.Errorf("GRE tunnel %q not found: %w", tunnelConfig.Name, err)
+	}
+	existingTunnel, isGRETunnel := tunnelLink.(*netlink.Gretun)
+	if !isGRETunnel {
+		return fmt.Errorf("link %q is a %s, not a GRE tunnel", tunnelConfig.Name, tunnelLink.Type())
+	}
+
+	// Only send the tunnel settings; the other link attributes stay as they are
+	updatedTunnel := &netlink.Gretun{
+		LinkAttrs: netlink.LinkAttrs{
+			Name:  existingTunnel.Name,
+			Index: existingTunnel.Index,
+		},
+		Link:       existingTunnel.Link,
+		Local:      localIPAddress,
+		Remote:     remoteIPAddress,
+		IKey:       tunnelConfig.Key,
+		OKey:       tunnelConfig.Key,
+		Ttl:        tunnelConfig.TTL,
+		Tos:        existingTunnel.Tos,
+		PMtuDisc:   existingTunnel.PMtuDisc,
+		EncapType:  existingTunnel.EncapType,
+		EncapFlags: existingTunnel.EncapFlags,
+		EncapSport: existingTunnel.EncapSport,
+		EncapDport: existingTunnel.EncapDport,
+	}
+
+	if err := linkModify(netlinkHandle, updatedTunnel); err != nil {
+		return fmt.Errorf("failed to update GRE tunnel %q: %w", tunnelConfig.Name, err)
+	}
+	return nil
+}
+
 // Delete removes a GRE tunnel
 // Parameters:
 //   - tunnelName: name of the GRE tunnel interface to delete
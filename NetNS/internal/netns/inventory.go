@@ -0,0 +1,234 @@
+package netns
+
+import (
+	"net"
+
+	"github.com/vishvananda/netlink"
+)
+
+// InterfaceInfo is a normalized view of an interface of any type
+type InterfaceInfo struct {
+	Namespace     string   `json:"namespace"` // Empty = host
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	State         string   `json:"state"`
+	MTU           int      `json:"mtu"`
+	MACAddress    string   `json:"mac_address,omitempty"`
+	TxQueueLen    int      `json:"txqueuelen"`
+	Alias         string   `json:"alias,omitempty"`
+	Master        string   `json:"master,omitempty"`
+	Addresses     []string `json:"addresses"`
+	PeerNamespace *string  `json:"peer_namespace,omitempty"` // veth only; empty = host
+	LocalIP       string   `json:"local_ip,omitempty"`       // tunnels only
+	RemoteIP      string   `json:"remote_ip,omitempty"`      // tunnels only
+	Managed       bool     `json:"managed"`                  // Recorded in the database
+}
+
+// List returns a normalized view of every interface in a namespace (or host if empty)
+// Parameters:
+//   - namespaceName: namespace to list interfaces from (empty = host)
+func (linkManager *LinkManager) List(namespaceName string) ([]InterfaceInfo, error) {
+	netlinkHandle, err := linkManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	defer netlinkHandle.Close()
+
+	networkLinks, err := netlinkHandle.LinkList()
+	if err != nil {
+		return nil, err
+	}
+
+	// Index to name, for masters
+	linkNames := make(map[int]string)
+	for _, networkLink := range networkLinks {
+		linkNames[networkLink.Attrs().Index] = networkLink.Attrs().Name
+	}
+
+	peerNamespaces := linkManager.peerNamespaceNames(netlinkHandle, namespaceName)
+
+	interfaceInfoList := make([]InterfaceInfo, 0, len(networkLinks))
+	for _, networkLink := range networkLinks {
+		linkAttrs := networkLink.Attrs()
+
+		interfaceInfo := InterfaceInfo{
+			Namespace:  namespaceName,
+			Name:       linkAttrs.Name,
+			Type:       networkLink.Type(),
+			State:      LinkStateDown,
+			MTU:        linkAttrs.MTU,
+			MACAddress: linkAttrs.HardwareAddr.String(),
+			TxQueueLen: linkAttrs.TxQLen,
+			Alias:      linkAttrs.Alias,
+			Master:     linkNames[linkAttrs.MasterIndex],
+			Addresses:  []string{},
+		}
+		if linkAttrs.Flags&1 != 0 { // IFF_UP
+			interfaceInfo.State = LinkStateUp
+		}
+
+		switch typedLink := networkLink.(type) {
+		case *netlink.Veth:
+			if linkAttrs.NetNsID < 0 {
+				peerNamespace := namespaceName
+				interfaceInfo.PeerNamespace = &peerNamespace
+			} else if peerNamespace, ok := peerNamespaces[linkAttrs.NetNsID]; ok {
+				interfaceInfo.PeerNamespace = &peerNamespace
+			}
+		case *netlink.Gretun:
+			interfaceInfo.LocalIP = ipString(typedLink.Local)
+			interfaceInfo.RemoteIP = ipString(typedLink.Remote)
+		case *netlink.Gretap:
+			interfaceInfo.LocalIP = ipString(typedLink.Local)
+			interfaceInfo.RemoteIP = ipString(typedLink.Remote)
+		case *netlink.Vxlan:
+			interfaceInfo.LocalIP = ipString(typedLink.SrcAddr)
+			interfaceInfo.RemoteIP = ipString(typedLink.Group)
+		}
+
+		addresses, err := netlinkHandle.AddrList(networkLink, familyAll)
+		if err == nil {
+			for _, address := range addresses {
+				interfaceInfo.Addresses = append(interfaceInfo.Addresses, address.IPNet.String())
+			}
+		}
+
+		interfaceInfoList = append(interfaceInfoList, interfaceInfo)
+	}
+
+	return interfaceInfoList, nil
+}
+
+// ListAll returns a normalized view of every interface on the host and in every namespace
+func (linkManager *LinkManager) ListAll() ([]InterfaceInfo, error) {
+	interfaceInfoList, err := linkManager.List("")
+	if err != nil {
+		return nil, err
+	}
+
+	namespaceNames, err := linkManager.namespaceManager.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, namespaceName := range namespaceNames {
+		namespaceInterfaces, err := linkManager.List(namespaceName)
+		if err != nil {
+			// Namespace may have disappeared meanwhile
+			continue
+		}
+		interfaceInfoList = append(interfaceInfoList, namespaceInterfaces...)
+	}
+
+	return interfaceInfoList, nil
+}
+
+// peerNamespaceNames maps the netns IDs known to a namespace to namespace names
+// so veth peers (reported by ID) can be resolved to a namespace.
+func (linkManager *LinkManager) peerNamespaceNames(netlinkHandle *netlink.Handle, namespaceName string) map[int]string {
+	peerNamespaces := make(map[int]string)
+
+	candidates := []string{""}
+	if namespaceNames, err := linkManager.namespaceManager.List(); err == nil {
+		candidates = append(candidates, namespaceNames...)
+	}
+
+	for _, candidate := range candidates {
+		if candidate == namespaceName {
+			continue
+		}
+
+		namespaceHandle, err := linkManager.namespaceManager.GetHandleOrHost(candidate)
+		if err != nil {
+			continue
+		}
+		namespaceID, err := netNsIDByFd(netlinkHandle, int(namespaceHandle))
+		namespaceHandle.Close()
+		if err == nil && namespaceID >= 0 {
+			peerNamespaces[namespaceID] = candidate
+		}
+	}
+
+	return peerNamespaces
+}
+
+func ipString(address net.IP) string {
+	if len(address) == 0 {
+		return ""
+	}
+	return address.String()
+}
+
+// InterfaceKey identifies an interface across namespaces (empty namespace = host)
+func InterfaceKey(namespaceName, interfaceName string) string {
+	return namespaceName + "/" + interfaceName
+}
+
+// MarkManaged sets Managed on interfaces whose key is in the managed set
+// Parameters:
+//   - interfaceInfoList: interfaces to mark
+//   - managedKeys: set of InterfaceKey values recorded in the database
+func MarkManaged(interfaceInfoList []InterfaceInfo, managedKeys map[string]bool) {
+	for index := range interfaceInfoList {
+		interfaceInfo := &interfaceInfoList[index]
+		interfaceInfo.Managed = managedKeys[InterfaceKey(interfaceInfo.Namespace, interfaceInfo.Name)]
+	}
+}
+
+// InterfaceStatistics contains link counters and state of an interface
+type InterfaceStatistics struct {
+	Namespace string
+	Name      string
+	Type      string
+	Up        bool // Administratively up and operationally not down
+	RxBytes   uint64
+	RxPackets uint64
+	RxErrors  uint64
+	RxDropped uint64
+	TxBytes   uint64
+	TxPackets uint64
+	TxErrors  uint64
+	TxDropped uint64
+}
+
+// Statistics returns link counters for every interface in a namespace (or host if empty)
+// Parameters:
+//   - namespaceName: namespace to read counters from (empty = host)
+func (linkManager *LinkManager) Statistics(namespaceName string) ([]InterfaceStatistics, error) {
+	netlinkHandle, err := linkManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	defer netlinkHandle.Close()
+
+	networkLinks, err := netlinkHandle.LinkList()
+	if err != nil {
+		return nil, err
+	}
+
+	statisticsList := make([]InterfaceStatistics, 0, len(networkLinks))
+	for _, networkLink := range networkLinks {
+		linkAttrs := networkLink.Attrs()
+
+		interfaceStatistics := InterfaceStatistics{
+			Namespace: namespaceName,
+			Name:      linkAttrs.Name,
+			Type:      networkLink.Type(),
+			Up:        linkAttrs.Flags&1 != 0 && linkAttrs.OperState != netlink.OperDown, // IFF_UP
+		}
+		if linkStatistics := linkAttrs.Statistics; linkStatistics != nil {
+			interfaceStatistics.RxBytes = linkStatistics.RxBytes
+			interfaceStatistics.RxPackets = linkStatistics.RxPackets
+			interfaceStatistics.RxErrors = linkStatistics.RxErrors
+			interfaceStatistics.RxDropped = linkStatistics.RxDropped
+			interfaceStatistics.TxBytes = linkStatistics.TxBytes
+			interfaceStatistics.TxPackets = linkStatistics.TxPackets
+			interfaceStatistics.TxErrors = linkStatistics.TxErrors
+			interfaceStatistics.TxDropped = linkStatistics.TxDropped
+		}
+
+		statisticsList = append(statisticsList, interfaceStatistics)
+	}
+
+	return statisticsList, nil
+}
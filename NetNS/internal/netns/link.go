@@ -0,0 +1,201 @@
+package netns
+
+import (
+	"fmt"
+	"net"
+)
+
+// Link administrative states
+const (
+	LinkStateUp   = "up"
+	LinkStateDown = "down"
+)
+
+// LinkManager handles generic link property operations for any interface type
+type LinkManager struct {
+	namespaceManager *Manager
+}
+
+// NewLinkManager creates a new link manager
+func NewLinkManager(namespaceManager *Manager) *LinkManager {
+	return &LinkManager{namespaceManager: namespaceManager}
+}
+
+// LinkProperties describes properties to set on an interface
+// Nil fields leave the corresponding property unchanged, so an empty alias
+// can be set (clearing it) and an undo can restore one.
+type LinkProperties struct {
+	Interface    string  // Interface to modify
+	Namespace    string  // Namespace where interface exists (empty = host)
+	MTU          *int    // MTU in bytes
+	HardwareAddr *string // MAC address (e.g., "02:00:00:00:00:01")
+	TxQueueLen   *int    // Transmit queue length in packets
+	Alias        *string // Interface alias (ifalias, empty = none)
+	State        *string // Administrative state: "up" or "down"
+}
+
+// IsEmpty reports whether no property is set
+func (properties LinkProperties) IsEmpty() bool {
+	return properties.MTU == nil && properties.HardwareAddr == nil && properties.TxQueueLen == nil &&
+		properties.Alias == nil && properties.State == nil
+}
+
+// Validate checks property values before touching the kernel
+func (properties LinkProperties) Validate() error {
+	if properties.IsEmpty() {
+		return fmt.Errorf("no link properties specified")
+	}
+	if properties.MTU != nil && *properties.MTU <= 0 {
+		return fmt.Errorf("invalid MTU %d", *properties.MTU)
+	}
+	if properties.TxQueueLen != nil && *properties.TxQueueLen < 0 {
+		return fmt.Errorf("invalid txqueuelen %d", *properties.TxQueueLen)
+	}
+	if properties.HardwareAddr != nil {
+		if _, err := net.ParseMAC(*properties.HardwareAddr); err != nil {
+			return fmt.Errorf("invalid MAC address %q: %w", *properties.HardwareAddr, err)
+		}
+	}
+	if properties.State != nil && *properties.State != LinkStateUp && *properties.State != LinkStateDown {
+		return fmt.Errorf("invalid state %q (expected up or down)", *properties.State)
+	}
+	return nil
+}
+
+// Set applies link properties to an interface
+// The MAC address is changed with the link down, as many drivers require.
+func (linkManager *LinkManager) Set(properties LinkProperties) error {
+	if err := properties.Validate(); err != nil {
+		return err
+	}
+
+	netlinkHandle, err := linkManager.namespaceManager.GetNetlinkHandleOrHost(properties.Namespace)
+	if err != nil {
+		return err
+	}
+	defer netlinkHandle.Close()
+
+	networkLink, err := netlinkHandle.LinkByName(properties.Interface)
+	if err != nil {
+		return fmt.Errorf("failed to find interface %q: %w", properties.Interface, err)
+	}
+	wasUp := networkLink.Attrs().Flags&1 != 0 // IFF_UP
+
+	if properties.MTU != nil {
+		if err := netlinkHandle.LinkSetMTU(networkLink, *properties.MTU); err != nil {
+			return fmt.Errorf("failed to set MTU: %w", err)
+		}
+	}
+
+	if properties.HardwareAddr != nil {
+		hardwareAddr, _ := net.ParseMAC(*properties.HardwareAddr)
+		if wasUp {
+			if err := netlinkHandle.LinkSetDown(networkLink); err != nil {
+				return fmt.Errorf("failed to set interface down: %w", err)
+			}
+		}
+		if err := netlinkHandle.LinkSetHardwareAddr(networkLink, hardwareAddr); err != nil {
+			if wasUp {
+				netlinkHandle.LinkSetUp(networkLink)
+			}
+			return fmt.Errorf("failed to set MAC address: %w", err)
+		}
+		if wasUp && (properties.State == nil || *properties.State != LinkStateDown) {
+			if err := netlinkHandle.LinkSetUp(networkLink); err != nil {
+				return fmt.Errorf("failed to set interface up: %w", err)
+			}
+		}
+	}
+
+	if properties.TxQueueLen != nil {
+		if err := netlinkHandle.LinkSetTxQLen(networkLink, *properties.TxQueueLen); err != nil {
+			return fmt.Errorf("failed to set txqueuelen: %w", err)
+		}
+	}
+
+	if properties.Alias != nil {
+		if err := netlinkHandle.LinkSetAlias(networkLink, *properties.Alias); err != nil {
+			return fmt.Errorf("failed to set alias: %w", err)
+		}
+	}
+
+	switch {
+	case properties.State == nil:
+	case *properties.State == LinkStateUp:
+		if err := netlinkHandle.LinkSetUp(networkLink); err != nil {
+			return fmt.Errorf("failed to set interface up: %w", err)
+		}
+	case *properties.State == LinkStateDown:
+		if err := netlinkHandle.LinkSetDown(networkLink); err != nil {
+			return fmt.Errorf("failed to set interface down: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Get returns the current properties of an interface
+// Every field is set; State reflects the administrative (IFF_UP) state.
+// Parameters:
+//   - interfaceName: interface to read
+//   - namespaceName: namespace of the interface (empty = host)
+func (linkManager *LinkManager) Get(interfaceName, namespaceName string) (LinkProperties, error) {
+	netlinkHandle, err := linkManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return LinkProperties{}, err
+	}
+	defer netlinkHandle.Close()
+
+	networkLink, err := netlinkHandle.LinkByName(interfaceName)
+	if err != nil {
+		return LinkProperties{}, fmt.Errorf("failed to find interface %q: %w", interfaceName, err)
+	}
+	linkAttrs := networkLink.Attrs()
+
+	hardwareAddr := linkAttrs.HardwareAddr.String()
+	state := LinkStateDown
+	if linkAttrs.Flags&1 != 0 { // IFF_UP
+		state = LinkStateUp
+	}
+	properties := LinkProperties{
+		Interface:    interfaceName,
+		Namespace:    namespaceName,
+		MTU:          &linkAttrs.MTU,
+		HardwareAddr: &hardwareAddr,
+		TxQueueLen:   &linkAttrs.TxQLen,
+		Alias:        &linkAttrs.Alias,
+		State:        &state,
+	}
+	if hardwareAddr == "" {
+		// Links without a MAC (e.g. GRE, loopback) cannot get one back
+		properties.HardwareAddr = nil
+	}
+	return properties, nil
+}
+
+// Revert returns the properties a change touches to their previous values
+// Only fields set in change are taken from previous, so applying the result
+// undoes change without touching anything else.
+// Parameters:
+//   - previous: properties before the change (from Get)
+//   - change: properties that were applied
+func (previous LinkProperties) Revert(change LinkProperties) LinkProperties {
+	reverted := LinkProperties{Interface: change.Interface, Namespace: change.Namespace}
+	if change.MTU != nil {
+		reverted.MTU = previous.MTU
+	}
+	if change.HardwareAddr != nil {
+		reverted.HardwareAddr = previous.HardwareAddr
+	}
+	if change.TxQueueLen != nil {
+		reverted.TxQueueLen = previous.TxQueueLen
+	}
+	if change.Alias != nil {
+		reverted.Alias = previous.Alias
+	}
+	if change.State != nil || change.HardwareAddr != nil {
+		// Changing the MAC may cycle the link, so restore its state as well
+		reverted.State = previous.State
+	}
+	return reverted
+}
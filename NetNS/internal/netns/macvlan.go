@@ -0,0 +1,274 @@
+package netns
+
+import (
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+)
+
+// Sub-interface kinds
+const (
+	KindMacvlan = "macvlan"
+	KindIPVlan  = "ipvlan"
+)
+
+// MacvlanManager handles macvlan and ipvlan operations
+type MacvlanManager struct {
+	namespaceManager *Manager
+}
+
+// NewMacvlanManager creates a new macvlan/ipvlan manager
+func NewMacvlanManager(namespaceManager *Manager) *MacvlanManager {
+	return &MacvlanManager{namespaceManager: namespaceManager}
+}
+
+// MacvlanLink represents a macvlan or ipvlan configuration
+type MacvlanLink struct {
+	Name            string // Interface name (e.g., mv0)
+	Kind            string // "macvlan" or "ipvlan"
+	Mode            string // macvlan: bridge/vepa/private/passthru, ipvlan: l2/l3/l3s
+	Parent          string // Parent interface name (host NIC, veth end, ...)
+	ParentNamespace string // Namespace where parent exists (empty = host)
+	Namespace       string // Namespace to move the link into (empty = host)
+}
+
+// Create creates a macvlan or ipvlan link on a parent and moves it into the target namespace
+func (macvlanManager *MacvlanManager) Create(linkConfig MacvlanLink) error {
+	if linkConfig.Kind == "" {
+		linkConfig.Kind = KindMacvlan
+	}
+
+	// Resolve parent interface in its namespace
+	netlinkHandle, err := macvlanManager.namespaceManager.GetNetlinkHandleOrHost(linkConfig.ParentNamespace)
+	if err != nil {
+		return err
+	}
+	defer netlinkHandle.Close()
+
+	parentLink, err := netlinkHandle.LinkByName(linkConfig.Parent)
+	if err != nil {
+		return fmt.Errorf("failed to find parent interface %q: %w", linkConfig.Parent, err)
+	}
+
+	linkAttrs := netlink.LinkAttrs{
+		Name:        linkConfig.Name,
+		ParentIndex: parentLink.Attrs().Index,
+	}
+
+	var newLink netlink.Link
+	switch linkConfig.Kind {
+	case KindMacvlan:
+		macvlanMode, err := ParseMacvlanMode(linkConfig.Mode)
+		if err != nil {
+			return err
+		}
+		newLink = &netlink.Macvlan{LinkAttrs: linkAttrs, Mode: macvlanMode}
+	case KindIPVlan:
+		ipvlanMode, err := ParseIPVlanMode(linkConfig.Mode)
+		if err != nil {
+			return err
+		}
+		newLink = &netlink.IPVlan{LinkAttrs: linkAttrs, Mode: ipvlanMode}
+	default:
+		return fmt.Errorf("unsupported link kind %q (expected macvlan or ipvlan)", linkConfig.Kind)
+	}
+
+	if err := netlinkHandle.LinkAdd(newLink); err != nil {
+		return fmt.Errorf("failed to create %s: %w", linkConfig.Kind, err)
+	}
+
+	// Move to target namespace if it differs from the parent's
+	if linkConfig.Namespace != linkConfig.ParentNamespace {
+		if err := macvlanManager.moveToNamespace(netlinkHandle, linkConfig.Name, linkConfig.Namespace); err != nil {
+			// Cleanup on failure
+			netlinkHandle.LinkDel(newLink)
+			return err
+		}
+	}
+
+	// Bring the link up in its final namespace
+	targetHandle, err := macvlanManager.namespaceManager.GetNetlinkHandleOrHost(linkConfig.Namespace)
+	if err != nil {
+		macvlanManager.Delete(linkConfig.Name, linkConfig.Namespace)
+		return err
+	}
+	defer targetHandle.Close()
+
+	createdLink, err := targetHandle.LinkByName(linkConfig.Name)
+	if err != nil {
+		macvlanManager.Delete(linkConfig.Name, linkConfig.Namespace)
+		return fmt.Errorf("failed to find created %s %q: %w", linkConfig.Kind, linkConfig.Name, err)
+	}
+
+	if err := targetHandle.LinkSetUp(createdLink); err != nil {
+		// Cleanup on failure; the link may already be in the target namespace
+		targetHandle.LinkDel(createdLink)
+		return fmt.Errorf("failed to bring up %s %q: %w", linkConfig.Kind, linkConfig.Name, err)
+	}
+	return nil
+}
+
+// moveToNamespace moves an interface from the handle's namespace to a namespace
+// Parameters:
+//   - netlinkHandle: handle for the namespace where the interface currently exists
+//   - interfaceName: name of the interface to move
+//   - namespaceName: name of the target namespace (empty = host)
+func (macvlanManager *MacvlanManager) moveToNamespace(netlinkHandle *netlink.Handle, interfaceName, namespaceName string) error {
+	networkLink, err := netlinkHandle.LinkByName(interfaceName)
+	if err != nil {
+		return fmt.Errorf("failed to find interface %q: %w", interfaceName, err)
+	}
+
+	namespaceHandle, err := macvlanManager.namespaceManager.GetHandleOrHost(namespaceName)
+	if err != nil {
+		return fmt.Errorf("failed to get namespace %q: %w", namespaceName, err)
+	}
+	defer namespaceHandle.Close()
+
+	if err := netlinkHandle.LinkSetNsFd(networkLink, int(namespaceHandle)); err != nil {
+		return fmt.Errorf("failed to move interface to namespace: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a macvlan or ipvlan link
+// Parameters:
+//   - linkName: name of the link to delete
+//   - namespaceName: namespace where link exists (empty = host)
+func (macvlanManager *MacvlanManager) Delete(linkName, namespaceName string) error {
+	netlinkHandle, err := macvlanManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return err
+	}
+	defer netlinkHandle.Close()
+
+	networkLink, err := netlinkHandle.LinkByName(linkName)
+	if err != nil {
+		return fmt.Errorf("link %q not found: %w", linkName, err)
+	}
+
+	if networkLink.Type() != KindMacvlan && networkLink.Type() != KindIPVlan {
+		return fmt.Errorf("link %q is a %s, not a macvlan or ipvlan", linkName, networkLink.Type())
+	}
+
+	return netlinkHandle.LinkDel(networkLink)
+}
+
+// MacvlanInfo contains macvlan/ipvlan information
+type MacvlanInfo struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Mode  string `json:"mode"`
+	State string `json:"state"`
+}
+
+// List returns all macvlan and ipvlan links in a namespace (or host if empty)
+// Parameters:
+//   - namespaceName: namespace to list links from (empty = host)
+func (macvlanManager *MacvlanManager) List(namespaceName string) ([]MacvlanInfo, error) {
+	netlinkHandle, err := macvlanManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	defer netlinkHandle.Close()
+
+	networkLinks, err := netlinkHandle.LinkList()
+	if err != nil {
+		return nil, err
+	}
+
+	var linkInfoList []MacvlanInfo
+	for _, networkLink := range networkLinks {
+		linkInfo := MacvlanInfo{
+			Name:  networkLink.Attrs().Name,
+			Kind:  networkLink.Type(),
+			State: "down",
+		}
+
+		switch typedLink := networkLink.(type) {
+		case *netlink.Macvlan:
+			linkInfo.Mode = macvlanModeToString(typedLink.Mode)
+		case *netlink.IPVlan:
+			linkInfo.Mode = ipvlanModeToString(typedLink.Mode)
+		default:
+			continue
+		}
+
+		if networkLink.Attrs().Flags&1 != 0 { // IFF_UP
+			linkInfo.State = "up"
+		}
+
+		linkInfoList = append(linkInfoList, linkInfo)
+	}
+
+	return linkInfoList, nil
+}
+
+// DefaultMacvlanMode returns the mode used when none is given for a link kind
+func DefaultMacvlanMode(kind string) string {
+	if kind == KindIPVlan {
+		return "l2"
+	}
+	return "bridge"
+}
+
+// ParseMacvlanMode converts a mode name to a netlink macvlan mode
+func ParseMacvlanMode(modeName string) (netlink.MacvlanMode, error) {
+	switch modeName {
+	case "", "bridge":
+		return netlink.MACVLAN_MODE_BRIDGE, nil
+	case "vepa":
+		return netlink.MACVLAN_MODE_VEPA, nil
+	case "private":
+		return netlink.MACVLAN_MODE_PRIVATE, nil
+	case "passthru":
+		return netlink.MACVLAN_MODE_PASSTHRU, nil
+	default:
+		return 0, fmt.Errorf("invalid macvlan mode %q (expected bridge, vepa, private or passthru)", modeName)
+	}
+}
+
+// ParseIPVlanMode converts a mode name to a netlink ipvlan mode
+func ParseIPVlanMode(modeName string) (netlink.IPVlanMode, error) {
+	switch modeName {
+	case "", "l2":
+		return netlink.IPVLAN_MODE_L2, nil
+	case "l3":
+		return netlink.IPVLAN_MODE_L3, nil
+	case "l3s":
+		return netlink.IPVLAN_MODE_L3S, nil
+	default:
+		return 0, fmt.Errorf("invalid ipvlan mode %q (expected l2, l3 or l3s)", modeName)
+	}
+}
+
+func macvlanModeToString(macvlanMode netlink.MacvlanMode) string {
+	switch macvlanMode {
+	case netlink.MACVLAN_MODE_PRIVATE:
+		return "private"
+	case netlink.MACVLAN_MODE_VEPA:
+		return "vepa"
+	case netlink.MACVLAN_MODE_BRIDGE:
+		return "bridge"
+	case netlink.MACVLAN_MODE_PASSTHRU:
+		return "passthru"
+	case netlink.MACVLAN_MODE_SOURCE:
+		return "source"
+	default:
+		return fmt.Sprintf("%d", macvlanMode)
+	}
+}
+
+func ipvlanModeToString(ipvlanMode netlink.IPVlanMode) string {
+	switch ipvlanMode {
+	case netlink.IPVLAN_MODE_L2:
+		return "l2"
+	case netlink.IPVLAN_MODE_L3:
+		return "l3"
+	case netlink.IPVLAN_MODE_L3S:
+		return "l3s"
+	default:
+		return fmt.Sprintf("%d", ipvlanMode)
+	}
+}
@@ -0,0 +1,91 @@
+//go:build linux
+
+package netns
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMacvlanLifecycle creates a macvlan and an ipvlan on a dummy parent, lists and deletes them
+// The parent lives in its own namespace and the macvlan is moved into a
+// second one, so the host's links are never touched. Needs root.
+func TestMacvlanLifecycle(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating namespaces and links needs root")
+	}
+
+	namespaceManager := NewManager()
+	dummyManager := NewDummyManager(namespaceManager)
+	macvlanManager := NewMacvlanManager(namespaceManager)
+
+	const parentNamespace = "nsm-test-mvparent"
+	const targetNamespace = "nsm-test-mvtarget"
+	for _, namespaceName := range []string{parentNamespace, targetNamespace} {
+		if err := namespaceManager.Create(namespaceName); err != nil {
+			t.Fatalf("create namespace %s: %v", namespaceName, err)
+		}
+		t.Cleanup(func() { namespaceManager.Delete(namespaceName) })
+	}
+
+	if err := dummyManager.Create("dummy0", nil, parentNamespace); err != nil {
+		t.Skipf("dummy parent unavailable (no dummy support in this kernel?): %v", err)
+	}
+
+	testCases := []struct {
+		linkConfig MacvlanLink
+		wantMode   string
+	}{
+		{
+			linkConfig: MacvlanLink{Name: "mv0", Kind: KindMacvlan, Mode: "vepa", Parent: "dummy0", ParentNamespace: parentNamespace, Namespace: targetNamespace},
+			wantMode:   "vepa",
+		},
+		{
+			linkConfig: MacvlanLink{Name: "ipvl0", Kind: KindIPVlan, Parent: "dummy0", ParentNamespace: parentNamespace, Namespace: parentNamespace},
+			wantMode:   "l2",
+		},
+	}
+
+	for _, testCase := range testCases {
+		linkConfig := testCase.linkConfig
+		t.Run(linkConfig.Kind, func(t *testing.T) {
+			if err := macvlanManager.Create(linkConfig); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+
+			linkInfoList, err := macvlanManager.List(linkConfig.Namespace)
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			var found *MacvlanInfo
+			for index := range linkInfoList {
+				if linkInfoList[index].Name == linkConfig.Name {
+					found = &linkInfoList[index]
+				}
+			}
+			if found == nil {
+				t.Fatalf("list of %s = %+v, want %s", linkConfig.Namespace, linkInfoList, linkConfig.Name)
+			}
+			if found.Kind != linkConfig.Kind || found.Mode != testCase.wantMode || found.State != "up" {
+				t.Errorf("listed %+v, want kind %s, mode %s, state up", *found, linkConfig.Kind, testCase.wantMode)
+			}
+
+			if err := macvlanManager.Delete(linkConfig.Name, linkConfig.Namespace); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			linkInfoList, err = macvlanManager.List(linkConfig.Namespace)
+			if err != nil {
+				t.Fatalf("list after delete: %v", err)
+			}
+			for _, linkInfo := range linkInfoList {
+				if linkInfo.Name == linkConfig.Name {
+					t.Errorf("%s still listed after delete", linkConfig.Name)
+				}
+			}
+		})
+	}
+
+	if err := macvlanManager.Delete("dummy0", parentNamespace); err == nil {
+		t.Error("delete of the dummy parent as a macvlan succeeded, want a kind error")
+	}
+}
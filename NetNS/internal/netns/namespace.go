@@ -197,3 +197,24 @@ func (namespaceManager *Manager) GetNetlinkHandle(namespaceName string) (*netlin
 
 	return netlinkHandle, nil
 }
+
+// GetHandleOrHost returns a netns handle for the given namespace, or the host namespace if empty
+// Parameters:
+//   - namespaceName: name of the namespace (empty = host)
+func (namespaceManager *Manager) GetHandleOrHost(namespaceName string) (netns.NsHandle, error) {
+	if namespaceName == "" {
+		// Host namespace is the one we run in, as with GetNetlinkHandleOrHost
+		return netns.Get()
+	}
+	return namespaceManager.GetHandle(namespaceName)
+}
+
+// GetNetlinkHandleOrHost returns a netlink handle for the namespace, or the host namespace if empty
+// Parameters:
+//   - namespaceName: name of the namespace (empty = host)
+func (namespaceManager *Manager) GetNetlinkHandleOrHost(namespaceName string) (*netlink.Handle, error) {
+	if namespaceName == "" {
+		return netlink.NewHandle()
+	}
+	return namespaceManager.GetNetlinkHandle(namespaceName)
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package netns
+
+import "github.com/vishvananda/netlink"
+
+// linkModify applies changed attributes to an existing link
+func linkModify(netlinkHandle *netlink.Handle, networkLink netlink.Link) error {
+	return netlinkHandle.LinkModify(networkLink)
+}
+
+// netNsIDByFd returns the netns ID a namespace file descriptor has from the handle's namespace
+func netNsIDByFd(netlinkHandle *netlink.Handle, fd int) (int, error) {
+	return netlinkHandle.GetNetNsIdByFd(fd)
+}
+
+// addrReplace adds an address, or updates the lifetimes of the matching address in place
+func addrReplace(netlinkHandle *netlink.Handle, networkLink netlink.Link, address *netlink.Addr) error {
+	return netlinkHandle.AddrReplace(networkLink, address)
+}
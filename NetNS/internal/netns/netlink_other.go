@@ -0,0 +1,20 @@
+//go:build !linux
+
+package netns
+
+import "github.com/vishvananda/netlink"
+
+// linkModify is not supported on non-Linux platforms
+func linkModify(netlinkHandle *netlink.Handle, networkLink netlink.Link) error {
+	return errNotLinux
+}
+
+// netNsIDByFd is not supported on non-Linux platforms
+func netNsIDByFd(netlinkHandle *netlink.Handle, fd int) (int, error) {
+	return -1, errNotLinux
+}
+
+// addrReplace is not supported on non-Linux platforms
+func addrReplace(netlinkHandle *netlink.Handle, networkLink netlink.Link, address *netlink.Addr) error {
+	return errNotLinux
+}
@@ -42,6 +42,32 @@ func (routeManager *RouteManager) Add(destination, gateway, interfaceName, names
 	return netlinkHandle.RouteAdd(networkRoute)
 }
 
+// Replace adds a route, or changes the gateway and interface of the route to its destination in place
+// Traffic keeps flowing: the kernel swaps the route atomically instead of deleting it first.
+// Parameters:
+//   - destination: destination network in CIDR format (or "default" for default route)
+//   - gateway: new gateway IP address (empty = directly connected)
+//   - interfaceName: new output interface name
+//   - namespaceName: namespace of the route (empty = host)
+func (routeManager *RouteManager) Replace(destination, gateway, interfaceName, namespaceName string) error {
+	networkRoute, err := routeManager.buildRoute(destination, gateway, interfaceName, namespaceName)
+	if err != nil {
+		return err
+	}
+
+	if namespaceName == "" {
+		return netlink.RouteReplace(networkRoute)
+	}
+
+	netlinkHandle, err := routeManager.namespaceManager.GetNetlinkHandle(namespaceName)
+	if err != nil {
+		return err
+	}
+	defer netlinkHandle.Close()
+
+	return netlinkHandle.RouteReplace(networkRoute)
+}
+
 // Delete removes a route
 // Parameters:
 //   - destination: destination network in CIDR format (or "default")
@@ -0,0 +1,123 @@
+package netns
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Traffic shaper kinds
+const (
+	ShaperTBF = "tbf"
+	ShaperHTB = "htb"
+)
+
+// TrafficManager handles traffic shaping and impairment (tc) operations
+type TrafficManager struct {
+	namespaceManager *Manager
+}
+
+// NewTrafficManager creates a new traffic control manager
+func NewTrafficManager(namespaceManager *Manager) *TrafficManager {
+	return &TrafficManager{namespaceManager: namespaceManager}
+}
+
+// Impairment describes WAN-style impairment and rate limiting on an interface
+type Impairment struct {
+	Interface        string  // Interface to impair
+	Namespace        string  // Namespace where interface exists (empty = host)
+	DelayMs          float64 // One-way latency in milliseconds
+	JitterMs         float64 // Latency variation in milliseconds (requires delay)
+	LossPercent      float64 // Random packet loss in percent
+	ReorderPercent   float64 // Percentage of packets sent immediately, i.e. reordered (requires delay)
+	DuplicatePercent float64 // Random packet duplication in percent
+	CorruptPercent   float64 // Random single-bit corruption in percent
+	RateKbit         uint64  // Rate limit in kbit/s (0 = unlimited)
+	CeilKbit         uint64  // HTB ceiling in kbit/s (0 = same as rate)
+	BurstBytes       uint32  // TBF bucket size in bytes (0 = automatic)
+	Shaper           string  // Rate limiter: "tbf" (default) or "htb"
+}
+
+// hasNetem reports whether any netem parameter is set
+func (impairment Impairment) hasNetem() bool {
+	return impairment.DelayMs > 0 || impairment.JitterMs > 0 || impairment.LossPercent > 0 ||
+		impairment.ReorderPercent > 0 || impairment.DuplicatePercent > 0 || impairment.CorruptPercent > 0
+}
+
+// Validate checks the impairment for inconsistent parameters
+func (impairment Impairment) Validate() error {
+	if !impairment.hasNetem() && impairment.RateKbit == 0 {
+		return fmt.Errorf("no impairment specified")
+	}
+	if impairment.JitterMs > 0 && impairment.DelayMs == 0 {
+		return fmt.Errorf("jitter requires a delay")
+	}
+	if impairment.ReorderPercent > 0 && impairment.DelayMs == 0 {
+		return fmt.Errorf("reordering requires a delay")
+	}
+	for name, percent := range map[string]float64{
+		"loss":      impairment.LossPercent,
+		"reorder":   impairment.ReorderPercent,
+		"duplicate": impairment.DuplicatePercent,
+		"corrupt":   impairment.CorruptPercent,
+	} {
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("%s must be between 0 and 100 percent", name)
+		}
+	}
+	switch impairment.Shaper {
+	case "", ShaperTBF, ShaperHTB:
+	default:
+		return fmt.Errorf("invalid shaper %q (expected tbf or htb)", impairment.Shaper)
+	}
+	return nil
+}
+
+// QdiscInfo contains the impairment currently applied to an interface
+type QdiscInfo struct {
+	Interface        string  `json:"interface"`
+	Root             string  `json:"root"` // Root qdisc type
+	DelayMs          float64 `json:"delay_ms,omitempty"`
+	JitterMs         float64 `json:"jitter_ms,omitempty"`
+	LossPercent      float64 `json:"loss_percent,omitempty"`
+	ReorderPercent   float64 `json:"reorder_percent,omitempty"`
+	DuplicatePercent float64 `json:"duplicate_percent,omitempty"`
+	CorruptPercent   float64 `json:"corrupt_percent,omitempty"`
+	RateKbit         uint64  `json:"rate_kbit,omitempty"`
+	CeilKbit         uint64  `json:"ceil_kbit,omitempty"`
+}
+
+// ParseRate converts a tc-style rate ("10mbit", "512kbit", "1gbit", "100000") to kbit/s
+func ParseRate(rate string) (uint64, error) {
+	rate = strings.ToLower(strings.TrimSpace(rate))
+	if rate == "" {
+		return 0, nil
+	}
+
+	multipliers := []struct {
+		suffix string
+		kbit   float64
+	}{
+		{"gbit", 1000 * 1000},
+		{"mbit", 1000},
+		{"kbit", 1},
+		{"bit", 0.001},
+	}
+
+	for _, multiplier := range multipliers {
+		if strings.HasSuffix(rate, multiplier.suffix) {
+			value, err := strconv.ParseFloat(strings.TrimSuffix(rate, multiplier.suffix), 64)
+			if err != nil || value < 0 {
+				return 0, fmt.Errorf("invalid rate %q", rate)
+			}
+			return uint64(value * multiplier.kbit), nil
+		}
+	}
+
+	// Plain numbers are kbit/s
+	value, err := strconv.ParseUint(rate, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate %q", rate)
+	}
+	return value, nil
+}
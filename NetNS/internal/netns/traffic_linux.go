@@ -0,0 +1,300 @@
+//go:build linux
+
+package netns
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/vishvananda/netlink"
+)
+
+// qdisc handles used for the impairment tree
+var (
+	rootQdiscHandle  = netlink.MakeHandle(1, 0)
+	htbClassHandle   = netlink.MakeHandle(1, 0x10)
+	tbfChildParent   = netlink.MakeHandle(1, 1)
+	netemChildHandle = netlink.MakeHandle(0x10, 0)
+)
+
+// Set replaces any existing impairment on an interface
+// Without a rate, netem is installed as root qdisc. With a rate, a tbf qdisc
+// (or an htb qdisc with a single default class) shapes traffic and netem is
+// attached beneath it. If the new tree cannot be added, the previous one is
+// put back.
+func (trafficManager *TrafficManager) Set(impairment Impairment) error {
+	if err := impairment.Validate(); err != nil {
+		return err
+	}
+
+	netlinkHandle, err := trafficManager.namespaceManager.GetNetlinkHandleOrHost(impairment.Namespace)
+	if err != nil {
+		return err
+	}
+	defer netlinkHandle.Close()
+
+	networkLink, err := netlinkHandle.LinkByName(impairment.Interface)
+	if err != nil {
+		return fmt.Errorf("failed to find interface %q: %w", impairment.Interface, err)
+	}
+	linkIndex := networkLink.Attrs().Index
+
+	previousTree, err := trafficManager.rootTree(netlinkHandle, networkLink)
+	if err != nil {
+		return err
+	}
+
+	// Start from a clean root
+	if err := trafficManager.deleteRoot(netlinkHandle, networkLink); err != nil {
+		return err
+	}
+
+	netemParent := uint32(netlink.HANDLE_ROOT)
+	netemHandle := rootQdiscHandle
+
+	if impairment.RateKbit > 0 {
+		rateBytes := impairment.RateKbit * 1000 / 8
+
+		switch impairment.Shaper {
+		case ShaperHTB:
+			htbQdisc := netlink.NewHtb(netlink.QdiscAttrs{
+				LinkIndex: linkIndex,
+				Handle:    rootQdiscHandle,
+				Parent:    netlink.HANDLE_ROOT,
+			})
+			htbQdisc.Defcls = 0x10
+			if err := netlinkHandle.QdiscAdd(htbQdisc); err != nil {
+				return trafficManager.restoreRoot(netlinkHandle, networkLink, previousTree, fmt.Errorf("failed to add htb qdisc: %w", err))
+			}
+
+			htbClass := netlink.NewHtbClass(netlink.ClassAttrs{
+				LinkIndex: linkIndex,
+				Handle:    htbClassHandle,
+				Parent:    rootQdiscHandle,
+			}, netlink.HtbClassAttrs{
+				Rate: impairment.RateKbit * 1000,
+				Ceil: impairment.CeilKbit * 1000,
+			})
+			if err := netlinkHandle.ClassAdd(htbClass); err != nil {
+				return trafficManager.restoreRoot(netlinkHandle, networkLink, previousTree, fmt.Errorf("failed to add htb class: %w", err))
+			}
+			netemParent = htbClassHandle
+
+		default:
+			burstBytes := impairment.BurstBytes
+			if burstBytes == 0 {
+				// Roughly 10ms worth of traffic, never below one MTU
+				burstBytes = uint32(math.Max(float64(rateBytes)/100, 1600))
+			}
+
+			tbfQdisc := &netlink.Tbf{
+				QdiscAttrs: netlink.QdiscAttrs{
+					LinkIndex: linkIndex,
+					Handle:    rootQdiscHandle,
+					Parent:    netlink.HANDLE_ROOT,
+				},
+				Rate:   rateBytes,
+				Buffer: netlink.Xmittime(rateBytes, burstBytes),
+				// Queue up to 50ms of traffic on top of the bucket
+				Limit: uint32(rateBytes/20) + burstBytes,
+			}
+			if err := netlinkHandle.QdiscAdd(tbfQdisc); err != nil {
+				return trafficManager.restoreRoot(netlinkHandle, networkLink, previousTree, fmt.Errorf("failed to add tbf qdisc: %w", err))
+			}
+			netemParent = tbfChildParent
+		}
+
+		netemHandle = netemChildHandle
+	}
+
+	if !impairment.hasNetem() {
+		return nil
+	}
+
+	netemQdisc := netlink.NewNetem(netlink.QdiscAttrs{
+		LinkIndex: linkIndex,
+		Handle:    netemHandle,
+		Parent:    netemParent,
+	}, netlink.NetemQdiscAttrs{
+		Latency:     uint32(impairment.DelayMs * 1000),
+		Jitter:      uint32(impairment.JitterMs * 1000),
+		Loss:        float32(impairment.LossPercent),
+		ReorderProb: float32(impairment.ReorderPercent),
+		Duplicate:   float32(impairment.DuplicatePercent),
+		CorruptProb: float32(impairment.CorruptPercent),
+	})
+	if err := netlinkHandle.QdiscAdd(netemQdisc); err != nil {
+		return trafficManager.restoreRoot(netlinkHandle, networkLink, previousTree, fmt.Errorf("failed to add netem qdisc: %w", err))
+	}
+
+	return nil
+}
+
+// Clear removes any impairment from an interface, restoring the default qdisc
+// Parameters:
+//   - interfaceName: name of the interface
+//   - namespaceName: namespace where interface exists (empty = host)
+func (trafficManager *TrafficManager) Clear(interfaceName, namespaceName string) error {
+	netlinkHandle, err := trafficManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return err
+	}
+	defer netlinkHandle.Close()
+
+	networkLink, err := netlinkHandle.LinkByName(interfaceName)
+	if err != nil {
+		return fmt.Errorf("failed to find interface %q: %w", interfaceName, err)
+	}
+
+	return trafficManager.deleteRoot(netlinkHandle, networkLink)
+}
+
+// deleteRoot deletes the configured root qdisc (the kernel default has handle 0 and is left alone)
+func (trafficManager *TrafficManager) deleteRoot(netlinkHandle *netlink.Handle, networkLink netlink.Link) error {
+	qdiscs, err := netlinkHandle.QdiscList(networkLink)
+	if err != nil {
+		return fmt.Errorf("failed to list qdiscs: %w", err)
+	}
+
+	for _, qdisc := range qdiscs {
+		qdiscAttrs := qdisc.Attrs()
+		if qdiscAttrs.Parent == netlink.HANDLE_ROOT && qdiscAttrs.Handle != 0 {
+			if err := netlinkHandle.QdiscDel(qdisc); err != nil {
+				return fmt.Errorf("failed to delete root qdisc: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// qdiscTree is a configured root qdisc with the classes and qdiscs beneath it
+type qdiscTree struct {
+	root     netlink.Qdisc
+	classes  []netlink.Class
+	children []netlink.Qdisc
+}
+
+// rootTree returns the configured qdisc tree of an interface, or nil if it has the kernel default
+func (trafficManager *TrafficManager) rootTree(netlinkHandle *netlink.Handle, networkLink netlink.Link) (*qdiscTree, error) {
+	qdiscs, err := netlinkHandle.QdiscList(networkLink)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list qdiscs: %w", err)
+	}
+
+	var tree *qdiscTree
+	for _, qdisc := range qdiscs {
+		if qdiscAttrs := qdisc.Attrs(); qdiscAttrs.Parent == netlink.HANDLE_ROOT && qdiscAttrs.Handle != 0 {
+			tree = &qdiscTree{root: qdisc}
+		}
+	}
+	if tree == nil {
+		return nil, nil
+	}
+
+	rootMajor, _ := netlink.MajorMinor(tree.root.Attrs().Handle)
+	for _, qdisc := range qdiscs {
+		if parentMajor, _ := netlink.MajorMinor(qdisc.Attrs().Parent); qdisc != tree.root && parentMajor == rootMajor {
+			tree.children = append(tree.children, qdisc)
+		}
+	}
+	if htbQdisc, ok := tree.root.(*netlink.Htb); ok {
+		// The kernel reports the full HTB version but only accepts its major number
+		htbQdisc.Version >>= 16
+		if tree.classes, err = netlinkHandle.ClassList(networkLink, tree.root.Attrs().Handle); err != nil {
+			return nil, fmt.Errorf("failed to list classes: %w", err)
+		}
+	}
+	return tree, nil
+}
+
+// restoreRoot removes a partially added tree and puts the previous one back
+// Returns the error that caused the restore, joined with any restore error.
+// Parameters:
+//   - tree: tree returned by rootTree before the change (nil = kernel default)
+//   - cause: error that caused the restore
+func (trafficManager *TrafficManager) restoreRoot(netlinkHandle *netlink.Handle, networkLink netlink.Link, tree *qdiscTree, cause error) error {
+	if err := trafficManager.deleteRoot(netlinkHandle, networkLink); err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to restore previous qdiscs: %w", err))
+	}
+	if tree == nil {
+		return cause
+	}
+
+	if err := netlinkHandle.QdiscAdd(tree.root); err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to restore %s qdisc: %w", tree.root.Type(), err))
+	}
+	for _, class := range tree.classes {
+		if err := netlinkHandle.ClassAdd(class); err != nil {
+			return errors.Join(cause, fmt.Errorf("failed to restore %s class: %w", class.Type(), err))
+		}
+	}
+	for _, qdisc := range tree.children {
+		if err := netlinkHandle.QdiscAdd(qdisc); err != nil {
+			return errors.Join(cause, fmt.Errorf("failed to restore %s qdisc: %w", qdisc.Type(), err))
+		}
+	}
+	return cause
+}
+
+// Show returns the impairment currently applied to an interface
+// Parameters:
+//   - interfaceName: name of the interface
+//   - namespaceName: namespace where interface exists (empty = host)
+func (trafficManager *TrafficManager) Show(interfaceName, namespaceName string) (*QdiscInfo, error) {
+	netlinkHandle, err := trafficManager.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	defer netlinkHandle.Close()
+
+	networkLink, err := netlinkHandle.LinkByName(interfaceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interface %q: %w", interfaceName, err)
+	}
+
+	qdiscs, err := netlinkHandle.QdiscList(networkLink)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list qdiscs: %w", err)
+	}
+
+	qdiscInfo := &QdiscInfo{Interface: interfaceName}
+	for _, qdisc := range qdiscs {
+		if qdisc.Attrs().Parent == netlink.HANDLE_ROOT {
+			qdiscInfo.Root = qdisc.Type()
+		}
+
+		switch typedQdisc := qdisc.(type) {
+		case *netlink.Netem:
+			qdiscInfo.DelayMs = ticksToMs(typedQdisc.Latency)
+			qdiscInfo.JitterMs = ticksToMs(typedQdisc.Jitter)
+			qdiscInfo.LossPercent = u32ToPercentage(typedQdisc.Loss)
+			qdiscInfo.ReorderPercent = u32ToPercentage(typedQdisc.ReorderProb)
+			qdiscInfo.DuplicatePercent = u32ToPercentage(typedQdisc.Duplicate)
+			qdiscInfo.CorruptPercent = u32ToPercentage(typedQdisc.CorruptProb)
+		case *netlink.Tbf:
+			qdiscInfo.RateKbit = typedQdisc.Rate * 8 / 1000
+		case *netlink.Htb:
+			classes, err := netlinkHandle.ClassList(networkLink, qdisc.Attrs().Handle)
+			if err != nil {
+				continue
+			}
+			for _, class := range classes {
+				if htbClass, ok := class.(*netlink.HtbClass); ok && htbClass.Handle == htbClassHandle {
+					qdiscInfo.RateKbit = htbClass.Rate * 8 / 1000
+					qdiscInfo.CeilKbit = htbClass.Ceil * 8 / 1000
+				}
+			}
+		}
+	}
+
+	return qdiscInfo, nil
+}
+
+func ticksToMs(ticks uint32) float64 {
+	return math.Round(float64(ticks)/netlink.TickInUsec()) / 1000
+}
+
+func u32ToPercentage(value uint32) float64 {
+	return math.Round(float64(value)/math.MaxUint32*100*1000) / 1000
+}
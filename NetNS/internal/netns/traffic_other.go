@@ -0,0 +1,18 @@
+//go:build !linux
+
+package netns
+
+// Set is not supported on non-Linux platforms
+func (trafficManager *TrafficManager) Set(impairment Impairment) error {
+	return errNotLinux
+}
+
+// Clear is not supported on non-Linux platforms
+func (trafficManager *TrafficManager) Clear(interfaceName, namespaceName string) error {
+	return errNotLinux
+}
+
+// Show is not supported on non-Linux platforms
+func (trafficManager *TrafficManager) Show(interfaceName, namespaceName string) (*QdiscInfo, error) {
+	return nil, errNotLinux
+}
@@ -0,0 +1,231 @@
+package netns
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	EventTypeNamespace = "namespace"
+	EventTypeLink      = "link"
+	EventTypeAddress   = "addr"
+	EventTypeRoute     = "route"
+	EventTypeNeighbor  = "neigh"
+)
+
+// Event actions
+const (
+	EventActionNew    = "new"
+	EventActionDelete = "del"
+)
+
+// namespacePollInterval is how often the watcher looks for created or deleted namespaces
+const namespacePollInterval = 2 * time.Second
+
+// subscriberBuffer is the number of events buffered per subscriber before events are dropped
+const subscriberBuffer = 256
+
+// Event is a single netlink change in a namespace
+type Event struct {
+	Time        time.Time `json:"time"`
+	Namespace   string    `json:"namespace"` // Empty = host
+	Type        string    `json:"type"`      // namespace, link, addr, route or neigh
+	Action      string    `json:"action"`    // new or del
+	Interface   string    `json:"interface,omitempty"`
+	State       string    `json:"state,omitempty"`   // link: up/down; neigh: NUD state
+	Address     string    `json:"address,omitempty"` // addr: CIDR; neigh: IP
+	MAC         string    `json:"mac,omitempty"`     // link and neigh
+	Destination string    `json:"destination,omitempty"`
+	Gateway     string    `json:"gateway,omitempty"`
+}
+
+// Watcher subscribes to link, address, route and neighbor updates in the host
+// and every watched namespace, re-subscribing as namespaces come and go, and
+// fans the updates out to subscribers.
+type Watcher struct {
+	namespaceManager *Manager
+	namespaceSource  func() ([]string, error)
+
+	mutex       sync.Mutex
+	subscribers map[int]chan Event
+	nextID      int
+	watched     map[string]chan struct{} // namespace -> done channel of its subscription
+
+	startOnce   sync.Once
+	stopOnce    sync.Once
+	stopChannel chan struct{}
+}
+
+// NewWatcher creates a new netlink event watcher
+// Parameters:
+//   - namespaceManager: namespace manager used to enter namespaces
+//   - namespaceSource: returns the namespaces to watch (nil = every namespace in /var/run/netns)
+func NewWatcher(namespaceManager *Manager, namespaceSource func() ([]string, error)) *Watcher {
+	if namespaceSource == nil {
+		namespaceSource = namespaceManager.List
+	}
+	return &Watcher{
+		namespaceManager: namespaceManager,
+		namespaceSource:  namespaceSource,
+		subscribers:      make(map[int]chan Event),
+		watched:          make(map[string]chan struct{}),
+		stopChannel:      make(chan struct{}),
+	}
+}
+
+// Subscribe registers a new subscriber, starting the watcher on first use
+// Events are dropped for subscribers that fall behind. Call the returned
+// function to unsubscribe.
+func (watcher *Watcher) Subscribe() (<-chan Event, func()) {
+	watcher.startOnce.Do(func() {
+		go watcher.run()
+	})
+
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+
+	subscriberID := watcher.nextID
+	watcher.nextID++
+	eventChannel := make(chan Event, subscriberBuffer)
+	watcher.subscribers[subscriberID] = eventChannel
+
+	unsubscribe := func() {
+		watcher.mutex.Lock()
+		defer watcher.mutex.Unlock()
+		if _, ok := watcher.subscribers[subscriberID]; ok {
+			delete(watcher.subscribers, subscriberID)
+			close(eventChannel)
+		}
+	}
+	return eventChannel, unsubscribe
+}
+
+// Stop stops all subscriptions and closes subscriber channels
+func (watcher *Watcher) Stop() {
+	watcher.stopOnce.Do(func() {
+		close(watcher.stopChannel)
+
+		watcher.mutex.Lock()
+		defer watcher.mutex.Unlock()
+		for namespaceName, done := range watcher.watched {
+			close(done)
+			delete(watcher.watched, namespaceName)
+		}
+		for subscriberID, eventChannel := range watcher.subscribers {
+			close(eventChannel)
+			delete(watcher.subscribers, subscriberID)
+		}
+	})
+}
+
+// run watches the host and polls for namespace changes until stopped
+func (watcher *Watcher) run() {
+	watcher.syncNamespaces()
+
+	ticker := time.NewTicker(namespacePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			watcher.syncNamespaces()
+		case <-watcher.stopChannel:
+			return
+		}
+	}
+}
+
+// syncNamespaces subscribes to new namespaces and drops deleted ones
+// The host is (re-)subscribed whenever its subscription is missing.
+func (watcher *Watcher) syncNamespaces() {
+	watcher.mutex.Lock()
+	_, hostWatched := watcher.watched[""]
+	watcher.mutex.Unlock()
+	if !hostWatched {
+		watcher.watch("")
+	}
+
+	namespaceNames, err := watcher.namespaceSource()
+	if err != nil {
+		log.Printf("watcher: failed to list namespaces: %v", err)
+		return
+	}
+
+	current := make(map[string]bool, len(namespaceNames))
+	for _, namespaceName := range namespaceNames {
+		current[namespaceName] = true
+	}
+
+	watcher.mutex.Lock()
+	var added, removed []string
+	for _, namespaceName := range namespaceNames {
+		if _, ok := watcher.watched[namespaceName]; !ok && watcher.namespaceManager.Exists(namespaceName) {
+			added = append(added, namespaceName)
+		}
+	}
+	for namespaceName, done := range watcher.watched {
+		if namespaceName == "" {
+			continue
+		}
+		if !current[namespaceName] || !watcher.namespaceManager.Exists(namespaceName) {
+			close(done)
+			delete(watcher.watched, namespaceName)
+			removed = append(removed, namespaceName)
+		}
+	}
+	watcher.mutex.Unlock()
+
+	for _, namespaceName := range removed {
+		watcher.publish(Event{Namespace: namespaceName, Type: EventTypeNamespace, Action: EventActionDelete})
+	}
+	for _, namespaceName := range added {
+		if watcher.watch(namespaceName) {
+			watcher.publish(Event{Namespace: namespaceName, Type: EventTypeNamespace, Action: EventActionNew})
+		}
+	}
+}
+
+// watch subscribes to updates in one namespace (empty = host)
+func (watcher *Watcher) watch(namespaceName string) bool {
+	done := make(chan struct{})
+	if err := watcher.subscribeNamespace(namespaceName, done); err != nil {
+		log.Printf("watcher: failed to subscribe in %q: %v", namespaceName, err)
+		return false
+	}
+
+	watcher.mutex.Lock()
+	watcher.watched[namespaceName] = done
+	watcher.mutex.Unlock()
+	return true
+}
+
+// forget drops a namespace whose subscription ended so the next poll re-subscribes
+func (watcher *Watcher) forget(namespaceName string, done chan struct{}) {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+
+	if watcher.watched[namespaceName] == done {
+		close(done)
+		delete(watcher.watched, namespaceName)
+	}
+}
+
+// publish fans an event out to all subscribers without blocking
+func (watcher *Watcher) publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+
+	for _, eventChannel := range watcher.subscribers {
+		select {
+		case eventChannel <- event:
+		default:
+			// Subscriber is too slow; drop the event rather than stall netlink
+		}
+	}
+}
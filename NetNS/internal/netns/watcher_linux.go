@@ -0,0 +1,215 @@
+//go:build linux
+
+package netns
+
+import (
+	"log"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+)
+
+// neighborStates maps NUD state bits to names as printed by ip neigh
+var neighborStates = []struct {
+	state int
+	name  string
+}{
+	{netlink.NUD_INCOMPLETE, "incomplete"},
+	{netlink.NUD_REACHABLE, "reachable"},
+	{netlink.NUD_STALE, "stale"},
+	{netlink.NUD_DELAY, "delay"},
+	{netlink.NUD_PROBE, "probe"},
+	{netlink.NUD_FAILED, "failed"},
+	{netlink.NUD_NOARP, "noarp"},
+	{netlink.NUD_PERMANENT, "permanent"},
+}
+
+// subscribeNamespace subscribes to link, address, route and neighbor updates in a namespace
+// Subscriptions end when done is closed. On failure done is closed before returning.
+// Parameters:
+//   - namespaceName: namespace to subscribe in (empty = host)
+//   - done: closed to end the subscriptions
+func (watcher *Watcher) subscribeNamespace(namespaceName string, done chan struct{}) error {
+	namespaceHandle, err := watcher.namespaceManager.GetHandleOrHost(namespaceName)
+	if err != nil {
+		close(done)
+		return err
+	}
+	// Sockets are bound to the namespace when subscribing, so the handle can go
+	defer namespaceHandle.Close()
+
+	netlinkHandle, err := watcher.namespaceManager.GetNetlinkHandleOrHost(namespaceName)
+	if err != nil {
+		close(done)
+		return err
+	}
+
+	errorCallback := func(err error) {
+		log.Printf("watcher: subscription error in %q: %v", namespaceName, err)
+	}
+
+	linkUpdates := make(chan netlink.LinkUpdate, subscriberBuffer)
+	addressUpdates := make(chan netlink.AddrUpdate, subscriberBuffer)
+	routeUpdates := make(chan netlink.RouteUpdate, subscriberBuffer)
+	neighborUpdates := make(chan netlink.NeighUpdate, subscriberBuffer)
+
+	subscribeErr := netlink.LinkSubscribeWithOptions(linkUpdates, done, netlink.LinkSubscribeOptions{
+		Namespace:     &namespaceHandle,
+		ErrorCallback: errorCallback,
+	})
+	if subscribeErr == nil {
+		subscribeErr = netlink.AddrSubscribeWithOptions(addressUpdates, done, netlink.AddrSubscribeOptions{
+			Namespace:     &namespaceHandle,
+			ErrorCallback: errorCallback,
+		})
+	}
+	if subscribeErr == nil {
+		subscribeErr = netlink.RouteSubscribeWithOptions(routeUpdates, done, netlink.RouteSubscribeOptions{
+			Namespace:     &namespaceHandle,
+			ErrorCallback: errorCallback,
+		})
+	}
+	if subscribeErr == nil {
+		subscribeErr = netlink.NeighSubscribeWithOptions(neighborUpdates, done, netlink.NeighSubscribeOptions{
+			Namespace:     &namespaceHandle,
+			ErrorCallback: errorCallback,
+		})
+	}
+	if subscribeErr != nil {
+		close(done)
+		netlinkHandle.Close()
+		return subscribeErr
+	}
+
+	// Index to name, so address/route/neighbor events can name their interface
+	linkNames := make(map[int]string)
+	if networkLinks, err := netlinkHandle.LinkList(); err == nil {
+		for _, networkLink := range networkLinks {
+			linkNames[networkLink.Attrs().Index] = networkLink.Attrs().Name
+		}
+	}
+	interfaceName := func(linkIndex int) string {
+		if name, ok := linkNames[linkIndex]; ok {
+			return name
+		}
+		if networkLink, err := netlinkHandle.LinkByIndex(linkIndex); err == nil {
+			linkNames[linkIndex] = networkLink.Attrs().Name
+			return networkLink.Attrs().Name
+		}
+		return ""
+	}
+
+	go func() {
+		defer netlinkHandle.Close()
+
+		for {
+			var event Event
+
+			select {
+			case <-done:
+				return
+
+			case update, ok := <-linkUpdates:
+				if !ok {
+					watcher.forget(namespaceName, done)
+					return
+				}
+				linkAttrs := update.Link.Attrs()
+				event = Event{
+					Type:      EventTypeLink,
+					Action:    EventActionNew,
+					Interface: linkAttrs.Name,
+					State:     LinkStateDown,
+					MAC:       linkAttrs.HardwareAddr.String(),
+				}
+				if linkAttrs.Flags&1 != 0 && linkAttrs.OperState != netlink.OperDown { // IFF_UP
+					event.State = LinkStateUp
+				}
+				if update.Header.Type == syscall.RTM_DELLINK {
+					event.Action = EventActionDelete
+					delete(linkNames, linkAttrs.Index)
+				} else {
+					linkNames[linkAttrs.Index] = linkAttrs.Name
+				}
+
+			case update, ok := <-addressUpdates:
+				if !ok {
+					watcher.forget(namespaceName, done)
+					return
+				}
+				event = Event{
+					Type:      EventTypeAddress,
+					Action:    EventActionNew,
+					Interface: interfaceName(update.LinkIndex),
+					Address:   update.LinkAddress.String(),
+				}
+				if !update.NewAddr {
+					event.Action = EventActionDelete
+				}
+
+			case update, ok := <-routeUpdates:
+				if !ok {
+					watcher.forget(namespaceName, done)
+					return
+				}
+				// Local and broadcast routes follow address changes; skip the noise
+				if update.Table == syscall.RT_TABLE_LOCAL {
+					continue
+				}
+				event = Event{
+					Type:        EventTypeRoute,
+					Action:      EventActionNew,
+					Interface:   interfaceName(update.LinkIndex),
+					Destination: "default",
+					Gateway:     ipOrEmpty(update.Gw),
+				}
+				if update.Dst != nil {
+					event.Destination = update.Dst.String()
+				}
+				if update.Type == syscall.RTM_DELROUTE {
+					event.Action = EventActionDelete
+				}
+
+			case update, ok := <-neighborUpdates:
+				if !ok {
+					watcher.forget(namespaceName, done)
+					return
+				}
+				event = Event{
+					Type:      EventTypeNeighbor,
+					Action:    EventActionNew,
+					Interface: interfaceName(update.LinkIndex),
+					Address:   ipOrEmpty(update.IP),
+					MAC:       update.HardwareAddr.String(),
+					State:     neighborState(update.State),
+				}
+				if update.Type == syscall.RTM_DELNEIGH {
+					event.Action = EventActionDelete
+				}
+			}
+
+			event.Namespace = namespaceName
+			watcher.publish(event)
+		}
+	}()
+
+	return nil
+}
+
+// neighborState returns the name of a NUD state
+func neighborState(state int) string {
+	for _, neighborStateName := range neighborStates {
+		if state&neighborStateName.state != 0 {
+			return neighborStateName.name
+		}
+	}
+	return "none"
+}
+
+func ipOrEmpty(address net.IP) string {
+	if address == nil {
+		return ""
+	}
+	return address.String()
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package netns
+
+// subscribeNamespace is not supported on non-Linux platforms
+func (watcher *Watcher) subscribeNamespace(namespaceName string, done chan struct{}) error {
+	close(done)
+	return errNotLinux
+}
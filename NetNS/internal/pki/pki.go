@@ -0,0 +1,195 @@
+// Package pki generates a local certificate authority for lab deployments.
+//
+// Init writes a CA, a server certificate for "netns-mgr serve --tls-cert" and
+// client certificates for mutual TLS. The common name of a client certificate
+// is the API principal it authenticates as. Keys are ECDSA P-256 in PKCS #8.
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File names written by Init
+const (
+	CAFile        = "ca.pem"
+	CAKeyFile     = "ca-key.pem"
+	ServerFile    = "server.pem"
+	ServerKeyFile = "server-key.pem"
+)
+
+// ClientFile returns the certificate file name for a client principal
+func ClientFile(clientName string) string {
+	return "client-" + clientName + ".pem"
+}
+
+// ClientKeyFile returns the key file name for a client principal
+func ClientKeyFile(clientName string) string {
+	return "client-" + clientName + "-key.pem"
+}
+
+// InitOptions describes the certificates to generate
+type InitOptions struct {
+	Dir         string        // Output directory, created if missing
+	ServerHosts []string      // DNS names and IP addresses of the server
+	ClientNames []string      // Common names (API principals) of client certificates
+	Validity    time.Duration // Lifetime of the server and client certificates
+	Force       bool          // Overwrite existing files
+}
+
+// caValidity is the lifetime of the generated CA
+const caValidity = 10 * 365 * 24 * time.Hour
+
+// issuedPair is a certificate and key ready to be written
+type issuedPair struct {
+	certFile    string
+	keyFile     string
+	certificate *x509.Certificate
+	certDER     []byte
+	key         *ecdsa.PrivateKey
+}
+
+// Init generates a CA, a server certificate and client certificates
+// Returns the paths of the files written.
+func Init(options InitOptions) ([]string, error) {
+	if len(options.ServerHosts) == 0 {
+		return nil, fmt.Errorf("at least one server host is required")
+	}
+
+	targetFiles := []string{CAFile, CAKeyFile, ServerFile, ServerKeyFile}
+	for _, clientName := range options.ClientNames {
+		if clientName == "" || filepath.Base(clientName) != clientName {
+			return nil, fmt.Errorf("invalid client name %q", clientName)
+		}
+		targetFiles = append(targetFiles, ClientFile(clientName), ClientKeyFile(clientName))
+	}
+	if !options.Force {
+		for _, fileName := range targetFiles {
+			if _, err := os.Stat(filepath.Join(options.Dir, fileName)); err == nil {
+				return nil, fmt.Errorf("%s already exists (use --force to overwrite)", filepath.Join(options.Dir, fileName))
+			}
+		}
+	}
+
+	notBefore := time.Now().Add(-time.Hour) // Tolerate small clock skew
+	caPair, err := issue(nil, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "netns-mgr lab CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	caPair.certFile, caPair.keyFile = CAFile, CAKeyFile
+
+	serverTemplate := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: options.ServerHosts[0]},
+		NotBefore:   notBefore,
+		NotAfter:    notBefore.Add(options.Validity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range options.ServerHosts {
+		if ip := net.ParseIP(host); ip != nil {
+			serverTemplate.IPAddresses = append(serverTemplate.IPAddresses, ip)
+		} else {
+			serverTemplate.DNSNames = append(serverTemplate.DNSNames, host)
+		}
+	}
+	serverPair, err := issue(caPair, serverTemplate)
+	if err != nil {
+		return nil, err
+	}
+	serverPair.certFile, serverPair.keyFile = ServerFile, ServerKeyFile
+
+	pairs := []*issuedPair{caPair, serverPair}
+	for _, clientName := range options.ClientNames {
+		clientPair, err := issue(caPair, &x509.Certificate{
+			Subject:     pkix.Name{CommonName: clientName},
+			NotBefore:   notBefore,
+			NotAfter:    notBefore.Add(options.Validity),
+			KeyUsage:    x509.KeyUsageDigitalSignature,
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		if err != nil {
+			return nil, err
+		}
+		clientPair.certFile, clientPair.keyFile = ClientFile(clientName), ClientKeyFile(clientName)
+		pairs = append(pairs, clientPair)
+	}
+
+	if err := os.MkdirAll(options.Dir, 0700); err != nil {
+		return nil, err
+	}
+	var writtenFiles []string
+	for _, pair := range pairs {
+		keyDER, err := x509.MarshalPKCS8PrivateKey(pair.key)
+		if err != nil {
+			return nil, err
+		}
+
+		certPath := filepath.Join(options.Dir, pair.certFile)
+		if err := writePEM(certPath, "CERTIFICATE", pair.certDER, 0644); err != nil {
+			return nil, err
+		}
+		keyPath := filepath.Join(options.Dir, pair.keyFile)
+		if err := writePEM(keyPath, "PRIVATE KEY", keyDER, 0600); err != nil {
+			return nil, err
+		}
+		writtenFiles = append(writtenFiles, certPath, keyPath)
+	}
+	return writtenFiles, nil
+}
+
+// issue creates a key and a certificate signed by the issuer (nil = self-signed)
+func issue(issuer *issuedPair, template *x509.Certificate) (*issuedPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	serialLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	template.SerialNumber, err = rand.Int(rand.Reader, serialLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	parentCertificate, signingKey := template, key
+	if issuer != nil {
+		parentCertificate, signingKey = issuer.certificate, issuer.key
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, parentCertificate, &key.PublicKey, signingKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate for %s: %w", template.Subject.CommonName, err)
+	}
+	certificate, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, err
+	}
+
+	return &issuedPair{certificate: certificate, certDER: certDER, key: key}, nil
+}
+
+// writePEM writes a single PEM block to a file
+func writePEM(path, blockType string, der []byte, mode os.FileMode) error {
+	pemData := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, pemData, mode); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file; --force must not leave keys readable
+	return os.Chmod(path, mode)
+}
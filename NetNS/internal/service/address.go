@@ -0,0 +1,254 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+)
+
+// AddressRequest identifies an address on an interface, for adding or deleting
+type AddressRequest struct {
+	Interface string            `json:"interface" validate:"required,ifname"`
+	Address   string            `json:"address" validate:"required,cidr"` // CIDR notation, e.g. 10.0.0.1/24
+	Namespace string            `json:"namespace"`                        // Empty = host
+	Labels    map[string]string `json:"labels,omitempty" validate:"dive,keys,labelkey,endkeys,labelvalue"`
+}
+
+// Validate checks the request before touching the kernel
+func (request AddressRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// UpdateAddressRequest changes the label and lifetimes of a recorded address
+// Lifetimes are not recorded: each update sets both, counting from now.
+type UpdateAddressRequest struct {
+	AddressLabel      *string `json:"address_label,omitempty" validate:"omitempty,max=15"` // IPv4 only; starts with the interface name (empty = interface name, omitted = unchanged)
+	ValidLifetime     int     `json:"valid_lifetime" validate:"gte=0,lt=4294967295"`       // Seconds until the address is removed (0 = forever)
+	PreferredLifetime int     `json:"preferred_lifetime" validate:"gte=0,lt=4294967295"`   // Seconds until the address is deprecated (0 = when it is removed)
+}
+
+// Validate checks the request before touching the kernel
+func (request UpdateAddressRequest) Validate() error {
+	if err := validateStruct(request); err != nil {
+		return err
+	}
+	if request.PreferredLifetime > 0 && request.ValidLifetime == 0 {
+		return invalidf("preferred_lifetime requires a valid_lifetime: addresses that never expire are always preferred")
+	}
+	if request.PreferredLifetime > request.ValidLifetime {
+		return invalidf("preferred_lifetime must not exceed valid_lifetime")
+	}
+	return nil
+}
+
+// AddAddress adds an address to an interface and records it
+func (service *Service) AddAddress(request AddressRequest) (*db.IPAddress, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := service.scopeNamespaces(&request.Namespace); err != nil {
+		return nil, err
+	}
+	releaseQuota, err := service.checkQuota(db.QuotaAddresses, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseQuota()
+
+	transaction := service.beginTransaction()
+
+	// Add to system
+	err = transaction.Apply("add address "+request.Address,
+		func() error { return service.addressManager.Add(request.Address, request.Interface, request.Namespace) },
+		func() error {
+			return service.addressManager.Delete(request.Address, request.Interface, request.Namespace)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database
+	var addressRecord *db.IPAddress
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		namespaceID, err := txRepository.NamespaceIDByName(request.Namespace)
+		if err != nil {
+			return err
+		}
+		addressRecord, err = txRepository.CreateIPAddress(request.Interface, namespaceID, request.Address, service.projectID())
+		if err != nil {
+			return err
+		}
+		addressRecord.Labels = request.Labels
+		return txRepository.SetLabels(db.LabelAddresses, addressRecord.ID, request.Labels)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record IP address: %w", err)
+	}
+
+	return addressRecord, nil
+}
+
+// DeleteAddress removes an address from an interface along with any matching records
+func (service *Service) DeleteAddress(request AddressRequest) error {
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	if err := service.scopeNamespaces(&request.Namespace); err != nil {
+		return err
+	}
+
+	// Remove the records (if any), then the address
+	transaction := service.beginTransaction()
+	return transaction.Commit(func(txRepository *db.Repository) error {
+		namespaceID, err := txRepository.NamespaceIDByName(request.Namespace)
+		if err != nil {
+			return err
+		}
+
+		// An unrecorded namespace has no address records
+		if request.Namespace == "" || namespaceID != nil {
+			addressRecords, err := txRepository.ListIPAddresses(namespaceID)
+			if err != nil {
+				return err
+			}
+			for _, addressRecord := range addressRecords {
+				if sameNamespaceID(addressRecord.NsID, namespaceID) &&
+					addressRecord.InterfaceName == request.Interface && addressRecord.Address == request.Address {
+					if err := txRepository.DeleteIPAddress(addressRecord.ID); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		return transaction.Apply("delete address "+request.Address,
+			func() error {
+				return service.addressManager.Delete(request.Address, request.Interface, request.Namespace)
+			},
+			func() error { return service.addressManager.Add(request.Address, request.Interface, request.Namespace) },
+		)
+	})
+}
+
+// DeleteAddressByID removes a recorded address
+func (service *Service) DeleteAddressByID(id int64) error {
+	addressRecord, err := service.repository.GetIPAddress(id)
+	if err != nil {
+		return err
+	}
+	if addressRecord == nil || !service.ownedByProject(addressRecord.ProjectID) {
+		return &NotFoundError{Resource: "address", Name: strconv.FormatInt(id, 10)}
+	}
+
+	namespaceName, err := service.repository.NamespaceNameByID(addressRecord.NsID)
+	if err != nil {
+		return err
+	}
+
+	return service.DeleteAddress(AddressRequest{
+		Interface: addressRecord.InterfaceName,
+		Address:   addressRecord.Address,
+		Namespace: namespaceName,
+	})
+}
+
+// UpdateAddress changes the label and lifetimes of a recorded address
+// Lifetimes change in place; a new label re-adds the address on the interface.
+// Parameters:
+//   - id: address record ID
+//   - request: new label and lifetimes
+func (service *Service) UpdateAddress(id int64, request UpdateAddressRequest) (*db.IPAddress, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	addressRecord, err := service.GetAddress(id)
+	if err != nil {
+		return nil, err
+	}
+	namespaceName, err := service.repository.NamespaceNameByID(addressRecord.NsID)
+	if err != nil {
+		return nil, err
+	}
+
+	addressLabel := addressRecord.AddressLabel
+	if request.AddressLabel != nil {
+		addressLabel = *request.AddressLabel
+	}
+	if addressLabel != "" {
+		if !isIPv4(addressRecord.Address) {
+			return nil, invalidf("address labels are only supported on IPv4 addresses")
+		}
+		if !strings.HasPrefix(addressLabel, addressRecord.InterfaceName) {
+			return nil, invalidf("address label %q must start with the interface name %q", addressLabel, addressRecord.InterfaceName)
+		}
+	}
+
+	transaction := service.beginTransaction()
+
+	// Apply in system; the undo keeps the address but cannot restore earlier lifetimes
+	err = transaction.Apply("update address "+addressRecord.Address,
+		func() error {
+			return service.addressManager.Update(addressRecord.Address, addressRecord.InterfaceName, namespaceName, netns.AddressOptions{
+				Label:             addressLabel,
+				ValidLifetime:     request.ValidLifetime,
+				PreferredLifetime: request.PreferredLifetime,
+			})
+		},
+		func() error {
+			return service.addressManager.Update(addressRecord.Address, addressRecord.InterfaceName, namespaceName, netns.AddressOptions{
+				Label: addressRecord.AddressLabel,
+			})
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		return txRepository.UpdateIPAddress(addressRecord.ID, addressLabel)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record IP address: %w", err)
+	}
+
+	addressRecord.AddressLabel = addressLabel
+	return addressRecord, nil
+}
+
+// GetAddress returns a recorded address with its labels
+func (service *Service) GetAddress(id int64) (*db.IPAddress, error) {
+	addressRecord, err := service.repository.GetIPAddress(id)
+	if err != nil {
+		return nil, err
+	}
+	if addressRecord == nil || !service.ownedByProject(addressRecord.ProjectID) {
+		return nil, &NotFoundError{Resource: "address", Name: strconv.FormatInt(id, 10)}
+	}
+	if addressRecord.Labels, err = service.repository.GetLabels(db.LabelAddresses, addressRecord.ID); err != nil {
+		return nil, err
+	}
+	return addressRecord, nil
+}
+
+// ListAddresses returns one page of the recorded addresses matching the list options, optionally in one namespace
+// Returns the cursor of the next page (empty = last page).
+func (service *Service) ListAddresses(namespaceName string, options ListOptions) ([]db.IPAddress, string, error) {
+	query, err := service.listQuery(namespaceName, options)
+	if err != nil {
+		return nil, "", err
+	}
+	return pagedRecords(service.repository.QueryIPAddresses(query))
+}
+
+// AddressInfos returns the addresses currently configured in a namespace (empty = host)
+func (service *Service) AddressInfos(namespaceName string) ([]netns.AddressInfo, error) {
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return nil, err
+	}
+	return service.addressManager.GetAddressInfos(namespaceName)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+)
+
+// Bond defaults applied when a request leaves them unset
+const (
+	DefaultBondMode   = "active-backup"
+	DefaultBondMiimon = 100
+)
+
+// CreateBondRequest describes a bond to create from managed veth ends
+type CreateBondRequest struct {
+	Name      string            `json:"name" validate:"required,ifname"`
+	Mode      string            `json:"mode" validate:"omitempty,oneof=balance-rr active-backup balance-xor broadcast 802.3ad balance-tlb balance-alb"` // Defaults to active-backup
+	Miimon    *int              `json:"miimon" validate:"omitempty,gte=0"`                                                                              // MII monitoring interval in ms (nil = 100, 0 = disabled)
+	Primary   string            `json:"primary"`                                                                                                        // Preferred slave (active-backup)
+	Slaves    []string          `json:"slaves" validate:"min=2,dive,required,ifname"`
+	Namespace string            `json:"namespace"` // Empty = host
+	Labels    map[string]string `json:"labels,omitempty" validate:"dive,keys,labelkey,endkeys,labelvalue"`
+}
+
+// Validate checks the request before touching the kernel
+func (request CreateBondRequest) Validate() error {
+	if err := validateStruct(request); err != nil {
+		return err
+	}
+	if request.Primary != "" {
+		for _, slaveName := range request.Slaves {
+			if slaveName == request.Primary {
+				return nil
+			}
+		}
+		return invalidf("primary %q must be one of slaves", request.Primary)
+	}
+	return nil
+}
+
+// CreateBond creates a bond and records it
+// Every slave must be a recorded veth end in the bond's namespace.
+func (service *Service) CreateBond(request CreateBondRequest) (*db.Bond, error) {
+	// Slaves are veth ends, named like every other project interface
+	request.Slaves = append([]string(nil), request.Slaves...)
+	service.scopeNames(&request.Name, &request.Primary)
+	for index := range request.Slaves {
+		service.scopeNames(&request.Slaves[index])
+	}
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := service.scopeNamespaces(&request.Namespace); err != nil {
+		return nil, err
+	}
+
+	bondMode := request.Mode
+	if bondMode == "" {
+		bondMode = DefaultBondMode
+	}
+	bondMiimon := DefaultBondMiimon
+	if request.Miimon != nil {
+		bondMiimon = *request.Miimon
+	}
+
+	namespaceID, err := service.repository.NamespaceIDByName(request.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// Slaves must be managed veth ends in the bond's namespace
+	for _, slaveName := range request.Slaves {
+		if err := service.checkManagedVethEnd(slaveName, namespaceID); err != nil {
+			return nil, err
+		}
+	}
+
+	bondConfig := netns.Bond{
+		Name:      request.Name,
+		Mode:      bondMode,
+		Miimon:    bondMiimon,
+		Primary:   request.Primary,
+		Slaves:    request.Slaves,
+		Namespace: request.Namespace,
+	}
+
+	transaction := service.beginTransaction()
+
+	// Create in system
+	err = transaction.Apply("create bond "+request.Name,
+		func() error { return service.bondManager.Create(bondConfig) },
+		func() error { return service.bondManager.Delete(request.Name, request.Namespace) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database
+	var bondRecord *db.Bond
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		var err error
+		bondRecord, err = txRepository.CreateBond(request.Name, bondMode, bondMiimon, request.Primary, request.Slaves, namespaceID)
+		if err != nil {
+			return err
+		}
+		bondRecord.Labels = request.Labels
+		return txRepository.SetLabels(db.LabelBonds, bondRecord.ID, request.Labels)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record bond: %w", err)
+	}
+
+	return bondRecord, nil
+}
+
+// DeleteBond deletes a bond and its record
+// Parameters:
+//   - bondName: bond to delete
+//   - namespaceName: namespace of the bond (empty = host)
+func (service *Service) DeleteBond(bondName, namespaceName string) error {
+	service.scopeNames(&bondName)
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return err
+	}
+
+	// Remove the record, then the bond; the record is needed to recreate it
+	transaction := service.beginTransaction()
+	return transaction.Commit(func(txRepository *db.Repository) error {
+		bondRecord, err := txRepository.GetBondByName(bondName)
+		if err != nil {
+			return err
+		}
+		visible := bondRecord != nil
+		if visible {
+			if visible, err = service.namespaceIDInProject(txRepository, bondRecord.NsID); err != nil {
+				return err
+			}
+		}
+		if service.project != nil && !visible {
+			return &NotFoundError{Resource: "bond", Name: bondName}
+		}
+
+		var recreate func() error
+		if bondRecord != nil {
+			if err := txRepository.DeleteBond(bondName); err != nil {
+				return err
+			}
+			recreate = func() error {
+				return service.bondManager.Create(netns.Bond{
+					Name:      bondRecord.Name,
+					Mode:      bondRecord.Mode,
+					Miimon:    bondRecord.Miimon,
+					Primary:   bondRecord.Primary,
+					Slaves:    bondRecord.Slaves,
+					Namespace: namespaceName,
+				})
+			}
+		}
+
+		return transaction.Apply("delete bond "+bondName,
+			func() error { return service.bondManager.Delete(bondName, namespaceName) },
+			recreate,
+		)
+	})
+}
+
+// ListBonds returns the recorded bonds matching the list options, optionally in one namespace
+func (service *Service) ListBonds(namespaceName string, options ListOptions) ([]db.Bond, error) {
+	namespaceID, err := service.namespaceFilter(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	bondRecords, err := service.repository.ListBonds(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+	bondRecords, err = filterInProjectNamespaces(service, bondRecords, func(bondRecord db.Bond) *int64 { return bondRecord.NsID })
+	if err != nil {
+		return nil, err
+	}
+	return labelRecords(service, db.LabelBonds, bondRecords, options, func(bondRecord *db.Bond) (int64, *map[string]string) {
+		return bondRecord.ID, &bondRecord.Labels
+	})
+}
+
+// BondInfos returns the bonds currently present in a namespace with per-slave state (empty = host)
+func (service *Service) BondInfos(namespaceName string) ([]netns.BondInfo, error) {
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return nil, err
+	}
+	return service.bondManager.List(namespaceName)
+}
+
+// checkManagedVethEnd verifies that an interface is a recorded veth end in the given namespace
+// Parameters:
+//   - interfaceName: veth end to check
+//   - namespaceID: namespace the interface must live in (nil = host)
+func (service *Service) checkManagedVethEnd(interfaceName string, namespaceID *int64) error {
+	vethPair, err := service.repository.GetVethPairByInterface(interfaceName)
+	if err != nil {
+		return err
+	}
+	if vethPair == nil {
+		return invalidf("slave %q is not a managed veth end", interfaceName)
+	}
+
+	endNamespaceID := vethPair.NsID
+	if vethPair.PeerName == interfaceName {
+		endNamespaceID = vethPair.PeerNsID
+	}
+
+	if !sameNamespaceID(endNamespaceID, namespaceID) {
+		return invalidf("slave %q is not in the bond's namespace", interfaceName)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+)
+
+// CreateBridgeRequest describes a bridge to create
+type CreateBridgeRequest struct {
+	Name      string            `json:"name" validate:"required,ifname"`
+	Namespace string            `json:"namespace"` // Empty = host
+	Labels    map[string]string `json:"labels,omitempty" validate:"dive,keys,labelkey,endkeys,labelvalue"`
+}
+
+// Validate checks the request before touching the kernel
+func (request CreateBridgeRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// BridgePortRequest identifies an interface attached to a bridge
+type BridgePortRequest struct {
+	Bridge    string `json:"bridge" validate:"required,ifname"`
+	Interface string `json:"interface" validate:"required,ifname"`
+	Namespace string `json:"namespace"` // Empty = host
+}
+
+// Validate checks the request before touching the kernel
+func (request BridgePortRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// CreateBridge creates a bridge and records it
+func (service *Service) CreateBridge(request CreateBridgeRequest) (*db.Bridge, error) {
+	service.scopeNames(&request.Name)
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := service.scopeNamespaces(&request.Namespace); err != nil {
+		return nil, err
+	}
+	releaseQuota, err := service.checkQuota(db.QuotaBridges, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseQuota()
+
+	transaction := service.beginTransaction()
+
+	// Create in system
+	err = transaction.Apply("create bridge "+request.Name,
+		func() error { return service.bridgeManager.Create(request.Name, request.Namespace) },
+		func() error { return service.bridgeManager.Delete(request.Name, request.Namespace) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database
+	var bridgeRecord *db.Bridge
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		namespaceID, err := txRepository.NamespaceIDByName(request.Namespace)
+		if err != nil {
+			return err
+		}
+		bridgeRecord, err = txRepository.CreateBridge(request.Name, namespaceID, service.projectID())
+		if err != nil {
+			return err
+		}
+		bridgeRecord.Labels = request.Labels
+		return txRepository.SetLabels(db.LabelBridges, bridgeRecord.ID, request.Labels)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record bridge: %w", err)
+	}
+
+	return bridgeRecord, nil
+}
+
+// DeleteBridge deletes a bridge and its record
+// Parameters:
+//   - bridgeName: bridge to delete
+//   - namespaceName: namespace of the bridge (empty = host)
+func (service *Service) DeleteBridge(bridgeName, namespaceName string) error {
+	service.scopeNames(&bridgeName)
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return err
+	}
+
+	// Remove the record (ports cascade), then the bridge
+	transaction := service.beginTransaction()
+	return transaction.Commit(func(txRepository *db.Repository) error {
+		bridgeRecord, err := txRepository.GetBridgeByName(bridgeName)
+		if err != nil {
+			return err
+		}
+		if service.project != nil && (bridgeRecord == nil || !service.ownedByProject(bridgeRecord.ProjectID)) {
+			return &NotFoundError{Resource: "bridge", Name: bridgeName}
+		}
+
+		var recreate func() error
+		if bridgeRecord != nil {
+			bridgePorts, err := txRepository.ListBridgePorts(bridgeRecord.ID)
+			if err != nil {
+				return err
+			}
+			if err := txRepository.DeleteBridge(bridgeName); err != nil {
+				return err
+			}
+			recreate = func() error {
+				if err := service.bridgeManager.Create(bridgeName, namespaceName); err != nil {
+					return err
+				}
+				for _, bridgePort := range bridgePorts {
+					if err := service.bridgeManager.AddPort(bridgeName, bridgePort.InterfaceName, namespaceName); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		}
+
+		return transaction.Apply("delete bridge "+bridgeName,
+			func() error { return service.bridgeManager.Delete(bridgeName, namespaceName) },
+			recreate,
+		)
+	})
+}
+
+// AddBridgePort attaches an interface to a bridge and records the port
+// Ports of unrecorded bridges are attached in the kernel only.
+func (service *Service) AddBridgePort(request BridgePortRequest) error {
+	service.scopeNames(&request.Bridge)
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	if err := service.scopeNamespaces(&request.Namespace); err != nil {
+		return err
+	}
+
+	transaction := service.beginTransaction()
+
+	// Add to system
+	err := transaction.Apply("add port "+request.Interface,
+		func() error {
+			return service.bridgeManager.AddPort(request.Bridge, request.Interface, request.Namespace)
+		},
+		func() error { return service.bridgeManager.RemovePort(request.Interface, request.Namespace) },
+	)
+	if err != nil {
+		return err
+	}
+
+	// Record in database (unmanaged bridges have no record)
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		bridgeRecord, err := txRepository.GetBridgeByName(request.Bridge)
+		if err != nil || bridgeRecord == nil {
+			return err
+		}
+		_, err = txRepository.AddBridgePort(bridgeRecord.ID, request.Interface)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record bridge port: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveBridgePort detaches an interface from a bridge and removes the port record
+func (service *Service) RemoveBridgePort(request BridgePortRequest) error {
+	service.scopeNames(&request.Bridge)
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	if err := service.scopeNamespaces(&request.Namespace); err != nil {
+		return err
+	}
+
+	// Remove the record (if any), then the port
+	transaction := service.beginTransaction()
+	return transaction.Commit(func(txRepository *db.Repository) error {
+		bridgeRecord, err := txRepository.GetBridgeByName(request.Bridge)
+		if err != nil {
+			return err
+		}
+		if bridgeRecord != nil {
+			if err := txRepository.RemoveBridgePort(bridgeRecord.ID, request.Interface); err != nil {
+				return err
+			}
+		}
+
+		return transaction.Apply("remove port "+request.Interface,
+			func() error { return service.bridgeManager.RemovePort(request.Interface, request.Namespace) },
+			func() error {
+				return service.bridgeManager.AddPort(request.Bridge, request.Interface, request.Namespace)
+			},
+		)
+	})
+}
+
+// ListBridges returns the recorded bridges matching the list options
+func (service *Service) ListBridges(options ListOptions) ([]db.Bridge, error) {
+	bridgeRecords, err := service.repository.ListBridges()
+	if err != nil {
+		return nil, err
+	}
+	bridgeRecords = filterOwned(service, bridgeRecords, func(bridgeRecord db.Bridge) *int64 { return bridgeRecord.ProjectID })
+	return labelRecords(service, db.LabelBridges, bridgeRecords, options, func(bridgeRecord *db.Bridge) (int64, *map[string]string) {
+		return bridgeRecord.ID, &bridgeRecord.Labels
+	})
+}
+
+// GetBridge returns a recorded bridge
+func (service *Service) GetBridge(bridgeName string) (*db.Bridge, error) {
+	service.scopeNames(&bridgeName)
+	bridgeRecord, err := service.repository.GetBridgeByName(bridgeName)
+	if err != nil {
+		return nil, err
+	}
+	if bridgeRecord == nil || !service.ownedByProject(bridgeRecord.ProjectID) {
+		return nil, &NotFoundError{Resource: "bridge", Name: bridgeName}
+	}
+	if bridgeRecord.Labels, err = service.repository.GetLabels(db.LabelBridges, bridgeRecord.ID); err != nil {
+		return nil, err
+	}
+	return bridgeRecord, nil
+}
+
+// BridgeInfos returns the bridges currently present in a namespace (empty = host)
+func (service *Service) BridgeInfos(namespaceName string) ([]netns.BridgeInfo, error) {
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return nil, err
+	}
+	return service.bridgeManager.GetBridgeInfos(namespaceName)
+}
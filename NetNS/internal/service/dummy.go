@@ -0,0 +1,161 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+)
+
+// CreateDummyRequest describes a dummy interface to create
+type CreateDummyRequest struct {
+	Name      string            `json:"name" validate:"required,ifname"`
+	Addresses []string          `json:"addresses" validate:"dive,cidr"` // CIDR notation, may be empty
+	Namespace string            `json:"namespace"`                      // Empty = host
+	Labels    map[string]string `json:"labels,omitempty" validate:"dive,keys,labelkey,endkeys,labelvalue"`
+}
+
+// Validate checks the request before touching the kernel
+func (request CreateDummyRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// CreateDummy creates a dummy interface and records it together with its addresses
+func (service *Service) CreateDummy(request CreateDummyRequest) (*db.DummyInterface, error) {
+	service.scopeNames(&request.Name)
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := service.scopeNamespaces(&request.Namespace); err != nil {
+		return nil, err
+	}
+	releaseQuota, err := service.checkQuota(db.QuotaAddresses, len(request.Addresses))
+	if err != nil {
+		return nil, err
+	}
+	defer releaseQuota()
+
+	transaction := service.beginTransaction()
+
+	// Create in system
+	err = transaction.Apply("create dummy interface "+request.Name,
+		func() error { return service.dummyManager.Create(request.Name, request.Addresses, request.Namespace) },
+		func() error { return service.dummyManager.Delete(request.Name, request.Namespace) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the interface and its addresses together
+	var dummyRecord *db.DummyInterface
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		namespaceID, err := txRepository.NamespaceIDByName(request.Namespace)
+		if err != nil {
+			return err
+		}
+		dummyRecord, err = txRepository.CreateDummyInterface(request.Name, namespaceID)
+		if err != nil {
+			return err
+		}
+		dummyRecord.Labels = request.Labels
+		if err := txRepository.SetLabels(db.LabelDummies, dummyRecord.ID, request.Labels); err != nil {
+			return err
+		}
+		for _, address := range request.Addresses {
+			if _, err := txRepository.CreateIPAddress(request.Name, namespaceID, address, service.projectID()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record dummy interface: %w", err)
+	}
+
+	return dummyRecord, nil
+}
+
+// DeleteDummy deletes a dummy interface and its records
+// Parameters:
+//   - interfaceName: interface to delete
+//   - namespaceName: namespace of the interface (empty = host)
+func (service *Service) DeleteDummy(interfaceName, namespaceName string) error {
+	service.scopeNames(&interfaceName)
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return err
+	}
+
+	// Remove the records (addresses go away with the interface), then the interface
+	transaction := service.beginTransaction()
+	return transaction.Commit(func(txRepository *db.Repository) error {
+		dummyRecord, err := txRepository.GetDummyInterfaceByName(interfaceName)
+		if err != nil {
+			return err
+		}
+		visible := dummyRecord != nil
+		if visible {
+			if visible, err = service.namespaceIDInProject(txRepository, dummyRecord.NsID); err != nil {
+				return err
+			}
+		}
+		if service.project != nil && !visible {
+			return &NotFoundError{Resource: "dummy interface", Name: interfaceName}
+		}
+
+		var recreate func() error
+		if dummyRecord != nil {
+			addressRecords, err := txRepository.ListIPAddresses(dummyRecord.NsID)
+			if err != nil {
+				return err
+			}
+			var addresses []string
+			for _, addressRecord := range addressRecords {
+				if addressRecord.InterfaceName == interfaceName && sameNamespaceID(addressRecord.NsID, dummyRecord.NsID) {
+					addresses = append(addresses, addressRecord.Address)
+				}
+			}
+
+			if err := txRepository.DeleteIPAddressesByInterface(interfaceName, dummyRecord.NsID); err != nil {
+				return err
+			}
+			if err := txRepository.DeleteDummyInterface(interfaceName); err != nil {
+				return err
+			}
+			recreate = func() error {
+				return service.dummyManager.Create(interfaceName, addresses, namespaceName)
+			}
+		}
+
+		return transaction.Apply("delete dummy interface "+interfaceName,
+			func() error { return service.dummyManager.Delete(interfaceName, namespaceName) },
+			recreate,
+		)
+	})
+}
+
+// ListDummies returns the recorded dummy interfaces matching the list options, optionally in one namespace
+func (service *Service) ListDummies(namespaceName string, options ListOptions) ([]db.DummyInterface, error) {
+	namespaceID, err := service.namespaceFilter(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	dummyRecords, err := service.repository.ListDummyInterfaces(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+	dummyRecords, err = filterInProjectNamespaces(service, dummyRecords, func(dummyRecord db.DummyInterface) *int64 { return dummyRecord.NsID })
+	if err != nil {
+		return nil, err
+	}
+	return labelRecords(service, db.LabelDummies, dummyRecords, options, func(dummyRecord *db.DummyInterface) (int64, *map[string]string) {
+		return dummyRecord.ID, &dummyRecord.Labels
+	})
+}
+
+// DummyInfos returns the dummy interfaces currently present in a namespace (empty = host)
+func (service *Service) DummyInfos(namespaceName string) ([]netns.DummyInfo, error) {
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return nil, err
+	}
+	return service.dummyManager.List(namespaceName)
+}
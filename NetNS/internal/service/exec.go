@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// defaultExecPath is the PATH commands run with unless the request sets one
+const defaultExecPath = "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// execWaitDelay bounds how long output is still collected once a command was
+// killed, in case a background child keeps its pipes open
+const execWaitDelay = 5 * time.Second
+
+// ExecRequest describes a command to run inside a namespace
+type ExecRequest struct {
+	Command        []string `json:"command" validate:"required,min=1,dive,required"`
+	Env            []string `json:"env,omitempty" validate:"dive,contains=="` // KEY=value entries added to a minimal environment
+	Dir            string   `json:"dir,omitempty"`                            // Working directory (empty = the server's)
+	Stdin          string   `json:"stdin,omitempty"`                          // Fed to the command's standard input
+	Timeout        int      `json:"timeout,omitempty" validate:"gte=0"`       // Seconds before the command is killed (0 = no limit)
+	MountNamespace *bool    `json:"mount_namespace,omitempty"`                // Private mount namespace with /etc/netns overlays and sysfs (default true)
+}
+
+// Validate checks the request before starting anything
+func (request ExecRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// ExecInNamespace runs a command inside a namespace and waits for it to end
+// Returns the command's exit code (-1 if a signal ended it). A non-zero exit
+// code is not an error; err is only set when the command could not run or was
+// stopped by the timeout or ctx.
+// Parameters:
+//   - ctx: cancelling it kills the command
+//   - namespaceName: namespace to run the command in
+//   - request: command and how to run it
+//   - stdin: standard input (nil = request.Stdin)
+//   - stdout: receives the command's standard output
+//   - stderr: receives the command's standard error
+func (service *Service) ExecInNamespace(ctx context.Context, namespaceName string, request ExecRequest, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	if err := request.Validate(); err != nil {
+		return -1, err
+	}
+	if namespaceName == "" {
+		return -1, invalidf("a namespace is required")
+	}
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return -1, err
+	}
+	if !service.namespaceManager.Exists(namespaceName) {
+		return -1, &NotFoundError{Resource: "namespace", Name: namespaceName}
+	}
+
+	if request.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(request.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	command := exec.CommandContext(ctx, request.Command[0], request.Command[1:]...)
+	command.Env = append([]string{defaultExecPath}, request.Env...)
+	command.Dir = request.Dir
+	command.Stdin = stdin
+	if stdin == nil && request.Stdin != "" {
+		command.Stdin = strings.NewReader(request.Stdin)
+	}
+	command.Stdout = stdout
+	command.Stderr = stderr
+	command.WaitDelay = execWaitDelay
+
+	mountNamespace := request.MountNamespace == nil || *request.MountNamespace
+	err := service.namespaceManager.StartCommand(command, namespaceName, mountNamespace)
+	if errors.Is(err, exec.ErrNotFound) {
+		return -1, invalidf("command %q not found in $PATH", request.Command[0])
+	}
+	if err != nil {
+		return -1, fmt.Errorf("failed to start %s in namespace %s: %w", request.Command[0], namespaceName, err)
+	}
+
+	err = command.Wait()
+	exitCode := command.ProcessState.ExitCode()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && request.Timeout > 0 {
+		return exitCode, fmt.Errorf("%s was killed after its %ds timeout: %w", request.Command[0], request.Timeout, ctx.Err())
+	}
+	if ctx.Err() != nil {
+		return exitCode, fmt.Errorf("%s was stopped: %w", request.Command[0], ctx.Err())
+	}
+	var exitError *exec.ExitError
+	if err != nil && !errors.As(err, &exitError) {
+		return exitCode, err
+	}
+	return exitCode, nil
+}
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+)
+
+// CreateGRETunnelRequest describes a GRE tunnel to create
+type CreateGRETunnelRequest struct {
+	Name      string            `json:"name" validate:"required,ifname"`
+	LocalIP   string            `json:"local_ip" validate:"required,ip"`
+	RemoteIP  string            `json:"remote_ip" validate:"required,ip"`
+	Key       uint32            `json:"key"`       // GRE key (0 = no key)
+	TTL       uint8             `json:"ttl"`       // Time to live (0 = inherit)
+	Namespace string            `json:"namespace"` // Empty = host
+	Labels    map[string]string `json:"labels,omitempty" validate:"dive,keys,labelkey,endkeys,labelvalue"`
+}
+
+// Validate checks the request before touching the kernel
+func (request CreateGRETunnelRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// UpdateGRETunnelRequest changes the settings of a GRE tunnel; omitted fields keep their value
+type UpdateGRETunnelRequest struct {
+	LocalIP  *string `json:"local_ip,omitempty" validate:"omitempty,ip"`
+	RemoteIP *string `json:"remote_ip,omitempty" validate:"omitempty,ip"`
+	Key      *uint32 `json:"key,omitempty"` // GRE key (0 = remove the key)
+	TTL      *uint8  `json:"ttl,omitempty"` // Time to live (0 = inherit)
+}
+
+// Validate checks the request before touching the kernel
+func (request UpdateGRETunnelRequest) Validate() error {
+	if err := validateStruct(request); err != nil {
+		return err
+	}
+	if request.LocalIP == nil && request.RemoteIP == nil && request.Key == nil && request.TTL == nil {
+		return invalidf("nothing to update: set local_ip, remote_ip, key or ttl")
+	}
+	return nil
+}
+
+// CreatePeerTunnelsRequest describes a GRE tunnel pair between two namespaces
+// The tunnels are named <tunnel_name>-1 (in ns1) and <tunnel_name>-2 (in ns2)
+// and both get the labels.
+type CreatePeerTunnelsRequest struct {
+	TunnelName  string            `json:"tunnel_name" validate:"required,ifname,max=13"` // Room for the "-1"/"-2" suffix
+	Ns1         string            `json:"ns1" validate:"required,nsname"`
+	Ns1IP       string            `json:"ns1_ip" validate:"required,ip"`          // Underlay endpoint in ns1
+	Ns1TunnelIP string            `json:"ns1_tunnel_ip" validate:"required,cidr"` // Address of the tunnel interface in ns1
+	Ns2         string            `json:"ns2" validate:"required,nsname"`
+	Ns2IP       string            `json:"ns2_ip" validate:"required,ip"`          // Underlay endpoint in ns2
+	Ns2TunnelIP string            `json:"ns2_tunnel_ip" validate:"required,cidr"` // Address of the tunnel interface in ns2
+	Labels      map[string]string `json:"labels,omitempty" validate:"dive,keys,labelkey,endkeys,labelvalue"`
+}
+
+// Validate checks the request before touching the kernel
+func (request CreatePeerTunnelsRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// Tunnel1Name returns the name of the tunnel created in ns1
+func (request CreatePeerTunnelsRequest) Tunnel1Name() string {
+	return request.TunnelName + "-1"
+}
+
+// Tunnel2Name returns the name of the tunnel created in ns2
+func (request CreatePeerTunnelsRequest) Tunnel2Name() string {
+	return request.TunnelName + "-2"
+}
+
+// CreateGRETunnel creates a GRE tunnel and records it
+func (service *Service) CreateGRETunnel(request CreateGRETunnelRequest) (*db.GRETunnel, error) {
+	service.scopeNames(&request.Name)
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := service.scopeNamespaces(&request.Namespace); err != nil {
+		return nil, err
+	}
+	releaseQuota, err := service.checkQuota(db.QuotaGRETunnels, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseQuota()
+
+	tunnelConfig := netns.GRETunnel{
+		Name:      request.Name,
+		LocalIP:   request.LocalIP,
+		RemoteIP:  request.RemoteIP,
+		Key:       request.Key,
+		TTL:       request.TTL,
+		Namespace: request.Namespace,
+	}
+
+	transaction := service.beginTransaction()
+
+	// Create in system
+	err = transaction.Apply("create GRE tunnel "+request.Name,
+		func() error { return service.greManager.CreateWithOptions(tunnelConfig) },
+		func() error { return service.greManager.Delete(request.Name, request.Namespace) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database
+	var tunnelRecord *db.GRETunnel
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		namespaceID, err := txRepository.NamespaceIDByName(request.Namespace)
+		if err != nil {
+			return err
+		}
+		tunnelRecord, err = txRepository.CreateGRETunnel(request.Name, request.LocalIP, request.RemoteIP, request.Key, request.TTL, namespaceID, service.projectID())
+		if err != nil {
+			return err
+		}
+		tunnelRecord.Labels = request.Labels
+		return txRepository.SetLabels(db.LabelGRETunnels, tunnelRecord.ID, request.Labels)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record GRE tunnel: %w", err)
+	}
+
+	return tunnelRecord, nil
+}
+
+// DeleteGRETunnel deletes a GRE tunnel and its record
+// Parameters:
+//   - tunnelName: tunnel to delete
+//   - namespaceName: namespace of the tunnel (empty = host)
+func (service *Service) DeleteGRETunnel(tunnelName, namespaceName string) error {
+	service.scopeNames(&tunnelName)
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return err
+	}
+
+	// Remove the record, then the tunnel; the record is needed to recreate it
+	transaction := service.beginTransaction()
+	return transaction.Commit(func(txRepository *db.Repository) error {
+		tunnelRecord, err := txRepository.GetGRETunnelByName(tunnelName)
+		if err != nil {
+			return err
+		}
+		if service.project != nil && (tunnelRecord == nil || !service.ownedByProject(tunnelRecord.ProjectID)) {
+			return &NotFoundError{Resource: "GRE tunnel", Name: tunnelName}
+		}
+
+		var recreate func() error
+		if tunnelRecord != nil {
+			if err := txRepository.DeleteGRETunnel(tunnelName); err != nil {
+				return err
+			}
+			recreate = func() error {
+				return service.greManager.CreateWithOptions(netns.GRETunnel{
+					Name:      tunnelRecord.Name,
+					LocalIP:   tunnelRecord.LocalIP,
+					RemoteIP:  tunnelRecord.RemoteIP,
+					Key:       tunnelRecord.Key,
+					TTL:       tunnelRecord.TTL,
+					Namespace: namespaceName,
+				})
+			}
+		}
+
+		return transaction.Apply("delete GRE tunnel "+tunnelName,
+			func() error { return service.greManager.Delete(tunnelName, namespaceName) },
+			recreate,
+		)
+	})
+}
+
+// UpdateGRETunnel changes the endpoints, key or TTL of a recorded GRE tunnel in place
+// The tunnel interface is modified rather than recreated, so its addresses and routes stay.
+// Parameters:
+//   - tunnelName: tunnel to update
+//   - request: settings to change
+func (service *Service) UpdateGRETunnel(tunnelName string, request UpdateGRETunnelRequest) (*db.GRETunnel, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	tunnelRecord, err := service.GetGRETunnel(tunnelName)
+	if err != nil {
+		return nil, err
+	}
+	namespaceName, err := service.repository.NamespaceNameByID(tunnelRecord.NsID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousConfig := netns.GRETunnel{
+		Name:      tunnelRecord.Name,
+		LocalIP:   tunnelRecord.LocalIP,
+		RemoteIP:  tunnelRecord.RemoteIP,
+		Key:       tunnelRecord.Key,
+		TTL:       tunnelRecord.TTL,
+		Namespace: namespaceName,
+	}
+	tunnelConfig := previousConfig
+	if request.LocalIP != nil {
+		tunnelConfig.LocalIP = *request.LocalIP
+	}
+	if request.RemoteIP != nil {
+		tunnelConfig.RemoteIP = *request.RemoteIP
+	}
+	if request.Key != nil {
+		tunnelConfig.Key = *request.Key
+	}
+	if request.TTL != nil {
+		tunnelConfig.TTL = *request.TTL
+	}
+	if isIPv4(tunnelConfig.LocalIP) != isIPv4(tunnelConfig.RemoteIP) {
+		return nil, invalidf("local_ip %s and remote_ip %s must be of the same address family", tunnelConfig.LocalIP, tunnelConfig.RemoteIP)
+	}
+
+	transaction := service.beginTransaction()
+
+	// Apply in system
+	err = transaction.Apply("update GRE tunnel "+tunnelConfig.Name,
+		func() error { return service.greManager.Update(tunnelConfig) },
+		func() error { return service.greManager.Update(previousConfig) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		return txRepository.UpdateGRETunnel(tunnelRecord.ID, tunnelConfig.LocalIP, tunnelConfig.RemoteIP, tunnelConfig.Key, tunnelConfig.TTL)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record GRE tunnel: %w", err)
+	}
+
+	tunnelRecord.LocalIP = tunnelConfig.LocalIP
+	tunnelRecord.RemoteIP = tunnelConfig.RemoteIP
+	tunnelRecord.Key = tunnelConfig.Key
+	tunnelRecord.TTL = tunnelConfig.TTL
+	return tunnelRecord, nil
+}
+
+// CreatePeerTunnels creates a GRE tunnel pair between two namespaces and records both tunnels
+func (service *Service) CreatePeerTunnels(request CreatePeerTunnelsRequest) ([]*db.GRETunnel, error) {
+	service.scopeNames(&request.TunnelName)
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := service.scopeNamespaces(&request.Ns1, &request.Ns2); err != nil {
+		return nil, err
+	}
+	releaseQuota, err := service.checkQuota(db.QuotaGRETunnels, 2)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseQuota()
+
+	transaction := service.beginTransaction()
+
+	// Create peer tunnels in system
+	err = transaction.Apply("create peer tunnels "+request.TunnelName,
+		func() error {
+			return service.greManager.CreatePeerTunnels(
+				request.Ns1, request.Ns1IP, request.Ns1TunnelIP,
+				request.Ns2, request.Ns2IP, request.Ns2TunnelIP,
+				request.TunnelName,
+			)
+		},
+		func() error {
+			return errors.Join(
+				service.greManager.Delete(request.Tunnel1Name(), request.Ns1),
+				service.greManager.Delete(request.Tunnel2Name(), request.Ns2),
+			)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record both tunnels, or neither
+	var tunnelRecords []*db.GRETunnel
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		namespace1ID, err := txRepository.NamespaceIDByName(request.Ns1)
+		if err != nil {
+			return err
+		}
+		namespace2ID, err := txRepository.NamespaceIDByName(request.Ns2)
+		if err != nil {
+			return err
+		}
+
+		tunnel1Record, err := txRepository.CreateGRETunnel(request.Tunnel1Name(), request.Ns1IP, request.Ns2IP, 0, 0, namespace1ID, service.projectID())
+		if err != nil {
+			return err
+		}
+		tunnel2Record, err := txRepository.CreateGRETunnel(request.Tunnel2Name(), request.Ns2IP, request.Ns1IP, 0, 0, namespace2ID, service.projectID())
+		if err != nil {
+			return err
+		}
+		tunnelRecords = []*db.GRETunnel{tunnel1Record, tunnel2Record}
+		for _, tunnelRecord := range tunnelRecords {
+			tunnelRecord.Labels = request.Labels
+			if err := txRepository.SetLabels(db.LabelGRETunnels, tunnelRecord.ID, request.Labels); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record GRE tunnels: %w", err)
+	}
+
+	return tunnelRecords, nil
+}
+
+// ListGRETunnels returns one page of the recorded GRE tunnels matching the list options, optionally in one namespace
+// Returns the cursor of the next page (empty = last page).
+func (service *Service) ListGRETunnels(namespaceName string, options ListOptions) ([]db.GRETunnel, string, error) {
+	query, err := service.listQuery(namespaceName, options)
+	if err != nil {
+		return nil, "", err
+	}
+	return pagedRecords(service.repository.QueryGRETunnels(query))
+}
+
+// GetGRETunnel returns a recorded GRE tunnel
+func (service *Service) GetGRETunnel(tunnelName string) (*db.GRETunnel, error) {
+	service.scopeNames(&tunnelName)
+	tunnelRecord, err := service.repository.GetGRETunnelByName(tunnelName)
+	if err != nil {
+		return nil, err
+	}
+	if tunnelRecord == nil || !service.ownedByProject(tunnelRecord.ProjectID) {
+		return nil, &NotFoundError{Resource: "GRE tunnel", Name: tunnelName}
+	}
+	if tunnelRecord.Labels, err = service.repository.GetLabels(db.LabelGRETunnels, tunnelRecord.ID); err != nil {
+		return nil, err
+	}
+	return tunnelRecord, nil
+}
+
+// GRETunnelInfos returns the GRE tunnels currently present in a namespace (empty = host)
+func (service *Service) GRETunnelInfos(namespaceName string) ([]netns.GRETunnelInfo, error) {
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return nil, err
+	}
+	return service.greManager.List(namespaceName)
+}
+
+// SetGRETunnelUp brings a GRE tunnel interface up
+func (service *Service) SetGRETunnelUp(tunnelName, namespaceName string) error {
+	service.scopeNames(&tunnelName)
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return err
+	}
+	return service.greManager.SetUp(tunnelName, namespaceName)
+}
+
+// SetGRETunnelDown brings a GRE tunnel interface down
+func (service *Service) SetGRETunnelDown(tunnelName, namespaceName string) error {
+	service.scopeNames(&tunnelName)
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return err
+	}
+	return service.greManager.SetDown(tunnelName, namespaceName)
+}
+
+// isIPv4 reports whether an IP address (or CIDR) is an IPv4 address
+func isIPv4(address string) bool {
+	if ip, _, err := net.ParseCIDR(address); err == nil {
+		return ip.To4() != nil
+	}
+	ip := net.ParseIP(address)
+	return ip != nil && ip.To4() != nil
+}
@@ -0,0 +1,441 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zenith/netns-mgr/internal/db"
+)
+
+// Label keys are 1-63 letters, digits, '.', '_', '-' or '/' starting with a
+// letter or digit; values are up to 63 of the same characters without '/'.
+// Neither may contain '=', '!' or ',', which separate selector terms.
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]{0,62}$`)
+	labelValuePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{0,63}$`)
+)
+
+// labelRequirement is one comma-separated term of a label selector
+type labelRequirement struct {
+	key     string
+	value   string
+	exists  bool // "key" and "!key" test presence only
+	negated bool // "!=" and "!key"
+}
+
+// Selector matches labels against every requirement of a label selector
+type Selector []labelRequirement
+
+// ParseSelector parses a label selector
+// Terms are separated by commas and must all match: "key=value" (or
+// "key==value"), "key!=value", "key" (label present) and "!key" (label absent).
+// An empty expression matches everything.
+func ParseSelector(expression string) (Selector, error) {
+	var selector Selector
+	for _, term := range strings.Split(expression, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		var requirement labelRequirement
+		switch {
+		case strings.Contains(term, "!="):
+			requirement.key, requirement.value, _ = strings.Cut(term, "!=")
+			requirement.negated = true
+		case strings.Contains(term, "=="):
+			requirement.key, requirement.value, _ = strings.Cut(term, "==")
+		case strings.Contains(term, "="):
+			requirement.key, requirement.value, _ = strings.Cut(term, "=")
+		case strings.HasPrefix(term, "!"):
+			requirement.key = strings.TrimPrefix(term, "!")
+			requirement.exists = true
+			requirement.negated = true
+		default:
+			requirement.key = term
+			requirement.exists = true
+		}
+
+		requirement.key = strings.TrimSpace(requirement.key)
+		requirement.value = strings.TrimSpace(requirement.value)
+		if !labelKeyPattern.MatchString(requirement.key) {
+			return nil, invalidf("invalid selector term %q: bad label key %q", term, requirement.key)
+		}
+		if !requirement.exists && !labelValuePattern.MatchString(requirement.value) {
+			return nil, invalidf("invalid selector term %q: bad label value %q", term, requirement.value)
+		}
+		selector = append(selector, requirement)
+	}
+	return selector, nil
+}
+
+// requirements converts the selector to the label requirements of a repository list query
+func (selector Selector) requirements() []db.LabelRequirement {
+	requirements := make([]db.LabelRequirement, len(selector))
+	for requirementIndex, requirement := range selector {
+		requirements[requirementIndex] = db.LabelRequirement{
+			Key:     requirement.key,
+			Value:   requirement.value,
+			Exists:  requirement.exists,
+			Negated: requirement.negated,
+		}
+	}
+	return requirements
+}
+
+// Matches reports whether labels satisfy every requirement of the selector
+func (selector Selector) Matches(labels map[string]string) bool {
+	for _, requirement := range selector {
+		value, present := labels[requirement.key]
+		var matched bool
+		if requirement.exists {
+			matched = present
+		} else {
+			matched = present && value == requirement.value
+		}
+		if matched == requirement.negated {
+			return false
+		}
+	}
+	return true
+}
+
+// labelRecords attaches the stored labels to records and keeps those matching the selector
+// Used by the resources without Query* repository operations, which support no other list options.
+// Parameters:
+//   - resourceType: label resource type of the records
+//   - records: records to label and filter
+//   - options: list options holding the selector
+//   - labelTarget: returns the ID and the labels field of a record
+func labelRecords[Record any](service *Service, resourceType string, records []Record, options ListOptions, labelTarget func(*Record) (int64, *map[string]string)) ([]Record, error) {
+	if options.pagedOrFiltered() {
+		return nil, invalidf("%s can only be filtered by label selector", resourceType)
+	}
+	selector, err := ParseSelector(options.Selector)
+	if err != nil {
+		return nil, err
+	}
+	labelsByResource, err := service.repository.ListLabels(resourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	matchingRecords := make([]Record, 0, len(records))
+	for recordIndex := range records {
+		recordID, labels := labelTarget(&records[recordIndex])
+		*labels = labelsByResource[recordID]
+		if selector.Matches(*labels) {
+			matchingRecords = append(matchingRecords, records[recordIndex])
+		}
+	}
+	return matchingRecords, nil
+}
+
+// UpdateLabelsRequest changes the labels of one resource
+// Labels are merged into the existing ones; a null value removes the label.
+type UpdateLabelsRequest struct {
+	Resource string             `json:"-"` // Label resource type, e.g. "veths"
+	Name     string             `json:"-"` // Resource name, or record ID for addresses and routes
+	Labels   map[string]*string `json:"labels" validate:"required,dive,keys,labelkey,endkeys,omitnil,labelvalue"`
+}
+
+// Validate checks the request before changing labels
+func (request UpdateLabelsRequest) Validate() error {
+	if !db.IsLabelResource(request.Resource) {
+		return invalidf("resource type %q has no labels", request.Resource)
+	}
+	return validateStruct(request)
+}
+
+// UpdateLabels sets and removes labels of a recorded resource
+// Returns the resulting labels.
+func (service *Service) UpdateLabels(request UpdateLabelsRequest) (map[string]string, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	resourceID, err := service.labelledRecordID(request.Resource, request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	setLabels := make(map[string]string)
+	var removedKeys []string
+	for key, value := range request.Labels {
+		if value == nil {
+			removedKeys = append(removedKeys, key)
+		} else {
+			setLabels[key] = *value
+		}
+	}
+
+	var labels map[string]string
+	transaction := service.beginTransaction()
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		if err := txRepository.SetLabels(request.Resource, resourceID, setLabels); err != nil {
+			return err
+		}
+		if err := txRepository.RemoveLabels(request.Resource, resourceID, removedKeys); err != nil {
+			return err
+		}
+		var err error
+		labels, err = txRepository.GetLabels(request.Resource, resourceID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// labelledRecordID looks up the record a label update applies to
+// Records outside the caller's project are reported as not found.
+// Parameters:
+//   - resourceType: label resource type
+//   - name: resource name, or record ID for addresses and routes
+func (service *Service) labelledRecordID(resourceType, name string) (int64, error) {
+	if resourceType == db.LabelNamespaces {
+		if err := service.scopeNamespaces(&name); err != nil {
+			return 0, err
+		}
+		namespaceRecord, err := service.repository.GetNamespaceByName(name)
+		if err != nil || namespaceRecord == nil {
+			return 0, firstError(err, &NotFoundError{Resource: "namespace", Name: name})
+		}
+		return namespaceRecord.ID, nil
+	}
+
+	var lookupID int64
+	if resourceType == db.LabelAddresses || resourceType == db.LabelRoutes {
+		var err error
+		if lookupID, err = strconv.ParseInt(name, 10, 64); err != nil {
+			return 0, invalidf("invalid id %q", name)
+		}
+	} else {
+		service.scopeNames(&name)
+	}
+
+	var resourceName string
+	var recordID int64
+	var ownerID, namespaceID *int64
+	var lookupErr error
+	switch resourceType {
+	case db.LabelAddresses:
+		resourceName = "address"
+		if addressRecord, err := service.repository.GetIPAddress(lookupID); addressRecord != nil {
+			recordID, ownerID = addressRecord.ID, addressRecord.ProjectID
+		} else {
+			lookupErr = err
+		}
+	case db.LabelRoutes:
+		resourceName = "route"
+		if routeRecord, err := service.repository.GetRoute(lookupID); routeRecord != nil {
+			recordID, ownerID = routeRecord.ID, routeRecord.ProjectID
+		} else {
+			lookupErr = err
+		}
+	case db.LabelVeths:
+		resourceName = "veth pair"
+		if vethPair, err := service.repository.GetVethPairByName(name); vethPair != nil {
+			recordID, ownerID = vethPair.ID, vethPair.ProjectID
+		} else {
+			lookupErr = err
+		}
+	case db.LabelBridges:
+		resourceName = "bridge"
+		if bridgeRecord, err := service.repository.GetBridgeByName(name); bridgeRecord != nil {
+			recordID, ownerID = bridgeRecord.ID, bridgeRecord.ProjectID
+		} else {
+			lookupErr = err
+		}
+	case db.LabelGRETunnels:
+		resourceName = "GRE tunnel"
+		if tunnelRecord, err := service.repository.GetGRETunnelByName(name); tunnelRecord != nil {
+			recordID, ownerID = tunnelRecord.ID, tunnelRecord.ProjectID
+		} else {
+			lookupErr = err
+		}
+	case db.LabelMacvlans:
+		resourceName = "link"
+		if linkRecord, err := service.repository.GetMacvlanLinkByName(name); linkRecord != nil {
+			recordID, namespaceID = linkRecord.ID, linkRecord.NsID
+		} else {
+			lookupErr = err
+		}
+	case db.LabelBonds:
+		resourceName = "bond"
+		if bondRecord, err := service.repository.GetBondByName(name); bondRecord != nil {
+			recordID, namespaceID = bondRecord.ID, bondRecord.NsID
+		} else {
+			lookupErr = err
+		}
+	case db.LabelDummies:
+		resourceName = "dummy interface"
+		if dummyRecord, err := service.repository.GetDummyInterfaceByName(name); dummyRecord != nil {
+			recordID, namespaceID = dummyRecord.ID, dummyRecord.NsID
+		} else {
+			lookupErr = err
+		}
+	default:
+		return 0, invalidf("resource type %q has no labels", resourceType)
+	}
+	notFound := &NotFoundError{Resource: resourceName, Name: name}
+	if lookupErr != nil || recordID == 0 {
+		return 0, firstError(lookupErr, notFound)
+	}
+
+	// Macvlans, bonds and dummies have no owner column; their namespace decides
+	visible := service.ownedByProject(ownerID)
+	if resourceType == db.LabelMacvlans || resourceType == db.LabelBonds || resourceType == db.LabelDummies {
+		var err error
+		if visible, err = service.namespaceIDInProject(service.repository, namespaceID); err != nil {
+			return 0, err
+		}
+	}
+	if !visible {
+		return 0, notFound
+	}
+	return recordID, nil
+}
+
+// firstError returns the first non-nil error
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateDeleteSelector checks the selector of a bulk delete without deleting anything
+// The selector is required so an empty one cannot delete everything.
+func ValidateDeleteSelector(selectorExpression string) error {
+	if strings.TrimSpace(selectorExpression) == "" {
+		return invalidf("selector is required")
+	}
+	_, err := ParseSelector(selectorExpression)
+	return err
+}
+
+// DeleteBySelector deletes every recorded resource of a type matching a label selector
+// Resources are deleted one by one through the regular delete operation; the
+// first failure stops the deletion. Returns the names (addresses and
+// destinations for addresses and routes) of the deleted resources.
+// Parameters:
+//   - resourceType: label resource type
+//   - selectorExpression: label selector, checked by ValidateDeleteSelector
+func (service *Service) DeleteBySelector(resourceType, selectorExpression string) ([]string, error) {
+	if err := ValidateDeleteSelector(selectorExpression); err != nil {
+		return nil, err
+	}
+	options := ListOptions{Selector: selectorExpression}
+
+	namespaceRecords, err := service.repository.ListNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	namespaceNames := make(map[int64]string, len(namespaceRecords))
+	for _, namespaceRecord := range namespaceRecords {
+		namespaceNames[namespaceRecord.ID] = namespaceRecord.Name
+	}
+	namespaceName := func(namespaceID *int64) string {
+		if namespaceID == nil {
+			return ""
+		}
+		return namespaceNames[*namespaceID]
+	}
+
+	type deletion struct {
+		name   string
+		delete func() error
+	}
+	var deletions []deletion
+
+	switch resourceType {
+	case db.LabelNamespaces:
+		records, _, err := service.ListNamespaces(options)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			deletions = append(deletions, deletion{record.Name, func() error { return service.DeleteNamespace(record.Name) }})
+		}
+	case db.LabelVeths:
+		records, _, err := service.ListVeths("", options)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			deletions = append(deletions, deletion{record.Name, func() error { return service.DeleteVeth(record.Name) }})
+		}
+	case db.LabelAddresses:
+		records, _, err := service.ListAddresses("", options)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			deletions = append(deletions, deletion{record.Address, func() error { return service.DeleteAddressByID(record.ID) }})
+		}
+	case db.LabelRoutes:
+		records, _, err := service.ListRoutes("", options)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			deletions = append(deletions, deletion{record.Destination, func() error { return service.DeleteRouteByID(record.ID) }})
+		}
+	case db.LabelBridges:
+		records, err := service.ListBridges(options)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			deletions = append(deletions, deletion{record.Name, func() error { return service.DeleteBridge(record.Name, namespaceName(record.NsID)) }})
+		}
+	case db.LabelGRETunnels:
+		records, _, err := service.ListGRETunnels("", options)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			deletions = append(deletions, deletion{record.Name, func() error { return service.DeleteGRETunnel(record.Name, namespaceName(record.NsID)) }})
+		}
+	case db.LabelMacvlans:
+		records, err := service.ListMacvlans("", options)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			deletions = append(deletions, deletion{record.Name, func() error { return service.DeleteMacvlan(record.Name, namespaceName(record.NsID)) }})
+		}
+	case db.LabelBonds:
+		records, err := service.ListBonds("", options)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			deletions = append(deletions, deletion{record.Name, func() error { return service.DeleteBond(record.Name, namespaceName(record.NsID)) }})
+		}
+	case db.LabelDummies:
+		records, err := service.ListDummies("", options)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			deletions = append(deletions, deletion{record.Name, func() error { return service.DeleteDummy(record.Name, namespaceName(record.NsID)) }})
+		}
+	default:
+		return nil, invalidf("resource type %q has no labels", resourceType)
+	}
+
+	deletedNames := make([]string, 0, len(deletions))
+	for _, pending := range deletions {
+		if err := pending.delete(); err != nil {
+			return deletedNames, fmt.Errorf("failed to delete %s after deleting %d: %w", pending.name, len(deletedNames), err)
+		}
+		deletedNames = append(deletedNames, pending.name)
+	}
+	return deletedNames, nil
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+)
+
+// SetLinkRequest describes link properties to set on any interface
+// Omitted (nil) properties are left unchanged; an empty alias clears it.
+type SetLinkRequest struct {
+	Interface    string  `json:"interface" validate:"required,ifname"`
+	Namespace    string  `json:"namespace"` // Empty = host
+	MTU          *int    `json:"mtu,omitempty" validate:"omitempty,gt=0"`
+	HardwareAddr *string `json:"mac_address,omitempty" validate:"omitempty,mac"`
+	TxQueueLen   *int    `json:"txqueuelen,omitempty" validate:"omitempty,gte=0"`
+	Alias        *string `json:"alias,omitempty"`
+	State        *string `json:"state,omitempty" validate:"omitempty,oneof=up down"`
+}
+
+// Validate checks the request before touching the kernel
+func (request SetLinkRequest) Validate() error {
+	if err := validateStruct(request); err != nil {
+		return err
+	}
+	if err := request.linkProperties().Validate(); err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+	return nil
+}
+
+// linkProperties converts the request into link properties
+func (request SetLinkRequest) linkProperties() netns.LinkProperties {
+	return netns.LinkProperties{
+		Interface:    request.Interface,
+		Namespace:    request.Namespace,
+		MTU:          request.MTU,
+		HardwareAddr: request.HardwareAddr,
+		TxQueueLen:   request.TxQueueLen,
+		Alias:        request.Alias,
+		State:        request.State,
+	}
+}
+
+// recordLinkProperties merges the properties set by the request into an interface's record
+func (request SetLinkRequest) recordLinkProperties(linkPropertyRecord *db.LinkProperty) {
+	if request.MTU != nil {
+		linkPropertyRecord.MTU = *request.MTU
+	}
+	if request.HardwareAddr != nil {
+		linkPropertyRecord.MACAddress = *request.HardwareAddr
+	}
+	if request.TxQueueLen != nil {
+		linkPropertyRecord.TxQueueLen = *request.TxQueueLen
+	}
+	if request.Alias != nil {
+		linkPropertyRecord.Alias = *request.Alias
+	}
+	if request.State != nil {
+		linkPropertyRecord.State = *request.State
+	}
+}
+
+// SetLinkProperties applies link properties to an interface and records them
+// If recording fails, the previous values are restored.
+func (service *Service) SetLinkProperties(request SetLinkRequest) (*db.LinkProperty, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := service.scopeNamespaces(&request.Namespace); err != nil {
+		return nil, err
+	}
+	linkProperties := request.linkProperties()
+
+	previousProperties, err := service.linkManager.Get(request.Interface, request.Namespace)
+	if err != nil {
+		return nil, &NotFoundError{Resource: "interface", Name: request.Interface}
+	}
+
+	transaction := service.beginTransaction()
+
+	// Apply in system
+	err = transaction.Apply("set link "+request.Interface,
+		func() error { return service.linkManager.Set(linkProperties) },
+		func() error { return service.linkManager.Set(previousProperties.Revert(linkProperties)) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database
+	var linkPropertyRecord *db.LinkProperty
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		namespaceID, err := txRepository.NamespaceIDByName(request.Namespace)
+		if err != nil {
+			return err
+		}
+		recorded, err := txRepository.GetLinkProperties(request.Interface, namespaceID)
+		if err != nil {
+			return err
+		}
+		if recorded == nil {
+			recorded = &db.LinkProperty{InterfaceName: request.Interface, NsID: namespaceID}
+		}
+		request.recordLinkProperties(recorded)
+		linkPropertyRecord, err = txRepository.SetLinkProperties(recorded)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record link properties: %w", err)
+	}
+
+	return linkPropertyRecord, nil
+}
+
+// ListInterfaces returns every interface in one namespace (empty = host), marking managed ones
+func (service *Service) ListInterfaces(namespaceName string) ([]netns.InterfaceInfo, error) {
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return nil, err
+	}
+	interfaceInfoList, err := service.linkManager.List(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.markManaged(interfaceInfoList); err != nil {
+		return nil, err
+	}
+	return interfaceInfoList, nil
+}
+
+// ListAllInterfaces returns every interface on the host and in every namespace, marking managed ones
+// A project only sees the interfaces in its own namespaces.
+func (service *Service) ListAllInterfaces() ([]netns.InterfaceInfo, error) {
+	interfaceInfoList, err := service.linkManager.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	if service.project != nil {
+		namespaceNames, err := service.projectNamespaces()
+		if err != nil {
+			return nil, err
+		}
+		projectNamespaceNames := make(map[string]bool, len(namespaceNames))
+		for _, namespaceName := range namespaceNames {
+			projectNamespaceNames[namespaceName] = true
+		}
+		var projectInterfaces []netns.InterfaceInfo
+		for _, interfaceInfo := range interfaceInfoList {
+			if projectNamespaceNames[interfaceInfo.Namespace] {
+				projectInterfaces = append(projectInterfaces, interfaceInfo)
+			}
+		}
+		interfaceInfoList = projectInterfaces
+	}
+	if err := service.markManaged(interfaceInfoList); err != nil {
+		return nil, err
+	}
+	return interfaceInfoList, nil
+}
+
+// markManaged flags the interfaces that are recorded in the database
+func (service *Service) markManaged(interfaceInfoList []netns.InterfaceInfo) error {
+	managedInterfaces, err := service.repository.ListManagedInterfaces()
+	if err != nil {
+		return err
+	}
+
+	managedKeys := make(map[string]bool)
+	for _, managedInterface := range managedInterfaces {
+		managedKeys[netns.InterfaceKey(managedInterface.Namespace, managedInterface.Name)] = true
+	}
+	netns.MarkManaged(interfaceInfoList, managedKeys)
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/zenith/netns-mgr/internal/db"
+)
+
+// ListOptions narrows, sorts and pages the records returned by list operations
+// Namespaces, veths, addresses, routes and GRE tunnels support every option;
+// the other resources only the label selector.
+type ListOptions struct {
+	Selector      string    `json:"selector,omitempty"`       // Label selector, e.g. "env=lab,team!=net"
+	Interface     string    `json:"interface,omitempty"`      // Only records on this interface
+	Within        string    `json:"within,omitempty"`         // Only records whose addresses lie inside this CIDR
+	CreatedAfter  time.Time `json:"created_after,omitempty"`  // Only records created at or after this time
+	CreatedBefore time.Time `json:"created_before,omitempty"` // Only records created before this time
+	Sort          string    `json:"sort,omitempty"`           // Sort field, "-" prefix for descending
+	Limit         int       `json:"limit,omitempty"`          // Page size (0 = everything)
+	Cursor        string    `json:"cursor,omitempty"`         // Next-page cursor returned with the previous page
+}
+
+// pagedOrFiltered reports whether the options use more than the label selector
+func (options ListOptions) pagedOrFiltered() bool {
+	return options.Interface != "" || options.Within != "" ||
+		!options.CreatedAfter.IsZero() || !options.CreatedBefore.IsZero() ||
+		options.Sort != "" || options.Limit != 0 || options.Cursor != ""
+}
+
+// listQuery converts list options to a repository list query limited to the caller's project
+// Parameters:
+//   - namespaceName: only records in this namespace (empty = all)
+//   - options: list options of the request
+func (service *Service) listQuery(namespaceName string, options ListOptions) (db.ListQuery, error) {
+	namespaceID, err := service.namespaceFilter(namespaceName)
+	if err != nil {
+		return db.ListQuery{}, err
+	}
+	selector, err := ParseSelector(options.Selector)
+	if err != nil {
+		return db.ListQuery{}, err
+	}
+	if options.Limit < 0 || options.Limit > db.MaxListLimit {
+		return db.ListQuery{}, invalidf("limit must be between 1 and %d (0 = no limit)", db.MaxListLimit)
+	}
+	service.scopeNames(&options.Interface)
+
+	query := db.ListQuery{
+		NamespaceID:   namespaceID,
+		ProjectID:     service.projectID(),
+		Interface:     options.Interface,
+		CreatedAfter:  options.CreatedAfter,
+		CreatedBefore: options.CreatedBefore,
+		Labels:        selector.requirements(),
+		Sort:          options.Sort,
+		Limit:         options.Limit,
+		Cursor:        options.Cursor,
+	}
+	if options.Within != "" {
+		if _, query.Within, err = net.ParseCIDR(options.Within); err != nil {
+			return db.ListQuery{}, invalidf("invalid network %q: expected CIDR notation", options.Within)
+		}
+	}
+	return query, nil
+}
+
+// pagedRecords passes on the page of a repository list query, reporting unusable queries as validation errors
+func pagedRecords[Record any](records []Record, nextCursor string, err error) ([]Record, string, error) {
+	var queryErr *db.InvalidQueryError
+	if errors.As(err, &queryErr) {
+		return nil, "", &ValidationError{Message: queryErr.Message}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return records, nextCursor, nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+)
+
+// CreateMacvlanRequest describes a macvlan or ipvlan interface to create
+type CreateMacvlanRequest struct {
+	Name            string            `json:"name" validate:"required,ifname"`
+	Kind            string            `json:"kind" validate:"omitempty,oneof=macvlan ipvlan"` // Defaults to macvlan
+	Mode            string            `json:"mode"`                                           // Defaults to bridge (macvlan) or l2 (ipvlan)
+	Parent          string            `json:"parent" validate:"required,ifname"`
+	ParentNamespace string            `json:"parent_namespace"` // Namespace where the parent exists (empty = host)
+	Namespace       string            `json:"namespace"`        // Namespace to move the link into (empty = host)
+	Labels          map[string]string `json:"labels,omitempty" validate:"dive,keys,labelkey,endkeys,labelvalue"`
+}
+
+// Validate checks the request before touching the kernel
+// The mode must be one the kind supports.
+func (request CreateMacvlanRequest) Validate() error {
+	if err := validateStruct(request); err != nil {
+		return err
+	}
+	var err error
+	if request.Kind == netns.KindIPVlan {
+		_, err = netns.ParseIPVlanMode(request.Mode)
+	} else {
+		_, err = netns.ParseMacvlanMode(request.Mode)
+	}
+	if err != nil {
+		return invalidf("%v", err)
+	}
+	return nil
+}
+
+// withDefaults fills in the kind and the mode as the kernel interprets them
+func (request CreateMacvlanRequest) withDefaults() CreateMacvlanRequest {
+	if request.Kind == "" {
+		request.Kind = netns.KindMacvlan
+	}
+	if request.Mode == "" {
+		request.Mode = netns.DefaultMacvlanMode(request.Kind)
+	}
+	return request
+}
+
+// CreateMacvlan creates a macvlan or ipvlan interface and records it
+func (service *Service) CreateMacvlan(request CreateMacvlanRequest) (*db.MacvlanLink, error) {
+	service.scopeNames(&request.Name)
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := service.scopeNamespaces(&request.ParentNamespace, &request.Namespace); err != nil {
+		return nil, err
+	}
+	request = request.withDefaults()
+
+	linkConfig := netns.MacvlanLink{
+		Name:            request.Name,
+		Kind:            request.Kind,
+		Mode:            request.Mode,
+		Parent:          request.Parent,
+		ParentNamespace: request.ParentNamespace,
+		Namespace:       request.Namespace,
+	}
+
+	transaction := service.beginTransaction()
+
+	// Create in system
+	err := transaction.Apply("create "+request.Kind+" "+request.Name,
+		func() error { return service.macvlanManager.Create(linkConfig) },
+		func() error { return service.macvlanManager.Delete(request.Name, request.Namespace) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database
+	var linkRecord *db.MacvlanLink
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		namespaceID, err := txRepository.NamespaceIDByName(request.Namespace)
+		if err != nil {
+			return err
+		}
+		parentNamespaceID, err := txRepository.NamespaceIDByName(request.ParentNamespace)
+		if err != nil {
+			return err
+		}
+		linkRecord, err = txRepository.CreateMacvlanLink(request.Name, request.Kind, request.Mode, request.Parent, parentNamespaceID, namespaceID)
+		if err != nil {
+			return err
+		}
+		linkRecord.Labels = request.Labels
+		return txRepository.SetLabels(db.LabelMacvlans, linkRecord.ID, request.Labels)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record %s: %w", request.Kind, err)
+	}
+
+	return linkRecord, nil
+}
+
+// DeleteMacvlan deletes a macvlan or ipvlan interface and its record
+// Parameters:
+//   - linkName: interface to delete
+//   - namespaceName: namespace of the interface (empty = host)
+func (service *Service) DeleteMacvlan(linkName, namespaceName string) error {
+	service.scopeNames(&linkName)
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return err
+	}
+
+	// Remove the record, then the link; the record is needed to recreate it
+	transaction := service.beginTransaction()
+	return transaction.Commit(func(txRepository *db.Repository) error {
+		linkRecord, err := txRepository.GetMacvlanLinkByName(linkName)
+		if err != nil {
+			return err
+		}
+		visible := linkRecord != nil
+		if visible {
+			if visible, err = service.namespaceIDInProject(txRepository, linkRecord.NsID); err != nil {
+				return err
+			}
+		}
+		if service.project != nil && !visible {
+			return &NotFoundError{Resource: "link", Name: linkName}
+		}
+
+		var recreate func() error
+		if linkRecord != nil {
+			parentNamespaceName, err := txRepository.NamespaceNameByID(linkRecord.ParentNsID)
+			if err != nil {
+				return err
+			}
+			if err := txRepository.DeleteMacvlanLink(linkName); err != nil {
+				return err
+			}
+			recreate = func() error {
+				return service.macvlanManager.Create(netns.MacvlanLink{
+					Name:            linkRecord.Name,
+					Kind:            linkRecord.Kind,
+					Mode:            linkRecord.Mode,
+					Parent:          linkRecord.Parent,
+					ParentNamespace: parentNamespaceName,
+					Namespace:       namespaceName,
+				})
+			}
+		}
+
+		return transaction.Apply("delete link "+linkName,
+			func() error { return service.macvlanManager.Delete(linkName, namespaceName) },
+			recreate,
+		)
+	})
+}
+
+// ListMacvlans returns the recorded macvlan and ipvlan interfaces
+// Parameters:
+//   - kind: only return this kind ("macvlan" or "ipvlan"; empty = both)
+//   - options: label selector the links must match
+func (service *Service) ListMacvlans(kind string, options ListOptions) ([]db.MacvlanLink, error) {
+	linkRecords, err := service.repository.ListMacvlanLinks(kind)
+	if err != nil {
+		return nil, err
+	}
+	linkRecords, err = filterInProjectNamespaces(service, linkRecords, func(linkRecord db.MacvlanLink) *int64 { return linkRecord.NsID })
+	if err != nil {
+		return nil, err
+	}
+	return labelRecords(service, db.LabelMacvlans, linkRecords, options, func(linkRecord *db.MacvlanLink) (int64, *map[string]string) {
+		return linkRecord.ID, &linkRecord.Labels
+	})
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/txn"
+)
+
+// Namespace statuses reported by NamespaceStatuses
+const (
+	NamespaceStatusActive    = "active"    // Exists and is recorded
+	NamespaceStatusUntracked = "untracked" // Exists but is not recorded
+	NamespaceStatusOrphaned  = "orphaned"  // Recorded but no longer exists
+)
+
+// CreateNamespaceRequest describes a namespace to create
+type CreateNamespaceRequest struct {
+	Name   string            `json:"name" validate:"required,nsname"`
+	Labels map[string]string `json:"labels,omitempty" validate:"dive,keys,labelkey,endkeys,labelvalue"`
+}
+
+// Validate checks the request before touching the kernel
+func (request CreateNamespaceRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// UpdateNamespaceRequest gives the new metadata of a namespace
+type UpdateNamespaceRequest struct {
+	Labels map[string]string `json:"labels" validate:"dive,keys,labelkey,endkeys,labelvalue"` // Replaces all labels (empty = remove all)
+}
+
+// Validate checks the request before touching the database
+func (request UpdateNamespaceRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// NamespaceStatus describes a namespace found in the kernel, the database or both
+type NamespaceStatus struct {
+	Name      string            `json:"name"`
+	Status    string            `json:"status"`
+	CreatedAt *time.Time        `json:"created_at,omitempty"` // Nil for untracked namespaces
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// CreateNamespace creates a namespace and records it
+func (service *Service) CreateNamespace(request CreateNamespaceRequest) (*db.Namespace, error) {
+	service.scopeNames(&request.Name)
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	releaseQuota, err := service.checkQuota(db.QuotaNamespaces, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseQuota()
+
+	transaction := service.beginTransaction()
+
+	// Create in system
+	err = transaction.Apply("create namespace "+request.Name,
+		func() error { return service.namespaceManager.Create(request.Name) },
+		func() error { return service.namespaceManager.Delete(request.Name) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database (the namespace is removed again on failure)
+	var namespaceRecord *db.Namespace
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		var err error
+		namespaceRecord, err = txRepository.CreateNamespace(request.Name, service.projectID())
+		if err != nil {
+			return err
+		}
+		namespaceRecord.Labels = request.Labels
+		return txRepository.SetLabels(db.LabelNamespaces, namespaceRecord.ID, request.Labels)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record namespace: %w", err)
+	}
+
+	return namespaceRecord, nil
+}
+
+// DeleteNamespace deletes a namespace and its record
+// Untracked namespaces are deleted from the kernel only.
+func (service *Service) DeleteNamespace(namespaceName string) error {
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return err
+	}
+
+	// Workloads must not outlive their namespace; their records go with it
+	workloadRecords, err := service.workloadsInNamespace(namespaceName)
+	if err != nil {
+		return err
+	}
+
+	// Running workloads are restarted if the namespace stays. Their undo only
+	// queues them: a restart writes to the database, which the SQL transaction
+	// holds while the namespace delete is undone.
+	var restartWorkloads []db.Workload
+	transaction := service.beginTransaction()
+	for index := range workloadRecords {
+		workloadRecord := workloadRecords[index]
+		var undo func() error
+		if workloadRecord.IsActive() {
+			undo = func() error {
+				restartWorkloads = append(restartWorkloads, workloadRecord)
+				return nil
+			}
+		}
+		err := transaction.Apply("stop workload "+workloadRecord.Name,
+			func() error { return service.stopWorkload(&workloadRecord) },
+			undo,
+		)
+		if err != nil {
+			return service.restartWorkloads(restartWorkloads, fmt.Errorf("failed to stop workload %s: %w", workloadRecord.Name, err))
+		}
+	}
+
+	// Remove the record, then the namespace; the record stays if the kernel refuses
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		namespaceRecord, err := txRepository.GetNamespaceByName(namespaceName)
+		if err != nil {
+			return err
+		}
+		if namespaceRecord != nil {
+			if err := txRepository.DeleteNamespace(namespaceName); err != nil {
+				return err
+			}
+		}
+
+		// A deleted namespace can only come back empty, so its undo always
+		// reports the rollback as incomplete
+		return transaction.Apply("delete namespace "+namespaceName,
+			func() error { return service.namespaceManager.Delete(namespaceName) },
+			func() error {
+				if err := service.namespaceManager.Create(namespaceName); err != nil {
+					return err
+				}
+				return fmt.Errorf("namespace %s was recreated empty: its interfaces, addresses and routes are lost", namespaceName)
+			},
+		)
+	})
+	if err != nil {
+		return service.restartWorkloads(restartWorkloads, err)
+	}
+
+	for _, workloadRecord := range workloadRecords {
+		os.RemoveAll(workloadRecord.LogDir)
+	}
+	return nil
+}
+
+// restartWorkloads restarts the workloads stopped by a namespace delete that failed
+// Workloads that cannot be restarted are reported as an incomplete rollback.
+// Parameters:
+//   - workloadRecords: workloads to restart
+//   - cause: error that failed the delete
+func (service *Service) restartWorkloads(workloadRecords []db.Workload, cause error) error {
+	var failures []error
+	for index := range workloadRecords {
+		if err := service.launchSupervisor(&workloadRecords[index]); err != nil {
+			failures = append(failures, fmt.Errorf("restart workload %s: %w (it stays stopped)", workloadRecords[index].Name, err))
+		}
+	}
+	if len(failures) == 0 {
+		return cause
+	}
+
+	var rollbackError *txn.RollbackError
+	if errors.As(cause, &rollbackError) {
+		rollbackError.Failed = append(rollbackError.Failed, failures...)
+		return cause
+	}
+	return &txn.RollbackError{Err: cause, Failed: failures}
+}
+
+// UpdateNamespace replaces the metadata (labels) of a recorded namespace
+// Unlike a label patch, labels missing from the request are removed.
+func (service *Service) UpdateNamespace(namespaceName string, request UpdateNamespaceRequest) (*db.NamespaceWithDetails, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	namespaceRecord, err := service.GetNamespace(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := service.beginTransaction()
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		return txRepository.ReplaceLabels(db.LabelNamespaces, namespaceRecord.ID, request.Labels)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record namespace: %w", err)
+	}
+
+	namespaceRecord.Labels = request.Labels
+	if len(namespaceRecord.Labels) == 0 {
+		namespaceRecord.Labels = nil
+	}
+	return namespaceRecord, nil
+}
+
+// ListNamespaces returns one page of the recorded namespaces matching the list options
+// Returns the cursor of the next page (empty = last page).
+func (service *Service) ListNamespaces(options ListOptions) ([]db.Namespace, string, error) {
+	query, err := service.listQuery("", options)
+	if err != nil {
+		return nil, "", err
+	}
+	return pagedRecords(service.repository.QueryNamespaces(query))
+}
+
+// GetNamespace returns a recorded namespace with the resources recorded in it
+func (service *Service) GetNamespace(namespaceName string) (*db.NamespaceWithDetails, error) {
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return nil, err
+	}
+
+	details, err := service.repository.GetNamespaceDetails(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	if details == nil {
+		return nil, &NotFoundError{Resource: "namespace", Name: namespaceName}
+	}
+	if details.Labels, err = service.repository.GetLabels(db.LabelNamespaces, details.ID); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// NamespaceStatuses compares the namespaces in the kernel with the recorded ones
+// Kernel namespaces come first, followed by orphaned records. A project only
+// sees its own namespaces, so nothing is untracked for it.
+func (service *Service) NamespaceStatuses() ([]NamespaceStatus, error) {
+	systemNamespaces, err := service.namespaceManager.List()
+	if err != nil {
+		return nil, err
+	}
+	namespaceRecords, _, err := service.ListNamespaces(ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	recordsByName := make(map[string]db.Namespace, len(namespaceRecords))
+	for _, namespaceRecord := range namespaceRecords {
+		recordsByName[namespaceRecord.Name] = namespaceRecord
+	}
+
+	statuses := make([]NamespaceStatus, 0, len(systemNamespaces)+len(namespaceRecords))
+	inSystem := make(map[string]bool, len(systemNamespaces))
+	for _, namespaceName := range systemNamespaces {
+		inSystem[namespaceName] = true
+
+		status := NamespaceStatus{Name: namespaceName, Status: NamespaceStatusUntracked}
+		if namespaceRecord, recorded := recordsByName[namespaceName]; recorded {
+			status.Status = NamespaceStatusActive
+			createdAt := namespaceRecord.CreatedAt
+			status.CreatedAt = &createdAt
+			status.Labels = namespaceRecord.Labels
+		} else if service.project != nil {
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, namespaceRecord := range namespaceRecords {
+		if inSystem[namespaceRecord.Name] {
+			continue
+		}
+		createdAt := namespaceRecord.CreatedAt
+		statuses = append(statuses, NamespaceStatus{
+			Name:      namespaceRecord.Name,
+			Status:    NamespaceStatusOrphaned,
+			CreatedAt: &createdAt,
+			Labels:    namespaceRecord.Labels,
+		})
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// processStartTime returns when a process started, in clock ticks since boot
+// Field 22 of /proc/<pid>/stat; it never changes for a process, so it tells a
+// process apart from a later one that reused its PID. A zombie has exited
+// already and is reported as gone.
+func processStartTime(processID int) (uint64, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", processID))
+	if err != nil {
+		return 0, err
+	}
+	// The command name in field 2 may contain spaces and parentheses, so
+	// the fields are counted from the last ')'
+	commandEnd := bytes.LastIndexByte(stat, ')')
+	if commandEnd < 0 {
+		return 0, fmt.Errorf("malformed /proc/%d/stat", processID)
+	}
+	fields := bytes.Fields(stat[commandEnd+1:])
+	const startTimeField = 22 - 3 // Fields after the name start at field 3
+	if len(fields) <= startTimeField {
+		return 0, fmt.Errorf("malformed /proc/%d/stat", processID)
+	}
+	if string(fields[0]) == "Z" {
+		return 0, fmt.Errorf("process %d has exited", processID)
+	}
+	return strconv.ParseUint(string(fields[startTimeField]), 10, 64)
+}
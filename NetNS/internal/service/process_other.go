@@ -0,0 +1,10 @@
+//go:build !linux
+
+package service
+
+import "errors"
+
+// processStartTime is unavailable without /proc, so no process is ever verified
+func processStartTime(processID int) (uint64, error) {
+	return 0, errors.New("process start times are only available on Linux")
+}
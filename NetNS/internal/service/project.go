@@ -0,0 +1,255 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/zenith/netns-mgr/internal/db"
+)
+
+// defaultPrefixLength is the length of a prefix derived from a project name
+const defaultPrefixLength = 4
+
+// CreateProjectRequest describes a project to create
+type CreateProjectRequest struct {
+	Name   string `json:"name" validate:"required,max=64"`
+	Prefix string `json:"prefix" validate:"omitempty,alphanum,lowercase,max=6"` // Defaults to the start of the name
+}
+
+// Validate checks the request before creating the project
+func (request CreateProjectRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// withDefaults derives the prefix from the name when none is given
+func (request CreateProjectRequest) withDefaults() CreateProjectRequest {
+	if request.Prefix != "" {
+		return request
+	}
+	var prefix strings.Builder
+	for _, character := range strings.ToLower(request.Name) {
+		if prefix.Len() == defaultPrefixLength {
+			break
+		}
+		if character < unicode.MaxASCII && (unicode.IsLetter(character) || unicode.IsDigit(character)) {
+			prefix.WriteRune(character)
+		}
+	}
+	request.Prefix = prefix.String()
+	return request
+}
+
+// CreateProject creates a project
+func (service *Service) CreateProject(request CreateProjectRequest) (*db.Project, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	request = request.withDefaults()
+	if request.Prefix == "" {
+		return nil, invalidf("cannot derive a prefix from project name %q; set prefix", request.Name)
+	}
+
+	existingProject, err := service.repository.GetProjectByName(request.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existingProject != nil {
+		return nil, invalidf("project %q already exists", request.Name)
+	}
+	prefixOwner, err := service.repository.GetProjectByPrefix(request.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	if prefixOwner != nil {
+		return nil, invalidf("prefix %q is already used by project %q", request.Prefix, prefixOwner.Name)
+	}
+
+	return service.repository.CreateProject(request.Name, request.Prefix)
+}
+
+// ListProjects returns all projects
+func (service *Service) ListProjects() ([]db.Project, error) {
+	return service.repository.ListProjects()
+}
+
+// DeleteProject deletes a project and the API tokens scoped to it
+// A project that still owns namespaces cannot be deleted.
+func (service *Service) DeleteProject(projectName string) error {
+	project, err := service.repository.GetProjectByName(projectName)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return &NotFoundError{Resource: "project", Name: projectName}
+	}
+
+	namespaceRecords, err := service.repository.ListNamespaces()
+	if err != nil {
+		return err
+	}
+	for _, namespaceRecord := range namespaceRecords {
+		if namespaceRecord.ProjectID != nil && *namespaceRecord.ProjectID == project.ID {
+			return invalidf("project %q still owns namespace %q; delete its namespaces first", projectName, namespaceRecord.Name)
+		}
+	}
+	return service.repository.DeleteProject(projectName)
+}
+
+// ForProject returns a copy of the service that acts on behalf of a project
+// The copy prefixes the names of the kernel objects it creates with the
+// project prefix, only touches namespaces owned by the project and only lists
+// the project's resources. A nil project returns an unrestricted copy.
+func (service *Service) ForProject(project *db.Project) *Service {
+	scopedService := *service
+	scopedService.project = project
+	return &scopedService
+}
+
+// Project returns the project the service acts for (nil = unrestricted)
+func (service *Service) Project() *db.Project {
+	return service.project
+}
+
+// projectID returns the ID recorded as owner of new resources (nil = none)
+func (service *Service) projectID() *int64 {
+	if service.project == nil {
+		return nil
+	}
+	return &service.project.ID
+}
+
+// ownedByProject reports whether a record with the given owner is visible to the service
+func (service *Service) ownedByProject(ownerID *int64) bool {
+	return service.project == nil || (ownerID != nil && *ownerID == service.project.ID)
+}
+
+// scopedName prefixes a kernel object name with "<prefix>-" unless it already is
+// Names are returned unchanged by an unrestricted service.
+func (service *Service) scopedName(name string) string {
+	if service.project == nil || name == "" {
+		return name
+	}
+	prefix := service.project.Prefix + "-"
+	if strings.HasPrefix(name, prefix) {
+		return name
+	}
+	return prefix + name
+}
+
+// scopeNames applies scopedName to the names of objects being created or looked up
+func (service *Service) scopeNames(names ...*string) {
+	for _, name := range names {
+		*name = service.scopedName(*name)
+	}
+}
+
+// scopeNamespaces prefixes namespace names and checks that the project owns them
+// Projects cannot use the host namespace; namespaces of other projects are
+// reported as not found.
+func (service *Service) scopeNamespaces(namespaceNames ...*string) error {
+	if service.project == nil {
+		return nil
+	}
+	for _, namespaceName := range namespaceNames {
+		if *namespaceName == "" {
+			return &ForbiddenError{Message: "project " + service.project.Name + " cannot use the host namespace"}
+		}
+		*namespaceName = service.scopedName(*namespaceName)
+
+		namespaceRecord, err := service.repository.GetNamespaceByName(*namespaceName)
+		if err != nil {
+			return err
+		}
+		if namespaceRecord == nil || !service.ownedByProject(namespaceRecord.ProjectID) {
+			return &NotFoundError{Resource: "namespace", Name: *namespaceName}
+		}
+	}
+	return nil
+}
+
+// projectNamespaces returns the IDs and names of the namespaces owned by the project
+// Returns a nil map for an unrestricted service.
+func (service *Service) projectNamespaces() (map[int64]string, error) {
+	if service.project == nil {
+		return nil, nil
+	}
+	namespaceRecords, err := service.repository.ListNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	namespaceNames := make(map[int64]string)
+	for _, namespaceRecord := range namespaceRecords {
+		if service.ownedByProject(namespaceRecord.ProjectID) {
+			namespaceNames[namespaceRecord.ID] = namespaceRecord.Name
+		}
+	}
+	return namespaceNames, nil
+}
+
+// inProjectNamespace reports whether a namespace ID is in the result of projectNamespaces
+// A nil map (unrestricted service) contains everything.
+func inProjectNamespace(namespaceNames map[int64]string, namespaceID *int64) bool {
+	if namespaceNames == nil {
+		return true
+	}
+	if namespaceID == nil {
+		return false
+	}
+	_, owned := namespaceNames[*namespaceID]
+	return owned
+}
+
+// filterOwned keeps the records visible to the service
+// Parameters:
+//   - records: records to filter
+//   - ownerID: returns the owning project of a record
+func filterOwned[Record any](service *Service, records []Record, ownerID func(Record) *int64) []Record {
+	if service.project == nil {
+		return records
+	}
+	var ownedRecords []Record
+	for _, record := range records {
+		if service.ownedByProject(ownerID(record)) {
+			ownedRecords = append(ownedRecords, record)
+		}
+	}
+	return ownedRecords
+}
+
+// filterInProjectNamespaces keeps the records located in the project's namespaces
+// Used for resources without an owner column of their own.
+// Parameters:
+//   - records: records to filter
+//   - namespaceID: returns the namespace of a record
+func filterInProjectNamespaces[Record any](service *Service, records []Record, namespaceID func(Record) *int64) ([]Record, error) {
+	namespaceNames, err := service.projectNamespaces()
+	if err != nil || namespaceNames == nil {
+		return records, err
+	}
+	var visibleRecords []Record
+	for _, record := range records {
+		if inProjectNamespace(namespaceNames, namespaceID(record)) {
+			visibleRecords = append(visibleRecords, record)
+		}
+	}
+	return visibleRecords, nil
+}
+
+// namespaceIDInProject reports whether a namespace ID belongs to the caller's project
+// Always true for an unrestricted service.
+// Parameters:
+//   - repository: repository to look the namespace up in (may be bound to a transaction)
+//   - namespaceID: namespace to check (nil = host)
+func (service *Service) namespaceIDInProject(repository *db.Repository, namespaceID *int64) (bool, error) {
+	if service.project == nil {
+		return true, nil
+	}
+	if namespaceID == nil {
+		return false, nil
+	}
+	namespaceRecord, err := repository.GetNamespace(*namespaceID)
+	if err != nil || namespaceRecord == nil {
+		return false, err
+	}
+	return service.ownedByProject(namespaceRecord.ProjectID), nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/zenith/netns-mgr/internal/db"
+)
+
+// QuotaUsage reports how much of one quota resource a project uses
+type QuotaUsage struct {
+	Resource string `json:"resource"`
+	Used     int    `json:"used"`
+	Limit    *int   `json:"limit"` // Nil = unlimited
+}
+
+// ProjectQuotaUsage reports the quota usage of one project
+type ProjectQuotaUsage struct {
+	Project string       `json:"project"`
+	Quotas  []QuotaUsage `json:"quotas"`
+}
+
+// SetQuotaRequest sets or removes the quota of a project for one resource
+type SetQuotaRequest struct {
+	Project  string `json:"project" validate:"required"`
+	Resource string `json:"resource" validate:"required,oneof=namespaces veths bridges gre_tunnels addresses"`
+	Limit    *int   `json:"limit" validate:"omitempty,min=0"` // Nil removes the quota
+}
+
+// Validate checks the request before changing the quota
+func (request SetQuotaRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// SetQuota sets or removes the quota of a project for one resource
+// Lowering a quota below the current usage is allowed; it only blocks new resources.
+func (service *Service) SetQuota(request SetQuotaRequest) error {
+	if err := request.Validate(); err != nil {
+		return err
+	}
+
+	project, err := service.repository.GetProjectByName(request.Project)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return &NotFoundError{Resource: "project", Name: request.Project}
+	}
+
+	if request.Limit == nil {
+		if err := service.repository.DeleteProjectQuota(project.ID, request.Resource); err != nil {
+			return &NotFoundError{Resource: "quota", Name: request.Project + "/" + request.Resource}
+		}
+		return nil
+	}
+	return service.repository.SetProjectQuota(project.ID, request.Resource, *request.Limit)
+}
+
+// QuotaUsages returns the quotas and current usage of projects
+// A project only sees its own usage; an unrestricted service reports every
+// project, or the one named by projectName.
+func (service *Service) QuotaUsages(projectName string) ([]ProjectQuotaUsage, error) {
+	var projects []db.Project
+	switch {
+	case service.project != nil:
+		projects = []db.Project{*service.project}
+	case projectName != "":
+		project, err := service.repository.GetProjectByName(projectName)
+		if err != nil {
+			return nil, err
+		}
+		if project == nil {
+			return nil, &NotFoundError{Resource: "project", Name: projectName}
+		}
+		projects = []db.Project{*project}
+	default:
+		var err error
+		projects, err = service.repository.ListProjects()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	usages := make([]ProjectQuotaUsage, 0, len(projects))
+	for _, project := range projects {
+		quotas, err := service.projectQuotaUsage(project.ID)
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, ProjectQuotaUsage{Project: project.Name, Quotas: quotas})
+	}
+	return usages, nil
+}
+
+// projectQuotaUsage returns the usage of every quota resource of a project
+func (service *Service) projectQuotaUsage(projectID int64) ([]QuotaUsage, error) {
+	quotas, err := service.repository.ListProjectQuotas(projectID)
+	if err != nil {
+		return nil, err
+	}
+	limits := make(map[string]int, len(quotas))
+	for _, quota := range quotas {
+		limits[quota.Resource] = quota.Limit
+	}
+
+	usages := make([]QuotaUsage, 0, len(db.QuotaResources))
+	for _, resource := range db.QuotaResources {
+		used, err := service.repository.CountProjectResources(projectID, resource)
+		if err != nil {
+			return nil, err
+		}
+		usage := QuotaUsage{Resource: resource, Used: used}
+		if limit, limited := limits[resource]; limited {
+			usage.Limit = &limit
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+// quotaLocks serializes the quota-checked creates of each project
+// Without it two concurrent creates could both pass the check before either
+// is recorded and together exceed the quota.
+type quotaLocks struct {
+	mutex    sync.Mutex
+	projects map[int64]*sync.Mutex
+}
+
+// lock blocks until no other quota-checked create of the project is running
+// Returns the function that unlocks it.
+func (locks *quotaLocks) lock(projectID int64) func() {
+	locks.mutex.Lock()
+	projectMutex, exists := locks.projects[projectID]
+	if !exists {
+		projectMutex = &sync.Mutex{}
+		locks.projects[projectID] = projectMutex
+	}
+	locks.mutex.Unlock()
+
+	projectMutex.Lock()
+	return projectMutex.Unlock
+}
+
+// checkQuota rejects creating resources that would exceed the project's quota
+// Called before any kernel change; always passes for an unrestricted service.
+// The project stays locked against other quota-checked creates until the
+// returned release function is called, which callers defer so the new records
+// are counted by the next check.
+// Parameters:
+//   - resource: one of db.QuotaResources
+//   - additional: number of records the operation is about to create
+func (service *Service) checkQuota(resource string, additional int) (release func(), err error) {
+	if service.project == nil || additional <= 0 {
+		return func() {}, nil
+	}
+
+	release = service.quotaLocks.lock(service.project.ID)
+	if err := service.quotaExceeded(resource, additional); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// quotaExceeded returns a ForbiddenError if the project's quota leaves no room for the new records
+// Parameters:
+//   - resource: one of db.QuotaResources
+//   - additional: number of records the operation is about to create
+func (service *Service) quotaExceeded(resource string, additional int) error {
+	quotas, err := service.repository.ListProjectQuotas(service.project.ID)
+	if err != nil {
+		return err
+	}
+	for _, quota := range quotas {
+		if quota.Resource != resource {
+			continue
+		}
+		used, err := service.repository.CountProjectResources(service.project.ID, resource)
+		if err != nil {
+			return err
+		}
+		if used+additional > quota.Limit {
+			return &ForbiddenError{Message: fmt.Sprintf(
+				"quota exceeded: project %s uses %d of %d %s", service.project.Name, used, quota.Limit, resource,
+			)}
+		}
+	}
+	return nil
+}
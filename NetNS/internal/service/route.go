@@ -0,0 +1,238 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+)
+
+// AddRouteRequest describes a route to add
+type AddRouteRequest struct {
+	Destination string            `json:"destination" validate:"required,cidr|eq=default"` // CIDR notation or "default"
+	Gateway     string            `json:"gateway" validate:"omitempty,ip"`
+	Interface   string            `json:"interface" validate:"omitempty,ifname"`
+	Namespace   string            `json:"namespace"` // Empty = host
+	Labels      map[string]string `json:"labels,omitempty" validate:"dive,keys,labelkey,endkeys,labelvalue"`
+}
+
+// Validate checks the request before touching the kernel
+func (request AddRouteRequest) Validate() error {
+	if err := validateStruct(request); err != nil {
+		return err
+	}
+	if request.Gateway == "" && request.Interface == "" {
+		return invalidf("either gateway or interface is required")
+	}
+	return nil
+}
+
+// ReplaceRouteRequest gives the new next hop of a route
+type ReplaceRouteRequest struct {
+	Gateway   string `json:"gateway" validate:"omitempty,ip"`
+	Interface string `json:"interface" validate:"omitempty,ifname"`
+}
+
+// Validate checks the request before touching the kernel
+func (request ReplaceRouteRequest) Validate() error {
+	if err := validateStruct(request); err != nil {
+		return err
+	}
+	if request.Gateway == "" && request.Interface == "" {
+		return invalidf("either gateway or interface is required")
+	}
+	return nil
+}
+
+// AddRoute adds a route and records it
+func (service *Service) AddRoute(request AddRouteRequest) (*db.Route, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := service.scopeNamespaces(&request.Namespace); err != nil {
+		return nil, err
+	}
+
+	transaction := service.beginTransaction()
+
+	// Add to system
+	err := transaction.Apply("add route "+request.Destination,
+		func() error {
+			return service.routeManager.Add(request.Destination, request.Gateway, request.Interface, request.Namespace)
+		},
+		func() error { return service.routeManager.Delete(request.Destination, request.Namespace) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database
+	var routeRecord *db.Route
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		namespaceID, err := txRepository.NamespaceIDByName(request.Namespace)
+		if err != nil {
+			return err
+		}
+		routeRecord, err = txRepository.CreateRoute(namespaceID, request.Destination, request.Gateway, request.Interface, service.projectID())
+		if err != nil {
+			return err
+		}
+		routeRecord.Labels = request.Labels
+		return txRepository.SetLabels(db.LabelRoutes, routeRecord.ID, request.Labels)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record route: %w", err)
+	}
+
+	return routeRecord, nil
+}
+
+// DeleteRoute deletes a route along with any matching records
+// Parameters:
+//   - destination: route destination (CIDR or "default")
+//   - namespaceName: namespace of the route (empty = host)
+func (service *Service) DeleteRoute(destination, namespaceName string) error {
+	if err := requireField("destination", destination); err != nil {
+		return err
+	}
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return err
+	}
+
+	// Remove the records (if any), then the route; the record is needed to re-add it
+	transaction := service.beginTransaction()
+	return transaction.Commit(func(txRepository *db.Repository) error {
+		namespaceID, err := txRepository.NamespaceIDByName(namespaceName)
+		if err != nil {
+			return err
+		}
+
+		var readd func() error
+
+		// An unrecorded namespace has no route records
+		if namespaceName == "" || namespaceID != nil {
+			routeRecords, err := txRepository.ListRoutes(namespaceID)
+			if err != nil {
+				return err
+			}
+			for _, routeRecord := range routeRecords {
+				if routeRecord.Destination != destination || !sameNamespaceID(routeRecord.NsID, namespaceID) {
+					continue
+				}
+				if err := txRepository.DeleteRoute(routeRecord.ID); err != nil {
+					return err
+				}
+				deletedRoute := routeRecord
+				readd = func() error {
+					return service.routeManager.Add(deletedRoute.Destination, deletedRoute.Gateway, deletedRoute.InterfaceName, namespaceName)
+				}
+			}
+		}
+
+		return transaction.Apply("delete route "+destination,
+			func() error { return service.routeManager.Delete(destination, namespaceName) },
+			readd,
+		)
+	})
+}
+
+// ReplaceRoute changes the gateway and interface of a recorded route
+// The kernel replaces the route in place, so traffic to the destination is never unrouted.
+// Parameters:
+//   - id: route record ID
+//   - request: new next hop; both fields replace the recorded ones
+func (service *Service) ReplaceRoute(id int64, request ReplaceRouteRequest) (*db.Route, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	routeRecord, err := service.GetRoute(id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Gateway != "" && routeRecord.Destination != "default" && isIPv4(routeRecord.Destination) != isIPv4(request.Gateway) {
+		return nil, invalidf("gateway %s is not of the address family of %s", request.Gateway, routeRecord.Destination)
+	}
+	namespaceName, err := service.repository.NamespaceNameByID(routeRecord.NsID)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := service.beginTransaction()
+
+	// Replace in system
+	err = transaction.Apply("replace route "+routeRecord.Destination,
+		func() error {
+			return service.routeManager.Replace(routeRecord.Destination, request.Gateway, request.Interface, namespaceName)
+		},
+		func() error {
+			return service.routeManager.Replace(routeRecord.Destination, routeRecord.Gateway, routeRecord.InterfaceName, namespaceName)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		return txRepository.UpdateRoute(routeRecord.ID, request.Gateway, request.Interface)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record route: %w", err)
+	}
+
+	routeRecord.Gateway = request.Gateway
+	routeRecord.InterfaceName = request.Interface
+	return routeRecord, nil
+}
+
+// DeleteRouteByID deletes a recorded route
+func (service *Service) DeleteRouteByID(id int64) error {
+	routeRecord, err := service.repository.GetRoute(id)
+	if err != nil {
+		return err
+	}
+	if routeRecord == nil || !service.ownedByProject(routeRecord.ProjectID) {
+		return &NotFoundError{Resource: "route", Name: strconv.FormatInt(id, 10)}
+	}
+
+	namespaceName, err := service.repository.NamespaceNameByID(routeRecord.NsID)
+	if err != nil {
+		return err
+	}
+
+	return service.DeleteRoute(routeRecord.Destination, namespaceName)
+}
+
+// GetRoute returns a recorded route with its labels
+func (service *Service) GetRoute(id int64) (*db.Route, error) {
+	routeRecord, err := service.repository.GetRoute(id)
+	if err != nil {
+		return nil, err
+	}
+	if routeRecord == nil || !service.ownedByProject(routeRecord.ProjectID) {
+		return nil, &NotFoundError{Resource: "route", Name: strconv.FormatInt(id, 10)}
+	}
+	if routeRecord.Labels, err = service.repository.GetLabels(db.LabelRoutes, routeRecord.ID); err != nil {
+		return nil, err
+	}
+	return routeRecord, nil
+}
+
+// ListRoutes returns one page of the recorded routes matching the list options, optionally in one namespace
+// Returns the cursor of the next page (empty = last page).
+func (service *Service) ListRoutes(namespaceName string, options ListOptions) ([]db.Route, string, error) {
+	query, err := service.listQuery(namespaceName, options)
+	if err != nil {
+		return nil, "", err
+	}
+	return pagedRecords(service.repository.QueryRoutes(query))
+}
+
+// RouteInfos returns the routes currently configured in a namespace (empty = host)
+func (service *Service) RouteInfos(namespaceName string) ([]netns.RouteInfo, error) {
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return nil, err
+	}
+	return service.routeManager.GetRouteInfos(namespaceName)
+}
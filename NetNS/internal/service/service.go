@@ -0,0 +1,167 @@
+// Package service holds the operations shared by the CLI and the REST API.
+//
+// Each operation validates a typed request, applies the kernel change and
+// records it in the database through a txn.Transaction, so both front ends
+// behave, fail and persist identically.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+	"github.com/zenith/netns-mgr/internal/txn"
+)
+
+// maxInterfaceNameLength is the kernel limit for interface names (IFNAMSIZ - 1)
+const maxInterfaceNameLength = 15
+
+// Service performs namespace and interface operations against the kernel and database
+type Service struct {
+	repository       *db.Repository
+	namespaceManager *netns.Manager
+	vethManager      *netns.VethManager
+	addressManager   *netns.AddressManager
+	routeManager     *netns.RouteManager
+	bridgeManager    *netns.BridgeManager
+	greManager       *netns.GREManager
+	macvlanManager   *netns.MacvlanManager
+	bondManager      *netns.BondManager
+	dummyManager     *netns.DummyManager
+	trafficManager   *netns.TrafficManager
+	linkManager      *netns.LinkManager
+	quotaLocks       *quotaLocks      // Shared by the copies made by ForProject
+	project          *db.Project      // Set by ForProject; nil = unrestricted
+	stepObserver     txn.StepObserver // Set by WithStepObserver; nil = none
+}
+
+// New creates a new service
+// Parameters:
+//   - repository: repository the operations are recorded in
+func New(repository *db.Repository) *Service {
+	namespaceManager := netns.NewManager()
+
+	return &Service{
+		repository:       repository,
+		namespaceManager: namespaceManager,
+		vethManager:      netns.NewVethManager(namespaceManager),
+		addressManager:   netns.NewAddressManager(namespaceManager),
+		routeManager:     netns.NewRouteManager(namespaceManager),
+		bridgeManager:    netns.NewBridgeManager(namespaceManager),
+		greManager:       netns.NewGREManager(namespaceManager),
+		macvlanManager:   netns.NewMacvlanManager(namespaceManager),
+		bondManager:      netns.NewBondManager(namespaceManager),
+		dummyManager:     netns.NewDummyManager(namespaceManager),
+		trafficManager:   netns.NewTrafficManager(namespaceManager),
+		linkManager:      netns.NewLinkManager(namespaceManager),
+		quotaLocks:       &quotaLocks{projects: make(map[int64]*sync.Mutex)},
+	}
+}
+
+// WithStepObserver returns a copy of the service reporting each kernel change to observer
+// Background jobs use it to log their progress and to stop between steps
+// once cancelled.
+func (service *Service) WithStepObserver(observer txn.StepObserver) *Service {
+	observedService := *service
+	observedService.stepObserver = observer
+	return &observedService
+}
+
+// beginTransaction starts a transaction reporting its kernel changes to the step observer
+func (service *Service) beginTransaction() *txn.Transaction {
+	return txn.Begin(service.repository).Observe(service.stepObserver)
+}
+
+// ValidationError reports a request with missing or invalid fields
+type ValidationError struct {
+	Message string
+}
+
+func (validationError *ValidationError) Error() string {
+	return validationError.Message
+}
+
+// NotFoundError reports a resource that does not exist
+type NotFoundError struct {
+	Resource string // Kind of resource, e.g. "namespace"
+	Name     string // Name or ID that was looked up
+}
+
+func (notFoundError *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %q not found", notFoundError.Resource, notFoundError.Name)
+}
+
+// ForbiddenError reports an operation the caller's project may not perform
+type ForbiddenError struct {
+	Message string
+}
+
+func (forbiddenError *ForbiddenError) Error() string {
+	return forbiddenError.Message
+}
+
+// IsValidation reports whether err is (or wraps) a ValidationError
+func IsValidation(err error) bool {
+	var validationError *ValidationError
+	return errors.As(err, &validationError)
+}
+
+// IsNotFound reports whether err is (or wraps) a NotFoundError
+func IsNotFound(err error) bool {
+	var notFoundError *NotFoundError
+	return errors.As(err, &notFoundError)
+}
+
+// IsForbidden reports whether err is (or wraps) a ForbiddenError
+func IsForbidden(err error) bool {
+	var forbiddenError *ForbiddenError
+	return errors.As(err, &forbiddenError)
+}
+
+// invalidf builds a ValidationError from a format string
+func invalidf(format string, args ...any) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// requireField checks that a request field is set
+// Parameters:
+//   - fieldName: field name used in the error message
+//   - value: field value
+func requireField(fieldName, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return invalidf("%s is required", fieldName)
+	}
+	return nil
+}
+
+// namespaceFilter resolves an optional namespace name used to filter listings
+// An empty name means no filter; an unknown name, or one outside the
+// caller's project, is a NotFoundError.
+func (service *Service) namespaceFilter(namespaceName string) (*int64, error) {
+	if namespaceName == "" {
+		return nil, nil
+	}
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return nil, err
+	}
+
+	namespaceID, err := service.repository.NamespaceIDByName(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	if namespaceID == nil {
+		return nil, &NotFoundError{Resource: "namespace", Name: namespaceName}
+	}
+	return namespaceID, nil
+}
+
+// sameNamespaceID compares two optional namespace IDs (nil = host)
+func sameNamespaceID(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"github.com/zenith/netns-mgr/internal/auth"
+	"github.com/zenith/netns-mgr/internal/db"
+)
+
+// CreateTokenRequest describes an API token to create
+type CreateTokenRequest struct {
+	Name    string `json:"name" validate:"required,max=64"`
+	Role    string `json:"role" validate:"required,oneof=viewer operator admin"`
+	Project string `json:"project"` // Confine the token to this project (empty = all projects)
+}
+
+// Validate checks the request before creating the token
+func (request CreateTokenRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// CreateAPIToken creates an API token and records its hash
+// The secret is returned once and cannot be recovered later.
+func (service *Service) CreateAPIToken(request CreateTokenRequest) (*db.APIToken, string, error) {
+	if err := request.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	existingToken, err := service.repository.GetAPITokenByName(request.Name)
+	if err != nil {
+		return nil, "", err
+	}
+	if existingToken != nil {
+		return nil, "", invalidf("API token %q already exists", request.Name)
+	}
+
+	var projectID *int64
+	if request.Project != "" {
+		project, err := service.repository.GetProjectByName(request.Project)
+		if err != nil {
+			return nil, "", err
+		}
+		if project == nil {
+			return nil, "", &NotFoundError{Resource: "project", Name: request.Project}
+		}
+		projectID = &project.ID
+	}
+
+	secret, err := auth.GenerateToken()
+	if err != nil {
+		return nil, "", err
+	}
+	tokenRecord, err := service.repository.CreateAPIToken(request.Name, request.Role, auth.HashToken(secret), projectID)
+	if err != nil {
+		return nil, "", err
+	}
+	return tokenRecord, secret, nil
+}
+
+// ListAPITokens returns all API tokens
+func (service *Service) ListAPITokens() ([]db.APIToken, error) {
+	return service.repository.ListAPITokens()
+}
+
+// RevokeAPIToken deletes an API token so it is no longer accepted
+func (service *Service) RevokeAPIToken(tokenName string) error {
+	tokenRecord, err := service.repository.GetAPITokenByName(tokenName)
+	if err != nil {
+		return err
+	}
+	if tokenRecord == nil {
+		return &NotFoundError{Resource: "API token", Name: tokenName}
+	}
+	return service.repository.DeleteAPIToken(tokenName)
+}
+
+// TokenProject returns the project an API token is confined to (nil = all projects)
+func (service *Service) TokenProject(tokenRecord *db.APIToken) (*db.Project, error) {
+	if tokenRecord.ProjectID == nil {
+		return nil, nil
+	}
+	project, err := service.repository.GetProject(*tokenRecord.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, &NotFoundError{Resource: "project", Name: tokenRecord.Project}
+	}
+	return project, nil
+}
+
+// AuthenticatePrincipal returns the API token named after a verified client certificate
+// An unknown name is a NotFoundError.
+func (service *Service) AuthenticatePrincipal(principalName string) (*db.APIToken, error) {
+	tokenRecord, err := service.repository.GetAPITokenByName(principalName)
+	if err != nil {
+		return nil, err
+	}
+	if tokenRecord == nil {
+		return nil, &NotFoundError{Resource: "API principal", Name: principalName}
+	}
+	if err := service.repository.TouchAPIToken(tokenRecord.ID); err != nil {
+		return nil, err
+	}
+	return tokenRecord, nil
+}
+
+// AuthenticateToken returns the API token matching a secret
+// An unknown secret is a NotFoundError.
+func (service *Service) AuthenticateToken(secret string) (*db.APIToken, error) {
+	tokenRecord, err := service.repository.GetAPITokenByHash(auth.HashToken(secret))
+	if err != nil {
+		return nil, err
+	}
+	if tokenRecord == nil {
+		return nil, &NotFoundError{Resource: "API token", Name: "(redacted)"}
+	}
+	if err := service.repository.TouchAPIToken(tokenRecord.ID); err != nil {
+		return nil, err
+	}
+	return tokenRecord, nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/zenith/netns-mgr/internal/db"
+	"github.com/zenith/netns-mgr/internal/netns"
+)
+
+// SetImpairmentRequest describes impairment to apply to an interface, replacing any existing one
+type SetImpairmentRequest struct {
+	Interface        string  `json:"interface" validate:"required,ifname"`
+	Namespace        string  `json:"namespace"` // Empty = host
+	DelayMs          float64 `json:"delay_ms" validate:"gte=0"`
+	JitterMs         float64 `json:"jitter_ms" validate:"gte=0"`
+	LossPercent      float64 `json:"loss_percent" validate:"gte=0,lte=100"`
+	ReorderPercent   float64 `json:"reorder_percent" validate:"gte=0,lte=100"`
+	DuplicatePercent float64 `json:"duplicate_percent" validate:"gte=0,lte=100"`
+	CorruptPercent   float64 `json:"corrupt_percent" validate:"gte=0,lte=100"`
+	Rate             string  `json:"rate"` // tc-style rate, e.g. "10mbit"
+	Ceil             string  `json:"ceil"` // htb only
+	BurstBytes       uint32  `json:"burst_bytes"`
+	Shaper           string  `json:"shaper" validate:"omitempty,oneof=tbf htb"` // Defaults to tbf
+}
+
+// Validate checks the request before touching the kernel
+func (request SetImpairmentRequest) Validate() error {
+	_, err := request.impairment()
+	return err
+}
+
+// impairment converts the request into a validated impairment
+func (request SetImpairmentRequest) impairment() (netns.Impairment, error) {
+	if err := validateStruct(request); err != nil {
+		return netns.Impairment{}, err
+	}
+
+	rateKbit, err := netns.ParseRate(request.Rate)
+	if err != nil {
+		return netns.Impairment{}, &ValidationError{Message: err.Error()}
+	}
+	ceilKbit, err := netns.ParseRate(request.Ceil)
+	if err != nil {
+		return netns.Impairment{}, &ValidationError{Message: err.Error()}
+	}
+	if ceilKbit > 0 && request.Shaper != netns.ShaperHTB {
+		return netns.Impairment{}, invalidf("ceil requires the htb shaper")
+	}
+
+	// The shaper only matters with a rate limit
+	shaper := ""
+	if rateKbit > 0 {
+		shaper = request.Shaper
+		if shaper == "" {
+			shaper = netns.ShaperTBF
+		}
+	}
+
+	impairment := netns.Impairment{
+		Interface:        request.Interface,
+		Namespace:        request.Namespace,
+		DelayMs:          request.DelayMs,
+		JitterMs:         request.JitterMs,
+		LossPercent:      request.LossPercent,
+		ReorderPercent:   request.ReorderPercent,
+		DuplicatePercent: request.DuplicatePercent,
+		CorruptPercent:   request.CorruptPercent,
+		RateKbit:         rateKbit,
+		CeilKbit:         ceilKbit,
+		BurstBytes:       request.BurstBytes,
+		Shaper:           shaper,
+	}
+	if err := impairment.Validate(); err != nil {
+		return netns.Impairment{}, &ValidationError{Message: err.Error()}
+	}
+	return impairment, nil
+}
+
+// SetImpairment applies impairment to an interface and records it
+// If recording fails, the previously recorded impairment (or none) is restored.
+func (service *Service) SetImpairment(request SetImpairmentRequest) (*db.Qdisc, error) {
+	if err := service.scopeNamespaces(&request.Namespace); err != nil {
+		return nil, err
+	}
+	impairment, err := request.impairment()
+	if err != nil {
+		return nil, err
+	}
+
+	namespaceID, err := service.repository.NamespaceIDByName(request.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	previousRecord, err := service.repository.GetQdiscByInterface(request.Interface, namespaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := service.beginTransaction()
+
+	// Apply in system; undo restores the previously recorded impairment, if any
+	err = transaction.Apply("set impairment on "+request.Interface,
+		func() error { return service.trafficManager.Set(impairment) },
+		func() error {
+			if previousRecord != nil {
+				return service.trafficManager.Set(impairmentFromQdisc(previousRecord, request.Namespace))
+			}
+			return service.trafficManager.Clear(request.Interface, request.Namespace)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database
+	var qdiscRecord *db.Qdisc
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		var err error
+		qdiscRecord, err = txRepository.SetQdisc(qdiscFromImpairment(impairment, namespaceID))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record qdisc: %w", err)
+	}
+
+	return qdiscRecord, nil
+}
+
+// ClearImpairment removes impairment from an interface and its record
+// Parameters:
+//   - interfaceName: interface to clear
+//   - namespaceName: namespace of the interface (empty = host)
+func (service *Service) ClearImpairment(interfaceName, namespaceName string) error {
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return err
+	}
+
+	// Remove the record (if any), then the qdisc; the record is needed to re-apply it
+	transaction := service.beginTransaction()
+	return transaction.Commit(func(txRepository *db.Repository) error {
+		namespaceID, err := txRepository.NamespaceIDByName(namespaceName)
+		if err != nil {
+			return err
+		}
+		qdiscRecord, err := txRepository.GetQdiscByInterface(interfaceName, namespaceID)
+		if err != nil {
+			return err
+		}
+
+		var reapply func() error
+		if qdiscRecord != nil {
+			if err := txRepository.DeleteQdisc(interfaceName, namespaceID); err != nil {
+				return err
+			}
+			reapply = func() error {
+				return service.trafficManager.Set(impairmentFromQdisc(qdiscRecord, namespaceName))
+			}
+		}
+
+		return transaction.Apply("clear impairment on "+interfaceName,
+			func() error { return service.trafficManager.Clear(interfaceName, namespaceName) },
+			reapply,
+		)
+	})
+}
+
+// ShowImpairment returns the impairment currently applied to an interface
+func (service *Service) ShowImpairment(interfaceName, namespaceName string) (*netns.QdiscInfo, error) {
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return nil, err
+	}
+	return service.trafficManager.Show(interfaceName, namespaceName)
+}
+
+// ListImpairments returns the recorded impairments, optionally in one namespace
+func (service *Service) ListImpairments(namespaceName string) ([]db.Qdisc, error) {
+	namespaceID, err := service.namespaceFilter(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	qdiscRecords, err := service.repository.ListQdiscs(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+	return filterInProjectNamespaces(service, qdiscRecords, func(qdiscRecord db.Qdisc) *int64 { return qdiscRecord.NsID })
+}
+
+// qdiscFromImpairment converts an applied impairment into its database record
+func qdiscFromImpairment(impairment netns.Impairment, namespaceID *int64) *db.Qdisc {
+	return &db.Qdisc{
+		InterfaceName:    impairment.Interface,
+		NsID:             namespaceID,
+		Shaper:           impairment.Shaper,
+		DelayMs:          impairment.DelayMs,
+		JitterMs:         impairment.JitterMs,
+		LossPercent:      impairment.LossPercent,
+		ReorderPercent:   impairment.ReorderPercent,
+		DuplicatePercent: impairment.DuplicatePercent,
+		CorruptPercent:   impairment.CorruptPercent,
+		RateKbit:         impairment.RateKbit,
+		CeilKbit:         impairment.CeilKbit,
+		BurstBytes:       impairment.BurstBytes,
+	}
+}
+
+// impairmentFromQdisc converts a database record back into an impairment
+func impairmentFromQdisc(qdiscRecord *db.Qdisc, namespaceName string) netns.Impairment {
+	return netns.Impairment{
+		Interface:        qdiscRecord.InterfaceName,
+		Namespace:        namespaceName,
+		DelayMs:          qdiscRecord.DelayMs,
+		JitterMs:         qdiscRecord.JitterMs,
+		LossPercent:      qdiscRecord.LossPercent,
+		ReorderPercent:   qdiscRecord.ReorderPercent,
+		DuplicatePercent: qdiscRecord.DuplicatePercent,
+		CorruptPercent:   qdiscRecord.CorruptPercent,
+		RateKbit:         qdiscRecord.RateKbit,
+		CeilKbit:         qdiscRecord.CeilKbit,
+		BurstBytes:       qdiscRecord.BurstBytes,
+		Shaper:           qdiscRecord.Shaper,
+	}
+}
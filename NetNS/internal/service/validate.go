@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// requestValidator checks the `validate` struct tags of request types
+// The same tags drive the request schemas in the OpenAPI document.
+var requestValidator = newRequestValidator()
+
+// newRequestValidator creates a validator that reports JSON field names
+// and knows the interface, namespace and label rules
+func newRequestValidator() *validator.Validate {
+	requestValidator := validator.New(validator.WithRequiredStructEnabled())
+
+	requestValidator.RegisterTagNameFunc(func(field reflect.StructField) string {
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "" || jsonName == "-" {
+			return field.Name
+		}
+		return jsonName
+	})
+	requestValidator.RegisterValidation("ifname", func(fieldLevel validator.FieldLevel) bool {
+		interfaceName := fieldLevel.Field().String()
+		return len(interfaceName) <= maxInterfaceNameLength && !strings.ContainsAny(interfaceName, "/ \t\n")
+	})
+	requestValidator.RegisterValidation("nsname", func(fieldLevel validator.FieldLevel) bool {
+		namespaceName := fieldLevel.Field().String()
+		return !strings.ContainsAny(namespaceName, "/ \t\n") && namespaceName != "." && namespaceName != ".."
+	})
+
+	requestValidator.RegisterValidation("labelkey", func(fieldLevel validator.FieldLevel) bool {
+		return labelKeyPattern.MatchString(fieldLevel.Field().String())
+	})
+	requestValidator.RegisterValidation("labelvalue", func(fieldLevel validator.FieldLevel) bool {
+		return labelValuePattern.MatchString(fieldLevel.Field().String())
+	})
+
+	return requestValidator
+}
+
+// validateStruct checks a request against its `validate` tags
+// The first failing field is reported as a ValidationError.
+func validateStruct(request any) error {
+	err := requestValidator.Struct(request)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrors validator.ValidationErrors
+	if errors.As(err, &fieldErrors) && len(fieldErrors) > 0 {
+		return &ValidationError{Message: fieldErrorMessage(fieldErrors[0])}
+	}
+	return err
+}
+
+// fieldErrorMessage describes a failed field check in API terms
+func fieldErrorMessage(fieldError validator.FieldError) string {
+	fieldName := fieldError.Field()
+	// Slice elements report "addresses[0]"; name the slice instead
+	if bracketIndex := strings.Index(fieldName, "["); bracketIndex > 0 {
+		fieldName = fieldName[:bracketIndex]
+	}
+	value := fmt.Sprint(fieldError.Value())
+
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fieldName)
+	case "ifname":
+		return fmt.Sprintf("%s %q must be at most %d characters without '/' or whitespace", fieldName, value, maxInterfaceNameLength)
+	case "nsname":
+		return fmt.Sprintf("invalid namespace name %q", value)
+	case "labelkey":
+		return fmt.Sprintf("label key %q must be 1-63 letters, digits, '.', '_', '-' or '/' starting with a letter or digit", value)
+	case "labelvalue":
+		return fmt.Sprintf("label value %q must be at most 63 letters, digits, '.', '_' or '-'", value)
+	case "cidr":
+		return fmt.Sprintf("%s %q is not a valid CIDR address (e.g. 10.0.0.1/24)", fieldName, value)
+	case "ip":
+		return fmt.Sprintf("%s %q is not a valid IP address", fieldName, value)
+	case "mac":
+		return fmt.Sprintf("%s %q is not a valid MAC address", fieldName, value)
+	case "cidr|eq=default":
+		return fmt.Sprintf("%s %q must be a CIDR address or \"default\"", fieldName, value)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", fieldName, strings.ReplaceAll(fieldError.Param(), " ", ", "))
+	case "min":
+		if fieldError.Kind() == reflect.Slice {
+			return fmt.Sprintf("%s requires at least %s entries", fieldName, fieldError.Param())
+		}
+		return fmt.Sprintf("%s must be at least %s", fieldName, fieldError.Param())
+	case "max":
+		if fieldError.Kind() == reflect.String {
+			return fmt.Sprintf("%s %q is longer than %s characters", fieldName, value, fieldError.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s", fieldName, fieldError.Param())
+	case "gte":
+		return fmt.Sprintf("%s must be at least %s", fieldName, fieldError.Param())
+	case "lte":
+		return fmt.Sprintf("%s must be at most %s", fieldName, fieldError.Param())
+	default:
+		return fmt.Sprintf("%s is invalid", fieldName)
+	}
+}
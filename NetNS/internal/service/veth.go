@@ -0,0 +1,164 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/zenith/netns-mgr/internal/db"
+)
+
+// CreateVethRequest describes a veth pair to create
+type CreateVethRequest struct {
+	Name          string            `json:"name" validate:"required,ifname"`
+	PeerName      string            `json:"peer_name" validate:"required,ifname"`
+	Namespace     string            `json:"namespace"`      // Namespace for the interface (empty = host)
+	PeerNamespace string            `json:"peer_namespace"` // Namespace for the peer (empty = host)
+	Labels        map[string]string `json:"labels,omitempty" validate:"dive,keys,labelkey,endkeys,labelvalue"`
+}
+
+// Validate checks the request before touching the kernel
+func (request CreateVethRequest) Validate() error {
+	if err := validateStruct(request); err != nil {
+		return err
+	}
+	if request.Name == request.PeerName {
+		return invalidf("name and peer_name must differ")
+	}
+	return nil
+}
+
+// CreateVeth creates a veth pair and records it
+func (service *Service) CreateVeth(request CreateVethRequest) (*db.VethPair, error) {
+	service.scopeNames(&request.Name, &request.PeerName)
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := service.scopeNamespaces(&request.Namespace, &request.PeerNamespace); err != nil {
+		return nil, err
+	}
+	releaseQuota, err := service.checkQuota(db.QuotaVeths, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseQuota()
+
+	transaction := service.beginTransaction()
+
+	// Create in system
+	err = transaction.Apply("create veth pair "+request.Name,
+		func() error {
+			return service.vethManager.Create(request.Name, request.PeerName, request.Namespace, request.PeerNamespace)
+		},
+		func() error { return service.vethManager.Delete(request.Name) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record in database
+	var vethPair *db.VethPair
+	err = transaction.Commit(func(txRepository *db.Repository) error {
+		namespaceID, err := txRepository.NamespaceIDByName(request.Namespace)
+		if err != nil {
+			return err
+		}
+		peerNamespaceID, err := txRepository.NamespaceIDByName(request.PeerNamespace)
+		if err != nil {
+			return err
+		}
+		vethPair, err = txRepository.CreateVethPair(request.Name, request.PeerName, namespaceID, peerNamespaceID, service.projectID())
+		if err != nil {
+			return err
+		}
+		vethPair.Labels = request.Labels
+		return txRepository.SetLabels(db.LabelVeths, vethPair.ID, request.Labels)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record veth pair: %w", err)
+	}
+
+	return vethPair, nil
+}
+
+// DeleteVeth deletes a veth pair and its record
+func (service *Service) DeleteVeth(interfaceName string) error {
+	service.scopeNames(&interfaceName)
+
+	// Remove the record, then the pair; the record is needed to recreate it
+	transaction := service.beginTransaction()
+	return transaction.Commit(func(txRepository *db.Repository) error {
+		vethPair, err := txRepository.GetVethPairByName(interfaceName)
+		if err != nil {
+			return err
+		}
+		if service.project != nil && (vethPair == nil || !service.ownedByProject(vethPair.ProjectID)) {
+			return &NotFoundError{Resource: "veth pair", Name: interfaceName}
+		}
+
+		var recreate func() error
+		if vethPair != nil {
+			namespaceName, err := txRepository.NamespaceNameByID(vethPair.NsID)
+			if err != nil {
+				return err
+			}
+			peerNamespaceName, err := txRepository.NamespaceNameByID(vethPair.PeerNsID)
+			if err != nil {
+				return err
+			}
+			if err := txRepository.DeleteVethPair(interfaceName); err != nil {
+				return err
+			}
+			recreate = func() error {
+				return service.vethManager.Create(vethPair.Name, vethPair.PeerName, namespaceName, peerNamespaceName)
+			}
+		}
+
+		return transaction.Apply("delete veth pair "+interfaceName,
+			func() error { return service.vethManager.Delete(interfaceName) },
+			recreate,
+		)
+	})
+}
+
+// ListVeths returns one page of the recorded veth pairs matching the list options, optionally with an end in one namespace
+// Returns the cursor of the next page (empty = last page).
+func (service *Service) ListVeths(namespaceName string, options ListOptions) ([]db.VethPair, string, error) {
+	query, err := service.listQuery(namespaceName, options)
+	if err != nil {
+		return nil, "", err
+	}
+	return pagedRecords(service.repository.QueryVethPairs(query))
+}
+
+// GetVeth returns a recorded veth pair
+func (service *Service) GetVeth(vethName string) (*db.VethPair, error) {
+	service.scopeNames(&vethName)
+	vethPair, err := service.repository.GetVethPairByName(vethName)
+	if err != nil {
+		return nil, err
+	}
+	if vethPair == nil || !service.ownedByProject(vethPair.ProjectID) {
+		return nil, &NotFoundError{Resource: "veth pair", Name: vethName}
+	}
+	if vethPair.Labels, err = service.repository.GetLabels(db.LabelVeths, vethPair.ID); err != nil {
+		return nil, err
+	}
+	return vethPair, nil
+}
+
+// SetVethUp brings a veth interface up
+func (service *Service) SetVethUp(interfaceName, namespaceName string) error {
+	service.scopeNames(&interfaceName)
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return err
+	}
+	return service.vethManager.SetUp(interfaceName, namespaceName)
+}
+
+// SetVethDown brings a veth interface down
+func (service *Service) SetVethDown(interfaceName, namespaceName string) error {
+	service.scopeNames(&interfaceName)
+	if err := service.scopeNamespaces(&namespaceName); err != nil {
+		return err
+	}
+	return service.vethManager.SetDown(interfaceName, namespaceName)
+}
@@ -0,0 +1,585 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/zenith/netns-mgr/internal/db"
+)
+
+// Workload supervision tuning
+const (
+	defaultWorkloadRestartDelay = 1                // Seconds before the first restart
+	maxWorkloadRestartDelay     = 60 * time.Second // Cap of the doubling restart delay
+	workloadHealthyRuntime      = 10 * time.Second // A run lasting this long resets the restart delay
+	workloadStopTimeout         = 10 * time.Second // Between SIGTERM and SIGKILL when stopping
+	workloadStartTimeout        = 5 * time.Second  // How long starting waits for the command to run
+	maxWorkloadLogSize          = 10 << 20         // A log larger than this is rotated to .1 when the command starts
+	defaultWorkloadLogLines     = 100              // Lines returned by WorkloadLogs by default
+)
+
+// Log files of a workload, in its log directory
+const (
+	WorkloadStdoutLog     = "stdout.log"
+	WorkloadStderrLog     = "stderr.log"
+	workloadSupervisorLog = "supervisor.log"
+)
+
+// RunWorkloadRequest describes a command to run and supervise inside a namespace
+type RunWorkloadRequest struct {
+	Name           string   `json:"name" validate:"required,max=64,nsname"`
+	Namespace      string   `json:"namespace" validate:"required,nsname"`
+	Command        []string `json:"command" validate:"required,min=1,dive,required"`
+	Env            []string `json:"env,omitempty" validate:"dive,contains=="`                                    // KEY=value entries added to a minimal environment
+	Dir            string   `json:"dir,omitempty"`                                                               // Working directory (empty = /)
+	RestartPolicy  string   `json:"restart_policy,omitempty" validate:"omitempty,oneof=never on-failure always"` // Default on-failure
+	MaxRestarts    int      `json:"max_restarts,omitempty" validate:"gte=0"`                                     // 0 = unlimited
+	RestartDelay   int      `json:"restart_delay,omitempty" validate:"gte=0,lte=3600"`                           // Seconds (0 = 1)
+	MountNamespace *bool    `json:"mount_namespace,omitempty"`                                                   // Private mount namespace with /etc/netns overlays and sysfs (default true)
+}
+
+// Validate checks the request before recording anything
+func (request RunWorkloadRequest) Validate() error {
+	return validateStruct(request)
+}
+
+// requireUnconfined rejects workload operations of project-scoped services
+// Workloads run as root with the host's filesystem, which no project may reach.
+func (service *Service) requireUnconfined() error {
+	if service.project != nil {
+		return &ForbiddenError{Message: "project " + service.project.Name + " cannot manage workloads"}
+	}
+	return nil
+}
+
+// RunWorkload records a workload and starts its supervisor
+// Waits briefly for the command to start, so a command that cannot run is
+// reported as failed right away.
+func (service *Service) RunWorkload(request RunWorkloadRequest) (*db.Workload, error) {
+	if err := service.requireUnconfined(); err != nil {
+		return nil, err
+	}
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if request.RestartPolicy == "" {
+		request.RestartPolicy = db.RestartOnFailure
+	}
+	if request.RestartDelay == 0 {
+		request.RestartDelay = defaultWorkloadRestartDelay
+	}
+
+	namespaceID, err := service.repository.NamespaceIDByName(request.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if namespaceID == nil {
+		return nil, &NotFoundError{Resource: "namespace", Name: request.Namespace}
+	}
+	existing, err := service.repository.GetWorkloadByName(request.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, invalidf("workload %q already exists", request.Name)
+	}
+
+	logDirectory, err := service.workloadLogDirectory(request.Name)
+	if err != nil {
+		return nil, err
+	}
+	workloadRecord, err := service.repository.CreateWorkload(&db.Workload{
+		Name:           request.Name,
+		NsID:           namespaceID,
+		Command:        request.Command,
+		Env:            request.Env,
+		Dir:            request.Dir,
+		MountNamespace: request.MountNamespace == nil || *request.MountNamespace,
+		RestartPolicy:  request.RestartPolicy,
+		MaxRestarts:    request.MaxRestarts,
+		RestartDelay:   request.RestartDelay,
+		LogDir:         logDirectory,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record workload: %w", err)
+	}
+
+	if err := service.launchSupervisor(workloadRecord); err != nil {
+		// Nothing runs without its supervisor, so the record goes too
+		service.repository.DeleteWorkload(workloadRecord.Name)
+		return nil, err
+	}
+	return service.waitForWorkloadStart(workloadRecord.Name)
+}
+
+// StartWorkload starts the supervisor of a stopped or ended workload
+func (service *Service) StartWorkload(workloadName string) (*db.Workload, error) {
+	workloadRecord, err := service.GetWorkload(workloadName)
+	if err != nil {
+		return nil, err
+	}
+	if workloadRecord.IsActive() {
+		return nil, invalidf("workload %q is already %s", workloadName, workloadRecord.Status)
+	}
+	_, command, err := service.repository.GetWorkloadProcesses(workloadRecord.ID)
+	if err != nil {
+		return nil, err
+	}
+	if processMatches(command) {
+		return nil, invalidf("workload %q still runs as pid %d without its supervisor; stop it first", workloadName, command.PID)
+	}
+
+	if err := service.launchSupervisor(workloadRecord); err != nil {
+		return nil, err
+	}
+	return service.waitForWorkloadStart(workloadName)
+}
+
+// StopWorkload stops a workload's supervisor and command
+// The command gets SIGTERM and, after workloadStopTimeout, SIGKILL. Stopping
+// a workload that is not running returns it unchanged.
+func (service *Service) StopWorkload(workloadName string) (*db.Workload, error) {
+	workloadRecord, err := service.GetWorkload(workloadName)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.stopWorkload(workloadRecord); err != nil {
+		return nil, err
+	}
+	return service.GetWorkload(workloadName)
+}
+
+// DeleteWorkload stops a workload and removes its record and logs
+func (service *Service) DeleteWorkload(workloadName string) error {
+	workloadRecord, err := service.GetWorkload(workloadName)
+	if err != nil {
+		return err
+	}
+	if err := service.stopWorkload(workloadRecord); err != nil {
+		return err
+	}
+	if err := service.repository.DeleteWorkload(workloadName); err != nil {
+		return err
+	}
+	return os.RemoveAll(workloadRecord.LogDir)
+}
+
+// GetWorkload returns a recorded workload
+// A workload recorded as active whose supervisor is gone (e.g. after a
+// reboot) is recorded and reported as WorkloadLost.
+func (service *Service) GetWorkload(workloadName string) (*db.Workload, error) {
+	if err := service.requireUnconfined(); err != nil {
+		return nil, err
+	}
+	workloadRecord, err := service.repository.GetWorkloadByName(workloadName)
+	if err != nil {
+		return nil, err
+	}
+	if workloadRecord == nil {
+		return nil, &NotFoundError{Resource: "workload", Name: workloadName}
+	}
+	if err := service.reportLostWorkload(workloadRecord); err != nil {
+		return nil, err
+	}
+	return workloadRecord, nil
+}
+
+// ListWorkloads returns the recorded workloads, optionally in one namespace
+func (service *Service) ListWorkloads(namespaceName string) ([]db.Workload, error) {
+	if err := service.requireUnconfined(); err != nil {
+		return nil, err
+	}
+	namespaceID, err := service.namespaceFilter(namespaceName)
+	if err != nil {
+		return nil, err
+	}
+	workloadRecords, err := service.repository.ListWorkloads(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+	for index := range workloadRecords {
+		if err := service.reportLostWorkload(&workloadRecords[index]); err != nil {
+			return nil, err
+		}
+	}
+	return workloadRecords, nil
+}
+
+// WorkloadLogs returns the end of a workload's stdout or stderr log
+// Parameters:
+//   - workloadName: workload to read the log of
+//   - stream: "stdout" or "stderr"
+//   - lines: number of lines from the end (0 = defaultWorkloadLogLines, negative = all)
+func (service *Service) WorkloadLogs(workloadName, stream string, lines int) (string, error) {
+	workloadRecord, err := service.GetWorkload(workloadName)
+	if err != nil {
+		return "", err
+	}
+
+	var logName string
+	switch stream {
+	case "", "stdout":
+		logName = WorkloadStdoutLog
+	case "stderr":
+		logName = WorkloadStderrLog
+	default:
+		return "", invalidf("stream must be stdout or stderr")
+	}
+	if lines == 0 {
+		lines = defaultWorkloadLogLines
+	}
+
+	content, err := os.ReadFile(filepath.Join(workloadRecord.LogDir, logName))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(lastLines(content, lines)), nil
+}
+
+// workloadsInNamespace returns the workloads recorded in a namespace (none if it is not recorded)
+func (service *Service) workloadsInNamespace(namespaceName string) ([]db.Workload, error) {
+	namespaceID, err := service.repository.NamespaceIDByName(namespaceName)
+	if err != nil || namespaceID == nil {
+		return nil, err
+	}
+	return service.repository.ListWorkloads(namespaceID)
+}
+
+// stopWorkload signals the supervisor of a workload and waits for it to exit
+// A command left behind by a supervisor that died is killed directly. Only
+// processes whose recorded start time still matches are signalled, so a PID
+// reused by an unrelated process is never touched.
+func (service *Service) stopWorkload(workloadRecord *db.Workload) error {
+	supervisor, _, err := service.repository.GetWorkloadProcesses(workloadRecord.ID)
+	if err != nil {
+		return err
+	}
+	if processMatches(supervisor) {
+		if err := syscall.Kill(supervisor.PID, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("failed to signal supervisor of workload %s: %w", workloadRecord.Name, err)
+		}
+		if !waitForProcessExit(supervisor, workloadStopTimeout+5*time.Second) {
+			return fmt.Errorf("supervisor of workload %s (pid %d) did not stop", workloadRecord.Name, supervisor.PID)
+		}
+	}
+
+	current, err := service.repository.GetWorkload(workloadRecord.ID)
+	if err != nil || current == nil {
+		return err
+	}
+	_, command, err := service.repository.GetWorkloadProcesses(current.ID)
+	if err != nil {
+		return err
+	}
+	commandAlive := processMatches(command)
+	if !current.IsActive() && !commandAlive {
+		return nil
+	}
+	if commandAlive {
+		syscall.Kill(-command.PID, syscall.SIGKILL)
+	}
+	return service.repository.SetWorkloadEnded(current.ID, db.WorkloadStopped, current.ExitCode, "", current.Restarts)
+}
+
+// workloadLogDirectory creates and returns the log directory of a workload
+// Logs live in "workloads/<name>" next to the database.
+func (service *Service) workloadLogDirectory(workloadName string) (string, error) {
+	databasePath := service.repository.DatabasePath()
+	if databasePath == "" {
+		return "", fmt.Errorf("workloads need a database file")
+	}
+	databasePath, err := filepath.Abs(databasePath)
+	if err != nil {
+		return "", err
+	}
+	logDirectory := filepath.Join(filepath.Dir(databasePath), "workloads", workloadName)
+	if err := os.MkdirAll(logDirectory, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create log directory: %w", err)
+	}
+	return logDirectory, nil
+}
+
+// launchSupervisor starts "netns-mgr workload supervise <name>" as a detached process
+// The supervisor gets its own session so it outlives the CLI or API server
+// that launched it.
+func (service *Service) launchSupervisor(workloadRecord *db.Workload) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the netns-mgr executable: %w", err)
+	}
+	databasePath, err := filepath.Abs(service.repository.DatabasePath())
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(workloadRecord.LogDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	supervisorLog, err := os.OpenFile(filepath.Join(workloadRecord.LogDir, workloadSupervisorLog), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer supervisorLog.Close()
+
+	if err := service.repository.ResetWorkloadForStart(workloadRecord.ID); err != nil {
+		return err
+	}
+
+	supervisor := exec.Command(executable, "--db", databasePath, "workload", "supervise", workloadRecord.Name)
+	supervisor.Dir = "/"
+	supervisor.Stdout = supervisorLog
+	supervisor.Stderr = supervisorLog
+	supervisor.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := supervisor.Start(); err != nil {
+		service.repository.SetWorkloadEnded(workloadRecord.ID, db.WorkloadFailed, nil, err.Error(), 0)
+		return fmt.Errorf("failed to start supervisor of workload %s: %w", workloadRecord.Name, err)
+	}
+	if err := service.repository.SetWorkloadSupervisor(workloadRecord.ID, recordedProcess(supervisor.Process.Pid)); err != nil {
+		return err
+	}
+
+	// Reap the supervisor when it exits, or a long-running server keeps a zombie that looks alive
+	go supervisor.Wait()
+	return nil
+}
+
+// waitForWorkloadStart waits until a started workload leaves WorkloadStarting
+func (service *Service) waitForWorkloadStart(workloadName string) (*db.Workload, error) {
+	deadline := time.Now().Add(workloadStartTimeout)
+	for {
+		workloadRecord, err := service.GetWorkload(workloadName)
+		if err != nil || workloadRecord.Status != db.WorkloadStarting || time.Now().After(deadline) {
+			return workloadRecord, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// SuperviseWorkload runs a workload's command and restarts it according to its policy
+// This is the body of "netns-mgr workload supervise"; it returns when the
+// command ends for good, or after stopping the command once ctx is cancelled.
+// Parameters:
+//   - ctx: cancelled to stop the workload (the supervisor's SIGTERM)
+//   - workloadName: workload to supervise
+func (service *Service) SuperviseWorkload(ctx context.Context, workloadName string) error {
+	workloadRecord, err := service.GetWorkload(workloadName)
+	if err != nil {
+		return err
+	}
+	namespaceName, err := service.repository.NamespaceNameByID(workloadRecord.NsID)
+	if err != nil {
+		return err
+	}
+	if err := service.repository.SetWorkloadSupervisor(workloadRecord.ID, recordedProcess(os.Getpid())); err != nil {
+		return err
+	}
+
+	restarts := 0
+	restartDelay := time.Duration(workloadRecord.RestartDelay) * time.Second
+	for {
+		runStartedAt := time.Now()
+		exitCode, err := service.runWorkloadOnce(ctx, workloadRecord, namespaceName)
+		if err != nil {
+			// A command that cannot start will not start on a retry either
+			return service.repository.SetWorkloadEnded(workloadRecord.ID, db.WorkloadFailed, nil, err.Error(), restarts)
+		}
+		if ctx.Err() != nil {
+			return service.repository.SetWorkloadEnded(workloadRecord.ID, db.WorkloadStopped, &exitCode, "", restarts)
+		}
+
+		failed := exitCode != 0
+		errorMessage := ""
+		if failed {
+			errorMessage = describeExit(exitCode)
+		}
+		restart := workloadRecord.RestartPolicy == db.RestartAlways || (workloadRecord.RestartPolicy == db.RestartOnFailure && failed)
+		if restart && workloadRecord.MaxRestarts > 0 && restarts >= workloadRecord.MaxRestarts {
+			restart = false
+			if failed {
+				errorMessage += fmt.Sprintf(" (gave up after %d restarts)", restarts)
+			}
+		}
+		if !restart {
+			finalStatus := db.WorkloadExited
+			if failed {
+				finalStatus = db.WorkloadFailed
+			}
+			return service.repository.SetWorkloadEnded(workloadRecord.ID, finalStatus, &exitCode, errorMessage, restarts)
+		}
+
+		if time.Since(runStartedAt) >= workloadHealthyRuntime {
+			restartDelay = time.Duration(workloadRecord.RestartDelay) * time.Second
+		}
+		restarts++
+		if err := service.repository.SetWorkloadEnded(workloadRecord.ID, db.WorkloadRestarting, &exitCode, errorMessage, restarts); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return service.repository.SetWorkloadEnded(workloadRecord.ID, db.WorkloadStopped, &exitCode, "", restarts)
+		case <-time.After(restartDelay):
+		}
+		restartDelay = min(restartDelay*2, maxWorkloadRestartDelay)
+	}
+}
+
+// runWorkloadOnce runs a workload's command until it ends or ctx is cancelled
+// Returns the exit code (-1 = ended by a signal); err is only set when the
+// command could not start.
+func (service *Service) runWorkloadOnce(ctx context.Context, workloadRecord *db.Workload, namespaceName string) (int, error) {
+	stdout, err := openWorkloadLog(filepath.Join(workloadRecord.LogDir, WorkloadStdoutLog))
+	if err != nil {
+		return -1, err
+	}
+	defer stdout.Close()
+	stderr, err := openWorkloadLog(filepath.Join(workloadRecord.LogDir, WorkloadStderrLog))
+	if err != nil {
+		return -1, err
+	}
+	defer stderr.Close()
+
+	command := exec.Command(workloadRecord.Command[0], workloadRecord.Command[1:]...)
+	command.Env = append([]string{defaultExecPath}, workloadRecord.Env...)
+	command.Dir = workloadRecord.Dir
+	if command.Dir == "" {
+		command.Dir = "/"
+	}
+	command.Stdout = stdout
+	command.Stderr = stderr
+	// Its own process group, so stopping also reaches the command's children
+	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := service.namespaceManager.StartCommand(command, namespaceName, workloadRecord.MountNamespace); err != nil {
+		return -1, fmt.Errorf("failed to start %s: %w", workloadRecord.Command[0], err)
+	}
+	processID := command.Process.Pid
+	if err := service.repository.SetWorkloadRunning(workloadRecord.ID, recordedProcess(processID)); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to record pid %d of workload %s: %v\n", processID, workloadRecord.Name, err)
+	}
+
+	waitResult := make(chan error, 1)
+	go func() { waitResult <- command.Wait() }()
+	select {
+	case <-waitResult:
+	case <-ctx.Done():
+		syscall.Kill(-processID, syscall.SIGTERM)
+		select {
+		case <-waitResult:
+		case <-time.After(workloadStopTimeout):
+			syscall.Kill(-processID, syscall.SIGKILL)
+			<-waitResult
+		}
+	}
+	return command.ProcessState.ExitCode(), nil
+}
+
+// openWorkloadLog opens a log for appending, first rotating it to .1 once it exceeds maxWorkloadLogSize
+func openWorkloadLog(logPath string) (*os.File, error) {
+	if logInfo, err := os.Stat(logPath); err == nil && logInfo.Size() > maxWorkloadLogSize {
+		if err := os.Rename(logPath, logPath+".1"); err != nil {
+			return nil, err
+		}
+	}
+	return os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// lastLines returns the last count lines of content (negative = all of it)
+func lastLines(content []byte, count int) []byte {
+	if count < 0 {
+		return content
+	}
+	end := len(content)
+	if end > 0 && content[end-1] == '\n' {
+		end--
+	}
+	start := end
+	for lineCount := 0; lineCount < count; lineCount++ {
+		newlineIndex := bytes.LastIndexByte(content[:start], '\n')
+		if newlineIndex < 0 {
+			return content
+		}
+		start = newlineIndex
+	}
+	return content[start+1:]
+}
+
+// describeExit explains a non-zero exit code
+func describeExit(exitCode int) string {
+	if exitCode < 0 {
+		return "terminated by a signal"
+	}
+	return fmt.Sprintf("exit status %d", exitCode)
+}
+
+// reportLostWorkload records an active workload whose supervisor is gone as WorkloadLost
+// The lost workload's processes are forgotten, so nothing signals their PIDs
+// once they are reused. A command still running without its supervisor is
+// only reported as lost and stays recorded, so stopping the workload kills it.
+func (service *Service) reportLostWorkload(workloadRecord *db.Workload) error {
+	if !workloadRecord.IsActive() {
+		return nil
+	}
+	supervisor, command, err := service.repository.GetWorkloadProcesses(workloadRecord.ID)
+	if err != nil {
+		return err
+	}
+	if processMatches(supervisor) {
+		return nil
+	}
+	// A supervisor that is being launched has not recorded itself yet
+	launching := workloadRecord.Status == db.WorkloadStarting && supervisor.PID == 0 &&
+		workloadRecord.StartedAt != nil && time.Since(*workloadRecord.StartedAt) < workloadStartTimeout
+	if launching {
+		return nil
+	}
+
+	workloadRecord.Status = db.WorkloadLost
+	if processMatches(command) {
+		return nil
+	}
+	if err := service.repository.SetWorkloadLost(workloadRecord.ID, supervisor.PID); err != nil {
+		return fmt.Errorf("failed to record workload %s as lost: %w", workloadRecord.Name, err)
+	}
+	workloadRecord.SupervisorPID = 0
+	workloadRecord.PID = 0
+	return nil
+}
+
+// recordedProcess identifies a process for recording, with its start time (0 = unavailable)
+func recordedProcess(processID int) db.WorkloadProcess {
+	startTime, _ := processStartTime(processID)
+	return db.WorkloadProcess{PID: processID, StartTime: startTime}
+}
+
+// processMatches reports whether a recorded process still runs
+// False for a process recorded without a start time, or whose PID now
+// belongs to a process started later.
+func processMatches(process db.WorkloadProcess) bool {
+	if process.PID <= 0 || process.StartTime == 0 {
+		return false
+	}
+	startTime, err := processStartTime(process.PID)
+	return err == nil && startTime == process.StartTime
+}
+
+// waitForProcessExit polls until a recorded process is gone
+// Returns false if it still exists after the timeout.
+func waitForProcessExit(process db.WorkloadProcess, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for processMatches(process) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return true
+}
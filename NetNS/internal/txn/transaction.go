@@ -0,0 +1,144 @@
+// Package txn keeps kernel state and the database in step.
+//
+// Each kernel change is applied together with a compensating action, and the
+// records are written in a single SQL transaction. If any step fails, the
+// kernel changes made so far are reverted in reverse order. Creates apply the
+// kernel change before Commit; deletes remove the record and apply the kernel
+// change inside Commit, so a kernel failure leaves the record in place.
+// An optional StepObserver is told about each kernel change before it is
+// applied and can stop the transaction there, e.g. when a job is cancelled.
+package txn
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zenith/netns-mgr/internal/db"
+)
+
+// StepObserver is called with the description of each kernel change before it is applied
+// Returning an error skips the change and reverts the changes made so far.
+type StepObserver func(description string) error
+
+// Transaction groups kernel changes with the database records describing them
+type Transaction struct {
+	repository *db.Repository
+	observer   StepObserver
+	steps      []step
+	finished   bool
+}
+
+// step is an applied kernel change and how to revert it
+type step struct {
+	description string
+	undo        func() error
+}
+
+// RollbackError is returned when an operation failed and some of its kernel
+// changes could not be reverted, leaving the kernel and database out of step
+type RollbackError struct {
+	Err    error   // Error that caused the rollback
+	Failed []error // Compensating actions that failed
+}
+
+func (rollbackError *RollbackError) Error() string {
+	failures := make([]string, len(rollbackError.Failed))
+	for failureIndex, failure := range rollbackError.Failed {
+		failures[failureIndex] = failure.Error()
+	}
+	return fmt.Sprintf("%v (rollback incomplete: %s)", rollbackError.Err, strings.Join(failures, "; "))
+}
+
+func (rollbackError *RollbackError) Unwrap() error {
+	return rollbackError.Err
+}
+
+// Begin starts a new transaction
+// Parameters:
+//   - repository: repository the records are committed to
+func Begin(repository *db.Repository) *Transaction {
+	return &Transaction{repository: repository}
+}
+
+// Observe reports every following kernel change to observer
+// Parameters:
+//   - observer: called before each change (nil = no observer)
+func (transaction *Transaction) Observe(observer StepObserver) *Transaction {
+	transaction.observer = observer
+	return transaction
+}
+
+// Apply performs a kernel change and remembers how to revert it
+// If the observer or apply fails, the changes made so far are reverted and the
+// error returned.
+// Parameters:
+//   - description: what the step does, used in rollback errors
+//   - apply: performs the change
+//   - undo: reverts the change (nil if it cannot be reverted)
+func (transaction *Transaction) Apply(description string, apply, undo func() error) error {
+	if transaction.finished {
+		return fmt.Errorf("%s: transaction already finished", description)
+	}
+
+	if transaction.observer != nil {
+		if err := transaction.observer(description); err != nil {
+			return transaction.unwind(err)
+		}
+	}
+	if err := apply(); err != nil {
+		return transaction.unwind(err)
+	}
+	if undo != nil {
+		transaction.steps = append(transaction.steps, step{description: description, undo: undo})
+	}
+	return nil
+}
+
+// Commit writes the records in a single SQL transaction
+// If record or the commit fails, the SQL transaction is rolled back and every
+// kernel change applied so far (including any applied inside record) is reverted.
+// Parameters:
+//   - record: performs the database changes through txRepository
+func (transaction *Transaction) Commit(record func(txRepository *db.Repository) error) error {
+	if transaction.finished {
+		return fmt.Errorf("transaction already finished")
+	}
+
+	if err := transaction.repository.WithTx(record); err != nil {
+		return transaction.unwind(err)
+	}
+	transaction.finished = true
+	return nil
+}
+
+// Rollback reverts every kernel change applied so far
+// It does nothing once the transaction has been committed or unwound, so it
+// is safe to defer right after Begin.
+func (transaction *Transaction) Rollback() error {
+	if transaction.finished {
+		return nil
+	}
+	return transaction.unwind(nil)
+}
+
+// unwind reverts applied steps in reverse order and finishes the transaction
+func (transaction *Transaction) unwind(cause error) error {
+	transaction.finished = true
+
+	var failures []error
+	for stepIndex := len(transaction.steps) - 1; stepIndex >= 0; stepIndex-- {
+		appliedStep := transaction.steps[stepIndex]
+		if err := appliedStep.undo(); err != nil {
+			failures = append(failures, fmt.Errorf("undo %s: %w", appliedStep.description, err))
+		}
+	}
+	transaction.steps = nil
+
+	if len(failures) == 0 {
+		return cause
+	}
+	if cause == nil {
+		cause = fmt.Errorf("transaction rolled back")
+	}
+	return &RollbackError{Err: cause, Failed: failures}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// AddAddress adds an address to an interface on the server
+func (client *Client) AddAddress(ctx context.Context, request AddressRequest) (*IPAddress, error) {
+	var address IPAddress
+	if err := client.do(ctx, http.MethodPost, "/addresses", nil, request, &address); err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// DeleteAddress removes an address from an interface on the server, recorded or not
+func (client *Client) DeleteAddress(ctx context.Context, request AddressRequest) error {
+	query := namespaceQuery(request.Namespace)
+	query.Set("interface", request.Interface)
+	query.Set("address", request.Address)
+	return client.do(ctx, http.MethodDelete, "/addresses", query, nil, nil)
+}
+
+// DeleteAddressByID removes a recorded address
+func (client *Client) DeleteAddressByID(ctx context.Context, id int64) error {
+	return client.do(ctx, http.MethodDelete, "/addresses/"+strconv.FormatInt(id, 10), nil, nil, nil)
+}
+
+// GetAddress returns a recorded address
+func (client *Client) GetAddress(ctx context.Context, id int64) (*IPAddress, error) {
+	var address IPAddress
+	if err := client.do(ctx, http.MethodGet, "/addresses/"+strconv.FormatInt(id, 10), nil, nil, &address); err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// UpdateAddress changes the label and lifetimes of a recorded address
+func (client *Client) UpdateAddress(ctx context.Context, id int64, request UpdateAddressRequest) (*IPAddress, error) {
+	var address IPAddress
+	if err := client.do(ctx, http.MethodPatch, "/addresses/"+strconv.FormatInt(id, 10), nil, request, &address); err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// ListAddresses returns the addresses recorded on the server that match the list options, optionally in one namespace
+func (client *Client) ListAddresses(ctx context.Context, namespaceName string, options ListOptions) ([]IPAddress, error) {
+	var addresses []IPAddress
+	err := client.do(ctx, http.MethodGet, "/addresses", listQuery(namespaceQuery(namespaceName), options), nil, &addresses)
+	return addresses, err
+}
+
+// AddressInfos returns the addresses currently present in a namespace (empty = host)
+func (client *Client) AddressInfos(ctx context.Context, namespaceName string) ([]AddressInfo, error) {
+	var addressInfos []AddressInfo
+	err := client.do(ctx, http.MethodGet, "/addresses/status", namespaceQuery(namespaceName), nil, &addressInfos)
+	return addressInfos, err
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateBridge creates a bridge on the server
+func (client *Client) CreateBridge(ctx context.Context, request CreateBridgeRequest) (*Bridge, error) {
+	var bridge Bridge
+	if err := client.do(ctx, http.MethodPost, "/bridges", nil, request, &bridge); err != nil {
+		return nil, err
+	}
+	return &bridge, nil
+}
+
+// DeleteBridge deletes a bridge on the server
+func (client *Client) DeleteBridge(ctx context.Context, bridgeName, namespaceName string) error {
+	return client.do(ctx, http.MethodDelete, "/bridges/"+url.PathEscape(bridgeName), namespaceQuery(namespaceName), nil, nil)
+}
+
+// AddBridgePort adds an interface to a bridge on the server
+func (client *Client) AddBridgePort(ctx context.Context, request BridgePortRequest) error {
+	return client.do(ctx, http.MethodPost, "/bridges/"+url.PathEscape(request.Bridge)+"/ports", nil, request, nil)
+}
+
+// RemoveBridgePort removes an interface from a bridge on the server
+func (client *Client) RemoveBridgePort(ctx context.Context, request BridgePortRequest) error {
+	path := "/bridges/" + url.PathEscape(request.Bridge) + "/ports/" + url.PathEscape(request.Interface)
+	return client.do(ctx, http.MethodDelete, path, namespaceQuery(request.Namespace), nil, nil)
+}
+
+// ListBridges returns the bridges recorded on the server that match the list options
+func (client *Client) ListBridges(ctx context.Context, options ListOptions) ([]Bridge, error) {
+	var bridges []Bridge
+	err := client.do(ctx, http.MethodGet, "/bridges", listQuery(url.Values{}, options), nil, &bridges)
+	return bridges, err
+}
+
+// GetBridge returns a recorded bridge
+func (client *Client) GetBridge(ctx context.Context, bridgeName string) (*Bridge, error) {
+	var bridge Bridge
+	if err := client.do(ctx, http.MethodGet, "/bridges/"+url.PathEscape(bridgeName), nil, nil, &bridge); err != nil {
+		return nil, err
+	}
+	return &bridge, nil
+}
+
+// BridgeInfos returns the bridges currently present in a namespace (empty = host)
+func (client *Client) BridgeInfos(ctx context.Context, namespaceName string) ([]BridgeInfo, error) {
+	var bridgeInfos []BridgeInfo
+	err := client.do(ctx, http.MethodGet, "/bridges/status", namespaceQuery(namespaceName), nil, &bridgeInfos)
+	return bridgeInfos, err
+}
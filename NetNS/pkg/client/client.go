@@ -0,0 +1,289 @@
+// Package client is a typed Go client for the netns-mgr REST API (/api/v1).
+//
+// Every method takes a context and returns plain structs matching the JSON the
+// server sends, so automation can drive `netns-mgr serve` without hand-written
+// JSON. The package only depends on the standard library:
+//
+//	apiClient := client.New("http://lab1:8080", nil)
+//	apiClient.SetToken(os.Getenv("NETNS_MGR_TOKEN"))
+//	veth, err := apiClient.CreateVeth(ctx, client.CreateVethRequest{Name: "veth0", PeerName: "veth1"})
+//	if client.IsNotFound(err) {
+//		...
+//	}
+//
+// Contexts from WithIdempotencyKey make POSTs safe to retry, and contexts from
+// WithETag and WithIfMatch make changes conditional on the resource being
+// unchanged since it was read.
+//
+// List methods return everything unless ListOptions.Limit is set; then a
+// context from WithNextCursor receives the cursor of the following page.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTimeout bounds each request made with the default HTTP client
+const defaultTimeout = 60 * time.Second
+
+// Client talks to a netns-mgr API server
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+}
+
+// New creates a new client
+// Parameters:
+//   - baseURL: server address, e.g. http://lab1:8080 (a missing scheme defaults to http)
+//   - httpClient: HTTP client to send requests with (nil = default with a 60s timeout)
+func New(baseURL string, httpClient *http.Client) *Client {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+	}
+}
+
+// NewTLSHTTPClient creates an HTTP client for servers using a private CA or client certificates
+// Parameters:
+//   - caFile: PEM CA bundle to verify the server with (empty = system roots)
+//   - certFile: PEM client certificate for mutual TLS (empty = none)
+//   - keyFile: PEM private key of the client certificate
+func NewTLSHTTPClient(caFile, certFile, keyFile string) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+	}
+
+	if certFile != "" || keyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport, Timeout: defaultTimeout}, nil
+}
+
+// SetToken sets the bearer token sent with every request (empty = none)
+func (client *Client) SetToken(token string) {
+	client.token = token
+}
+
+// Error is an error response returned by the server
+type Error struct {
+	StatusCode int    // HTTP status code
+	Method     string // Request method
+	Path       string // Request path below /api/v1
+	Message    string // Message from the server's {"error": ...} body
+}
+
+func (apiError *Error) Error() string {
+	return apiError.Message
+}
+
+// IsNotFound reports whether err is a 404 response from the server
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsInvalid reports whether err is a 400 response, i.e. the request failed validation
+func IsInvalid(err error) bool {
+	return hasStatus(err, http.StatusBadRequest)
+}
+
+// IsUnauthorized reports whether err is a 401 response, i.e. the token is missing or invalid
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is a 403 response, i.e. the token's role is too low
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsConflict reports whether err is a 409 response, e.g. cancelling a job that has finished
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsPreconditionFailed reports whether err is a 412 response, i.e. the resource changed since its ETag was read
+func IsPreconditionFailed(err error) bool {
+	return hasStatus(err, http.StatusPreconditionFailed)
+}
+
+// hasStatus reports whether err is (or wraps) an Error with the given status code
+func hasStatus(err error, statusCode int) bool {
+	var apiError *Error
+	return errors.As(err, &apiError) && apiError.StatusCode == statusCode
+}
+
+// do sends a request to /api/v1 and decodes the JSON response into result
+// Parameters:
+//   - ctx: context for cancellation and deadlines
+//   - method: HTTP method
+//   - path: path below /api/v1, already escaped
+//   - query: query parameters (may be nil)
+//   - body: request body encoded as JSON (nil = no body)
+//   - result: destination for the response body (nil = discard)
+func (client *Client) do(ctx context.Context, method, path string, query url.Values, body, result any) error {
+	request, err := client.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to reach server: %w", err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return responseError(response.StatusCode, method, path, responseBody)
+	}
+
+	options := requestOptionsFrom(ctx)
+	if options.etag != nil {
+		*options.etag = response.Header.Get("ETag")
+	}
+	if options.nextCursor != nil {
+		*options.nextCursor = response.Header.Get("X-Next-Cursor")
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(responseBody, result); err != nil {
+		return fmt.Errorf("failed to decode response from %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// newRequest builds a request to /api/v1 with the token and the options of ctx
+// Parameters:
+//   - ctx: context for cancellation and deadlines, carrying the request options
+//   - method: HTTP method
+//   - path: path below /api/v1, already escaped
+//   - query: query parameters (may be nil)
+//   - body: request body encoded as JSON (nil = no body)
+func (client *Client) newRequest(ctx context.Context, method, path string, query url.Values, body any) (*http.Request, error) {
+	requestURL := client.baseURL + "/api/v1" + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		bodyReader = bytes.NewReader(payload)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, requestURL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+	if client.token != "" {
+		request.Header.Set("Authorization", "Bearer "+client.token)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	options := requestOptionsFrom(ctx)
+	if options.idempotencyKey != "" && method == http.MethodPost {
+		request.Header.Set("Idempotency-Key", options.idempotencyKey)
+	}
+	if options.ifMatch != "" {
+		request.Header.Set("If-Match", options.ifMatch)
+	}
+	return request, nil
+}
+
+// responseError builds the Error of a failed response from its {"error": ...} body
+func responseError(statusCode int, method, path string, responseBody []byte) error {
+	var errorResponse struct {
+		Error string `json:"error"`
+	}
+	message := http.StatusText(statusCode)
+	if json.Unmarshal(responseBody, &errorResponse) == nil && errorResponse.Error != "" {
+		message = errorResponse.Error
+	}
+	return &Error{StatusCode: statusCode, Method: method, Path: path, Message: message}
+}
+
+// namespaceQuery builds the ?namespace= parameter (empty = host, omitted)
+func namespaceQuery(namespaceName string) url.Values {
+	query := url.Values{}
+	if namespaceName != "" {
+		query.Set("namespace", namespaceName)
+	}
+	return query
+}
+
+// listQuery adds the list options to a query
+func listQuery(query url.Values, options ListOptions) url.Values {
+	if options.Selector != "" {
+		query.Set("selector", options.Selector)
+	}
+	if options.Interface != "" {
+		query.Set("interface", options.Interface)
+	}
+	if options.Within != "" {
+		query.Set("within", options.Within)
+	}
+	if !options.CreatedAfter.IsZero() {
+		query.Set("created_after", options.CreatedAfter.Format(time.RFC3339))
+	}
+	if !options.CreatedBefore.IsZero() {
+		query.Set("created_before", options.CreatedBefore.Format(time.RFC3339))
+	}
+	if options.Sort != "" {
+		query.Set("sort", options.Sort)
+	}
+	if options.Limit != 0 {
+		query.Set("limit", strconv.Itoa(options.Limit))
+	}
+	if options.Cursor != "" {
+		query.Set("cursor", options.Cursor)
+	}
+	return query
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestClient starts a server with the given handler and returns a client for it
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	apiClient := New(server.URL, nil)
+	apiClient.SetToken("test-token")
+	return apiClient
+}
+
+// TestTypedDecoding checks that requests are sent as JSON and responses decode into the client's types
+func TestTypedDecoding(t *testing.T) {
+	createdAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	apiClient := newTestClient(t, func(writer http.ResponseWriter, request *http.Request) {
+		if got := request.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("Authorization = %q, want the bearer token", got)
+		}
+		writer.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case request.Method == http.MethodPost && request.URL.Path == "/api/v1/namespaces":
+			var createRequest CreateNamespaceRequest
+			if err := json.NewDecoder(request.Body).Decode(&createRequest); err != nil {
+				t.Errorf("decode request body: %v", err)
+			}
+			writer.Header().Set("Location", "/api/v1/namespaces/"+createRequest.Name)
+			writer.WriteHeader(http.StatusCreated)
+			json.NewEncoder(writer).Encode(map[string]any{
+				"id":         7,
+				"name":       createRequest.Name,
+				"created_at": createdAt,
+				"labels":     createRequest.Labels,
+			})
+		case request.Method == http.MethodGet && request.URL.Path == "/api/v1/namespaces":
+			if got := request.URL.Query().Get("selector"); got != "env=lab" {
+				t.Errorf("selector = %q, want env=lab", got)
+			}
+			writer.Header().Set("X-Next-Cursor", "next-page")
+			json.NewEncoder(writer).Encode([]map[string]any{
+				{"id": 7, "name": "lab1", "created_at": createdAt},
+				{"id": 8, "name": "lab2", "created_at": createdAt, "project_id": 3},
+			})
+		case request.Method == http.MethodGet && request.URL.Path == "/api/v1/jobs/5":
+			json.NewEncoder(writer).Encode(map[string]any{
+				"id":          5,
+				"operation":   "delete namespace",
+				"status":      JobSucceeded,
+				"steps_done":  2,
+				"result":      map[string]any{"deleted": "lab1"},
+				"created_at":  createdAt,
+				"finished_at": createdAt.Add(time.Second),
+				"logs": []map[string]any{
+					{"timestamp": createdAt, "message": "delete veths"},
+					{"timestamp": createdAt, "message": "delete namespace lab1"},
+				},
+			})
+		default:
+			t.Errorf("unexpected request %s %s", request.Method, request.URL.Path)
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	namespace, err := apiClient.CreateNamespace(ctx, CreateNamespaceRequest{Name: "lab1", Labels: map[string]string{"env": "lab"}})
+	if err != nil {
+		t.Fatalf("CreateNamespace: %v", err)
+	}
+	if namespace.ID != 7 || namespace.Name != "lab1" || !namespace.CreatedAt.Equal(createdAt) || namespace.Labels["env"] != "lab" {
+		t.Errorf("CreateNamespace = %+v", *namespace)
+	}
+
+	var nextCursor string
+	namespaces, err := apiClient.ListNamespaces(WithNextCursor(ctx, &nextCursor), ListOptions{Selector: "env=lab", Limit: 2})
+	if err != nil {
+		t.Fatalf("ListNamespaces: %v", err)
+	}
+	if len(namespaces) != 2 || namespaces[1].Name != "lab2" || namespaces[1].ProjectID == nil || *namespaces[1].ProjectID != 3 {
+		t.Errorf("ListNamespaces = %+v", namespaces)
+	}
+	if nextCursor != "next-page" {
+		t.Errorf("next cursor = %q, want next-page", nextCursor)
+	}
+
+	job, err := apiClient.GetJob(ctx, 5)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if !job.IsFinished() || job.StepsDone != 2 || len(job.Logs) != 2 || job.Logs[1].Message != "delete namespace lab1" {
+		t.Errorf("GetJob = %+v", *job)
+	}
+	if string(job.Result) != `{"deleted":"lab1"}` {
+		t.Errorf("job result = %s", job.Result)
+	}
+}
+
+// TestAPIErrors checks that error responses become *Error values the Is* helpers recognise
+func TestAPIErrors(t *testing.T) {
+	apiClient := newTestClient(t, func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/api/v1/namespaces/missing":
+			writer.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(writer).Encode(map[string]string{"error": "namespace missing not found"})
+		case "/api/v1/namespaces":
+			writer.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(writer).Encode(map[string]string{"error": "name is required"})
+		case "/api/v1/namespaces/lab1":
+			if got := request.Header.Get("If-Match"); got != `"stale"` {
+				t.Errorf("If-Match = %q, want the stale ETag", got)
+			}
+			writer.WriteHeader(http.StatusPreconditionFailed)
+			writer.Write([]byte("not json"))
+		}
+	})
+	ctx := context.Background()
+
+	_, err := apiClient.GetNamespace(ctx, "missing")
+	var apiError *Error
+	if !errors.As(err, &apiError) {
+		t.Fatalf("GetNamespace error = %v, want *Error", err)
+	}
+	if apiError.StatusCode != http.StatusNotFound || apiError.Method != http.MethodGet || apiError.Path != "/namespaces/missing" || apiError.Message != "namespace missing not found" {
+		t.Errorf("GetNamespace error = %+v", *apiError)
+	}
+	if !IsNotFound(err) || IsInvalid(err) {
+		t.Errorf("IsNotFound = %v, IsInvalid = %v, want true, false", IsNotFound(err), IsInvalid(err))
+	}
+
+	_, err = apiClient.CreateNamespace(ctx, CreateNamespaceRequest{})
+	if !IsInvalid(err) || err.Error() != "name is required" {
+		t.Errorf("CreateNamespace error = %v, want a 400 with the server's message", err)
+	}
+
+	_, err = apiClient.UpdateNamespace(WithIfMatch(ctx, `"stale"`), "lab1", UpdateNamespaceRequest{})
+	if !IsPreconditionFailed(err) || err.Error() != http.StatusText(http.StatusPreconditionFailed) {
+		t.Errorf("UpdateNamespace error = %v, want a 412 with the status text", err)
+	}
+}
+
+// TestContextCancellation checks that cancelling the context aborts a request in flight
+func TestContextCancellation(t *testing.T) {
+	requestReceived := make(chan struct{})
+	apiClient := newTestClient(t, func(writer http.ResponseWriter, request *http.Request) {
+		close(requestReceived)
+		<-request.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-requestReceived
+		cancel()
+	}()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := apiClient.ListNamespaces(ctx, ListOptions{})
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("ListNamespaces error = %v, want context.Canceled", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("ListNamespaces did not return after the context was cancelled")
+	}
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxExecEventSize bounds one Server-Sent Event of an exec stream
+const maxExecEventSize = 1 << 20
+
+// ExecInNamespace runs a command in a namespace on the server and copies its output as it arrives
+// Returns the command's exit code (-1 if a signal ended it); a non-zero exit
+// code is not an error. The HTTP client's timeout does not apply to the
+// stream: use ctx or request.Timeout to bound the command.
+// Parameters:
+//   - ctx: cancelling it disconnects, which kills the command
+//   - namespaceName: namespace to run the command in
+//   - request: command and how to run it
+//   - stdout: receives the command's standard output
+//   - stderr: receives the command's standard error
+func (client *Client) ExecInNamespace(ctx context.Context, namespaceName string, request ExecRequest, stdout, stderr io.Writer) (int, error) {
+	path := "/namespaces/" + url.PathEscape(namespaceName) + "/exec"
+	httpRequest, err := client.newRequest(ctx, http.MethodPost, path, nil, request)
+	if err != nil {
+		return -1, err
+	}
+	httpRequest.Header.Set("Accept", "text/event-stream")
+
+	streamingClient := *client.httpClient
+	streamingClient.Timeout = 0
+	response, err := streamingClient.Do(httpRequest)
+	if err != nil {
+		return -1, fmt.Errorf("failed to reach server: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		responseBody, err := io.ReadAll(response.Body)
+		if err != nil {
+			return -1, err
+		}
+		return -1, responseError(response.StatusCode, http.MethodPost, path, responseBody)
+	}
+
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxExecEventSize)
+	var eventType string
+	var eventData []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if field, value, found := strings.Cut(line, ":"); found && line != "" {
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				eventType = value
+			case "data":
+				eventData = append(eventData, value)
+			}
+			continue
+		}
+		if line != "" {
+			continue
+		}
+
+		// A blank line ends the event
+		data := strings.Join(eventData, "\n")
+		eventData = nil
+		switch eventType {
+		case "stdout", "stderr":
+			var output struct {
+				Data string `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(data), &output); err != nil {
+				return -1, fmt.Errorf("failed to decode %s event: %w", eventType, err)
+			}
+			destination := stdout
+			if eventType == "stderr" {
+				destination = stderr
+			}
+			if _, err := io.WriteString(destination, output.Data); err != nil {
+				return -1, err
+			}
+		case "exit":
+			var exit struct {
+				ExitCode int    `json:"exit_code"`
+				Error    string `json:"error"`
+			}
+			if err := json.Unmarshal([]byte(data), &exit); err != nil {
+				return -1, fmt.Errorf("failed to decode exit event: %w", err)
+			}
+			if exit.Error != "" {
+				return exit.ExitCode, errors.New(exit.Error)
+			}
+			return exit.ExitCode, nil
+		}
+		eventType = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return -1, err
+	}
+	return -1, fmt.Errorf("exec stream ended before the command exited")
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateGRETunnel creates a GRE tunnel on the server
+func (client *Client) CreateGRETunnel(ctx context.Context, request CreateGRETunnelRequest) (*GRETunnel, error) {
+	var tunnel GRETunnel
+	if err := client.do(ctx, http.MethodPost, "/gre", nil, request, &tunnel); err != nil {
+		return nil, err
+	}
+	return &tunnel, nil
+}
+
+// DeleteGRETunnel deletes a GRE tunnel on the server
+func (client *Client) DeleteGRETunnel(ctx context.Context, tunnelName, namespaceName string) error {
+	return client.do(ctx, http.MethodDelete, "/gre/"+url.PathEscape(tunnelName), namespaceQuery(namespaceName), nil, nil)
+}
+
+// CreatePeerTunnels creates a GRE tunnel pair between two namespaces on the server
+func (client *Client) CreatePeerTunnels(ctx context.Context, request CreatePeerTunnelsRequest) ([]*GRETunnel, error) {
+	if err := client.do(ctx, http.MethodPost, "/gre/peer", nil, request, nil); err != nil {
+		return nil, err
+	}
+
+	// The server only reports the tunnel names; fetch the records
+	var tunnels []*GRETunnel
+	for _, tunnelName := range []string{request.Tunnel1Name(), request.Tunnel2Name()} {
+		tunnel, err := client.GetGRETunnel(ctx, tunnelName)
+		if err != nil {
+			return nil, err
+		}
+		tunnels = append(tunnels, tunnel)
+	}
+	return tunnels, nil
+}
+
+// ListGRETunnels returns the GRE tunnels recorded on the server that match the list options, optionally in one namespace
+func (client *Client) ListGRETunnels(ctx context.Context, namespaceName string, options ListOptions) ([]GRETunnel, error) {
+	var tunnels []GRETunnel
+	err := client.do(ctx, http.MethodGet, "/gre", listQuery(namespaceQuery(namespaceName), options), nil, &tunnels)
+	return tunnels, err
+}
+
+// GetGRETunnel returns a recorded GRE tunnel
+func (client *Client) GetGRETunnel(ctx context.Context, tunnelName string) (*GRETunnel, error) {
+	var tunnel GRETunnel
+	if err := client.do(ctx, http.MethodGet, "/gre/"+url.PathEscape(tunnelName), nil, nil, &tunnel); err != nil {
+		return nil, err
+	}
+	return &tunnel, nil
+}
+
+// UpdateGRETunnel changes the endpoints, key or TTL of a recorded GRE tunnel in place
+func (client *Client) UpdateGRETunnel(ctx context.Context, tunnelName string, request UpdateGRETunnelRequest) (*GRETunnel, error) {
+	var tunnel GRETunnel
+	if err := client.do(ctx, http.MethodPatch, "/gre/"+url.PathEscape(tunnelName), nil, request, &tunnel); err != nil {
+		return nil, err
+	}
+	return &tunnel, nil
+}
+
+// GRETunnelInfos returns the GRE tunnels currently present in a namespace (empty = host)
+func (client *Client) GRETunnelInfos(ctx context.Context, namespaceName string) ([]GRETunnelInfo, error) {
+	var tunnelInfos []GRETunnelInfo
+	err := client.do(ctx, http.MethodGet, "/gre/status", namespaceQuery(namespaceName), nil, &tunnelInfos)
+	return tunnelInfos, err
+}
+
+// SetGRETunnelUp brings a GRE tunnel up
+func (client *Client) SetGRETunnelUp(ctx context.Context, tunnelName, namespaceName string) error {
+	path := "/gre/" + url.PathEscape(tunnelName) + "/up"
+	return client.do(ctx, http.MethodPost, path, namespaceQuery(namespaceName), nil, nil)
+}
+
+// SetGRETunnelDown brings a GRE tunnel down
+func (client *Client) SetGRETunnelDown(ctx context.Context, tunnelName, namespaceName string) error {
+	path := "/gre/" + url.PathEscape(tunnelName) + "/down"
+	return client.do(ctx, http.MethodPost, path, namespaceQuery(namespaceName), nil, nil)
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultJobPollInterval is how often WaitJob polls by default
+const DefaultJobPollInterval = 500 * time.Millisecond
+
+// ListJobs returns the jobs visible to the token without their logs, newest first
+// Parameters:
+//   - limit: maximum number of jobs (0 = server default)
+func (client *Client) ListJobs(ctx context.Context, limit int) ([]Job, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var jobs []Job
+	err := client.do(ctx, http.MethodGet, "/jobs", query, nil, &jobs)
+	return jobs, err
+}
+
+// GetJob returns a job with its progress and step logs
+func (client *Client) GetJob(ctx context.Context, jobID int64) (*Job, error) {
+	var job Job
+	if err := client.do(ctx, http.MethodGet, fmt.Sprintf("/jobs/%d", jobID), nil, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CancelJob cancels a queued or running job
+// A running job stops before its next step, so it may still be running
+// when CancelJob returns; use WaitJob to wait for it. Cancelling a finished
+// job fails with an error for which IsConflict is true.
+func (client *Client) CancelJob(ctx context.Context, jobID int64) (*Job, error) {
+	var job Job
+	if err := client.do(ctx, http.MethodDelete, fmt.Sprintf("/jobs/%d", jobID), nil, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// WaitJob polls a job until it finishes and returns it
+// The job is returned whatever its final status; check Status and Error.
+// Parameters:
+//   - jobID: job to wait for
+//   - interval: time between polls (0 = DefaultJobPollInterval)
+func (client *Client) WaitJob(ctx context.Context, jobID int64, interval time.Duration) (*Job, error) {
+	if interval <= 0 {
+		interval = DefaultJobPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job, err := client.GetJob(ctx, jobID)
+		if err != nil || job.IsFinished() {
+			return job, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// StartCreatePeerTunnels queues the creation of a GRE tunnel pair as a background job
+func (client *Client) StartCreatePeerTunnels(ctx context.Context, request CreatePeerTunnelsRequest) (*Job, error) {
+	var job Job
+	if err := client.do(ctx, http.MethodPost, "/gre/peer", asyncQuery(), request, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// StartDeleteNamespace queues the deletion of a namespace as a background job
+func (client *Client) StartDeleteNamespace(ctx context.Context, namespaceName string) (*Job, error) {
+	var job Job
+	if err := client.do(ctx, http.MethodDelete, "/namespaces/"+url.PathEscape(namespaceName), asyncQuery(), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// StartDeleteBySelector queues the deletion of every resource of a type matching a label selector as a background job
+// The job's result holds the names of the deleted resources under "deleted".
+func (client *Client) StartDeleteBySelector(ctx context.Context, resourceType, selector string) (*Job, error) {
+	query := asyncQuery()
+	query.Set("selector", selector)
+
+	var job Job
+	if err := client.do(ctx, http.MethodDelete, "/"+resourceType, query, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// asyncQuery builds the ?async=true parameter that runs an operation as a job
+func asyncQuery() url.Values {
+	return url.Values{"async": {"true"}}
+}
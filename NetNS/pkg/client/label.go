@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// UpdateLabels sets labels of a recorded resource; a nil value removes the label
+// Returns the resulting labels.
+// Parameters:
+//   - resourceType: one of the Label* resource types
+//   - name: resource name, or record ID for addresses and routes
+//   - labels: labels to set or remove
+func (client *Client) UpdateLabels(ctx context.Context, resourceType, name string, labels map[string]*string) (map[string]string, error) {
+	var response struct {
+		Labels map[string]string `json:"labels"`
+	}
+	body := map[string]any{"labels": labels}
+	path := "/" + resourceType + "/" + url.PathEscape(name) + "/labels"
+	if err := client.do(ctx, http.MethodPatch, path, nil, body, &response); err != nil {
+		return nil, err
+	}
+	return response.Labels, nil
+}
+
+// DeleteBySelector deletes every recorded resource of a type matching a label selector
+// Returns the names of the deleted resources (addresses and destinations for
+// addresses and routes).
+func (client *Client) DeleteBySelector(ctx context.Context, resourceType, selector string) ([]string, error) {
+	var response struct {
+		Deleted []string `json:"deleted"`
+	}
+	query := url.Values{"selector": {selector}}
+	if err := client.do(ctx, http.MethodDelete, "/"+resourceType, query, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Deleted, nil
+}
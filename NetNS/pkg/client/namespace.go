@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateNamespace creates a namespace on the server
+func (client *Client) CreateNamespace(ctx context.Context, request CreateNamespaceRequest) (*Namespace, error) {
+	var namespace Namespace
+	if err := client.do(ctx, http.MethodPost, "/namespaces", nil, request, &namespace); err != nil {
+		return nil, err
+	}
+	return &namespace, nil
+}
+
+// DeleteNamespace deletes a namespace on the server
+func (client *Client) DeleteNamespace(ctx context.Context, namespaceName string) error {
+	return client.do(ctx, http.MethodDelete, "/namespaces/"+url.PathEscape(namespaceName), nil, nil, nil)
+}
+
+// ListNamespaces returns the namespaces recorded on the server that match the list options
+func (client *Client) ListNamespaces(ctx context.Context, options ListOptions) ([]Namespace, error) {
+	var namespaces []Namespace
+	err := client.do(ctx, http.MethodGet, "/namespaces", listQuery(url.Values{}, options), nil, &namespaces)
+	return namespaces, err
+}
+
+// GetNamespace returns a recorded namespace with the resources recorded in it
+func (client *Client) GetNamespace(ctx context.Context, namespaceName string) (*NamespaceWithDetails, error) {
+	var details NamespaceWithDetails
+	if err := client.do(ctx, http.MethodGet, "/namespaces/"+url.PathEscape(namespaceName), nil, nil, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// UpdateNamespace replaces the labels of a recorded namespace
+func (client *Client) UpdateNamespace(ctx context.Context, namespaceName string, request UpdateNamespaceRequest) (*NamespaceWithDetails, error) {
+	var namespace NamespaceWithDetails
+	if err := client.do(ctx, http.MethodPut, "/namespaces/"+url.PathEscape(namespaceName), nil, request, &namespace); err != nil {
+		return nil, err
+	}
+	return &namespace, nil
+}
+
+// NamespaceStatuses compares the namespaces in the server's kernel with the recorded ones
+func (client *Client) NamespaceStatuses(ctx context.Context) ([]NamespaceStatus, error) {
+	var namespaceStatuses []NamespaceStatus
+	err := client.do(ctx, http.MethodGet, "/namespaces/status", nil, nil, &namespaceStatuses)
+	return namespaceStatuses, err
+}
@@ -0,0 +1,56 @@
+package client
+
+import "context"
+
+// requestOptionsKey is the context key of the per-request options
+type requestOptionsKey struct{}
+
+// requestOptions are headers set on, and read from, the requests made with a context
+type requestOptions struct {
+	idempotencyKey string  // Idempotency-Key sent with POSTs
+	ifMatch        string  // If-Match sent with changes
+	etag           *string // Receives the ETag of the response
+	nextCursor     *string // Receives the next-page cursor of a list response
+}
+
+// WithIdempotencyKey makes the POSTs sent with the returned context safe to retry
+// The server replays its stored response when it sees a POST repeated with the
+// same key, instead of running it again. Use a new key (e.g. a UUID) for each
+// operation and the same key for its retries.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	options := requestOptionsFrom(ctx)
+	options.idempotencyKey = key
+	return context.WithValue(ctx, requestOptionsKey{}, options)
+}
+
+// WithIfMatch makes the changes sent with the returned context conditional on an ETag
+// A change to a resource that no longer has this ETag fails with an error
+// for which IsPreconditionFailed is true.
+func WithIfMatch(ctx context.Context, etag string) context.Context {
+	options := requestOptionsFrom(ctx)
+	options.ifMatch = etag
+	return context.WithValue(ctx, requestOptionsKey{}, options)
+}
+
+// WithETag stores the ETag of the responses to requests sent with the returned context in target
+// GetNamespace, GetVeth, GetBridge, GetGRETunnel, GetAddress and GetRoute responses carry one.
+func WithETag(ctx context.Context, target *string) context.Context {
+	options := requestOptionsFrom(ctx)
+	options.etag = target
+	return context.WithValue(ctx, requestOptionsKey{}, options)
+}
+
+// WithNextCursor stores the next-page cursor of the list responses to requests sent with the returned context in target
+// The cursor is empty after the last page; pass it as ListOptions.Cursor to
+// get the next one.
+func WithNextCursor(ctx context.Context, target *string) context.Context {
+	options := requestOptionsFrom(ctx)
+	options.nextCursor = target
+	return context.WithValue(ctx, requestOptionsKey{}, options)
+}
+
+// requestOptionsFrom returns the request options of a context
+func requestOptionsFrom(ctx context.Context) requestOptions {
+	options, _ := ctx.Value(requestOptionsKey{}).(requestOptions)
+	return options
+}
@@ -0,0 +1,20 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// QuotaUsages returns the quotas and usage of the token's project
+// Unrestricted tokens get every project, or the one named by projectName.
+func (client *Client) QuotaUsages(ctx context.Context, projectName string) ([]ProjectQuotaUsage, error) {
+	query := url.Values{}
+	if projectName != "" {
+		query.Set("project", projectName)
+	}
+
+	var quotaUsages []ProjectQuotaUsage
+	err := client.do(ctx, http.MethodGet, "/quotas", query, nil, &quotaUsages)
+	return quotaUsages, err
+}
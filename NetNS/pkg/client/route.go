@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// AddRoute adds a route on the server
+func (client *Client) AddRoute(ctx context.Context, request AddRouteRequest) (*Route, error) {
+	var route Route
+	if err := client.do(ctx, http.MethodPost, "/routes", nil, request, &route); err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// DeleteRoute deletes a route by destination on the server, recorded or not
+func (client *Client) DeleteRoute(ctx context.Context, destination, namespaceName string) error {
+	query := namespaceQuery(namespaceName)
+	query.Set("destination", destination)
+	return client.do(ctx, http.MethodDelete, "/routes", query, nil, nil)
+}
+
+// DeleteRouteByID deletes a recorded route
+func (client *Client) DeleteRouteByID(ctx context.Context, id int64) error {
+	return client.do(ctx, http.MethodDelete, "/routes/"+strconv.FormatInt(id, 10), nil, nil, nil)
+}
+
+// GetRoute returns a recorded route
+func (client *Client) GetRoute(ctx context.Context, id int64) (*Route, error) {
+	var route Route
+	if err := client.do(ctx, http.MethodGet, "/routes/"+strconv.FormatInt(id, 10), nil, nil, &route); err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// ReplaceRoute changes the gateway and interface of a recorded route in place
+func (client *Client) ReplaceRoute(ctx context.Context, id int64, request ReplaceRouteRequest) (*Route, error) {
+	var route Route
+	if err := client.do(ctx, http.MethodPut, "/routes/"+strconv.FormatInt(id, 10), nil, request, &route); err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// ListRoutes returns the routes recorded on the server that match the list options, optionally in one namespace
+func (client *Client) ListRoutes(ctx context.Context, namespaceName string, options ListOptions) ([]Route, error) {
+	var routes []Route
+	err := client.do(ctx, http.MethodGet, "/routes", listQuery(namespaceQuery(namespaceName), options), nil, &routes)
+	return routes, err
+}
+
+// RouteInfos returns the routes currently present in a namespace (empty = host)
+func (client *Client) RouteInfos(ctx context.Context, namespaceName string) ([]RouteInfo, error) {
+	var routeInfos []RouteInfo
+	err := client.do(ctx, http.MethodGet, "/routes/status", namespaceQuery(namespaceName), nil, &routeInfos)
+	return routeInfos, err
+}
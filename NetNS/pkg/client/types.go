@@ -0,0 +1,434 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The types below mirror the JSON the server sends and accepts. They are
+// declared here, rather than shared with the server, so that the client only
+// depends on the standard library.
+
+// Namespace is a recorded network namespace
+type Namespace struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	CreatedAt time.Time         `json:"created_at"`
+	ProjectID *int64            `json:"project_id,omitempty"` // Owning project (nil = none)
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// NamespaceWithDetails is a namespace with the resources recorded in it
+type NamespaceWithDetails struct {
+	Namespace
+	VethPairs   []VethPair       `json:"veth_pairs,omitempty"`
+	IPAddresses []IPAddress      `json:"ip_addresses,omitempty"`
+	Routes      []Route          `json:"routes,omitempty"`
+	Bridges     []Bridge         `json:"bridges,omitempty"`
+	GRETunnels  []GRETunnel      `json:"gre_tunnels,omitempty"`
+	Bonds       []Bond           `json:"bonds,omitempty"`
+	Macvlans    []MacvlanLink    `json:"macvlans,omitempty"`
+	Dummies     []DummyInterface `json:"dummies,omitempty"`
+	Qdiscs      []Qdisc          `json:"qdiscs,omitempty"`
+}
+
+// VethPair is a recorded virtual ethernet pair
+type VethPair struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	PeerName  string            `json:"peer_name"`
+	NsID      *int64            `json:"ns_id,omitempty"`
+	PeerNsID  *int64            `json:"peer_ns_id,omitempty"`
+	ProjectID *int64            `json:"project_id,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// IPAddress is a recorded interface address
+type IPAddress struct {
+	ID            int64             `json:"id"`
+	InterfaceName string            `json:"interface_name"`
+	NsID          *int64            `json:"ns_id,omitempty"`
+	Address       string            `json:"address"`                 // CIDR format
+	AddressLabel  string            `json:"address_label,omitempty"` // IPv4 address label (empty = interface name)
+	ProjectID     *int64            `json:"project_id,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// Route is a recorded route
+type Route struct {
+	ID            int64             `json:"id"`
+	NsID          *int64            `json:"ns_id,omitempty"`
+	Destination   string            `json:"destination"` // CIDR or "default"
+	Gateway       string            `json:"gateway,omitempty"`
+	InterfaceName string            `json:"interface_name,omitempty"`
+	ProjectID     *int64            `json:"project_id,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// Bridge is a recorded bridge
+type Bridge struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	NsID      *int64            `json:"ns_id,omitempty"`
+	ProjectID *int64            `json:"project_id,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// GRETunnel is a recorded GRE tunnel
+type GRETunnel struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`      // Tunnel interface name (e.g., gre1)
+	LocalIP   string            `json:"local_ip"`  // Local endpoint IP address
+	RemoteIP  string            `json:"remote_ip"` // Remote endpoint IP address
+	Key       uint32            `json:"key"`       // GRE key for multiplexing (0 = no key)
+	TTL       uint8             `json:"ttl"`       // Time to live (0 = inherit)
+	NsID      *int64            `json:"ns_id"`     // Namespace where tunnel is created
+	ProjectID *int64            `json:"project_id,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Bond is a recorded bond interface
+type Bond struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	Mode      string            `json:"mode"`              // active-backup, balance-rr, 802.3ad, ...
+	Miimon    int               `json:"miimon"`            // MII monitoring interval in ms (0 = disabled)
+	Primary   string            `json:"primary,omitempty"` // Preferred slave (active-backup)
+	NsID      *int64            `json:"ns_id,omitempty"`
+	Slaves    []string          `json:"slaves,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// MacvlanLink is a recorded macvlan or ipvlan interface
+type MacvlanLink struct {
+	ID         int64             `json:"id"`
+	Name       string            `json:"name"`
+	Kind       string            `json:"kind"` // "macvlan" or "ipvlan"
+	Mode       string            `json:"mode"` // bridge/vepa/private/passthru or l2/l3/l3s
+	Parent     string            `json:"parent"`
+	ParentNsID *int64            `json:"parent_ns_id,omitempty"` // Namespace where parent exists (nil = host)
+	NsID       *int64            `json:"ns_id,omitempty"`        // Namespace the link was moved into (nil = host)
+	Labels     map[string]string `json:"labels,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// DummyInterface is a recorded dummy interface
+type DummyInterface struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	NsID      *int64            `json:"ns_id,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Qdisc is a recorded traffic impairment of an interface
+type Qdisc struct {
+	ID               int64     `json:"id"`
+	InterfaceName    string    `json:"interface_name"`
+	NsID             *int64    `json:"ns_id,omitempty"`
+	Shaper           string    `json:"shaper,omitempty"`
+	DelayMs          float64   `json:"delay_ms"`
+	JitterMs         float64   `json:"jitter_ms"`
+	LossPercent      float64   `json:"loss_percent"`
+	ReorderPercent   float64   `json:"reorder_percent"`
+	DuplicatePercent float64   `json:"duplicate_percent"`
+	CorruptPercent   float64   `json:"corrupt_percent"`
+	RateKbit         uint64    `json:"rate_kbit"`
+	CeilKbit         uint64    `json:"ceil_kbit"`
+	BurstBytes       uint32    `json:"burst_bytes"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// NamespaceStatus is the live state of a namespace
+type NamespaceStatus struct {
+	Name      string            `json:"name"`
+	Status    string            `json:"status"`
+	CreatedAt *time.Time        `json:"created_at,omitempty"` // Nil for untracked namespaces
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// AddressInfo is an address as configured in the kernel
+type AddressInfo struct {
+	Interface         string `json:"interface"`
+	Address           string `json:"address"`
+	Family            string `json:"family"`
+	Scope             string `json:"scope"`
+	Label             string `json:"label,omitempty"`              // IPv4 address label
+	ValidLifetime     int    `json:"valid_lifetime,omitempty"`     // Seconds left (0 = forever)
+	PreferredLifetime int    `json:"preferred_lifetime,omitempty"` // Seconds left (0 = forever)
+}
+
+// RouteInfo is a route as configured in the kernel
+type RouteInfo struct {
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway,omitempty"`
+	Interface   string `json:"interface,omitempty"`
+	Scope       string `json:"scope"`
+	Protocol    string `json:"protocol"`
+}
+
+// BridgeInfo is a bridge as configured in the kernel
+type BridgeInfo struct {
+	Name  string   `json:"name"`
+	Ports []string `json:"ports"`
+	State string   `json:"state"`
+}
+
+// GRETunnelInfo is a GRE tunnel as configured in the kernel
+type GRETunnelInfo struct {
+	Name     string `json:"name"`
+	LocalIP  string `json:"local_ip"`
+	RemoteIP string `json:"remote_ip"`
+	Key      uint32 `json:"key,omitempty"`
+	TTL      uint8  `json:"ttl,omitempty"`
+	State    string `json:"state"`
+}
+
+// Resource types that carry labels, as used by UpdateLabels and DeleteBySelector
+const (
+	LabelNamespaces = "namespaces"
+	LabelVeths      = "veths"
+	LabelAddresses  = "addresses"
+	LabelRoutes     = "routes"
+	LabelBridges    = "bridges"
+	LabelGRETunnels = "gre"
+	LabelMacvlans   = "macvlans"
+	LabelBonds      = "bonds"
+	LabelDummies    = "dummies"
+)
+
+// ListOptions narrows the records returned by the List methods
+type ListOptions struct {
+	Selector      string    `json:"selector,omitempty"`       // Label selector, e.g. "env=lab,team!=net"
+	Interface     string    `json:"interface,omitempty"`      // Only records on this interface
+	Within        string    `json:"within,omitempty"`         // Only records whose addresses lie inside this CIDR
+	CreatedAfter  time.Time `json:"created_after,omitempty"`  // Only records created at or after this time
+	CreatedBefore time.Time `json:"created_before,omitempty"` // Only records created before this time
+	Sort          string    `json:"sort,omitempty"`           // Sort field, "-" prefix for descending
+	Limit         int       `json:"limit,omitempty"`          // Page size (0 = everything)
+	Cursor        string    `json:"cursor,omitempty"`         // Next-page cursor returned with the previous page
+}
+
+// QuotaUsage is the usage and limit of one resource of a project
+type QuotaUsage struct {
+	Resource string `json:"resource"`
+	Used     int    `json:"used"`
+	Limit    *int   `json:"limit"` // Nil = unlimited
+}
+
+// ProjectQuotaUsage lists the quotas of a project
+type ProjectQuotaUsage struct {
+	Project string       `json:"project"`
+	Quotas  []QuotaUsage `json:"quotas"`
+}
+
+// Job is a background operation started with one of the Start* methods
+type Job struct {
+	ID         int64           `json:"id"`
+	Operation  string          `json:"operation"` // e.g. "create gre peer"
+	Status     string          `json:"status"`
+	Actor      string          `json:"actor"`                // API principal that submitted the job
+	ProjectID  *int64          `json:"project_id,omitempty"` // Project of the submitting token (nil = none)
+	StepsDone  int             `json:"steps_done"`
+	Result     json.RawMessage `json:"result,omitempty"` // Success response of the operation
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Logs       []JobLog        `json:"logs,omitempty"` // Only filled by GetJob
+}
+
+// JobLog is one step log line of a job
+type JobLog struct {
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+}
+
+// Job statuses
+const (
+	JobQueued      = "queued"
+	JobRunning     = "running"
+	JobSucceeded   = "succeeded"
+	JobFailed      = "failed"
+	JobCancelled   = "cancelled"
+	JobInterrupted = "interrupted" // Queued or running when the server stopped
+)
+
+// IsFinished reports whether the job has reached a final status
+func (job *Job) IsFinished() bool {
+	switch job.Status {
+	case JobSucceeded, JobFailed, JobCancelled, JobInterrupted:
+		return true
+	}
+	return false
+}
+
+// Workload is a supervised command running inside a namespace
+type Workload struct {
+	ID             int64      `json:"id"`
+	Name           string     `json:"name"`
+	NsID           *int64     `json:"ns_id"`
+	Command        []string   `json:"command"`
+	Env            []string   `json:"env,omitempty"` // KEY=value entries added to a minimal environment
+	Dir            string     `json:"dir,omitempty"` // Working directory (empty = /)
+	MountNamespace bool       `json:"mount_namespace"`
+	RestartPolicy  string     `json:"restart_policy"` // never, on-failure or always
+	MaxRestarts    int        `json:"max_restarts"`   // 0 = unlimited
+	RestartDelay   int        `json:"restart_delay"`  // Seconds before a restart; doubles while the command keeps failing quickly
+	LogDir         string     `json:"log_dir"`        // Holds stdout.log and stderr.log on the server
+	Status         string     `json:"status"`
+	SupervisorPID  int        `json:"supervisor_pid,omitempty"`
+	PID            int        `json:"pid,omitempty"`       // Of the running command
+	Restarts       int        `json:"restarts"`            // Since the last start
+	ExitCode       *int       `json:"exit_code,omitempty"` // Of the last run (-1 = ended by a signal)
+	Error          string     `json:"error,omitempty"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Workload statuses
+const (
+	WorkloadStarting   = "starting" // Supervisor launched, command not started yet
+	WorkloadRunning    = "running"
+	WorkloadRestarting = "restarting" // Waiting to restart the command after it ended
+	WorkloadStopped    = "stopped"    // Stopped on request
+	WorkloadExited     = "exited"     // Ended successfully and not restarted
+	WorkloadFailed     = "failed"     // Failed to start, or ended with an error and not restarted
+	WorkloadLost       = "lost"       // Was active but its supervisor died without recording an end (e.g. a reboot)
+)
+
+// CreateNamespaceRequest describes a namespace to create
+type CreateNamespaceRequest struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// UpdateNamespaceRequest replaces the labels of a namespace
+type UpdateNamespaceRequest struct {
+	Labels map[string]string `json:"labels"` // Replaces all labels (empty = remove all)
+}
+
+// CreateVethRequest describes a veth pair to create
+type CreateVethRequest struct {
+	Name          string            `json:"name"`
+	PeerName      string            `json:"peer_name"`
+	Namespace     string            `json:"namespace"`      // Namespace for the interface (empty = host)
+	PeerNamespace string            `json:"peer_namespace"` // Namespace for the peer (empty = host)
+	Labels        map[string]string `json:"labels,omitempty"`
+}
+
+// AddressRequest identifies an address of an interface
+type AddressRequest struct {
+	Interface string            `json:"interface"`
+	Address   string            `json:"address"`   // CIDR notation, e.g. 10.0.0.1/24
+	Namespace string            `json:"namespace"` // Empty = host
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// UpdateAddressRequest changes the label and lifetimes of an address in place
+type UpdateAddressRequest struct {
+	AddressLabel      *string `json:"address_label,omitempty"` // IPv4 only; starts with the interface name (empty = interface name, omitted = unchanged)
+	ValidLifetime     int     `json:"valid_lifetime"`          // Seconds until the address is removed (0 = forever)
+	PreferredLifetime int     `json:"preferred_lifetime"`      // Seconds until the address is deprecated (0 = when it is removed)
+}
+
+// AddRouteRequest describes a route to add
+type AddRouteRequest struct {
+	Destination string            `json:"destination"` // CIDR notation or "default"
+	Gateway     string            `json:"gateway"`
+	Interface   string            `json:"interface"`
+	Namespace   string            `json:"namespace"` // Empty = host
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// ReplaceRouteRequest changes the next hop of a route in place
+type ReplaceRouteRequest struct {
+	Gateway   string `json:"gateway"`
+	Interface string `json:"interface"`
+}
+
+// CreateBridgeRequest describes a bridge to create
+type CreateBridgeRequest struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"` // Empty = host
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// BridgePortRequest identifies an interface attached to a bridge
+type BridgePortRequest struct {
+	Bridge    string `json:"bridge"`
+	Interface string `json:"interface"`
+	Namespace string `json:"namespace"` // Empty = host
+}
+
+// CreateGRETunnelRequest describes a GRE tunnel to create
+type CreateGRETunnelRequest struct {
+	Name      string            `json:"name"`
+	LocalIP   string            `json:"local_ip"`
+	RemoteIP  string            `json:"remote_ip"`
+	Key       uint32            `json:"key"`       // GRE key (0 = no key)
+	TTL       uint8             `json:"ttl"`       // Time to live (0 = inherit)
+	Namespace string            `json:"namespace"` // Empty = host
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// UpdateGRETunnelRequest changes the fields of a GRE tunnel that are set
+type UpdateGRETunnelRequest struct {
+	LocalIP  *string `json:"local_ip,omitempty"`
+	RemoteIP *string `json:"remote_ip,omitempty"`
+	Key      *uint32 `json:"key,omitempty"` // GRE key (0 = remove the key)
+	TTL      *uint8  `json:"ttl,omitempty"` // Time to live (0 = inherit)
+}
+
+// CreatePeerTunnelsRequest describes a GRE tunnel pair between two namespaces
+// The tunnels are named <tunnel_name>-1 (in ns1) and <tunnel_name>-2 (in ns2).
+type CreatePeerTunnelsRequest struct {
+	TunnelName  string            `json:"tunnel_name"` // Up to 13 characters, leaving room for the "-1"/"-2" suffix
+	Ns1         string            `json:"ns1"`
+	Ns1IP       string            `json:"ns1_ip"`        // Underlay endpoint in ns1
+	Ns1TunnelIP string            `json:"ns1_tunnel_ip"` // Address of the tunnel interface in ns1
+	Ns2         string            `json:"ns2"`
+	Ns2IP       string            `json:"ns2_ip"`        // Underlay endpoint in ns2
+	Ns2TunnelIP string            `json:"ns2_tunnel_ip"` // Address of the tunnel interface in ns2
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// Tunnel1Name returns the name of the tunnel created in ns1
+func (request CreatePeerTunnelsRequest) Tunnel1Name() string {
+	return request.TunnelName + "-1"
+}
+
+// Tunnel2Name returns the name of the tunnel created in ns2
+func (request CreatePeerTunnelsRequest) Tunnel2Name() string {
+	return request.TunnelName + "-2"
+}
+
+// ExecRequest describes a command to run inside a namespace
+type ExecRequest struct {
+	Command        []string `json:"command"`
+	Env            []string `json:"env,omitempty"`             // KEY=value entries added to a minimal environment
+	Dir            string   `json:"dir,omitempty"`             // Working directory (empty = the server's)
+	Stdin          string   `json:"stdin,omitempty"`           // Fed to the command's standard input
+	Timeout        int      `json:"timeout,omitempty"`         // Seconds before the command is killed (0 = no limit)
+	MountNamespace *bool    `json:"mount_namespace,omitempty"` // Private mount namespace with /etc/netns overlays and sysfs (default true)
+}
+
+// RunWorkloadRequest describes a command to run and supervise inside a namespace
+type RunWorkloadRequest struct {
+	Name           string   `json:"name"`
+	Namespace      string   `json:"namespace"`
+	Command        []string `json:"command"`
+	Env            []string `json:"env,omitempty"`             // KEY=value entries added to a minimal environment
+	Dir            string   `json:"dir,omitempty"`             // Working directory (empty = /)
+	RestartPolicy  string   `json:"restart_policy,omitempty"`  // never, on-failure (default) or always
+	MaxRestarts    int      `json:"max_restarts,omitempty"`    // 0 = unlimited
+	RestartDelay   int      `json:"restart_delay,omitempty"`   // Seconds (0 = 1)
+	MountNamespace *bool    `json:"mount_namespace,omitempty"` // Private mount namespace with /etc/netns overlays and sysfs (default true)
+}
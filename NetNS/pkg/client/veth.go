@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateVeth creates a veth pair on the server
+func (client *Client) CreateVeth(ctx context.Context, request CreateVethRequest) (*VethPair, error) {
+	var vethPair VethPair
+	if err := client.do(ctx, http.MethodPost, "/veths", nil, request, &vethPair); err != nil {
+		return nil, err
+	}
+	return &vethPair, nil
+}
+
+// DeleteVeth deletes a veth pair on the server
+func (client *Client) DeleteVeth(ctx context.Context, interfaceName string) error {
+	return client.do(ctx, http.MethodDelete, "/veths/"+url.PathEscape(interfaceName), nil, nil, nil)
+}
+
+// ListVeths returns the veth pairs recorded on the server that match the list options
+// Parameters:
+//   - namespaceName: only pairs with an end in this namespace (empty = all)
+//   - options: filters, sort and page of the list
+func (client *Client) ListVeths(ctx context.Context, namespaceName string, options ListOptions) ([]VethPair, error) {
+	var vethPairs []VethPair
+	err := client.do(ctx, http.MethodGet, "/veths", listQuery(namespaceQuery(namespaceName), options), nil, &vethPairs)
+	return vethPairs, err
+}
+
+// GetVeth returns a recorded veth pair
+func (client *Client) GetVeth(ctx context.Context, vethName string) (*VethPair, error) {
+	var vethPair VethPair
+	if err := client.do(ctx, http.MethodGet, "/veths/"+url.PathEscape(vethName), nil, nil, &vethPair); err != nil {
+		return nil, err
+	}
+	return &vethPair, nil
+}
+
+// SetVethUp brings a veth end up
+func (client *Client) SetVethUp(ctx context.Context, interfaceName, namespaceName string) error {
+	path := "/veths/" + url.PathEscape(interfaceName) + "/up"
+	return client.do(ctx, http.MethodPost, path, namespaceQuery(namespaceName), nil, nil)
+}
+
+// SetVethDown brings a veth end down
+func (client *Client) SetVethDown(ctx context.Context, interfaceName, namespaceName string) error {
+	path := "/veths/" + url.PathEscape(interfaceName) + "/down"
+	return client.do(ctx, http.MethodPost, path, namespaceQuery(namespaceName), nil, nil)
+}
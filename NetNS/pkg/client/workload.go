@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// RunWorkload records a workload on the server and starts supervising it
+func (client *Client) RunWorkload(ctx context.Context, request RunWorkloadRequest) (*Workload, error) {
+	var workload Workload
+	if err := client.do(ctx, http.MethodPost, "/workloads", nil, request, &workload); err != nil {
+		return nil, err
+	}
+	return &workload, nil
+}
+
+// ListWorkloads returns the workloads on the server with their status
+// Parameters:
+//   - namespaceName: only list the workloads of this namespace (empty = all)
+func (client *Client) ListWorkloads(ctx context.Context, namespaceName string) ([]Workload, error) {
+	var workloads []Workload
+	err := client.do(ctx, http.MethodGet, "/workloads", namespaceQuery(namespaceName), nil, &workloads)
+	return workloads, err
+}
+
+// GetWorkload returns a workload with its status
+func (client *Client) GetWorkload(ctx context.Context, workloadName string) (*Workload, error) {
+	return client.workloadRequest(ctx, http.MethodGet, "/workloads/"+url.PathEscape(workloadName))
+}
+
+// StartWorkload starts a stopped or ended workload
+func (client *Client) StartWorkload(ctx context.Context, workloadName string) (*Workload, error) {
+	return client.workloadRequest(ctx, http.MethodPost, "/workloads/"+url.PathEscape(workloadName)+"/start")
+}
+
+// StopWorkload stops a workload and returns it once it has stopped
+func (client *Client) StopWorkload(ctx context.Context, workloadName string) (*Workload, error) {
+	return client.workloadRequest(ctx, http.MethodPost, "/workloads/"+url.PathEscape(workloadName)+"/stop")
+}
+
+// DeleteWorkload stops a workload and removes it with its logs
+func (client *Client) DeleteWorkload(ctx context.Context, workloadName string) error {
+	return client.do(ctx, http.MethodDelete, "/workloads/"+url.PathEscape(workloadName), nil, nil, nil)
+}
+
+// WorkloadLogs returns the end of a workload's stdout or stderr log
+// Parameters:
+//   - workloadName: workload to read the log of
+//   - stream: "stdout" or "stderr" (empty = stdout)
+//   - lines: number of lines from the end (0 = server default, negative = all)
+func (client *Client) WorkloadLogs(ctx context.Context, workloadName, stream string, lines int) (string, error) {
+	query := url.Values{}
+	if stream != "" {
+		query.Set("stream", stream)
+	}
+	if lines != 0 {
+		query.Set("lines", strconv.Itoa(lines))
+	}
+
+	path := "/workloads/" + url.PathEscape(workloadName) + "/logs"
+	request, err := client.newRequest(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Accept", "text/plain")
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach server: %w", err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		return "", responseError(response.StatusCode, http.MethodGet, path, responseBody)
+	}
+	return string(responseBody), nil
+}
+
+// workloadRequest sends a bodiless request that returns a workload
+func (client *Client) workloadRequest(ctx context.Context, method, path string) (*Workload, error) {
+	var workload Workload
+	if err := client.do(ctx, method, path, nil, nil, &workload); err != nil {
+		return nil, err
+	}
+	return &workload, nil
+}
@@ -310,6 +310,258 @@ create_gre_tunnel() {
     log_success "Created GRE tunnel: $tunnel_name (local=$local_ip, remote=$remote_ip)"
 }
 
+# Create macvlan or ipvlan link on a parent interface
+# Parameters:
+#   $1 = link_name             : Name of the link interface
+#   $2 = kind                  : "macvlan" or "ipvlan"
+#   $3 = mode                  : macvlan mode (bridge/vepa/private/passthru) or ipvlan mode (l2/l3/l3s)
+#   $4 = parent_name           : Parent interface name
+#   $5 = parent_namespace_name : Namespace where parent exists (optional, empty = host)
+#   $6 = namespace_name        : Namespace to move link into (optional, empty = host)
+create_macvlan_link() {
+    local link_name=$1
+    local kind=$2
+    local mode=$3
+    local parent_name=$4
+    local parent_namespace_name=$5
+    local namespace_name=$6
+
+    local parent_exec=""
+    if [[ -n "$parent_namespace_name" && "$parent_namespace_name" != "NULL" ]]; then
+        parent_exec="ip netns exec $parent_namespace_name"
+    fi
+
+    local target_exec=""
+    if [[ -n "$namespace_name" && "$namespace_name" != "NULL" ]]; then
+        target_exec="ip netns exec $namespace_name"
+    fi
+
+    # Check if link already exists in target
+    if $target_exec ip link show "$link_name" &>/dev/null; then
+        log_warn "${kind} '$link_name' already exists, skipping"
+        return 0
+    fi
+
+    # Create link on parent
+    $parent_exec ip link add "$link_name" link "$parent_name" type "$kind" mode "$mode" 2>/dev/null || {
+        log_warn "Failed to create ${kind} '$link_name' on $parent_name"
+        return 0
+    }
+
+    # Move to target namespace if it differs from the parent's
+    if [[ "$namespace_name" != "$parent_namespace_name" ]]; then
+        if [[ -n "$target_exec" ]]; then
+            $parent_exec ip link set "$link_name" netns "$namespace_name"
+        else
+            $parent_exec ip link set "$link_name" netns 1
+        fi
+    fi
+
+    $target_exec ip link set "$link_name" up 2>/dev/null || true
+
+    log_success "Created ${kind}: $link_name (parent=$parent_name, mode=$mode)"
+}
+
+# Create bond and enslave interfaces
+# Parameters:
+#   $1 = bond_name      : Name of the bond interface
+#   $2 = mode           : Bond mode (active-backup, balance-rr, 802.3ad, ...)
+#   $3 = miimon         : MII monitoring interval in ms (0 = disabled)
+#   $4 = primary        : Preferred slave (optional)
+#   $5 = slaves         : Space-separated slave interface names
+#   $6 = namespace_name : Namespace to create bond in (optional, empty = host)
+create_bond() {
+    local bond_name=$1
+    local mode=$2
+    local miimon=$3
+    local primary=$4
+    local slaves=$5
+    local namespace_name=$6
+
+    local ns_exec=""
+    if [[ -n "$namespace_name" && "$namespace_name" != "NULL" ]]; then
+        ns_exec="ip netns exec $namespace_name"
+    fi
+
+    # Check if bond already exists
+    if $ns_exec ip link show "$bond_name" &>/dev/null; then
+        log_warn "Bond '$bond_name' already exists, skipping"
+        return 0
+    fi
+
+    $ns_exec ip link add "$bond_name" type bond mode "$mode" miimon "${miimon:-0}"
+
+    # Slaves must be down to join a bond
+    for slave in $slaves; do
+        $ns_exec ip link set "$slave" down 2>/dev/null || true
+        $ns_exec ip link set "$slave" master "$bond_name" 2>/dev/null || log_warn "Failed to enslave $slave to $bond_name"
+        $ns_exec ip link set "$slave" up 2>/dev/null || true
+    done
+
+    if [[ -n "$primary" && "$primary" != "NULL" ]]; then
+        $ns_exec ip link set "$bond_name" type bond primary "$primary" 2>/dev/null || true
+    fi
+
+    $ns_exec ip link set "$bond_name" up
+
+    log_success "Created bond: $bond_name (mode=$mode, slaves=$slaves)"
+}
+
+# Create dummy interface
+# Parameters:
+#   $1 = interface_name : Name of the dummy interface
+#   $2 = namespace_name : Namespace to create interface in (optional, empty = host)
+create_dummy() {
+    local interface_name=$1
+    local namespace_name=$2
+
+    local ns_exec=""
+    if [[ -n "$namespace_name" && "$namespace_name" != "NULL" ]]; then
+        ns_exec="ip netns exec $namespace_name"
+    fi
+
+    if $ns_exec ip link show "$interface_name" &>/dev/null; then
+        log_warn "Dummy interface '$interface_name' already exists, skipping"
+        return 0
+    fi
+
+    $ns_exec ip link add "$interface_name" type dummy
+    $ns_exec ip link set "$interface_name" up
+
+    log_success "Created dummy interface: $interface_name"
+}
+
+# Apply recorded link properties to an interface
+# Parameters:
+#   $1 = interface_name : Interface to modify
+#   $2 = mtu            : MTU in bytes (0 = unchanged)
+#   $3 = mac_address    : MAC address (optional)
+#   $4 = txqueuelen     : Transmit queue length (0 = unchanged)
+#   $5 = alias          : Interface alias (optional)
+#   $6 = state          : "up" or "down" (optional)
+#   $7 = namespace_name : Namespace where interface exists (optional, empty = host)
+apply_link_properties() {
+    local interface_name=$1
+    local mtu=$2
+    local mac_address=$3
+    local txqueuelen=$4
+    local alias=$5
+    local state=$6
+    local namespace_name=$7
+
+    local ns_exec=""
+    if [[ -n "$namespace_name" && "$namespace_name" != "NULL" ]]; then
+        ns_exec="ip netns exec $namespace_name"
+    fi
+
+    if ! $ns_exec ip link show "$interface_name" &>/dev/null; then
+        log_warn "Interface '$interface_name' not found, skipping link properties"
+        return 0
+    fi
+
+    [[ "$mtu" -gt 0 ]] && $ns_exec ip link set "$interface_name" mtu "$mtu"
+    if [[ -n "$mac_address" ]]; then
+        # Many drivers require the link down to change the MAC address
+        $ns_exec ip link set "$interface_name" down
+        $ns_exec ip link set "$interface_name" address "$mac_address"
+        $ns_exec ip link set "$interface_name" up
+    fi
+    [[ "$txqueuelen" -gt 0 ]] && $ns_exec ip link set "$interface_name" txqueuelen "$txqueuelen"
+    [[ -n "$alias" ]] && $ns_exec ip link set "$interface_name" alias "$alias"
+    [[ -n "$state" ]] && $ns_exec ip link set "$interface_name" "$state"
+
+    log_success "Applied link properties on $interface_name"
+}
+
+# Check whether a (possibly fractional) number is greater than zero
+# Parameters:
+#   $1 = value : Number to check
+positive() {
+    awk -v value="$1" 'BEGIN { exit !(value > 0) }'
+}
+
+# Apply traffic impairment (netem, optionally under a tbf/htb rate limiter)
+# Parameters:
+#   $1  = interface_name    : Interface to impair
+#   $2  = shaper            : Rate limiter, "tbf" or "htb" (used only with a rate)
+#   $3  = delay_ms          : One-way latency in milliseconds
+#   $4  = jitter_ms         : Latency variation in milliseconds
+#   $5  = loss_percent      : Random packet loss in percent
+#   $6  = reorder_percent   : Percentage of packets reordered
+#   $7  = duplicate_percent : Random packet duplication in percent
+#   $8  = corrupt_percent   : Random packet corruption in percent
+#   $9  = rate_kbit         : Rate limit in kbit/s (0 = unlimited)
+#   $10 = ceil_kbit         : HTB ceiling in kbit/s (0 = same as rate)
+#   $11 = burst_bytes       : TBF bucket size in bytes (0 = automatic)
+#   $12 = namespace_name    : Namespace where interface exists (optional, empty = host)
+apply_qdisc() {
+    local interface_name=$1
+    local shaper=$2
+    local delay_ms=$3
+    local jitter_ms=$4
+    local loss_percent=$5
+    local reorder_percent=$6
+    local duplicate_percent=$7
+    local corrupt_percent=$8
+    local rate_kbit=$9
+    local ceil_kbit=${10}
+    local burst_bytes=${11}
+    local namespace_name=${12}
+
+    local ns_exec=""
+    if [[ -n "$namespace_name" && "$namespace_name" != "NULL" ]]; then
+        ns_exec="ip netns exec $namespace_name"
+    fi
+
+    if ! $ns_exec ip link show "$interface_name" &>/dev/null; then
+        log_warn "Interface '$interface_name' not found, skipping impairment"
+        return 0
+    fi
+
+    # Build netem options from non-zero values
+    local netem_opts=""
+    if positive "$delay_ms"; then
+        netem_opts="delay ${delay_ms}ms"
+        positive "$jitter_ms" && netem_opts="$netem_opts ${jitter_ms}ms"
+    fi
+    positive "$loss_percent" && netem_opts="$netem_opts loss ${loss_percent}%"
+    positive "$reorder_percent" && netem_opts="$netem_opts reorder ${reorder_percent}%"
+    positive "$duplicate_percent" && netem_opts="$netem_opts duplicate ${duplicate_percent}%"
+    positive "$corrupt_percent" && netem_opts="$netem_opts corrupt ${corrupt_percent}%"
+
+    # Start from the default qdisc
+    $ns_exec tc qdisc del dev "$interface_name" root &>/dev/null || true
+
+    local netem_parent="root handle 1:"
+    if [[ "$rate_kbit" -gt 0 ]]; then
+        if [[ "$shaper" == "htb" ]]; then
+            local ceil_opts=""
+            [[ "$ceil_kbit" -gt 0 ]] && ceil_opts="ceil ${ceil_kbit}kbit"
+
+            $ns_exec tc qdisc add dev "$interface_name" root handle 1: htb default 10
+            $ns_exec tc class add dev "$interface_name" parent 1: classid 1:10 htb rate "${rate_kbit}kbit" $ceil_opts
+            netem_parent="parent 1:10 handle 10:"
+        else
+            # Roughly 10ms worth of traffic, never below one MTU; queue 50ms on top
+            if [[ "$burst_bytes" -eq 0 ]]; then
+                burst_bytes=$(( rate_kbit * 5 / 4 ))
+                [[ "$burst_bytes" -lt 1600 ]] && burst_bytes=1600
+            fi
+            local limit_bytes=$(( rate_kbit * 1000 / 8 / 20 + burst_bytes ))
+
+            $ns_exec tc qdisc add dev "$interface_name" root handle 1: tbf \
+                rate "${rate_kbit}kbit" burst "$burst_bytes" limit "$limit_bytes"
+            netem_parent="parent 1:1 handle 10:"
+        fi
+    fi
+
+    if [[ -n "$netem_opts" ]]; then
+        $ns_exec tc qdisc add dev "$interface_name" $netem_parent netem $netem_opts
+    fi
+
+    log_success "Applied impairment on $interface_name"
+}
+
 # Main restore function
 restore_all() {
     log_info "=========================================="
@@ -370,6 +622,60 @@ restore_all() {
         create_veth "$veth_name" "$peer_name" "$namespace_name" "$peer_namespace_name"
     done < <(query_db "SELECT id, name, peer_name, ns_id, peer_ns_id FROM veth_pairs ORDER BY id;")
 
+    # 3b. Restore macvlan/ipvlan links (parents may be veth ends)
+    log_info "Restoring macvlan/ipvlan links..."
+
+    # Query output: "1|mv0|macvlan|bridge|eth0||2"
+    # means: id=1, name=mv0, kind=macvlan, mode=bridge, parent=eth0, parent_ns_id=(host), ns_id=2
+    while IFS='|' read -r link_id link_name kind mode parent_name parent_namespace_id namespace_id; do
+        [[ -z "$link_name" ]] && continue
+
+        parent_namespace_name=""
+        namespace_name=""
+
+        if [[ -n "$parent_namespace_id" && "$parent_namespace_id" != "NULL" ]]; then
+            parent_namespace_name=$(query_db "SELECT name FROM namespaces WHERE id=$parent_namespace_id;")
+        fi
+
+        if [[ -n "$namespace_id" && "$namespace_id" != "NULL" ]]; then
+            namespace_name=$(query_db "SELECT name FROM namespaces WHERE id=$namespace_id;")
+        fi
+
+        create_macvlan_link "$link_name" "$kind" "$mode" "$parent_name" "$parent_namespace_name" "$namespace_name"
+    done < <(query_db "SELECT id, name, kind, mode, parent, parent_ns_id, ns_id FROM macvlan_links ORDER BY id;")
+
+    # 3c. Restore bonds (slaves are veth ends)
+    log_info "Restoring bonds..."
+
+    # Query output: "1|bond0|active-backup|100|veth-a|2"
+    # means: id=1, name=bond0, mode=active-backup, miimon=100, primary=veth-a, ns_id=2
+    while IFS='|' read -r bond_id bond_name mode miimon primary namespace_id; do
+        [[ -z "$bond_name" ]] && continue
+
+        namespace_name=""
+        if [[ -n "$namespace_id" && "$namespace_id" != "NULL" ]]; then
+            namespace_name=$(query_db "SELECT name FROM namespaces WHERE id=$namespace_id;")
+        fi
+
+        slaves=$(query_db "SELECT interface_name FROM bond_slaves WHERE bond_id=$bond_id ORDER BY id;" | tr '\n' ' ')
+
+        create_bond "$bond_name" "$mode" "$miimon" "$primary" "$slaves" "$namespace_name"
+    done < <(query_db "SELECT id, name, mode, miimon, primary_slave, ns_id FROM bonds ORDER BY id;")
+
+    # 3d. Restore dummy interfaces (addresses are restored with the others below)
+    log_info "Restoring dummy interfaces..."
+
+    while IFS='|' read -r dummy_id interface_name namespace_id; do
+        [[ -z "$interface_name" ]] && continue
+
+        namespace_name=""
+        if [[ -n "$namespace_id" && "$namespace_id" != "NULL" ]]; then
+            namespace_name=$(query_db "SELECT name FROM namespaces WHERE id=$namespace_id;")
+        fi
+
+        create_dummy "$interface_name" "$namespace_name"
+    done < <(query_db "SELECT id, name, ns_id FROM dummy_interfaces ORDER BY id;")
+
     # 4. Restore IP addresses
     log_info "Restoring IP addresses..."
 
@@ -438,6 +744,37 @@ restore_all() {
         create_gre_tunnel "$tunnel_name" "$local_ip" "$remote_ip" "$gre_key" "$ttl" "$namespace_name"
     done < <(query_db "SELECT id, name, local_ip, remote_ip, gre_key, ttl, ns_id FROM gre_tunnels ORDER BY id;")
 
+    # 8. Restore link properties (after all interfaces exist)
+    log_info "Restoring link properties..."
+
+    while IFS='|' read -r property_id interface_name namespace_id mtu mac_address txqueuelen alias state; do
+        [[ -z "$interface_name" ]] && continue
+
+        namespace_name=""
+        if [[ -n "$namespace_id" && "$namespace_id" != "NULL" ]]; then
+            namespace_name=$(query_db "SELECT name FROM namespaces WHERE id=$namespace_id;")
+        fi
+
+        apply_link_properties "$interface_name" "$mtu" "$mac_address" "$txqueuelen" "$alias" "$state" "$namespace_name"
+    done < <(query_db "SELECT id, interface_name, ns_id, mtu, mac_address, txqueuelen, alias, state FROM link_properties ORDER BY id;")
+
+    # 9. Restore traffic impairment
+    log_info "Restoring traffic impairment..."
+
+    while IFS='|' read -r qdisc_id interface_name namespace_id shaper delay_ms jitter_ms loss_percent \
+        reorder_percent duplicate_percent corrupt_percent rate_kbit ceil_kbit burst_bytes; do
+        [[ -z "$interface_name" ]] && continue
+
+        namespace_name=""
+        if [[ -n "$namespace_id" && "$namespace_id" != "NULL" ]]; then
+            namespace_name=$(query_db "SELECT name FROM namespaces WHERE id=$namespace_id;")
+        fi
+
+        apply_qdisc "$interface_name" "$shaper" "$delay_ms" "$jitter_ms" "$loss_percent" \
+            "$reorder_percent" "$duplicate_percent" "$corrupt_percent" \
+            "$rate_kbit" "$ceil_kbit" "$burst_bytes" "$namespace_name"
+    done < <(query_db "SELECT id, interface_name, ns_id, shaper, delay_ms, jitter_ms, loss_percent, reorder_percent, duplicate_percent, corrupt_percent, rate_kbit, ceil_kbit, burst_bytes FROM qdiscs ORDER BY id;")
+
     log_info "=========================================="
     log_info "Restoration complete!"
     log_info "=========================================="